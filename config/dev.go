@@ -2,6 +2,8 @@
 
 package config
 
+import "time"
+
 var Conf = &Config{
 	Consul: ConsulConfig{
 		DSN: "localhost:18500",
@@ -20,4 +22,56 @@ var Conf = &Config{
 	Cloopen: CloopenConfig{
 		AppId: "8aaf07087fe90a32017ff389d7d301c2",
 	},
+	MQ: MQConfig{
+		Driver: "kafka",
+		Brokers: []string{
+			"localhost:9092",
+			"localhost:9093",
+			"localhost:9094",
+			"localhost:9095",
+		},
+	},
+	Spider: SpiderConfig{
+		RateLimit: 50,
+		Burst:     10,
+	},
+	JWT: JWTConfig{
+		Issuer:      "yellowbook",
+		ActiveKeyId: "dev",
+		Keys: []JWTKeyConfig{
+			{Id: "dev", Path: "config/keys/jwt-dev.pem"},
+		},
+	},
+	Password: PasswordConfig{
+		BcryptCost: 10,
+	},
+	OAuth: OAuthConfig{
+		StateKey: "yellowbook-dev-oauth-state",
+		// 本地调试 GitHub 登录时填上 ClientId，secret 放到 config/keys/github-client-secret，这个文件不会提交
+		Github: OAuthClientConfig{
+			ClientSecretPath: "config/keys/github-client-secret",
+			RedirectURL:      "http://127.0.0.1:8080/users/oauth/github/callback",
+		},
+	},
+	RateLimit: RateLimitConfig{
+		Rules: []RateLimitRuleConfig{
+			{Prefix: "", Key: "ip", Limit: 100, Interval: time.Second},
+			{Prefix: "/users/signup", Key: "ip", Limit: 10, Interval: time.Minute},
+			{Prefix: "/users/login", Key: "ip", Limit: 30, Interval: time.Minute},
+			{Prefix: "/users/login_sms", Key: "ip", Limit: 30, Interval: time.Minute},
+			{Prefix: "/users", Key: "user", Limit: 600, Interval: time.Minute},
+		},
+	},
+	Email: EmailConfig{
+		Driver: "memory",
+		From:   "noreply@yellowbook.com",
+	},
+	Account: AccountConfig{
+		DeletionCoolingOff: time.Hour * 24 * 7,
+		ArticlePolicy:      "unpublish",
+	},
+	// 没有配置密码，第一次启动时随机生成并打印在控制台
+	Admin: AdminConfig{
+		BootstrapUsername: "admin",
+	},
 }
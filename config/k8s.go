@@ -2,6 +2,8 @@
 
 package config
 
+import "time"
+
 var Conf = &Config{
 	Web: GinConfig{
 		Port: ":8081",
@@ -16,4 +18,53 @@ var Conf = &Config{
 	Cloopen: CloopenConfig{
 		AppId: "8aaf07087fe90a32017ff389d7d301c2",
 	},
+	MQ: MQConfig{
+		Driver: "kafka",
+		Brokers: []string{
+			"yellowbook-kafka:9092",
+		},
+	},
+	Spider: SpiderConfig{
+		RateLimit: 50,
+		Burst:     10,
+	},
+	JWT: JWTConfig{
+		Issuer:      "yellowbook",
+		ActiveKeyId: "k1",
+		Keys: []JWTKeyConfig{
+			{Id: "k1", Path: "/etc/yellowbook/jwt/k1.pem"},
+		},
+	},
+	Password: PasswordConfig{
+		BcryptCost: 12,
+	},
+	OAuth: OAuthConfig{
+		StateKeyPath: "/etc/yellowbook/oauth/state-key",
+		Github: OAuthClientConfig{
+			ClientId:         "c54992dff1a03482b7de",
+			ClientSecretPath: "/etc/yellowbook/oauth/github-client-secret",
+			RedirectURL:      "http://dev.yellowbook.com/users/oauth/github/callback",
+		},
+	},
+	RateLimit: RateLimitConfig{
+		Rules: []RateLimitRuleConfig{
+			{Prefix: "", Key: "ip", Limit: 100, Interval: time.Second},
+			{Prefix: "/users/signup", Key: "ip", Limit: 10, Interval: time.Minute},
+			{Prefix: "/users/login", Key: "ip", Limit: 30, Interval: time.Minute},
+			{Prefix: "/users/login_sms", Key: "ip", Limit: 30, Interval: time.Minute},
+			{Prefix: "/users", Key: "user", Limit: 600, Interval: time.Minute},
+		},
+	},
+	Email: EmailConfig{
+		Driver: "memory",
+		From:   "noreply@yellowbook.com",
+	},
+	Account: AccountConfig{
+		DeletionCoolingOff: time.Hour * 24 * 7,
+		ArticlePolicy:      "unpublish",
+	},
+	Admin: AdminConfig{
+		BootstrapUsername:     "admin",
+		BootstrapPasswordPath: "/etc/yellowbook/admin/password",
+	},
 }
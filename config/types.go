@@ -1,12 +1,23 @@
 package config
 
+import "time"
+
 type Config struct {
-	Consul  ConsulConfig
-	Web     GinConfig
-	Manage  GinConfig
-	DB      DBConfig
-	Redis   RedisConfig
-	Cloopen CloopenConfig
+	Consul    ConsulConfig
+	Web       GinConfig
+	Manage    GinConfig
+	DB        DBConfig
+	Redis     RedisConfig
+	Cloopen   CloopenConfig
+	MQ        MQConfig
+	Spider    SpiderConfig
+	JWT       JWTConfig
+	Password  PasswordConfig
+	OAuth     OAuthConfig
+	RateLimit RateLimitConfig
+	Email     EmailConfig
+	Account   AccountConfig
+	Admin     AdminConfig
 }
 
 type ConsulConfig struct {
@@ -29,3 +40,105 @@ type RedisConfig struct {
 type CloopenConfig struct {
 	AppId string
 }
+
+type MQConfig struct {
+	// Driver 可选 kafka、memory，memory 只给单测和本地开发用，消息不持久化，其它进程也收不到
+	Driver  string
+	Brokers []string
+}
+
+type SpiderConfig struct {
+	// RateLimit 每秒最多处理的消息数，0 表示不限速
+	RateLimit float64
+	Burst     int
+}
+
+type JWTConfig struct {
+	Issuer string
+	// ActiveKeyId 签发新 token 用的密钥
+	ActiveKeyId string
+	// Keys 校验时接受的所有密钥，轮换时新旧密钥同时存在
+	Keys []JWTKeyConfig
+}
+
+type JWTKeyConfig struct {
+	Id string
+	// Path PEM 文件路径，只用于校验的旧密钥可以只放公钥
+	Path string
+}
+
+type PasswordConfig struct {
+	// BcryptCost 调高以后，旧密码在用户下次登录时重新哈希
+	BcryptCost int
+}
+
+type OAuthConfig struct {
+	// StateKey 签名 OAuth state 用的密钥，不能为空
+	StateKey string
+	// StateKeyPath 从文件读 StateKey，比如挂载进来的 secret
+	StateKeyPath string
+	// Github ClientId 为空时不开启
+	Github OAuthClientConfig
+	// OIDC 通用的 OpenID Connect 登录，Name 用在路由 /users/oauth/{name} 里
+	OIDC []OIDCConfig
+}
+
+type OAuthClientConfig struct {
+	ClientId     string
+	ClientSecret string
+	// ClientSecretPath 从文件读 ClientSecret，设置了就不用 ClientSecret
+	ClientSecretPath string
+	// RedirectURL 需要和第三方应用里配置的回调地址一致，形如 /users/oauth/{name}/callback
+	RedirectURL string
+}
+
+type OIDCConfig struct {
+	Name string
+	// Issuer 从 {Issuer}/.well-known/openid-configuration 读取端点
+	Issuer string
+	OAuthClientConfig
+	Scopes []string
+}
+
+type RateLimitConfig struct {
+	// Rules 默认规则，远程配置里有 ratelimit.rules 时以远程的为准，修改后不用重启
+	Rules []RateLimitRuleConfig
+}
+
+type RateLimitRuleConfig struct {
+	// Prefix 路径前缀，空字符串匹配所有请求
+	Prefix string
+	// Key 可选 ip、user、route
+	Key      string
+	Limit    int
+	Interval time.Duration
+}
+
+type EmailConfig struct {
+	// Driver 可选 smtp、memory，memory 只把邮件打印出来
+	Driver string
+	// Addr SMTP 服务器地址，形如 smtp.example.com:587
+	Addr string
+	// Username 为空时不认证
+	Username string
+	Password string
+	From     string
+}
+
+type AccountConfig struct {
+	// DeletionCoolingOff 申请注销到真正匿名化之间的冷静期，期间登录会取消申请
+	DeletionCoolingOff time.Duration
+	// ArticlePolicy 注销账号的文章怎么处理，可选 unpublish、reassign，默认 unpublish
+	ArticlePolicy string
+	// ReassignTo ArticlePolicy 为 reassign 时把文章转给这个账号
+	ReassignTo uint64
+}
+
+type AdminConfig struct {
+	// BootstrapUsername 还没有管理员时用它创建超级管理员，为空时不创建
+	BootstrapUsername string
+	// BootstrapPassword 和 BootstrapPasswordPath 都为空时随机生成，创建成功后打印一次
+	BootstrapPassword string
+	// BootstrapPasswordPath 从文件读密码，比如挂载进来的 secret，设置了就不用 BootstrapPassword
+	BootstrapPasswordPath string
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// 管理后台的权限配置在角色上，管理员拥有所有角色权限的并集
+const (
+	// PermissionAll 超级管理员，以后新增的权限也包含在内
+	PermissionAll             = "*"
+	PermissionUserRead        = "user:read"
+	PermissionUserWrite       = "user:write"
+	PermissionArticleRead     = "article:read"
+	PermissionArticleModerate = "article:moderate"
+	PermissionSpiderControl   = "spider:control"
+	PermissionAdminManage     = "admin:manage"
+	PermissionAuditRead       = "audit:read"
+)
+
+type Permission struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Permissions 可以分配给角色的权限
+var Permissions = []Permission{
+	{Code: PermissionAll, Description: "所有权限"},
+	{Code: PermissionUserRead, Description: "查看用户"},
+	{Code: PermissionUserWrite, Description: "管理用户"},
+	{Code: PermissionArticleRead, Description: "查看文章"},
+	{Code: PermissionArticleModerate, Description: "上下架文章"},
+	{Code: PermissionSpiderControl, Description: "控制爬虫"},
+	{Code: PermissionAdminManage, Description: "管理管理员和角色"},
+	{Code: PermissionAuditRead, Description: "查看审计日志"},
+}
+
+// Admin 管理后台的账号，和 App 的用户完全分开
+type Admin struct {
+	Id         uint64
+	Username   string
+	Password   string
+	Disabled   bool
+	Roles      []Role
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+func (a Admin) HasPermission(permission string) bool {
+	for _, r := range a.Roles {
+		for _, p := range r.Permissions {
+			if p == permission || p == PermissionAll {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type Role struct {
+	Id          uint64
+	Name        string
+	Description string
+	Permissions []string
+	CreateTime  time.Time
+	UpdateTime  time.Time
+}
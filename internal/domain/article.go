@@ -1,10 +1,17 @@
 package domain
 
+// 文章状态，作者注销账号时按配置下架
+const (
+	ArticleStatusPublished   uint8 = 1
+	ArticleStatusUnpublished uint8 = 2
+)
+
 type Article struct {
 	Id        uint64
 	Title     string
 	Content   string
 	ImageList []string
+	Status    uint8
 	Author    Author
 }
 
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计日志记录的对象类型
+const (
+	AuditEntityUser    = "user"
+	AuditEntityArticle = "article"
+	AuditEntityAdmin   = "admin"
+	AuditEntityRole    = "role"
+	AuditEntitySpider  = "spider"
+	AuditEntityLockout = "lockout"
+)
+
+// AuditLog 管理后台的一次操作，只追加不修改
+type AuditLog struct {
+	Id        uint64
+	AdminId   uint64
+	AdminName string
+	// Action 形如 user.merge，没有指定时是路由
+	Action     string
+	EntityType string
+	EntityId   string
+	// Before 和 After 是操作前后对象的 JSON 快照，查询类的操作 After 里是查询条件
+	Before     json.RawMessage
+	After      json.RawMessage
+	Ip         string
+	Path       string
+	Status     int
+	CreateTime time.Time
+}
+
+// AuditFilter 零值的条件不参与过滤
+type AuditFilter struct {
+	AdminId    uint64
+	Action     string
+	EntityType string
+	EntityId   string
+	StartTime  time.Time
+	EndTime    time.Time
+	Page       int
+	PageSize   int
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type ImageRehostStatus uint8
+
+const (
+	ImageRehostPending ImageRehostStatus = iota
+	ImageRehostSucceeded
+	ImageRehostFailed
+)
+
+// ImageRehostTask 爬虫文章里第三方图片转存到自己 OSS 的任务
+type ImageRehostTask struct {
+	Id            uint64
+	ArticleId     uint64
+	SourceUrl     string
+	TargetUrl     string
+	Status        ImageRehostStatus
+	Retry         int
+	LastError     string
+	NextRetryTime time.Time
+	CreateTime    time.Time
+	UpdateTime    time.Time
+}
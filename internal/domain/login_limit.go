@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+const (
+	LockoutByAccount = "account"
+	LockoutByIp      = "ip"
+	// LockoutByAdmin 后台账号单独计数，App 登录怎么填都碰不到
+	LockoutByAdmin = "admin"
+)
+
+// LoginLockout 登录失败次数过多触发的锁定
+type LoginLockout struct {
+	Kind        string
+	Value       string
+	Failures    int
+	Ip          string
+	LockedUntil time.Time
+	CreateTime  time.Time
+}
@@ -14,3 +14,8 @@ type Resource struct {
 	UpdateTime time.Time
 	UploadUser *User
 }
+
+// AvatarSizes 上传头像时生成的正方形边长，Profile.Avatar 使用 AvatarDefaultSize
+var AvatarSizes = []int{64, 256, 1024}
+
+const AvatarDefaultSize = 256
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// 第三方登录记录 provider 的名字，比如 github
+const (
+	LoginByEmail = "email"
+	LoginByPhone = "phone"
+	LoginBySMS   = "sms"
+)
+
+// Session 一个登录设备，refresh token 只保存哈希
+type Session struct {
+	Id          string
+	UserId      uint64
+	RefreshHash string
+	Device      string
+	UserAgent   string
+	Ip          string
+	LoginMethod string
+	CreateTime  time.Time
+	ExpireTime  time.Time
+	LastSeen    time.Time
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// TOTP 用户绑定的验证器，Enabled 为 false 时是还没确认的密钥
+type TOTP struct {
+	UserId     uint64
+	Secret     string
+	Enabled    bool
+	LastStep   int64
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+// TwoFactorChallenge 第一步验证通过以后发给客户端，凭它和验证码换登录 token
+type TwoFactorChallenge struct {
+	Id          string
+	UserId      uint64
+	LoginMethod string
+	Attempts    int
+	ExpireTime  time.Time
+}
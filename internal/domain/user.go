@@ -5,24 +5,95 @@ import (
 	"time"
 )
 
+// 可以绑定到账号上的登录方式，第三方登录用 ExternalIdentity.Provider 区分
+const (
+	IdentityPhone = "phone"
+	IdentityEmail = "email"
+)
+
+// PhoneRegexPattern 手机号格式，web 和 spider 的校验共用，用 regexp2 编译
+const PhoneRegexPattern = `^1\d{10}$`
+
 type User struct {
-	Id         uint64
-	Email      string
-	Phone      string
-	Password   string
+	Id            uint64
+	Email         string
+	EmailVerified bool
+	Phone         string
+	// Handle 唯一的用户名，可以没有
+	Handle           string
+	HandleUpdateTime time.Time
+	Password         string
+	// DeleteAfter 申请注销后冷静期结束的时间，零值表示没有申请
+	DeleteAfter time.Time
+	Banned      bool
+	// BanUntil 零值表示永久封禁
+	BanUntil   time.Time
+	BanReason  string
 	CreateTime time.Time
 	UpdateTime time.Time
-	GithubId   uint64
 	Profile    *Profile
 }
 
+// BannedAt 临时封禁到期以后自动失效，不需要再解封
+func (u User) BannedAt(now time.Time) bool {
+	return u.Banned && (u.BanUntil.IsZero() || now.Before(u.BanUntil))
+}
+
+// Verified 邮箱注册的账号需要先验证邮箱，手机号和第三方登录已经验证过归属
+func (u User) Verified() bool {
+	return u.Phone != "" || u.Email == "" || u.EmailVerified
+}
+
 type Profile struct {
 	UserId       uint64
 	Nickname     string
 	Birthday     string
 	Introduction string
 	Avatar       string
-	Gender       proto.Gender
-	CreateTime   time.Time
-	UpdateTime   time.Time
+	// Avatars 上传头像生成的各个尺寸，key 是边长；直接填的头像地址没有这些
+	Avatars    map[int]string
+	Gender     proto.Gender
+	Privacy    Privacy
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+// Privacy 别人看公开主页时隐藏哪些信息，Private 的账号只显示昵称和头像
+type Privacy struct {
+	HideBirthday bool
+	HideGender   bool
+	Private      bool
+}
+
+// PublicProfile 公开主页的投影，特意不带手机号、邮箱这些字段，
+// 从缓存里拿到完整的 User 也只能转成这个结构返回
+type PublicProfile struct {
+	UserId       uint64
+	Handle       string
+	Nickname     string
+	Avatar       string
+	Avatars      map[int]string
+	Introduction string
+	// Birthday 和 Gender 按隐私设置可能是零值
+	Birthday       string
+	Gender         proto.Gender
+	Private        bool
+	ArticleCount   int64
+	RecentArticles []Article
+}
+
+// ExternalIdentity 第三方授权后拿到的用户信息，Subject 在同一个 Provider 里唯一
+type ExternalIdentity struct {
+	Provider  string
+	Subject   string
+	Login     string
+	Name      string
+	AvatarUrl string
+	Email     string
+}
+
+// UserFilter 管理后台查询用户，proto 里没有的条件放在外面
+type UserFilter struct {
+	*proto.GetUserListRequest
+	Handle string
 }
@@ -0,0 +1,17 @@
+package event
+
+import "strconv"
+
+type ArticleSaved struct {
+	ArticleId uint64   `json:"article_id"`
+	AuthorId  uint64   `json:"author_id"`
+	Title     string   `json:"title"`
+	ImageList []string `json:"image_list"`
+	// Created 为 true 表示新建，否则是编辑
+	Created bool `json:"created"`
+}
+
+func (e ArticleSaved) Type() string  { return TypeArticleSaved }
+func (e ArticleSaved) Version() int  { return 1 }
+func (e ArticleSaved) Topic() string { return TopicArticle }
+func (e ArticleSaved) Key() string   { return strconv.FormatUint(e.ArticleId, 10) }
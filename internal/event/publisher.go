@@ -0,0 +1,20 @@
+package event
+
+import "context"
+
+// Publisher 业务写成功后发布领域事件
+// 线上的实现是 dao.OutboxPublisher，和业务数据写在同一个事务里，再由 OutboxRelay 投递到消息队列
+type Publisher interface {
+	Publish(ctx context.Context, evts ...Event) error
+}
+
+// NopPublisher 什么都不做，给单测用
+type NopPublisher struct{}
+
+func NewNopPublisher() Publisher {
+	return NopPublisher{}
+}
+
+func (NopPublisher) Publish(ctx context.Context, evts ...Event) error {
+	return nil
+}
@@ -0,0 +1,16 @@
+package event
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNopPublisher_Publish(t *testing.T) {
+	p := NewNopPublisher()
+	err := p.Publish(context.Background(),
+		UserRegistered{UserId: 1, Method: RegisterByEmail},
+		ArticleSaved{ArticleId: 2, AuthorId: 1, Title: "标题", Created: true},
+	)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,25 @@
+package event
+
+type ResourceUploaded struct {
+	Url          string `json:"url"`
+	Purpose      int32  `json:"purpose"`
+	Mimetype     string `json:"mimetype"`
+	UploadUserId uint64 `json:"upload_user_id"`
+}
+
+func (e ResourceUploaded) Type() string  { return TypeResourceUploaded }
+func (e ResourceUploaded) Version() int  { return 1 }
+func (e ResourceUploaded) Topic() string { return TopicResource }
+func (e ResourceUploaded) Key() string   { return e.Url }
+
+// ResourceDeleted 只表示系统里的资源记录已经删除。
+// 现在用的图床没有删除接口，OSS 上的文件不会被删掉，URL 依然可以访问，目前也没有消费者处理这个事件
+type ResourceDeleted struct {
+	Url          string `json:"url"`
+	UploadUserId uint64 `json:"upload_user_id"`
+}
+
+func (e ResourceDeleted) Type() string  { return TypeResourceDeleted }
+func (e ResourceDeleted) Version() int  { return 1 }
+func (e ResourceDeleted) Topic() string { return TopicResource }
+func (e ResourceDeleted) Key() string   { return e.Url }
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	TopicUser     = "yellowbook_user_events"
+	TopicArticle  = "yellowbook_article_events"
+	TopicResource = "yellowbook_resource_events"
+)
+
+const (
+	TypeUserRegistered    = "user.registered"
+	TypeUserProfileEdited = "user.profile_edited"
+	TypeUserMerged        = "user.merged"
+	TypeUserDeleted       = "user.deleted"
+	TypeArticleSaved      = "article.saved"
+	TypeResourceUploaded  = "resource.uploaded"
+	TypeResourceDeleted   = "resource.deleted"
+)
+
+// Event 业务写成功后对外发布的领域事件
+// Key 决定分区，同一个实体的事件保证有序
+type Event interface {
+	Type() string
+	Version() int
+	Topic() string
+	Key() string
+}
+
+// Envelope 统一的消息格式，Payload 的结构由 Type 和 Version 决定
+type Envelope struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Timestamp int64           `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func NewEnvelope(evt Event, now time.Time) (Envelope, error) {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	id, err := newId()
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		Id:        id,
+		Type:      evt.Type(),
+		Version:   evt.Version(),
+		Timestamp: now.UnixMilli(),
+		Payload:   payload,
+	}, nil
+}
+
+func newId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
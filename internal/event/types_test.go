@@ -0,0 +1,35 @@
+package event
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	now := time.UnixMilli(1694575373863)
+
+	env, err := NewEnvelope(UserRegistered{UserId: 1, Method: RegisterByEmail}, now)
+	require.NoError(t, err)
+	assert.Len(t, env.Id, 32)
+	assert.Equal(t, TypeUserRegistered, env.Type)
+	assert.Equal(t, 1, env.Version)
+	assert.Equal(t, int64(1694575373863), env.Timestamp)
+	assert.JSONEq(t, `{"user_id":1,"method":"email"}`, string(env.Payload))
+
+	env, err = NewEnvelope(ArticleSaved{ArticleId: 2, AuthorId: 1, Title: "标题", Created: true}, now)
+	require.NoError(t, err)
+	assert.Equal(t, TypeArticleSaved, env.Type)
+	assert.JSONEq(t, `{"article_id":2,"author_id":1,"title":"标题","image_list":null,"created":true}`, string(env.Payload))
+
+	// 同一时刻的两个事件 id 也不同
+	other, err := NewEnvelope(UserRegistered{UserId: 1, Method: RegisterByEmail}, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, env.Id, other.Id)
+
+	b, err := json.Marshal(env)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"type":"article.saved"`)
+}
@@ -0,0 +1,55 @@
+package event
+
+import "strconv"
+
+const (
+	RegisterByEmail = "email"
+	RegisterByPhone = "phone"
+)
+
+type UserRegistered struct {
+	UserId uint64 `json:"user_id"`
+	// Method 第三方登录注册的是 provider 的名字，比如 github
+	Method string `json:"method"`
+}
+
+func (e UserRegistered) Type() string  { return TypeUserRegistered }
+func (e UserRegistered) Version() int  { return 1 }
+func (e UserRegistered) Topic() string { return TopicUser }
+func (e UserRegistered) Key() string   { return strconv.FormatUint(e.UserId, 10) }
+
+type UserProfileEdited struct {
+	UserId       uint64 `json:"user_id"`
+	Nickname     string `json:"nickname"`
+	Birthday     string `json:"birthday"`
+	Introduction string `json:"introduction"`
+	Avatar       string `json:"avatar"`
+	Gender       int32  `json:"gender"`
+}
+
+func (e UserProfileEdited) Type() string  { return TypeUserProfileEdited }
+func (e UserProfileEdited) Version() int  { return 1 }
+func (e UserProfileEdited) Topic() string { return TopicUser }
+func (e UserProfileEdited) Key() string   { return strconv.FormatUint(e.UserId, 10) }
+
+// UserMerged FromId 已经删除，它的数据都归到 ToId 下
+type UserMerged struct {
+	FromId uint64 `json:"from_id"`
+	ToId   uint64 `json:"to_id"`
+}
+
+func (e UserMerged) Type() string  { return TypeUserMerged }
+func (e UserMerged) Version() int  { return 1 }
+func (e UserMerged) Topic() string { return TopicUser }
+func (e UserMerged) Key() string   { return strconv.FormatUint(e.FromId, 10) }
+
+// UserDeleted 账号已经匿名化，ReassignTo 为 0 表示文章已下架，否则文章转给了 ReassignTo
+type UserDeleted struct {
+	UserId     uint64 `json:"user_id"`
+	ReassignTo uint64 `json:"reassign_to"`
+}
+
+func (e UserDeleted) Type() string  { return TypeUserDeleted }
+func (e UserDeleted) Version() int  { return 1 }
+func (e UserDeleted) Topic() string { return TopicUser }
+func (e UserDeleted) Key() string   { return strconv.FormatUint(e.UserId, 10) }
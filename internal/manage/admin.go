@@ -0,0 +1,505 @@
+package manage
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/shenxiang11/zippo/slice"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/jwt_generator"
+	"yellowbook/internal/service"
+)
+
+type AdminHandler struct {
+	svc      service.IAdminService
+	jwt      jwt_generator.IJWTGenerator
+	limiter  service.ILoginLimitService
+	sessions service.IAdminSessionService
+}
+
+func NewAdminHandler(
+	svc service.IAdminService,
+	jwt jwt_generator.IJWTGenerator,
+	limiter service.ILoginLimitService,
+	sessions service.IAdminSessionService,
+) *AdminHandler {
+	return &AdminHandler{
+		svc:      svc,
+		jwt:      jwt,
+		limiter:  limiter,
+		sessions: sessions,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(ug *gin.RouterGroup) {
+	ug.POST("/login", h.Login)
+	ug.POST("/logout", h.Logout)
+	ug.GET("/me", h.Me)
+
+	manage := RequirePermission(domain.PermissionAdminManage)
+	ug.GET("/permissions", manage, h.Permissions)
+	ug.POST("/list", manage, h.List)
+	ug.POST("/create", manage, h.Create)
+	ug.POST("/disable", manage, h.Disable)
+	ug.POST("/roles/assign", manage, h.AssignRoles)
+	ug.POST("/roles/list", manage, h.Roles)
+	ug.POST("/roles/save", manage, h.SaveRole)
+	ug.POST("/roles/delete", manage, h.DeleteRole)
+}
+
+type AdminLoginReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (h *AdminHandler) Login(ctx *gin.Context) {
+	var req AdminLoginReq
+	if err := ctx.Bind(&req); err != nil || req.Username == "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "admin.login", domain.AuditEntityAdmin, "")
+	auditAfter(ctx, gin.H{"username": req.Username})
+
+	err := h.limiter.Check(ctx, domain.LockoutByAdmin, req.Username, ctx.ClientIP())
+	var limitedErr *service.LoginLimitedError
+	if errors.As(err, &limitedErr) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(limitedErr.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, Result{
+			Code: 4,
+			Msg:  "尝试太频繁，请稍后再试",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	admin, err := h.svc.Login(ctx, req.Username, req.Password)
+	switch {
+	case errors.Is(err, service.ErrInvalidAdminOrPassword):
+		if err = h.limiter.Fail(ctx, domain.LockoutByAdmin, req.Username, ctx.ClientIP()); err != nil {
+			log.Printf("记录登录失败出错：%v\n", err)
+		}
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "用户名或密码不正确",
+		})
+		return
+	case errors.Is(err, service.ErrAdminDisabled):
+		ctx.JSON(http.StatusForbidden, Result{
+			Code: 4,
+			Msg:  "账号已停用",
+		})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	if err = h.limiter.Succeed(ctx, domain.LockoutByAdmin, req.Username); err != nil {
+		log.Printf("清除登录失败记录出错：%v\n", err)
+	}
+
+	// 登录接口不经过登录中间件，放进 ctx 审计日志才有操作人
+	ctx.Set("Admin", admin)
+	auditTarget(ctx, "admin.login", domain.AuditEntityAdmin, strconv.FormatUint(admin.Id, 10))
+
+	sessionId, err := h.sessions.Create(ctx, admin.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	token, err := generateAdminToken(h.jwt, admin.Id, sessionId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.Header("X-Jwt-Token", token)
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "登录成功",
+	})
+}
+
+// Logout 删除当前会话，手上的 token 马上失效
+func (h *AdminHandler) Logout(ctx *gin.Context) {
+	adminId := ctx.GetUint64("AdminId")
+	auditTarget(ctx, "admin.logout", domain.AuditEntityAdmin, strconv.FormatUint(adminId, 10))
+
+	if err := h.sessions.Revoke(ctx, ctx.GetString("AdminSessionId")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "退出登录成功",
+	})
+}
+
+type RoleVo struct {
+	Id          uint64   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type AdminVo struct {
+	Id         uint64   `json:"id"`
+	Username   string   `json:"username"`
+	Disabled   bool     `json:"disabled"`
+	Roles      []RoleVo `json:"roles"`
+	CreateTime int64    `json:"createTime"`
+}
+
+func toRoleVo(r domain.Role, index int) RoleVo {
+	permissions := r.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return RoleVo{
+		Id:          r.Id,
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: permissions,
+	}
+}
+
+func toAdminVo(a domain.Admin, index int) AdminVo {
+	return AdminVo{
+		Id:         a.Id,
+		Username:   a.Username,
+		Disabled:   a.Disabled,
+		Roles:      slice.Map[domain.Role, RoleVo](a.Roles, toRoleVo),
+		CreateTime: a.CreateTime.UnixMilli(),
+	}
+}
+
+// Me 当前登录的管理员和权限，前端用来决定显示哪些菜单
+func (h *AdminHandler) Me(ctx *gin.Context) {
+	v, _ := ctx.Get("Admin")
+	admin, _ := v.(domain.Admin)
+	ctx.JSON(http.StatusOK, Result{
+		Data: toAdminVo(admin, 0),
+	})
+}
+
+func (h *AdminHandler) Permissions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: domain.Permissions,
+	})
+}
+
+func (h *AdminHandler) List(ctx *gin.Context) {
+	admins, err := h.svc.List(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: slice.Map[domain.Admin, AdminVo](admins, toAdminVo),
+	})
+}
+
+type CreateAdminReq struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	RoleIds  []uint64 `json:"roleIds"`
+}
+
+func (h *AdminHandler) Create(ctx *gin.Context) {
+	var req CreateAdminReq
+	if err := ctx.Bind(&req); err != nil || req.Username == "" || utf8.RuneCountInString(req.Username) > 64 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+	if len(req.Password) < 8 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "密码至少 8 位",
+		})
+		return
+	}
+
+	auditTarget(ctx, "admin.create", domain.AuditEntityAdmin, "")
+	id, err := h.svc.Create(ctx, req.Username, req.Password, req.RoleIds)
+	switch {
+	case errors.Is(err, service.ErrAdminDuplicate):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "用户名已存在",
+		})
+	case errors.Is(err, service.ErrRoleNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "角色不存在",
+		})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	default:
+		auditTarget(ctx, "admin.create", domain.AuditEntityAdmin, strconv.FormatUint(id, 10))
+		auditAfter(ctx, h.adminSnapshot(ctx, id))
+		ctx.JSON(http.StatusOK, Result{
+			Msg:  "创建成功",
+			Data: id,
+		})
+	}
+}
+
+type DisableAdminReq struct {
+	Id       uint64 `json:"id"`
+	Disabled bool   `json:"disabled"`
+}
+
+func (h *AdminHandler) Disable(ctx *gin.Context) {
+	var req DisableAdminReq
+	if err := ctx.Bind(&req); err != nil || req.Id == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "admin.disable", domain.AuditEntityAdmin, strconv.FormatUint(req.Id, 10))
+	auditBefore(ctx, h.adminSnapshot(ctx, req.Id))
+
+	err := h.svc.SetDisabled(ctx, ctx.GetUint64("AdminId"), req.Id, req.Disabled)
+	switch {
+	case errors.Is(err, service.ErrDisableSelf):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "不能停用自己",
+		})
+	case errors.Is(err, service.ErrAdminNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "管理员不存在",
+		})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	default:
+		auditAfter(ctx, h.adminSnapshot(ctx, req.Id))
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "操作成功",
+		})
+	}
+}
+
+type AssignRolesReq struct {
+	AdminId uint64   `json:"adminId"`
+	RoleIds []uint64 `json:"roleIds"`
+}
+
+// AssignRoles 整体替换管理员的角色
+func (h *AdminHandler) AssignRoles(ctx *gin.Context) {
+	var req AssignRolesReq
+	if err := ctx.Bind(&req); err != nil || req.AdminId == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "admin.roles", domain.AuditEntityAdmin, strconv.FormatUint(req.AdminId, 10))
+	auditBefore(ctx, h.adminSnapshot(ctx, req.AdminId))
+
+	err := h.svc.SetRoles(ctx, req.AdminId, req.RoleIds)
+	switch {
+	case errors.Is(err, service.ErrAdminNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "管理员不存在",
+		})
+	case errors.Is(err, service.ErrRoleNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "角色不存在",
+		})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	default:
+		auditAfter(ctx, h.adminSnapshot(ctx, req.AdminId))
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "操作成功",
+		})
+	}
+}
+
+func (h *AdminHandler) Roles(ctx *gin.Context) {
+	roles, err := h.svc.ListRoles(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: slice.Map[domain.Role, RoleVo](roles, toRoleVo),
+	})
+}
+
+type SaveRoleReq struct {
+	// Id 为 0 时新建
+	Id          uint64   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func (h *AdminHandler) SaveRole(ctx *gin.Context) {
+	var req SaveRoleReq
+	if err := ctx.Bind(&req); err != nil || req.Name == "" || utf8.RuneCountInString(req.Name) > 64 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "role.save", domain.AuditEntityRole, "")
+	if req.Id != 0 {
+		auditTarget(ctx, "role.save", domain.AuditEntityRole, strconv.FormatUint(req.Id, 10))
+		auditBefore(ctx, h.roleSnapshot(ctx, req.Id))
+	}
+
+	id, err := h.svc.SaveRole(ctx, domain.Role{
+		Id:          req.Id,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	switch {
+	case errors.Is(err, service.ErrUnknownPermission):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "不支持的权限",
+		})
+	case errors.Is(err, service.ErrRoleDuplicate):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "角色名已存在",
+		})
+	case errors.Is(err, service.ErrRoleNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "角色不存在",
+		})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	default:
+		auditTarget(ctx, "role.save", domain.AuditEntityRole, strconv.FormatUint(id, 10))
+		auditAfter(ctx, h.roleSnapshot(ctx, id))
+		ctx.JSON(http.StatusOK, Result{
+			Msg:  "保存成功",
+			Data: id,
+		})
+	}
+}
+
+type DeleteRoleReq struct {
+	Id uint64 `json:"id"`
+}
+
+func (h *AdminHandler) DeleteRole(ctx *gin.Context) {
+	var req DeleteRoleReq
+	if err := ctx.Bind(&req); err != nil || req.Id == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "role.delete", domain.AuditEntityRole, strconv.FormatUint(req.Id, 10))
+	auditBefore(ctx, h.roleSnapshot(ctx, req.Id))
+
+	err := h.svc.DeleteRole(ctx, req.Id)
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "角色不存在",
+		})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	default:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "删除成功",
+		})
+	}
+}
+
+// adminSnapshot 审计用的快照，不带密码，查不到的时候是 nil
+func (h *AdminHandler) adminSnapshot(ctx *gin.Context, id uint64) *AdminVo {
+	a, err := h.svc.Find(ctx, id)
+	if err != nil {
+		return nil
+	}
+	res := toAdminVo(a, 0)
+	return &res
+}
+
+func (h *AdminHandler) roleSnapshot(ctx *gin.Context, id uint64) *RoleVo {
+	roles, err := h.svc.ListRoles(ctx)
+	if err != nil {
+		return nil
+	}
+	for i, r := range roles {
+		if r.Id == id {
+			res := toRoleVo(r, i)
+			return &res
+		}
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package manage
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"yellowbook/internal/domain"
 	"yellowbook/internal/service"
 )
 
@@ -17,7 +20,8 @@ func NewArticleHandler(svc service.IArticleService) *ArticleHandler {
 }
 
 func (u *ArticleHandler) RegisterRoutes(ug *gin.RouterGroup) {
-	ug.POST("/list", u.GetList)
+	ug.POST("/list", RequirePermission(domain.PermissionArticleRead), u.GetList)
+	ug.POST("/status", RequirePermission(domain.PermissionArticleModerate), u.SetStatus)
 }
 
 func (u *ArticleHandler) GetList(ctx *gin.Context) {
@@ -46,3 +50,66 @@ func (u *ArticleHandler) GetList(ctx *gin.Context) {
 		},
 	})
 }
+
+type SetArticleStatusReq struct {
+	Id     uint64 `json:"id"`
+	Status uint8  `json:"status"`
+}
+
+// SetStatus 上架或者下架文章
+func (u *ArticleHandler) SetStatus(ctx *gin.Context) {
+	var req SetArticleStatusReq
+	if err := ctx.Bind(&req); err != nil || req.Id == 0 ||
+		(req.Status != domain.ArticleStatusPublished && req.Status != domain.ArticleStatusUnpublished) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "article.status", domain.AuditEntityArticle, strconv.FormatUint(req.Id, 10))
+	art, err := u.svc.FindById(ctx, req.Id)
+	if err == nil {
+		auditBefore(ctx, articleSnapshot{
+			Id:       art.Id,
+			Title:    art.Title,
+			AuthorId: art.Author.Id,
+			Status:   art.Status,
+		})
+		err = u.svc.SetStatus(ctx, req.Id, req.Status)
+	}
+	if errors.Is(err, service.ErrArticleNotFound) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "文章不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	art.Status = req.Status
+	auditAfter(ctx, articleSnapshot{
+		Id:       art.Id,
+		Title:    art.Title,
+		AuthorId: art.Author.Id,
+		Status:   art.Status,
+	})
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "操作成功",
+	})
+}
+
+type articleSnapshot struct {
+	Id       uint64 `json:"id"`
+	Title    string `json:"title"`
+	AuthorId uint64 `json:"authorId"`
+	Status   uint8  `json:"status"`
+}
@@ -0,0 +1,209 @@
+package manage
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/shenxiang11/zippo/slice"
+	"log"
+	"net/http"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/service"
+)
+
+const auditKey = "AuditLog"
+
+// AuditMiddlewareBuilder 记录所有会修改数据的请求，放在登录中间件后面才能拿到操作人。
+// 查询用户列表这种会看到手机号、邮箱的请求虽然不修改数据，也要记录，只有普通的列表才忽略
+type AuditMiddlewareBuilder struct {
+	paths []string
+	svc   service.IAuditService
+}
+
+func NewAuditMiddlewareBuilder(svc service.IAuditService) *AuditMiddlewareBuilder {
+	return &AuditMiddlewareBuilder{
+		svc: svc,
+	}
+}
+
+func (a *AuditMiddlewareBuilder) IgnorePaths(path string) *AuditMiddlewareBuilder {
+	a.paths = append(a.paths, path)
+	return a
+}
+
+func (a *AuditMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodGet {
+			return
+		}
+		for _, path := range a.paths {
+			if ctx.Request.URL.Path == path {
+				return
+			}
+		}
+
+		entry := &domain.AuditLog{}
+		ctx.Set(auditKey, entry)
+
+		ctx.Next()
+
+		if v, ok := ctx.Get("Admin"); ok {
+			if admin, ok := v.(domain.Admin); ok {
+				entry.AdminId = admin.Id
+				entry.AdminName = admin.Username
+			}
+		}
+		if entry.Action == "" {
+			entry.Action = ctx.Request.URL.Path
+		}
+		entry.Ip = ctx.ClientIP()
+		entry.Path = ctx.Request.URL.Path
+		entry.Status = ctx.Writer.Status()
+
+		// 客户端断开也要记下来
+		err := a.svc.Record(context.WithoutCancel(ctx.Request.Context()), *entry)
+		if err != nil {
+			log.Printf("记录审计日志出错：%v\n", err)
+		}
+	}
+}
+
+func currentAudit(ctx *gin.Context) *domain.AuditLog {
+	v, _ := ctx.Get(auditKey)
+	entry, _ := v.(*domain.AuditLog)
+	return entry
+}
+
+// auditTarget 标记这次操作的动作和对象，没有经过审计中间件时什么都不做
+func auditTarget(ctx *gin.Context, action string, entityType string, entityId string) {
+	if entry := currentAudit(ctx); entry != nil {
+		entry.Action = action
+		entry.EntityType = entityType
+		entry.EntityId = entityId
+	}
+}
+
+func auditBefore(ctx *gin.Context, snapshot any) {
+	if entry := currentAudit(ctx); entry != nil {
+		entry.Before = marshalSnapshot(snapshot)
+	}
+}
+
+func auditAfter(ctx *gin.Context, snapshot any) {
+	if entry := currentAudit(ctx); entry != nil {
+		entry.After = marshalSnapshot(snapshot)
+	}
+}
+
+// marshalSnapshot 马上序列化，后面再改对象不会影响已经记下的快照
+func marshalSnapshot(snapshot any) json.RawMessage {
+	if snapshot == nil {
+		return nil
+	}
+	res, err := json.Marshal(snapshot)
+	if err != nil {
+		log.Printf("序列化审计快照出错：%v\n", err)
+		return nil
+	}
+	return res
+}
+
+type AuditHandler struct {
+	svc service.IAuditService
+}
+
+func NewAuditHandler(svc service.IAuditService) *AuditHandler {
+	return &AuditHandler{
+		svc: svc,
+	}
+}
+
+func (h *AuditHandler) RegisterRoutes(ug *gin.RouterGroup) {
+	ug.POST("/list", RequirePermission(domain.PermissionAuditRead), h.List)
+}
+
+type AuditListReq struct {
+	AdminId    uint64 `json:"adminId"`
+	Action     string `json:"action"`
+	EntityType string `json:"entityType"`
+	EntityId   string `json:"entityId"`
+	// StartTime 和 EndTime 是毫秒时间戳，0 表示不限
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+	Page      int   `json:"page"`
+	PageSize  int   `json:"pageSize"`
+}
+
+type AuditLogVo struct {
+	Id         uint64          `json:"id"`
+	AdminId    uint64          `json:"adminId"`
+	AdminName  string          `json:"adminName"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityId   string          `json:"entityId"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Ip         string          `json:"ip"`
+	Path       string          `json:"path"`
+	Status     int             `json:"status"`
+	CreateTime int64           `json:"createTime"`
+}
+
+// List 按操作人、对象和时间范围查审计日志，按时间倒序
+func (h *AuditHandler) List(ctx *gin.Context) {
+	var req AuditListReq
+	if err := ctx.Bind(&req); err != nil || (req.EndTime != 0 && req.EndTime < req.StartTime) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	filter := domain.AuditFilter{
+		AdminId:    req.AdminId,
+		Action:     req.Action,
+		EntityType: req.EntityType,
+		EntityId:   req.EntityId,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if req.StartTime != 0 {
+		filter.StartTime = time.UnixMilli(req.StartTime)
+	}
+	if req.EndTime != 0 {
+		filter.EndTime = time.UnixMilli(req.EndTime)
+	}
+
+	logs, total, err := h.svc.Search(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: gin.H{
+			"total": total,
+			"list": slice.Map[domain.AuditLog, AuditLogVo](logs, func(el domain.AuditLog, index int) AuditLogVo {
+				return AuditLogVo{
+					Id:         el.Id,
+					AdminId:    el.AdminId,
+					AdminName:  el.AdminName,
+					Action:     el.Action,
+					EntityType: el.EntityType,
+					EntityId:   el.EntityId,
+					Before:     el.Before,
+					After:      el.After,
+					Ip:         el.Ip,
+					Path:       el.Path,
+					Status:     el.Status,
+					CreateTime: el.CreateTime.UnixMilli(),
+				}
+			}),
+		},
+	})
+}
@@ -0,0 +1,115 @@
+package manage
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/service"
+	svcmocks "yellowbook/internal/service/mocks"
+)
+
+func TestAuditMiddlewareBuilder_Build(t *testing.T) {
+	admin := domain.Admin{Id: 1, Username: "admin"}
+
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) service.IAuditService
+		method string
+		path   string
+	}{
+		{
+			name: "记录操作和快照",
+			mock: func(ctrl *gomock.Controller) service.IAuditService {
+				svc := svcmocks.NewMockIAuditService(ctrl)
+				svc.EXPECT().Record(gomock.Any(), domain.AuditLog{
+					AdminId:    1,
+					AdminName:  "admin",
+					Action:     "article.status",
+					EntityType: domain.AuditEntityArticle,
+					EntityId:   "3",
+					Before:     json.RawMessage(`{"status":1}`),
+					After:      json.RawMessage(`{"status":2}`),
+					Ip:         "192.0.2.1",
+					Path:       "/articles/status",
+					Status:     http.StatusOK,
+				}).Return(nil)
+				return svc
+			},
+			method: http.MethodPost,
+			path:   "/articles/status",
+		},
+		{
+			name: "没有标记的请求用路径当动作",
+			mock: func(ctrl *gomock.Controller) service.IAuditService {
+				svc := svcmocks.NewMockIAuditService(ctrl)
+				svc.EXPECT().Record(gomock.Any(), domain.AuditLog{
+					AdminId:   1,
+					AdminName: "admin",
+					Action:    "/users/merge",
+					Ip:        "192.0.2.1",
+					Path:      "/users/merge",
+					Status:    http.StatusForbidden,
+				}).Return(nil)
+				return svc
+			},
+			method: http.MethodPost,
+			path:   "/users/merge",
+		},
+		{
+			name: "GET 请求不记录",
+			mock: func(ctrl *gomock.Controller) service.IAuditService {
+				return svcmocks.NewMockIAuditService(ctrl)
+			},
+			method: http.MethodGet,
+			path:   "/spider/status",
+		},
+		{
+			name: "忽略的列表不记录",
+			mock: func(ctrl *gomock.Controller) service.IAuditService {
+				return svcmocks.NewMockIAuditService(ctrl)
+			},
+			method: http.MethodPost,
+			path:   "/articles/list",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("Admin", admin)
+			})
+			server.Use(NewAuditMiddlewareBuilder(tc.mock(ctrl)).IgnorePaths("/articles/list").Build())
+			server.POST("/articles/status", func(ctx *gin.Context) {
+				auditTarget(ctx, "article.status", domain.AuditEntityArticle, "3")
+				auditBefore(ctx, gin.H{"status": 1})
+				auditAfter(ctx, gin.H{"status": 2})
+				ctx.Status(http.StatusOK)
+			})
+			server.POST("/users/merge", func(ctx *gin.Context) {
+				ctx.Status(http.StatusForbidden)
+			})
+			server.POST("/articles/list", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+			server.GET("/spider/status", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			req.RemoteAddr = "192.0.2.1:1234"
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+		})
+	}
+}
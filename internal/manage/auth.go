@@ -0,0 +1,104 @@
+package manage
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/jwt_generator"
+	"yellowbook/internal/service"
+)
+
+// adminSubjectPrefix 和 App 共用签名密钥，靠 subject 前缀区分，用户的 token 不能拿来访问后台
+const adminSubjectPrefix = "admin:"
+
+// LoginMiddlewareBuilder 校验管理员的 token 和会话，把带权限的管理员放进 ctx 的 Admin 里
+type LoginMiddlewareBuilder struct {
+	paths    []string
+	jwt      jwt_generator.IJWTGenerator
+	svc      service.IAdminService
+	sessions service.IAdminSessionService
+}
+
+func NewLoginMiddlewareBuilder(jwt jwt_generator.IJWTGenerator, svc service.IAdminService, sessions service.IAdminSessionService) *LoginMiddlewareBuilder {
+	return &LoginMiddlewareBuilder{
+		jwt:      jwt,
+		svc:      svc,
+		sessions: sessions,
+	}
+}
+
+func (l *LoginMiddlewareBuilder) IgnorePaths(path string) *LoginMiddlewareBuilder {
+	l.paths = append(l.paths, path)
+	return l
+}
+
+func (l *LoginMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, path := range l.paths {
+			if ctx.Request.URL.Path == path {
+				return
+			}
+		}
+
+		tokenStr := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		claims, err := l.jwt.Parse(tokenStr)
+		if err != nil || !strings.HasPrefix(claims.Subject, adminSubjectPrefix) || claims.ID == "" {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseUint(strings.TrimPrefix(claims.Subject, adminSubjectPrefix), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// 退出登录以后会话被删掉，token 没过期也不能再用
+		err = l.sessions.Check(ctx, claims.ID, id)
+		if errors.Is(err, service.ErrSessionRevoked) {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		// 每次都查权限，停用账号或者调整角色马上生效
+		admin, err := l.svc.Authenticate(ctx, id)
+		if errors.Is(err, service.ErrAdminNotFound) || errors.Is(err, service.ErrAdminDisabled) {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.Set("Admin", admin)
+		ctx.Set("AdminId", admin.Id)
+		ctx.Set("AdminSessionId", claims.ID)
+	}
+}
+
+// RequirePermission 放在路由上，没有权限返回 403
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		v, _ := ctx.Get("Admin")
+		admin, ok := v.(domain.Admin)
+		if !ok || !admin.HasPermission(permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, Result{
+				Code: 4,
+				Msg:  "没有权限",
+			})
+			return
+		}
+	}
+}
+
+func generateAdminToken(jwt jwt_generator.IJWTGenerator, id uint64, sessionId string) (string, error) {
+	return jwt.Generate(adminSubjectPrefix+strconv.FormatUint(id, 10), sessionId, service.AdminSessionExpire)
+}
@@ -0,0 +1,166 @@
+package manage
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/jwt_generator"
+	jwtmocks "yellowbook/internal/pkg/jwt_generator/mocks"
+	"yellowbook/internal/service"
+	svcmocks "yellowbook/internal/service/mocks"
+)
+
+func TestLoginMiddlewareBuilder_Build(t *testing.T) {
+	reader := domain.Admin{Id: 1, Roles: []domain.Role{
+		{Name: "运营", Permissions: []string{domain.PermissionUserRead}},
+	}}
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService)
+		path     string
+		wantCode int
+	}{
+		{
+			name: "有权限",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "admin:1", ID: "sid"}, nil)
+				svc := svcmocks.NewMockIAdminService(ctrl)
+				svc.EXPECT().Authenticate(gomock.Any(), uint64(1)).Return(reader, nil)
+				sessions := svcmocks.NewMockIAdminSessionService(ctrl)
+				sessions.EXPECT().Check(gomock.Any(), "sid", uint64(1)).Return(nil)
+				return j, svc, sessions
+			},
+			path:     "/users/list",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "没有权限",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "admin:1", ID: "sid"}, nil)
+				svc := svcmocks.NewMockIAdminService(ctrl)
+				svc.EXPECT().Authenticate(gomock.Any(), uint64(1)).Return(reader, nil)
+				sessions := svcmocks.NewMockIAdminSessionService(ctrl)
+				sessions.EXPECT().Check(gomock.Any(), "sid", uint64(1)).Return(nil)
+				return j, svc, sessions
+			},
+			path:     "/users/merge",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "超级管理员",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "admin:2", ID: "sid"}, nil)
+				svc := svcmocks.NewMockIAdminService(ctrl)
+				svc.EXPECT().Authenticate(gomock.Any(), uint64(2)).Return(domain.Admin{Id: 2, Roles: []domain.Role{
+					{Permissions: []string{domain.PermissionAll}},
+				}}, nil)
+				sessions := svcmocks.NewMockIAdminSessionService(ctrl)
+				sessions.EXPECT().Check(gomock.Any(), "sid", uint64(2)).Return(nil)
+				return j, svc, sessions
+			},
+			path:     "/users/merge",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "App 用户的 token",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "1", ID: "ssid"}, nil)
+				return j, svcmocks.NewMockIAdminService(ctrl), svcmocks.NewMockIAdminSessionService(ctrl)
+			},
+			path:     "/users/list",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "管理员已停用",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "admin:1", ID: "sid"}, nil)
+				svc := svcmocks.NewMockIAdminService(ctrl)
+				svc.EXPECT().Authenticate(gomock.Any(), uint64(1)).Return(domain.Admin{}, service.ErrAdminDisabled)
+				sessions := svcmocks.NewMockIAdminSessionService(ctrl)
+				sessions.EXPECT().Check(gomock.Any(), "sid", uint64(1)).Return(nil)
+				return j, svc, sessions
+			},
+			path:     "/users/list",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "token 不合法",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(nil, errors.New("token 过期"))
+				return j, svcmocks.NewMockIAdminService(ctrl), svcmocks.NewMockIAdminSessionService(ctrl)
+			},
+			path:     "/users/list",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "已经退出登录",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "admin:1", ID: "sid"}, nil)
+				sessions := svcmocks.NewMockIAdminSessionService(ctrl)
+				sessions.EXPECT().Check(gomock.Any(), "sid", uint64(1)).Return(service.ErrSessionRevoked)
+				return j, svcmocks.NewMockIAdminService(ctrl), sessions
+			},
+			path:     "/users/list",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "没有会话 id",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				j := jwtmocks.NewMockIJWTGenerator(ctrl)
+				j.EXPECT().Parse("token").Return(&jwt.RegisteredClaims{Subject: "admin:1"}, nil)
+				return j, svcmocks.NewMockIAdminService(ctrl), svcmocks.NewMockIAdminSessionService(ctrl)
+			},
+			path:     "/users/list",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "登录不需要 token",
+			mock: func(ctrl *gomock.Controller) (jwt_generator.IJWTGenerator, service.IAdminService, service.IAdminSessionService) {
+				return jwtmocks.NewMockIJWTGenerator(ctrl), svcmocks.NewMockIAdminService(ctrl), svcmocks.NewMockIAdminSessionService(ctrl)
+			},
+			path:     "/admins/login",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			j, svc, sessions := tc.mock(ctrl)
+			server := gin.New()
+			server.Use(NewLoginMiddlewareBuilder(j, svc, sessions).IgnorePaths("/admins/login").Build())
+			ok := func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			}
+			server.POST("/admins/login", ok)
+			server.POST("/users/list", RequirePermission(domain.PermissionUserRead), ok)
+			server.POST("/users/merge", RequirePermission(domain.PermissionUserWrite), ok)
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer token")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package manage
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/spider"
+)
+
+type SpiderController interface {
+	Pause()
+	Resume()
+	Status() spider.Status
+}
+
+type SpiderHandler struct {
+	ctl SpiderController
+}
+
+func NewSpiderHandler(ctl SpiderController) *SpiderHandler {
+	return &SpiderHandler{
+		ctl: ctl,
+	}
+}
+
+func (h *SpiderHandler) RegisterRoutes(ug *gin.RouterGroup) {
+	control := RequirePermission(domain.PermissionSpiderControl)
+	ug.GET("/status", control, h.Status)
+	ug.POST("/pause", control, h.Pause)
+	ug.POST("/resume", control, h.Resume)
+}
+
+func (h *SpiderHandler) Status(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: h.ctl.Status(),
+	})
+}
+
+func (h *SpiderHandler) Pause(ctx *gin.Context) {
+	auditTarget(ctx, "spider.pause", domain.AuditEntitySpider, "")
+	auditBefore(ctx, h.ctl.Status())
+	h.ctl.Pause()
+	status := h.ctl.Status()
+	auditAfter(ctx, status)
+	ctx.JSON(http.StatusOK, Result{
+		Data: status,
+	})
+}
+
+func (h *SpiderHandler) Resume(ctx *gin.Context) {
+	auditTarget(ctx, "spider.resume", domain.AuditEntitySpider, "")
+	auditBefore(ctx, h.ctl.Status())
+	h.ctl.Resume()
+	status := h.ctl.Status()
+	auditAfter(ctx, status)
+	ctx.JSON(http.StatusOK, Result{
+		Data: status,
+	})
+}
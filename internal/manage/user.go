@@ -1,33 +1,71 @@
 package manage
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/shenxiang11/yellowbook-proto/proto"
 	"github.com/shenxiang11/zippo/slice"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 	"yellowbook/internal/domain"
 	"yellowbook/internal/service"
 )
 
 type UserHandler struct {
-	svc service.IUserService
+	svc        service.IUserService
+	sessionSvc service.ISessionService
+	limiter    service.ILoginLimitService
+	twoFactor  service.ITwoFactorService
 }
 
-func NewUserHandler(svc service.IUserService) *UserHandler {
+func NewUserHandler(
+	svc service.IUserService,
+	sessionSvc service.ISessionService,
+	limiter service.ILoginLimitService,
+	twoFactor service.ITwoFactorService,
+) *UserHandler {
 	return &UserHandler{
-		svc: svc,
+		svc:        svc,
+		sessionSvc: sessionSvc,
+		limiter:    limiter,
+		twoFactor:  twoFactor,
 	}
 }
 
 func (u *UserHandler) RegisterRoutes(ug *gin.RouterGroup) {
-	ug.POST("/list", u.GetList)
+	read := RequirePermission(domain.PermissionUserRead)
+	write := RequirePermission(domain.PermissionUserWrite)
+
+	ug.POST("/list", read, u.GetList)
+	ug.POST("/merge", write, u.Merge)
+	ug.POST("/lockouts/list", read, u.Lockouts)
+	ug.POST("/lockouts/unlock", write, u.Unlock)
+	ug.POST("/2fa/reset", write, u.ResetTwoFactor)
+	ug.POST("/ban", write, u.Ban)
+	ug.POST("/unban", write, u.Unban)
+	ug.POST("/logout", write, u.ForceLogout)
+	ug.POST("/profile", write, u.EditProfile)
+}
+
+// UserListReq proto 里没有用户名，在外面补上，可以只填用户名的一部分
+type UserListReq struct {
+	*proto.GetUserListRequest
+	Handle string `json:"handle"`
+}
+
+type UserVo struct {
+	*proto.User
+	Handle string `json:"handle"`
 }
 
 func (u *UserHandler) GetList(ctx *gin.Context) {
 	fmt.Println(ctx.Request.Header.Get("Yellow-Book-Timezone"))
 
-	var req proto.GetUserListRequest
+	req := UserListReq{GetUserListRequest: &proto.GetUserListRequest{}}
 	if err := ctx.Bind(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, Result{
 			Code: 4,
@@ -36,7 +74,11 @@ func (u *UserHandler) GetList(ctx *gin.Context) {
 		return
 	}
 
-	users, total, err := u.svc.QueryUsers(ctx, &req)
+	auditTarget(ctx, "user.list", domain.AuditEntityUser, "")
+	users, total, err := u.svc.QueryUsers(ctx, domain.UserFilter{
+		GetUserListRequest: req.GetUserListRequest,
+		Handle:             strings.ToLower(strings.TrimPrefix(req.Handle, "@")),
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, Result{
 			Code: 500,
@@ -44,11 +86,19 @@ func (u *UserHandler) GetList(ctx *gin.Context) {
 		})
 		return
 	}
+	// 列表里有手机号和邮箱，记下谁在什么条件下看到了哪些用户
+	auditAfter(ctx, gin.H{
+		"filter": &req,
+		"total":  total,
+		"userIds": slice.Map[domain.User, uint64](users, func(el domain.User, index int) uint64 {
+			return el.Id
+		}),
+	})
 
 	ctx.JSON(http.StatusOK, Result{
 		Data: gin.H{
 			"total": total,
-			"list": slice.Map[domain.User, *proto.User](users, func(el domain.User, index int) *proto.User {
+			"list": slice.Map[domain.User, UserVo](users, func(el domain.User, index int) UserVo {
 				item := &proto.User{
 					Id:         el.Id,
 					Email:      el.Email,
@@ -68,8 +118,482 @@ func (u *UserHandler) GetList(ctx *gin.Context) {
 					}
 				}
 
-				return item
+				return UserVo{User: item, Handle: el.Handle}
 			}),
 		},
 	})
 }
+
+type MergeReq struct {
+	// FromId 合并后被删除的账号
+	FromId uint64 `json:"fromId"`
+	ToId   uint64 `json:"toId"`
+}
+
+// Merge 同一个人注册了多个账号时，把 FromId 的登录方式、文章和资源合并到 ToId
+func (u *UserHandler) Merge(ctx *gin.Context) {
+	var req MergeReq
+	if err := ctx.Bind(&req); err != nil || req.FromId == 0 || req.ToId == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "user.merge", domain.AuditEntityUser, strconv.FormatUint(req.FromId, 10))
+	auditBefore(ctx, gin.H{
+		"from": u.userSnapshot(ctx, req.FromId),
+		"to":   u.userSnapshot(ctx, req.ToId),
+	})
+
+	err := u.svc.Merge(ctx, req.FromId, req.ToId)
+	switch {
+	case errors.Is(err, service.ErrMergeSameUser):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "不能合并同一个账号",
+		})
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "账号不存在",
+		})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	auditAfter(ctx, gin.H{
+		"to": u.userSnapshot(ctx, req.ToId),
+	})
+
+	// 被删除的账号不能再继续使用
+	if err = u.sessionSvc.RevokeAll(ctx, req.FromId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "合并成功，但下线原账号失败",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "合并成功",
+	})
+}
+
+type LockoutsReq struct {
+	// Limit 默认 50
+	Limit int `json:"limit"`
+}
+
+type LockoutVo struct {
+	Kind        string `json:"kind"`
+	Value       string `json:"value"`
+	Failures    int    `json:"failures"`
+	Ip          string `json:"ip"`
+	LockedUntil int64  `json:"lockedUntil"`
+	CreateTime  int64  `json:"createTime"`
+}
+
+// Lockouts 最近因为登录失败次数过多触发的锁定，按时间倒序
+func (u *UserHandler) Lockouts(ctx *gin.Context) {
+	var req LockoutsReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 1000 {
+		req.Limit = 50
+	}
+
+	auditTarget(ctx, "lockout.list", domain.AuditEntityLockout, "")
+	lockouts, err := u.limiter.Lockouts(ctx, req.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	auditAfter(ctx, gin.H{
+		"limit": req.Limit,
+		"count": len(lockouts),
+	})
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: slice.Map[domain.LoginLockout, LockoutVo](lockouts, func(el domain.LoginLockout, index int) LockoutVo {
+			return LockoutVo{
+				Kind:        el.Kind,
+				Value:       el.Value,
+				Failures:    el.Failures,
+				Ip:          el.Ip,
+				LockedUntil: el.LockedUntil.UnixMilli(),
+				CreateTime:  el.CreateTime.UnixMilli(),
+			}
+		}),
+	})
+}
+
+type UnlockReq struct {
+	// Kind 可选 account、ip、admin
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+func (u *UserHandler) Unlock(ctx *gin.Context) {
+	var req UnlockReq
+	if err := ctx.Bind(&req); err != nil || req.Value == "" ||
+		(req.Kind != domain.LockoutByAccount && req.Kind != domain.LockoutByIp && req.Kind != domain.LockoutByAdmin) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "lockout.unlock", domain.AuditEntityLockout, req.Kind+":"+req.Value)
+	if err := u.limiter.Unlock(ctx, req.Kind, req.Value); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已解锁",
+	})
+}
+
+type ResetTwoFactorReq struct {
+	UserId uint64 `json:"userId"`
+}
+
+// ResetTwoFactor 用户验证器和恢复码都丢了，核实身份以后由管理员关掉两步验证
+func (u *UserHandler) ResetTwoFactor(ctx *gin.Context) {
+	var req ResetTwoFactorReq
+	if err := ctx.Bind(&req); err != nil || req.UserId == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "user.2fa_reset", domain.AuditEntityUser, strconv.FormatUint(req.UserId, 10))
+	enabled, err := u.twoFactor.Enabled(ctx, req.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	auditBefore(ctx, gin.H{"enabled": enabled})
+
+	if err = u.twoFactor.Reset(ctx, req.UserId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	auditAfter(ctx, gin.H{"enabled": false})
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "两步验证已重置",
+	})
+}
+
+type BanReq struct {
+	UserId uint64 `json:"userId"`
+	// Until 毫秒时间戳，0 表示永久封禁
+	Until int64 `json:"until"`
+	// Reason 用户登录时会看到
+	Reason string `json:"reason"`
+}
+
+// Ban 封禁账号并下线所有设备，临时封禁到期以后自动解封
+func (u *UserHandler) Ban(ctx *gin.Context) {
+	var req BanReq
+	if err := ctx.Bind(&req); err != nil || req.UserId == 0 ||
+		req.Reason == "" || utf8.RuneCountInString(req.Reason) > 255 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+	var until time.Time
+	if req.Until != 0 {
+		until = time.UnixMilli(req.Until)
+		if !until.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, Result{
+				Code: 4,
+				Msg:  "封禁结束时间必须晚于现在",
+			})
+			return
+		}
+	}
+
+	auditTarget(ctx, "user.ban", domain.AuditEntityUser, strconv.FormatUint(req.UserId, 10))
+	auditBefore(ctx, u.userSnapshot(ctx, req.UserId))
+
+	err := u.svc.Ban(ctx, req.UserId, until, req.Reason)
+	if errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "账号不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	auditAfter(ctx, u.userSnapshot(ctx, req.UserId))
+
+	// 登录校验中间件也会拦，这里下线是为了让 refresh token 也失效
+	if err = u.sessionSvc.RevokeAll(ctx, req.UserId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "已封禁，但下线失败",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已封禁",
+	})
+}
+
+type UnbanReq struct {
+	UserId uint64 `json:"userId"`
+}
+
+func (u *UserHandler) Unban(ctx *gin.Context) {
+	var req UnbanReq
+	if err := ctx.Bind(&req); err != nil || req.UserId == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "user.unban", domain.AuditEntityUser, strconv.FormatUint(req.UserId, 10))
+	auditBefore(ctx, u.userSnapshot(ctx, req.UserId))
+
+	err := u.svc.Unban(ctx, req.UserId)
+	if errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "账号不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	auditAfter(ctx, u.userSnapshot(ctx, req.UserId))
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已解封",
+	})
+}
+
+type ForceLogoutReq struct {
+	UserId uint64 `json:"userId"`
+}
+
+// ForceLogout 下线账号的所有设备，access token 马上失效
+func (u *UserHandler) ForceLogout(ctx *gin.Context) {
+	var req ForceLogoutReq
+	if err := ctx.Bind(&req); err != nil || req.UserId == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	auditTarget(ctx, "user.logout", domain.AuditEntityUser, strconv.FormatUint(req.UserId, 10))
+	if err := u.sessionSvc.RevokeAll(ctx, req.UserId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已下线",
+	})
+}
+
+// EditProfileReq 只修改传了的字段
+type EditProfileReq struct {
+	UserId       uint64        `json:"userId"`
+	Nickname     *string       `json:"nickname"`
+	Birthday     *string       `json:"birthday"`
+	Introduction *string       `json:"introduction"`
+	Avatar       *string       `json:"avatar"`
+	Gender       *proto.Gender `json:"gender"`
+}
+
+// EditProfile 修正用户的资料，比如违规的昵称和简介
+func (u *UserHandler) EditProfile(ctx *gin.Context) {
+	var req EditProfileReq
+	if err := ctx.Bind(&req); err != nil || req.UserId == 0 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+	if req.Nickname != nil {
+		if count := utf8.RuneCountInString(*req.Nickname); count < 2 || count > 24 {
+			ctx.JSON(http.StatusBadRequest, Result{
+				Code: 4,
+				Msg:  "昵称请设置 2-24 个字符",
+			})
+			return
+		}
+	}
+	if req.Introduction != nil && utf8.RuneCountInString(*req.Introduction) > 100 {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "简介不能多于 100 个字符",
+		})
+		return
+	}
+
+	auditTarget(ctx, "user.profile", domain.AuditEntityUser, strconv.FormatUint(req.UserId, 10))
+	user, err := u.svc.QueryProfile(ctx, req.UserId)
+	if errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "账号不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	profile := domain.Profile{UserId: req.UserId}
+	if user.Profile != nil {
+		profile = *user.Profile
+	}
+	auditBefore(ctx, profileSnapshot(profile))
+
+	if req.Nickname != nil {
+		profile.Nickname = *req.Nickname
+	}
+	if req.Birthday != nil {
+		profile.Birthday = *req.Birthday
+	}
+	if req.Introduction != nil {
+		profile.Introduction = *req.Introduction
+	}
+	if req.Gender != nil {
+		profile.Gender = *req.Gender
+	}
+
+	err = u.svc.EditProfile(ctx, profile)
+	// 头像单独修改，比如清掉违规的头像，原来的各个尺寸也一起清掉
+	if err == nil && req.Avatar != nil {
+		profile.Avatar = *req.Avatar
+		err = u.svc.SetAvatar(ctx, req.UserId, *req.Avatar)
+	}
+	if errors.Is(err, service.ErrUserBirthdayFormat) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "生日格式不正确",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	auditAfter(ctx, profileSnapshot(profile))
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "更新成功",
+	})
+}
+
+func profileSnapshot(p domain.Profile) gin.H {
+	return gin.H{
+		"nickname":     p.Nickname,
+		"birthday":     p.Birthday,
+		"introduction": p.Introduction,
+		"avatar":       p.Avatar,
+		"gender":       p.Gender,
+	}
+}
+
+type userSnapshot struct {
+	Id          uint64 `json:"id"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Nickname    string `json:"nickname"`
+	Handle      string `json:"handle,omitempty"`
+	DeleteAfter int64  `json:"deleteAfter,omitempty"`
+	Banned      bool   `json:"banned,omitempty"`
+	BanUntil    int64  `json:"banUntil,omitempty"`
+	BanReason   string `json:"banReason,omitempty"`
+}
+
+// userSnapshot 审计用的快照，不带密码，查不到的时候是 nil
+func (u *UserHandler) userSnapshot(ctx *gin.Context, id uint64) *userSnapshot {
+	user, err := u.svc.QueryProfile(ctx, id)
+	if err != nil {
+		return nil
+	}
+	res := &userSnapshot{
+		Id:     user.Id,
+		Email:  user.Email,
+		Phone:  user.Phone,
+		Handle: user.Handle,
+	}
+	if user.Profile != nil {
+		res.Nickname = user.Profile.Nickname
+	}
+	if !user.DeleteAfter.IsZero() {
+		res.DeleteAfter = user.DeleteAfter.UnixMilli()
+	}
+	if user.Banned {
+		res.Banned = true
+		res.BanReason = user.BanReason
+		if !user.BanUntil.IsZero() {
+			res.BanUntil = user.BanUntil.UnixMilli()
+		}
+	}
+	return res
+}
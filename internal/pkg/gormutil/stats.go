@@ -0,0 +1,90 @@
+package gormutil
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+const queryStartKey = "query_stats:start"
+
+// QueryStats 以插件的形式统计 SQL 耗时和错误率（指数加权平均），用来判断数据库的压力
+type QueryStats struct {
+	mu        sync.Mutex
+	alpha     float64
+	latency   float64
+	errorRate float64
+}
+
+func NewQueryStats() *QueryStats {
+	return &QueryStats{alpha: 0.1}
+}
+
+func (s *QueryStats) Name() string {
+	return "yellowbook:query_stats"
+}
+
+func (s *QueryStats) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("query_stats:before_create", s.before),
+		cb.Create().After("gorm:create").Register("query_stats:after_create", s.after),
+		cb.Query().Before("gorm:query").Register("query_stats:before_query", s.before),
+		cb.Query().After("gorm:query").Register("query_stats:after_query", s.after),
+		cb.Update().Before("gorm:update").Register("query_stats:before_update", s.before),
+		cb.Update().After("gorm:update").Register("query_stats:after_update", s.after),
+		cb.Delete().Before("gorm:delete").Register("query_stats:before_delete", s.before),
+		cb.Delete().After("gorm:delete").Register("query_stats:after_delete", s.after),
+		cb.Row().Before("gorm:row").Register("query_stats:before_row", s.before),
+		cb.Row().After("gorm:row").Register("query_stats:after_row", s.after),
+		cb.Raw().Before("gorm:raw").Register("query_stats:before_raw", s.before),
+		cb.Raw().After("gorm:raw").Register("query_stats:after_raw", s.after),
+	)
+}
+
+func (s *QueryStats) before(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func (s *QueryStats) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(queryStartKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+
+	// 查不到数据是正常的业务结果，不算错误
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	s.Observe(time.Since(start), err)
+}
+
+func (s *QueryStats) Observe(d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var failed float64
+	if err != nil {
+		failed = 1
+	}
+	s.latency += s.alpha * (float64(d) - s.latency)
+	s.errorRate += s.alpha * (failed - s.errorRate)
+}
+
+func (s *QueryStats) Latency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.latency)
+}
+
+func (s *QueryStats) ErrorRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errorRate
+}
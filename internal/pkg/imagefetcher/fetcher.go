@@ -0,0 +1,177 @@
+package imagefetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL      = errors.New("图片地址不合法")
+	ErrForbiddenHost   = errors.New("不允许访问内网地址")
+	ErrTooLarge        = errors.New("图片太大")
+	ErrUnsupportedType = errors.New("不支持的图片格式")
+	ErrBadStatus       = errors.New("下载图片失败")
+)
+
+// StatusError 记录远端返回的非 200 状态码，4xx 基本不会因为重试而变好
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status %d", ErrBadStatus.Error(), e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrBadStatus
+}
+
+type Image struct {
+	Data     []byte
+	Mimetype string
+	Filename string
+}
+
+type Fetcher struct {
+	client       *http.Client
+	maxSize      int64
+	allowedTypes map[string]string
+	allowPrivate bool
+}
+
+// NewFetcher 创建图片下载器，timeout 是单张图片的总耗时上限，maxSize 单位是字节
+func NewFetcher(timeout time.Duration, maxSize int64) *Fetcher {
+	f := &Fetcher{
+		maxSize: maxSize,
+		allowedTypes: map[string]string{
+			"image/jpeg": ".jpg",
+			"image/png":  ".png",
+			"image/gif":  ".gif",
+			"image/webp": ".webp",
+		},
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			if f.allowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+				return ErrForbiddenHost
+			}
+			return nil
+		},
+	}
+
+	f.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// 不走代理，否则上面的检查只能看到代理的地址，图片地址指向内网也拦不住
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+
+	return f
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Image, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Image{}, ErrInvalidURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Image{}, ErrInvalidURL
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenHost) {
+			return Image{}, ErrForbiddenHost
+		}
+		return Image{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Image{}, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if resp.ContentLength > f.maxSize {
+		return Image{}, ErrTooLarge
+	}
+
+	// 多读一个字节，用来判断是否超过限制
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxSize+1))
+	if err != nil {
+		return Image{}, err
+	}
+	if int64(len(data)) > f.maxSize {
+		return Image{}, ErrTooLarge
+	}
+
+	// 不相信对方返回的 Content-Type，按内容判断
+	mimetype := http.DetectContentType(data)
+	ext, ok := f.allowedTypes[mimetype]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "image"
+	}
+	if path.Ext(name) != ext {
+		name += ext
+	}
+
+	return Image{
+		Data:     data,
+		Mimetype: mimetype,
+		Filename: name,
+	}, nil
+}
+
+// Reader 方便直接交给 oss 上传
+func (i Image) Reader() io.Reader {
+	return bytes.NewReader(i.Data)
+}
+
+// IsPermanent 判断错误是否是重试也无法恢复的
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrInvalidURL) ||
+		errors.Is(err, ErrForbiddenHost) ||
+		errors.Is(err, ErrTooLarge) ||
+		errors.Is(err, ErrUnsupportedType) {
+		return true
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= 400 && se.StatusCode < 500 &&
+			se.StatusCode != http.StatusRequestTimeout &&
+			se.StatusCode != http.StatusTooManyRequests
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package imagefetcher
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func pngBytes(t *testing.T) []byte {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)))
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	pic := pngBytes(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png":
+			w.Write(pic)
+		case "/no-ext":
+			// 故意返回错误的 Content-Type
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(pic)
+		case "/big.png":
+			w.Write(bytes.Repeat([]byte{0}, 2048))
+		case "/page.html":
+			w.Write([]byte("<html><body>hello</body></html>"))
+		case "/busy.png":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name          string
+		url           string
+		wantErr       error
+		wantPermanent bool
+		wantFilename  string
+	}{
+		{
+			name:         "下载成功",
+			url:          server.URL + "/a.png",
+			wantFilename: "a.png",
+		},
+		{
+			name:         "按内容识别类型",
+			url:          server.URL + "/no-ext",
+			wantFilename: "no-ext.png",
+		},
+		{
+			name:          "超过大小限制",
+			url:           server.URL + "/big.png",
+			wantErr:       ErrTooLarge,
+			wantPermanent: true,
+		},
+		{
+			name:          "不是图片",
+			url:           server.URL + "/page.html",
+			wantErr:       ErrUnsupportedType,
+			wantPermanent: true,
+		},
+		{
+			name:          "不支持的协议",
+			url:           "ftp://example.com/a.png",
+			wantErr:       ErrInvalidURL,
+			wantPermanent: true,
+		},
+		{
+			name:          "404",
+			url:           server.URL + "/missing.png",
+			wantErr:       ErrBadStatus,
+			wantPermanent: true,
+		},
+		{
+			name:          "503 可以重试",
+			url:           server.URL + "/busy.png",
+			wantErr:       ErrBadStatus,
+			wantPermanent: false,
+		},
+	}
+
+	f := NewFetcher(time.Second, 1024)
+	f.allowPrivate = true
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := f.Fetch(context.Background(), tc.url)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Equal(t, tc.wantPermanent, IsPermanent(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "image/png", img.Mimetype)
+			assert.Equal(t, tc.wantFilename, img.Filename)
+			assert.Equal(t, pic, img.Data)
+		})
+	}
+}
+
+func TestFetcher_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	f := NewFetcher(50*time.Millisecond, 1024)
+	f.allowPrivate = true
+
+	_, err := f.Fetch(context.Background(), server.URL+"/slow.png")
+	require.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestFetcher_ForbiddenHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	f := NewFetcher(time.Second, 1024)
+
+	_, err := f.Fetch(context.Background(), server.URL+"/a.png")
+	assert.ErrorIs(t, err, ErrForbiddenHost)
+	assert.True(t, IsPermanent(err))
+}
+
+// 走代理的话拨号检查的是代理地址，内网图片地址就拦不住了
+func TestFetcher_NoProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://8.8.8.8:3128")
+	t.Setenv("HTTPS_PROXY", "http://8.8.8.8:3128")
+
+	f := NewFetcher(time.Second, 1024)
+	transport, ok := f.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Nil(t, transport.Proxy)
+}
@@ -0,0 +1,117 @@
+package imageutil
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Crop 把 r 范围内的内容复制成一张新的 RGBA 图片，r 用的是 src 的坐标
+func Crop(src image.Image, r image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, r.Min, draw.Src)
+	return dst
+}
+
+// Resize 缩放到 width×height，缩小时按面积平均，放大时双线性插值
+func Resize(src *image.RGBA, width int, height int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if sw == 0 || sh == 0 || width == 0 || height == 0 {
+		return dst
+	}
+
+	xs := weights(sw, width)
+	ys := weights(sh, height)
+
+	// 先横向缩放到 width×sh，中间结果保留小数避免两次取整
+	tmp := make([]float64, width*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+y):]
+		for x, ws := range xs {
+			var r, g, b, a float64
+			for _, w := range ws {
+				p := row[w.idx*4:]
+				r += float64(p[0]) * w.weight
+				g += float64(p[1]) * w.weight
+				b += float64(p[2]) * w.weight
+				a += float64(p[3]) * w.weight
+			}
+			off := (y*width + x) * 4
+			tmp[off], tmp[off+1], tmp[off+2], tmp[off+3] = r, g, b, a
+		}
+	}
+
+	for y, ws := range ys {
+		row := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width; x++ {
+			var r, g, b, a float64
+			for _, w := range ws {
+				off := (w.idx*width + x) * 4
+				r += tmp[off] * w.weight
+				g += tmp[off+1] * w.weight
+				b += tmp[off+2] * w.weight
+				a += tmp[off+3] * w.weight
+			}
+			p := row[x*4:]
+			p[0], p[1], p[2], p[3] = clamp(r), clamp(g), clamp(b), clamp(a)
+		}
+	}
+
+	return dst
+}
+
+type weight struct {
+	idx    int
+	weight float64
+}
+
+// weights 计算目标的每个像素由原图哪几个像素按什么比例组成
+func weights(srcLen int, dstLen int) [][]weight {
+	scale := float64(srcLen) / float64(dstLen)
+	res := make([][]weight, dstLen)
+
+	for i := range res {
+		if scale >= 1 {
+			start, end := float64(i)*scale, float64(i+1)*scale
+			for j := int(start); j < srcLen && float64(j) < end; j++ {
+				overlap := math.Min(end, float64(j+1)) - math.Max(start, float64(j))
+				if overlap > 0 {
+					res[i] = append(res[i], weight{idx: j, weight: overlap / scale})
+				}
+			}
+			continue
+		}
+
+		center := (float64(i)+0.5)*scale - 0.5
+		j := int(math.Floor(center))
+		f := center - float64(j)
+		res[i] = []weight{
+			{idx: clampIndex(j, srcLen), weight: 1 - f},
+			{idx: clampIndex(j+1, srcLen), weight: f},
+		}
+	}
+
+	return res
+}
+
+func clampIndex(i int, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+func clamp(v float64) uint8 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
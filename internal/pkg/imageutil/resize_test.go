@@ -0,0 +1,71 @@
+package imageutil
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCrop(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	src.Set(2, 1, color.RGBA{R: 255, A: 255})
+
+	dst := Crop(src, image.Rect(2, 1, 4, 3))
+
+	assert.Equal(t, image.Rect(0, 0, 2, 2), dst.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{}, dst.RGBAAt(1, 1))
+}
+
+func TestResize(t *testing.T) {
+	// 左半边白色，右半边黑色
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{A: 255}
+			if x < 2 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		size   int
+		assert func(t *testing.T, dst *image.RGBA)
+	}{
+		{
+			name: "缩小按面积平均",
+			size: 1,
+			assert: func(t *testing.T, dst *image.RGBA) {
+				assert.Equal(t, color.RGBA{R: 128, G: 128, B: 128, A: 255}, dst.RGBAAt(0, 0))
+			},
+		},
+		{
+			name: "缩小一半保留边界",
+			size: 2,
+			assert: func(t *testing.T, dst *image.RGBA) {
+				assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, dst.RGBAAt(0, 1))
+				assert.Equal(t, color.RGBA{A: 255}, dst.RGBAAt(1, 1))
+			},
+		},
+		{
+			name: "放大不越界",
+			size: 16,
+			assert: func(t *testing.T, dst *image.RGBA) {
+				assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, dst.RGBAAt(0, 0))
+				assert.Equal(t, color.RGBA{A: 255}, dst.RGBAAt(15, 15))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := Resize(src, tc.size, tc.size)
+			assert.Equal(t, image.Rect(0, 0, tc.size, tc.size), dst.Bounds())
+			tc.assert(t, dst)
+		})
+	}
+}
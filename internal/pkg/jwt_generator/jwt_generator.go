@@ -1,41 +1,65 @@
 package jwt_generator
 
 import (
-	"crypto/rsa"
+	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"time"
 )
 
+var errUnknownKey = errors.New("未知的签名密钥")
+
 type IJWTGenerator interface {
-	Generate(id string, expire time.Duration) (string, error)
+	// Generate jwtId 为空时不写 jti
+	Generate(id string, jwtId string, expire time.Duration) (string, error)
+	Parse(token string) (*jwt.RegisteredClaims, error)
 }
 
 type JWTGenerator struct {
-	privateKey *rsa.PrivateKey
-	issuer     string
-	nowFunc    func() time.Time
+	keys    *KeySet
+	issuer  string
+	nowFunc func() time.Time
 }
 
-func NewJWTGenerator(issuer string, privateKey string) *JWTGenerator {
-	pk, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
-	if err != nil {
-		panic("cannot parse private key")
-	}
-
+func NewJWTGenerator(issuer string, keys *KeySet) *JWTGenerator {
 	return &JWTGenerator{
-		privateKey: pk,
-		issuer:     issuer,
-		nowFunc:    time.Now,
+		keys:    keys,
+		issuer:  issuer,
+		nowFunc: time.Now,
 	}
 }
 
-func (j *JWTGenerator) Generate(id string, expire time.Duration) (string, error) {
+func (j *JWTGenerator) Generate(id string, jwtId string, expire time.Duration) (string, error) {
 	now := j.nowFunc()
 	token := jwt.NewWithClaims(jwt.SigningMethodRS512, jwt.RegisteredClaims{
 		Issuer:    j.issuer,
 		Subject:   id,
+		ID:        jwtId,
 		ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
 		IssuedAt:  jwt.NewNumericDate(now),
 	})
-	return token.SignedString(j.privateKey)
+	token.Header["kid"] = j.keys.activeId
+	return token.SignedString(j.keys.signing)
+}
+
+// Parse 校验签名、签发方和过期时间，按 kid 选择公钥
+func (j *JWTGenerator) Parse(token string) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		// 上线前签发的 token 没有 kid 也没有会话 id，登录中间件反正不认，这里直接拒绝
+		kid, _ := token.Header["kid"].(string)
+		pub, ok := j.keys.PublicKey(kid)
+		if !ok {
+			return nil, errUnknownKey
+		}
+		return pub, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS512.Alg()}),
+		jwt.WithIssuer(j.issuer),
+		jwt.WithTimeFunc(j.nowFunc),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
 }
@@ -0,0 +1,93 @@
+package jwt_generator
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"sort"
+)
+
+// KeySet 签发和校验共用的一组密钥，用 kid 区分
+// 轮换时先把新密钥加进来并设为 active，旧密钥保留到它签发的 token 全部过期再删掉
+type KeySet struct {
+	activeId string
+	signing  *rsa.PrivateKey
+	public   map[string]*rsa.PublicKey
+}
+
+// NewKeySet pems 是 kid 到 PEM 的映射，PEM 可以是私钥也可以是公钥
+// 只用来校验的旧密钥只需要公钥，active 对应的必须是私钥
+func NewKeySet(activeId string, pems map[string][]byte) (*KeySet, error) {
+	ks := &KeySet{
+		activeId: activeId,
+		public:   make(map[string]*rsa.PublicKey, len(pems)),
+	}
+
+	for kid, pem := range pems {
+		if pk, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			ks.public[kid] = &pk.PublicKey
+			if kid == activeId {
+				ks.signing = pk
+			}
+			continue
+		}
+
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("密钥 %s 解析失败: %w", kid, err)
+		}
+		ks.public[kid] = pub
+	}
+
+	if ks.signing == nil {
+		return nil, fmt.Errorf("缺少签发用的私钥 %s", activeId)
+	}
+	return ks, nil
+}
+
+func (k *KeySet) ActiveId() string {
+	return k.activeId
+}
+
+func (k *KeySet) PublicKey(kid string) (*rsa.PublicKey, bool) {
+	pub, ok := k.public[kid]
+	return pub, ok
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 对外公开所有可用于校验的公钥
+func (k *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(k.public))
+	for kid := range k.public {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		pub := k.public[kid]
+		keys = append(keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS512.Alg(),
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+	return JWKS{Keys: keys}
+}
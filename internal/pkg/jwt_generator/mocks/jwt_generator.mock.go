@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	jwt "github.com/golang-jwt/jwt/v5"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -35,16 +36,31 @@ func (m *MockIJWTGenerator) EXPECT() *MockIJWTGeneratorMockRecorder {
 }
 
 // Generate mocks base method.
-func (m *MockIJWTGenerator) Generate(id string, expire time.Duration) (string, error) {
+func (m *MockIJWTGenerator) Generate(id, jwtId string, expire time.Duration) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Generate", id, expire)
+	ret := m.ctrl.Call(m, "Generate", id, jwtId, expire)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Generate indicates an expected call of Generate.
-func (mr *MockIJWTGeneratorMockRecorder) Generate(id, expire interface{}) *gomock.Call {
+func (mr *MockIJWTGeneratorMockRecorder) Generate(id, jwtId, expire interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockIJWTGenerator)(nil).Generate), id, expire)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockIJWTGenerator)(nil).Generate), id, jwtId, expire)
+}
+
+// Parse mocks base method.
+func (m *MockIJWTGenerator) Parse(token string) (*jwt.RegisteredClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Parse", token)
+	ret0, _ := ret[0].(*jwt.RegisteredClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Parse indicates an expected call of Parse.
+func (mr *MockIJWTGeneratorMockRecorder) Parse(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockIJWTGenerator)(nil).Parse), token)
 }
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"github.com/shenxiang11/zippo/slice"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository/dao"
+)
+
+var (
+	ErrAdminNotFound  = dao.ErrAdminNotFound
+	ErrAdminDuplicate = dao.ErrAdminDuplicate
+	ErrRoleNotFound   = dao.ErrRoleNotFound
+	ErrRoleDuplicate  = dao.ErrRoleDuplicate
+)
+
+type IAdminRepository interface {
+	Count(ctx context.Context) (int64, error)
+	Create(ctx context.Context, a domain.Admin, roleIds []uint64) (uint64, error)
+	// FindById 带上角色和权限
+	FindById(ctx context.Context, id uint64) (domain.Admin, error)
+	// FindByUsername 只有账号本身，不带角色
+	FindByUsername(ctx context.Context, username string) (domain.Admin, error)
+	// List 带上角色和权限
+	List(ctx context.Context) ([]domain.Admin, error)
+	SetDisabled(ctx context.Context, id uint64, disabled bool) error
+	SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	SaveRole(ctx context.Context, r domain.Role) (uint64, error)
+	DeleteRole(ctx context.Context, id uint64) error
+}
+
+// AdminRepository 管理员不多，权限每次请求都查库，改了马上生效
+type AdminRepository struct {
+	dao dao.AdminDAO
+}
+
+func NewAdminRepository(dao dao.AdminDAO) IAdminRepository {
+	return &AdminRepository{dao: dao}
+}
+
+func (r *AdminRepository) Count(ctx context.Context) (int64, error) {
+	return r.dao.Count(ctx)
+}
+
+func (r *AdminRepository) Create(ctx context.Context, a domain.Admin, roleIds []uint64) (uint64, error) {
+	return r.dao.Insert(ctx, dao.Admin{
+		Username: a.Username,
+		Password: a.Password,
+		Disabled: a.Disabled,
+	}, roleIds)
+}
+
+func (r *AdminRepository) FindById(ctx context.Context, id uint64) (domain.Admin, error) {
+	a, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.Admin{}, err
+	}
+
+	admins, err := r.withRoles(ctx, []dao.Admin{a})
+	if err != nil {
+		return domain.Admin{}, err
+	}
+	return admins[0], nil
+}
+
+func (r *AdminRepository) FindByUsername(ctx context.Context, username string) (domain.Admin, error) {
+	a, err := r.dao.FindByUsername(ctx, username)
+	if err != nil {
+		return domain.Admin{}, err
+	}
+	return r.adminToDomain(a), nil
+}
+
+func (r *AdminRepository) List(ctx context.Context) ([]domain.Admin, error) {
+	admins, err := r.dao.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.withRoles(ctx, admins)
+}
+
+func (r *AdminRepository) withRoles(ctx context.Context, admins []dao.Admin) ([]domain.Admin, error) {
+	ids := slice.Map[dao.Admin, uint64](admins, func(el dao.Admin, index int) uint64 {
+		return el.Id
+	})
+	links, err := r.dao.FindAdminRoles(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Admin, 0, len(admins))
+	if len(links) == 0 {
+		for _, a := range admins {
+			res = append(res, r.adminToDomain(a))
+		}
+		return res, nil
+	}
+
+	roleIds := slice.Map[dao.AdminUserRole, uint64](links, func(el dao.AdminUserRole, index int) uint64 {
+		return el.RoleId
+	})
+	roles, err := r.findRoles(ctx, roleIds)
+	if err != nil {
+		return nil, err
+	}
+	roleMap := make(map[uint64]domain.Role, len(roles))
+	for _, role := range roles {
+		roleMap[role.Id] = role
+	}
+	adminRoles := make(map[uint64][]domain.Role, len(admins))
+	for _, link := range links {
+		if role, ok := roleMap[link.RoleId]; ok {
+			adminRoles[link.AdminId] = append(adminRoles[link.AdminId], role)
+		}
+	}
+
+	for _, a := range admins {
+		da := r.adminToDomain(a)
+		da.Roles = adminRoles[a.Id]
+		res = append(res, da)
+	}
+	return res, nil
+}
+
+func (r *AdminRepository) SetDisabled(ctx context.Context, id uint64, disabled bool) error {
+	return r.dao.SetDisabled(ctx, id, disabled)
+}
+
+func (r *AdminRepository) SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error {
+	return r.dao.SetRoles(ctx, adminId, roleIds)
+}
+
+func (r *AdminRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	return r.findRoles(ctx, nil)
+}
+
+func (r *AdminRepository) findRoles(ctx context.Context, ids []uint64) ([]domain.Role, error) {
+	roles, err := r.dao.FindRoles(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIds := slice.Map[dao.AdminRole, uint64](roles, func(el dao.AdminRole, index int) uint64 {
+		return el.Id
+	})
+	permissions, err := r.dao.FindPermissions(ctx, roleIds)
+	if err != nil {
+		return nil, err
+	}
+	rolePermissions := make(map[uint64][]string, len(roles))
+	for _, p := range permissions {
+		rolePermissions[p.RoleId] = append(rolePermissions[p.RoleId], p.Permission)
+	}
+
+	return slice.Map[dao.AdminRole, domain.Role](roles, func(el dao.AdminRole, index int) domain.Role {
+		return domain.Role{
+			Id:          el.Id,
+			Name:        el.Name,
+			Description: el.Description,
+			Permissions: rolePermissions[el.Id],
+			CreateTime:  time.UnixMilli(el.CreateTime).UTC(),
+			UpdateTime:  time.UnixMilli(el.UpdateTime).UTC(),
+		}
+	}), nil
+}
+
+func (r *AdminRepository) SaveRole(ctx context.Context, role domain.Role) (uint64, error) {
+	return r.dao.SaveRole(ctx, dao.AdminRole{
+		Id:          role.Id,
+		Name:        role.Name,
+		Description: role.Description,
+	}, role.Permissions)
+}
+
+func (r *AdminRepository) DeleteRole(ctx context.Context, id uint64) error {
+	return r.dao.DeleteRole(ctx, id)
+}
+
+func (r *AdminRepository) adminToDomain(a dao.Admin) domain.Admin {
+	return domain.Admin{
+		Id:         a.Id,
+		Username:   a.Username,
+		Password:   a.Password,
+		Disabled:   a.Disabled,
+		CreateTime: time.UnixMilli(a.CreateTime).UTC(),
+		UpdateTime: time.UnixMilli(a.UpdateTime).UTC(),
+	}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/repository/cache"
+)
+
+var ErrAdminSessionNotFound = cache.ErrAdminSessionNotFound
+
+type IAdminSessionRepository interface {
+	Create(ctx context.Context, id string, adminId uint64, ttl time.Duration) error
+	// FindAdminId 返回会话所属的管理员 id
+	FindAdminId(ctx context.Context, id string) (uint64, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type CachedAdminSessionRepository struct {
+	cache cache.AdminSessionCache
+}
+
+func NewAdminSessionRepository(c cache.AdminSessionCache) IAdminSessionRepository {
+	return &CachedAdminSessionRepository{cache: c}
+}
+
+func (repo *CachedAdminSessionRepository) Create(ctx context.Context, id string, adminId uint64, ttl time.Duration) error {
+	return repo.cache.Set(ctx, id, adminId, ttl)
+}
+
+func (repo *CachedAdminSessionRepository) FindAdminId(ctx context.Context, id string) (uint64, error) {
+	return repo.cache.Get(ctx, id)
+}
+
+func (repo *CachedAdminSessionRepository) Delete(ctx context.Context, id string) error {
+	return repo.cache.Delete(ctx, id)
+}
@@ -7,10 +7,17 @@ import (
 	"yellowbook/internal/repository/dao"
 )
 
+var ErrArticleNotFound = dao.ErrArticleNotFound
+
 type IArticleRepository interface {
 	Create(ctx context.Context, domain domain.Article) (uint64, error)
 	Update(ctx context.Context, domain domain.Article) error
 	List(ctx context.Context) ([]domain.Article, int64, error)
+	FindById(ctx context.Context, id uint64) (domain.Article, error)
+	// ListPublishedByAuthor 作者最新的 limit 篇已上架文章，total 是已上架文章的总数
+	ListPublishedByAuthor(ctx context.Context, authorId uint64, limit int) ([]domain.Article, int64, error)
+	UpdateImageList(ctx context.Context, id uint64, imageList []string) error
+	UpdateStatus(ctx context.Context, id uint64, status uint8) error
 }
 
 type ArticleRepository struct {
@@ -51,12 +58,44 @@ func (a *ArticleRepository) List(ctx context.Context) ([]domain.Article, int64,
 	}), total, nil
 }
 
+func (a *ArticleRepository) FindById(ctx context.Context, id uint64) (domain.Article, error) {
+	art, err := a.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.Article{}, err
+	}
+
+	return a.entityToDomain(art), nil
+}
+
+func (a *ArticleRepository) ListPublishedByAuthor(ctx context.Context, authorId uint64, limit int) ([]domain.Article, int64, error) {
+	articles, total, err := a.dao.FindByAuthor(ctx, authorId, dao.ArticleStatusPublished, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return slice.Map[dao.Article, domain.Article](articles, func(el dao.Article, index int) domain.Article {
+		return a.entityToDomain(el)
+	}), total, nil
+}
+
+func (a *ArticleRepository) UpdateImageList(ctx context.Context, id uint64, imageList []string) error {
+	return a.dao.UpdateImageList(ctx, id, imageList)
+}
+
+func (a *ArticleRepository) UpdateStatus(ctx context.Context, id uint64, status uint8) error {
+	return a.dao.UpdateStatus(ctx, id, status)
+}
+
 func (a *ArticleRepository) entityToDomain(u dao.Article) domain.Article {
 	e := domain.Article{
 		Id:        u.Id,
 		Title:     u.Title,
 		Content:   u.Content,
 		ImageList: u.ImageList,
+		Status:    u.Status,
+		Author: domain.Author{
+			Id: u.AuthorId,
+		},
 	}
 
 	return e
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"github.com/shenxiang11/zippo/slice"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository/dao"
+)
+
+type IAuditRepository interface {
+	Create(ctx context.Context, l domain.AuditLog) error
+	Search(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, int64, error)
+}
+
+type AuditRepository struct {
+	dao dao.IAuditLogDAO
+}
+
+func NewAuditRepository(dao dao.IAuditLogDAO) IAuditRepository {
+	return &AuditRepository{dao: dao}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, l domain.AuditLog) error {
+	return r.dao.Insert(ctx, dao.AuditLog{
+		AdminId:    l.AdminId,
+		AdminName:  l.AdminName,
+		Action:     l.Action,
+		EntityType: l.EntityType,
+		EntityId:   l.EntityId,
+		Before:     string(l.Before),
+		After:      string(l.After),
+		Ip:         l.Ip,
+		Path:       l.Path,
+		Status:     l.Status,
+	})
+}
+
+func (r *AuditRepository) Search(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, int64, error) {
+	f := dao.AuditLogFilter{
+		AdminId:    filter.AdminId,
+		Action:     filter.Action,
+		EntityType: filter.EntityType,
+		EntityId:   filter.EntityId,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+	}
+	if !filter.StartTime.IsZero() {
+		f.StartTime = filter.StartTime.UnixMilli()
+	}
+	if !filter.EndTime.IsZero() {
+		f.EndTime = filter.EndTime.UnixMilli()
+	}
+
+	logs, total, err := r.dao.Search(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return slice.Map[dao.AuditLog, domain.AuditLog](logs, func(el dao.AuditLog, index int) domain.AuditLog {
+		return domain.AuditLog{
+			Id:         el.Id,
+			AdminId:    el.AdminId,
+			AdminName:  el.AdminName,
+			Action:     el.Action,
+			EntityType: el.EntityType,
+			EntityId:   el.EntityId,
+			Before:     rawJSON(el.Before),
+			After:      rawJSON(el.After),
+			Ip:         el.Ip,
+			Path:       el.Path,
+			Status:     el.Status,
+			CreateTime: time.UnixMilli(el.CreateTime).UTC(),
+		}
+	}), total, nil
+}
+
+// rawJSON 空字符串不是合法的 JSON，序列化的时候会报错
+func rawJSON(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	return []byte(s)
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+var ErrAdminSessionNotFound = errors.New("管理员会话不存在")
+
+type AdminSessionCache interface {
+	Set(ctx context.Context, id string, adminId uint64, ttl time.Duration) error
+	// Get 返回会话所属的管理员 id
+	Get(ctx context.Context, id string) (uint64, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// RedisAdminSessionCache 后台会话只需要知道属于谁，过期交给 Redis
+type RedisAdminSessionCache struct {
+	client redis.Cmdable
+}
+
+func NewAdminSessionCache(client redis.Cmdable) AdminSessionCache {
+	return &RedisAdminSessionCache{client: client}
+}
+
+func (c *RedisAdminSessionCache) Set(ctx context.Context, id string, adminId uint64, ttl time.Duration) error {
+	return c.client.Set(ctx, c.key(id), adminId, ttl).Err()
+}
+
+func (c *RedisAdminSessionCache) Get(ctx context.Context, id string) (uint64, error) {
+	adminId, err := c.client.Get(ctx, c.key(id)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrAdminSessionNotFound
+	}
+	return adminId, err
+}
+
+func (c *RedisAdminSessionCache) Delete(ctx context.Context, id string) error {
+	return c.client.Del(ctx, c.key(id)).Err()
+}
+
+func (c *RedisAdminSessionCache) key(id string) string {
+	return fmt.Sprintf("admin:session:%s", id)
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"math/rand"
+	"strconv"
+	"time"
+	"yellowbook/internal/domain"
+)
+
+//go:embed lua/check_login.lua
+var luaCheckLogin string
+
+//go:embed lua/record_login_failure.lua
+var luaRecordLoginFailure string
+
+// LoginTarget 一个限流维度，比如某个账号或者某个 IP
+type LoginTarget struct {
+	Kind  string
+	Value string
+	// LockThreshold 窗口内失败这么多次后锁定
+	LockThreshold int
+}
+
+type LoginLimit struct {
+	Window time.Duration
+	// FreeAttempts 窗口内前几次失败不需要等待
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockDuration time.Duration
+}
+
+type LoginLimitCache interface {
+	// Check locked 为 true 时 wait 是剩余的锁定时间，否则是还要等待多久才能再试
+	Check(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) (wait time.Duration, locked bool, err error)
+	// RecordFailure 返回每个维度窗口内的失败次数，locked 表示这次失败触发了锁定
+	RecordFailure(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) (failures []int, locked []bool, err error)
+	Clear(ctx context.Context, kind string, value string) error
+	// AddLockout 保留最近的锁定记录给管理后台看
+	AddLockout(ctx context.Context, l domain.LoginLockout) error
+	Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error)
+}
+
+// lockoutKeep 锁定记录最多保留的条数
+const lockoutKeep = 1000
+
+type RedisLoginLimitCache struct {
+	client redis.Cmdable
+}
+
+func NewLoginLimitCache(client redis.Cmdable) LoginLimitCache {
+	return &RedisLoginLimitCache{client: client}
+}
+
+func (c *RedisLoginLimitCache) Check(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) (time.Duration, bool, error) {
+	res, err := c.client.Eval(ctx, luaCheckLogin, c.keys(targets),
+		now.UnixMilli(),
+		limit.Window.Milliseconds(),
+		limit.FreeAttempts,
+		limit.BaseDelay.Milliseconds(),
+		limit.MaxDelay.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(res) != 2 {
+		return 0, false, ErrUnknown
+	}
+
+	wait := time.Duration(res[1]) * time.Millisecond
+	switch res[0] {
+	case 0:
+		return 0, false, nil
+	case -1:
+		return wait, true, nil
+	case -2:
+		return wait, false, nil
+	default:
+		return 0, false, ErrUnknown
+	}
+}
+
+func (c *RedisLoginLimitCache) RecordFailure(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) ([]int, []bool, error) {
+	args := []any{
+		now.UnixMilli(),
+		limit.Window.Milliseconds(),
+		// 同一毫秒内的多次失败也要分开记
+		strconv.FormatInt(now.UnixNano(), 36) + strconv.FormatInt(rand.Int63(), 36),
+		limit.LockDuration.Milliseconds(),
+	}
+	for _, t := range targets {
+		args = append(args, t.LockThreshold)
+	}
+
+	res, err := c.client.Eval(ctx, luaRecordLoginFailure, c.keys(targets), args...).Int64Slice()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res) != len(targets) {
+		return nil, nil, ErrUnknown
+	}
+
+	failures := make([]int, len(res))
+	locked := make([]bool, len(res))
+	for i, cnt := range res {
+		if cnt < 0 {
+			cnt = -cnt
+			locked[i] = true
+		}
+		failures[i] = int(cnt)
+	}
+	return failures, locked, nil
+}
+
+func (c *RedisLoginLimitCache) Clear(ctx context.Context, kind string, value string) error {
+	return c.client.Del(ctx, c.failKey(kind, value), c.lockKey(kind, value)).Err()
+}
+
+func (c *RedisLoginLimitCache) AddLockout(ctx context.Context, l domain.LoginLockout) error {
+	val, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, c.lockoutKey(), val)
+		pipe.LTrim(ctx, c.lockoutKey(), 0, lockoutKeep-1)
+		return nil
+	})
+	return err
+}
+
+func (c *RedisLoginLimitCache) Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error) {
+	vals, err := c.client.LRange(ctx, c.lockoutKey(), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.LoginLockout, 0, len(vals))
+	for _, val := range vals {
+		var l domain.LoginLockout
+		if err = json.Unmarshal([]byte(val), &l); err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, nil
+}
+
+func (c *RedisLoginLimitCache) keys(targets []LoginTarget) []string {
+	keys := make([]string, 0, len(targets)*2)
+	for _, t := range targets {
+		keys = append(keys, c.failKey(t.Kind, t.Value), c.lockKey(t.Kind, t.Value))
+	}
+	return keys
+}
+
+func (c *RedisLoginLimitCache) failKey(kind string, value string) string {
+	return fmt.Sprintf("login:fail:%s:%s", kind, value)
+}
+
+func (c *RedisLoginLimitCache) lockKey(kind string, value string) string {
+	return fmt.Sprintf("login:lock:%s:%s", kind, value)
+}
+
+func (c *RedisLoginLimitCache) lockoutKey() string {
+	return "login:lockouts"
+}
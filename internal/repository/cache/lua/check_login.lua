@@ -0,0 +1,34 @@
+-- KEYS 每个维度两个 key：失败记录（zset，score 是毫秒时间）、锁定标记
+-- ARGV[1] 当前毫秒时间，ARGV[2] 窗口毫秒，ARGV[3] 不限制的失败次数
+-- ARGV[4] 基础延迟毫秒，ARGV[5] 最大延迟毫秒
+-- 返回 {0, 0} 可以尝试，{-1, 剩余锁定毫秒}，{-2, 需要等待的毫秒}
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local free = tonumber(ARGV[3])
+local base = tonumber(ARGV[4])
+local max = tonumber(ARGV[5])
+
+local wait = 0
+for i = 1, #KEYS, 2 do
+    local locked = redis.call("pttl", KEYS[i + 1])
+    if locked > 0 then
+        return {-1, locked}
+    end
+
+    redis.call("zremrangebyscore", KEYS[i], "-inf", now - window)
+    local cnt = redis.call("zcard", KEYS[i])
+    if cnt >= free then
+        -- 超出以后每多失败一次，等待时间翻倍
+        local delay = math.min(base * 2 ^ (cnt - free), max)
+        local last = tonumber(redis.call("zrange", KEYS[i], -1, -1, "withscores")[2])
+        local remain = math.floor(last + delay - now)
+        if remain > wait then
+            wait = remain
+        end
+    end
+end
+
+if wait > 0 then
+    return {-2, wait}
+end
+return {0, 0}
@@ -0,0 +1,6 @@
+local key = KEYS[1]
+-- challenge 可能刚好过期，直接 hincrby 会创建一个没有过期时间的 key
+if redis.call("exists", key) == 0 then
+    return -1
+end
+return redis.call("hincrby", key, "attempts", 1)
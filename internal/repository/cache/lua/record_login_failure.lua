@@ -0,0 +1,26 @@
+-- KEYS 和 check_login.lua 一样
+-- ARGV[1] 当前毫秒时间，ARGV[2] 窗口毫秒，ARGV[3] 这次失败的唯一标识，ARGV[4] 锁定毫秒
+-- ARGV[5] 开始依次是每个维度的锁定阈值
+-- 返回每个维度的失败次数，触发锁定的是负数
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local member = ARGV[3]
+local lock = tonumber(ARGV[4])
+
+local res = {}
+for i = 1, #KEYS, 2 do
+    local threshold = tonumber(ARGV[4 + (i + 1) / 2])
+    redis.call("zadd", KEYS[i], now, member)
+    redis.call("zremrangebyscore", KEYS[i], "-inf", now - window)
+    redis.call("pexpire", KEYS[i], window)
+
+    local cnt = redis.call("zcard", KEYS[i])
+    if cnt >= threshold then
+        redis.call("set", KEYS[i + 1], 1, "PX", lock)
+        -- 解锁以后重新计数
+        redis.call("del", KEYS[i])
+        cnt = -cnt
+    end
+    table.insert(res, cnt)
+end
+return res
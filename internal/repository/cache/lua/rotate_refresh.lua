@@ -0,0 +1,15 @@
+local key = KEYS[1]
+-- 客户端带来的 refresh token 哈希
+local expected = ARGV[1]
+local next = ARGV[2]
+local current = redis.call("hget", key, "refresh_hash")
+if not current then
+    -- 会话不存在或已过期
+    return -1
+elseif current ~= expected then
+    -- 旧的 refresh token 被重复使用，可能已经泄露
+    return -2
+else
+    redis.call("hset", key, "refresh_hash", next)
+    return 0
+end
@@ -0,0 +1,8 @@
+local key = KEYS[1]
+local lastSeen = ARGV[1]
+-- 会话可能刚好过期，直接 hset 会创建一个没有过期时间的 key
+if redis.call("exists", key) == 0 then
+    return -1
+end
+redis.call("hset", key, "last_seen", lastSeen)
+return 0
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type OAuthStateCache interface {
+	// MarkUsed 第一次标记返回 true，已经用过返回 false
+	MarkUsed(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+type RedisOAuthStateCache struct {
+	client redis.Cmdable
+}
+
+func NewOAuthStateCache(client redis.Cmdable) OAuthStateCache {
+	return &RedisOAuthStateCache{client: client}
+}
+
+func (c *RedisOAuthStateCache) MarkUsed(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.key(nonce), 1, ttl).Result()
+}
+
+func (c *RedisOAuthStateCache) key(nonce string) string {
+	return fmt.Sprintf("oauth:state:%s", nonce)
+}
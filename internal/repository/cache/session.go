@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+	"yellowbook/internal/domain"
+)
+
+var (
+	ErrSessionNotFound      = errors.New("会话不存在")
+	ErrRefreshTokenMismatch = errors.New("refresh token 不匹配")
+)
+
+//go:embed lua/rotate_refresh.lua
+var luaRotateRefresh string
+
+//go:embed lua/touch_session.lua
+var luaTouchSession string
+
+type SessionCache interface {
+	Set(ctx context.Context, s domain.Session) error
+	Get(ctx context.Context, id string) (domain.Session, error)
+	// ListByUserId 顺便清理已经过期的会话
+	ListByUserId(ctx context.Context, uid uint64) ([]domain.Session, error)
+	// RotateRefresh refresh_hash 等于 expected 时替换成 next
+	RotateRefresh(ctx context.Context, id string, expected string, next string) error
+	Touch(ctx context.Context, id string, lastSeen time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+// RedisSessionCache 每个会话一个 hash，另外用 set 记录用户名下的会话 id
+type RedisSessionCache struct {
+	client redis.Cmdable
+}
+
+func NewSessionCache(client redis.Cmdable) SessionCache {
+	return &RedisSessionCache{client: client}
+}
+
+func (cache *RedisSessionCache) Set(ctx context.Context, s domain.Session) error {
+	key := cache.key(s.Id)
+	indexKey := cache.indexKey(s.UserId)
+	_, err := cache.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"user_id", s.UserId,
+			"refresh_hash", s.RefreshHash,
+			"device", s.Device,
+			"user_agent", s.UserAgent,
+			"ip", s.Ip,
+			"login_method", s.LoginMethod,
+			"create_time", s.CreateTime.UnixMilli(),
+			"expire_time", s.ExpireTime.UnixMilli(),
+			"last_seen", s.LastSeen.UnixMilli(),
+		)
+		pipe.ExpireAt(ctx, key, s.ExpireTime)
+		pipe.SAdd(ctx, indexKey, s.Id)
+		// 会话有效期都一样，新会话一定最晚过期，索引跟着它走
+		pipe.ExpireAt(ctx, indexKey, s.ExpireTime)
+		return nil
+	})
+	return err
+}
+
+func (cache *RedisSessionCache) Get(ctx context.Context, id string) (domain.Session, error) {
+	vals, err := cache.client.HGetAll(ctx, cache.key(id)).Result()
+	if err != nil {
+		return domain.Session{}, err
+	}
+	if len(vals) == 0 {
+		return domain.Session{}, ErrSessionNotFound
+	}
+
+	return cache.toDomain(id, vals)
+}
+
+func (cache *RedisSessionCache) ListByUserId(ctx context.Context, uid uint64) ([]domain.Session, error) {
+	indexKey := cache.indexKey(uid)
+	ids, err := cache.client.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []domain.Session{}, nil
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, 0, len(ids))
+	_, err = cache.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			cmds = append(cmds, pipe.HGetAll(ctx, cache.key(id)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]domain.Session, 0, len(ids))
+	var expired []any
+	for i, cmd := range cmds {
+		vals := cmd.Val()
+		if len(vals) == 0 {
+			expired = append(expired, ids[i])
+			continue
+		}
+		s, err := cache.toDomain(ids[i], vals)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	if len(expired) > 0 {
+		if err = cache.client.SRem(ctx, indexKey, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sessions, nil
+}
+
+func (cache *RedisSessionCache) RotateRefresh(ctx context.Context, id string, expected string, next string) error {
+	res, err := cache.client.Eval(ctx, luaRotateRefresh, []string{cache.key(id)}, expected, next).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		return nil
+	case -1:
+		return ErrSessionNotFound
+	case -2:
+		return ErrRefreshTokenMismatch
+	default:
+		return ErrUnknown
+	}
+}
+
+func (cache *RedisSessionCache) Touch(ctx context.Context, id string, lastSeen time.Time) error {
+	return cache.client.Eval(ctx, luaTouchSession, []string{cache.key(id)}, lastSeen.UnixMilli()).Err()
+}
+
+func (cache *RedisSessionCache) Delete(ctx context.Context, id string) error {
+	key := cache.key(id)
+	uid, err := cache.client.HGet(ctx, key, "user_id").Uint64()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.SRem(ctx, cache.indexKey(uid), id)
+		return nil
+	})
+	return err
+}
+
+func (cache *RedisSessionCache) toDomain(id string, vals map[string]string) (domain.Session, error) {
+	uid, err := strconv.ParseUint(vals["user_id"], 10, 64)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	return domain.Session{
+		Id:          id,
+		UserId:      uid,
+		RefreshHash: vals["refresh_hash"],
+		Device:      vals["device"],
+		UserAgent:   vals["user_agent"],
+		Ip:          vals["ip"],
+		LoginMethod: vals["login_method"],
+		CreateTime:  cache.parseTime(vals["create_time"]),
+		ExpireTime:  cache.parseTime(vals["expire_time"]),
+		LastSeen:    cache.parseTime(vals["last_seen"]),
+	}, nil
+}
+
+func (cache *RedisSessionCache) parseTime(val string) time.Time {
+	ms, _ := strconv.ParseInt(val, 10, 64)
+	return time.UnixMilli(ms)
+}
+
+func (cache *RedisSessionCache) key(id string) string {
+	return fmt.Sprintf("user:session:%s", id)
+}
+
+func (cache *RedisSessionCache) indexKey(uid uint64) string {
+	return fmt.Sprintf("user:sessions:%d", uid)
+}
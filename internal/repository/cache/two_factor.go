@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+	"yellowbook/internal/domain"
+)
+
+var ErrChallengeNotFound = errors.New("两步验证的 challenge 不存在")
+
+//go:embed lua/incr_challenge.lua
+var luaIncrChallenge string
+
+type TwoFactorChallengeCache interface {
+	Set(ctx context.Context, c domain.TwoFactorChallenge) error
+	Get(ctx context.Context, id string) (domain.TwoFactorChallenge, error)
+	// IncrAttempts 返回加一以后的失败次数
+	IncrAttempts(ctx context.Context, id string) (int, error)
+	// Delete 返回 false 说明已经被删掉了，用来保证 challenge 只能用一次
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type RedisTwoFactorChallengeCache struct {
+	client redis.Cmdable
+}
+
+func NewTwoFactorChallengeCache(client redis.Cmdable) TwoFactorChallengeCache {
+	return &RedisTwoFactorChallengeCache{client: client}
+}
+
+func (cache *RedisTwoFactorChallengeCache) Set(ctx context.Context, c domain.TwoFactorChallenge) error {
+	key := cache.key(c.Id)
+	_, err := cache.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"user_id", c.UserId,
+			"login_method", c.LoginMethod,
+			"attempts", c.Attempts,
+			"expire_time", c.ExpireTime.UnixMilli(),
+		)
+		pipe.ExpireAt(ctx, key, c.ExpireTime)
+		return nil
+	})
+	return err
+}
+
+func (cache *RedisTwoFactorChallengeCache) Get(ctx context.Context, id string) (domain.TwoFactorChallenge, error) {
+	vals, err := cache.client.HGetAll(ctx, cache.key(id)).Result()
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+	if len(vals) == 0 {
+		return domain.TwoFactorChallenge{}, ErrChallengeNotFound
+	}
+
+	uid, err := strconv.ParseUint(vals["user_id"], 10, 64)
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+	attempts, _ := strconv.Atoi(vals["attempts"])
+	expireTime, _ := strconv.ParseInt(vals["expire_time"], 10, 64)
+
+	return domain.TwoFactorChallenge{
+		Id:          id,
+		UserId:      uid,
+		LoginMethod: vals["login_method"],
+		Attempts:    attempts,
+		ExpireTime:  time.UnixMilli(expireTime),
+	}, nil
+}
+
+func (cache *RedisTwoFactorChallengeCache) IncrAttempts(ctx context.Context, id string) (int, error) {
+	res, err := cache.client.Eval(ctx, luaIncrChallenge, []string{cache.key(id)}).Int()
+	if err != nil {
+		return 0, err
+	}
+	if res < 0 {
+		return 0, ErrChallengeNotFound
+	}
+	return res, nil
+}
+
+func (cache *RedisTwoFactorChallengeCache) Delete(ctx context.Context, id string) (bool, error) {
+	n, err := cache.client.Del(ctx, cache.key(id)).Result()
+	return n > 0, err
+}
+
+func (cache *RedisTwoFactorChallengeCache) key(id string) string {
+	return fmt.Sprintf("login:2fa:%s", id)
+}
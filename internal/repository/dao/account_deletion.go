@@ -0,0 +1,144 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"yellowbook/internal/event"
+)
+
+var ErrDeletionNotDue = errors.New("没有到期的注销申请")
+
+func (dao *GormUserDAO) ScheduleDeletion(ctx context.Context, id uint64, deleteAfter int64) error {
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND deleted_time = ?", id, 0).
+		Updates(map[string]any{
+			"delete_after": deleteAfter,
+			"update_time":  time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (dao *GormUserDAO) CancelDeletion(ctx context.Context, id uint64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND delete_after > ?", id, 0).
+		Updates(map[string]any{
+			"delete_after": 0,
+			"update_time":  time.Now().UnixMilli(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (dao *GormUserDAO) FindDueDeletions(ctx context.Context, now int64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := dao.db.WithContext(ctx).Model(&User{}).
+		Where("delete_after > ? AND delete_after <= ?", 0, now).
+		Order("delete_after ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
+
+// Anonymize 保留 users 和 user_profiles 的行，文章和其它数据还指向这个 id
+// 邮箱和手机号置为 NULL，唯一索引允许多个 NULL，这些标识可以重新注册
+func (dao *GormUserDAO) Anonymize(ctx context.Context, id uint64, reassignTo uint64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 加锁后再判断一次，期间登录取消了申请就不处理
+		var u User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&u).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		if u.DeleteAfter == 0 || u.DeleteAfter > now {
+			return ErrDeletionNotDue
+		}
+
+		err = tx.Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+			IdentityEmail:    nil,
+			IdentityPhone:    nil,
+			"handle":         nil,
+			"email_verified": false,
+			"password":       "",
+			"delete_after":   0,
+			"deleted_time":   now,
+			"update_time":    now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Where("user_id = ?", id).Delete(&UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err = tx.Where("user_id = ?", id).Delete(&UserHandleHistory{}).Error; err != nil {
+			return err
+		}
+		if err = tx.Where("user_id = ?", id).Delete(&UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err = tx.Where("user_id = ?", id).Delete(&UserTOTP{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&UserProfile{}).Where("user_id = ?", id).Updates(map[string]any{
+			"nickname":        "",
+			"birthday":        0,
+			"avatar":          "",
+			"avatar_variants": "",
+			"gender":          0,
+			"introduction":    "",
+			"update_time":     now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		articles := tx.Model(&Article{}).Where("author_id = ?", id)
+		if reassignTo != 0 {
+			err = articles.Updates(map[string]any{"author_id": reassignTo, "update_time": now}).Error
+		} else {
+			err = articles.Updates(map[string]any{"status": ArticleStatusUnpublished, "update_time": now}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = deleteResources(tx, id); err != nil {
+			return err
+		}
+
+		return appendOutbox(tx, event.UserDeleted{UserId: id, ReassignTo: reassignTo})
+	})
+}
+
+// deleteResources 只删除资源记录，oss.IService 没有删除接口，OSS 上的文件会保留。
+// 每个文件发一条 ResourceDeleted，以后换了能删除的存储可以据此补删
+func deleteResources(tx *gorm.DB, uid uint64) error {
+	var resources []Resource
+	err := tx.Where("upload_user_id = ?", uid).Find(&resources).Error
+	if err != nil || len(resources) == 0 {
+		return err
+	}
+
+	if err = tx.Where("upload_user_id = ?", uid).Delete(&Resource{}).Error; err != nil {
+		return err
+	}
+
+	for _, r := range resources {
+		err = appendOutbox(tx, event.ResourceDeleted{Url: r.Url, UploadUserId: uid})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	ErrAdminNotFound  = gorm.ErrRecordNotFound
+	ErrAdminDuplicate = errors.New("管理员用户名冲突")
+	ErrRoleNotFound   = errors.New("角色不存在")
+	ErrRoleDuplicate  = errors.New("角色名冲突")
+)
+
+// Admin 管理后台的账号，不和 users 共用，App 的用户拿不到后台权限
+type Admin struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	Username   string `gorm:"type:varchar(64);unique"`
+	Password   string
+	Disabled   bool
+	CreateTime int64
+	UpdateTime int64
+}
+
+type AdminRole struct {
+	Id          uint64 `gorm:"primaryKey,autoIncrement"`
+	Name        string `gorm:"type:varchar(64);unique"`
+	Description string `gorm:"type:varchar(255)"`
+	CreateTime  int64
+	UpdateTime  int64
+}
+
+type AdminRolePermission struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	RoleId     uint64 `gorm:"uniqueIndex:uk_role_permission"`
+	Permission string `gorm:"type:varchar(64);uniqueIndex:uk_role_permission"`
+}
+
+type AdminUserRole struct {
+	Id      uint64 `gorm:"primaryKey,autoIncrement"`
+	AdminId uint64 `gorm:"uniqueIndex:uk_admin_role"`
+	RoleId  uint64 `gorm:"uniqueIndex:uk_admin_role;index"`
+}
+
+type AdminDAO interface {
+	Count(ctx context.Context) (int64, error)
+	// Insert roleIds 里有不存在的角色时返回 ErrRoleNotFound
+	Insert(ctx context.Context, a Admin, roleIds []uint64) (uint64, error)
+	FindById(ctx context.Context, id uint64) (Admin, error)
+	FindByUsername(ctx context.Context, username string) (Admin, error)
+	List(ctx context.Context) ([]Admin, error)
+	SetDisabled(ctx context.Context, id uint64, disabled bool) error
+	// SetRoles 替换管理员的全部角色
+	SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error
+	// FindAdminRoles 返回这些管理员和角色的对应关系
+	FindAdminRoles(ctx context.Context, adminIds []uint64) ([]AdminUserRole, error)
+	// FindRoles ids 为空时返回所有角色
+	FindRoles(ctx context.Context, ids []uint64) ([]AdminRole, error)
+	FindPermissions(ctx context.Context, roleIds []uint64) ([]AdminRolePermission, error)
+	// SaveRole Id 为 0 时新建，否则更新，权限整体替换
+	SaveRole(ctx context.Context, r AdminRole, permissions []string) (uint64, error)
+	// DeleteRole 连同权限和管理员身上的这个角色一起删除
+	DeleteRole(ctx context.Context, id uint64) error
+}
+
+type GormAdminDAO struct {
+	db *gorm.DB
+}
+
+func NewAdminDAO(db *gorm.DB) AdminDAO {
+	return &GormAdminDAO{db: db}
+}
+
+func (dao *GormAdminDAO) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&Admin{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *GormAdminDAO) Insert(ctx context.Context, a Admin, roleIds []uint64) (uint64, error) {
+	now := time.Now().UnixMilli()
+	a.CreateTime = now
+	a.UpdateTime = now
+
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		return replaceAdminRoles(tx, a.Id, roleIds)
+	})
+	if isDuplicate(err) {
+		return 0, ErrAdminDuplicate
+	}
+
+	return a.Id, err
+}
+
+func (dao *GormAdminDAO) FindById(ctx context.Context, id uint64) (Admin, error) {
+	var a Admin
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&a).Error
+	return a, err
+}
+
+func (dao *GormAdminDAO) FindByUsername(ctx context.Context, username string) (Admin, error) {
+	var a Admin
+	err := dao.db.WithContext(ctx).Where("username = ?", username).First(&a).Error
+	return a, err
+}
+
+func (dao *GormAdminDAO) List(ctx context.Context) ([]Admin, error) {
+	var admins []Admin
+	err := dao.db.WithContext(ctx).Order("id").Find(&admins).Error
+	return admins, err
+}
+
+func (dao *GormAdminDAO) SetDisabled(ctx context.Context, id uint64, disabled bool) error {
+	res := dao.db.WithContext(ctx).Model(&Admin{}).Where("id = ?", id).Updates(map[string]any{
+		"disabled":    disabled,
+		"update_time": time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAdminNotFound
+	}
+	return nil
+}
+
+func (dao *GormAdminDAO) SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Admin{}).Where("id = ?", adminId).Update("update_time", time.Now().UnixMilli())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrAdminNotFound
+		}
+		return replaceAdminRoles(tx, adminId, roleIds)
+	})
+}
+
+func replaceAdminRoles(tx *gorm.DB, adminId uint64, roleIds []uint64) error {
+	if err := tx.Where("admin_id = ?", adminId).Delete(&AdminUserRole{}).Error; err != nil {
+		return err
+	}
+	if len(roleIds) == 0 {
+		return nil
+	}
+
+	seen := make(map[uint64]struct{}, len(roleIds))
+	links := make([]AdminUserRole, 0, len(roleIds))
+	for _, id := range roleIds {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		links = append(links, AdminUserRole{AdminId: adminId, RoleId: id})
+	}
+
+	// 加共享锁，避免角色在这期间被删掉
+	var count int64
+	err := tx.Model(&AdminRole{}).Clauses(clause.Locking{Strength: "SHARE"}).Where("id IN ?", roleIds).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if int(count) != len(links) {
+		return ErrRoleNotFound
+	}
+
+	return tx.Create(&links).Error
+}
+
+func (dao *GormAdminDAO) FindAdminRoles(ctx context.Context, adminIds []uint64) ([]AdminUserRole, error) {
+	var links []AdminUserRole
+	if len(adminIds) == 0 {
+		return links, nil
+	}
+	err := dao.db.WithContext(ctx).Where("admin_id IN ?", adminIds).Find(&links).Error
+	return links, err
+}
+
+func (dao *GormAdminDAO) FindRoles(ctx context.Context, ids []uint64) ([]AdminRole, error) {
+	var roles []AdminRole
+	query := dao.db.WithContext(ctx).Order("id")
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	err := query.Find(&roles).Error
+	return roles, err
+}
+
+func (dao *GormAdminDAO) FindPermissions(ctx context.Context, roleIds []uint64) ([]AdminRolePermission, error) {
+	var permissions []AdminRolePermission
+	if len(roleIds) == 0 {
+		return permissions, nil
+	}
+	err := dao.db.WithContext(ctx).Where("role_id IN ?", roleIds).Order("id").Find(&permissions).Error
+	return permissions, err
+}
+
+func (dao *GormAdminDAO) SaveRole(ctx context.Context, r AdminRole, permissions []string) (uint64, error) {
+	now := time.Now().UnixMilli()
+	r.UpdateTime = now
+
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if r.Id == 0 {
+			r.CreateTime = now
+			if err := tx.Create(&r).Error; err != nil {
+				return err
+			}
+		} else {
+			res := tx.Model(&AdminRole{}).Where("id = ?", r.Id).Updates(map[string]any{
+				"name":        r.Name,
+				"description": r.Description,
+				"update_time": r.UpdateTime,
+			})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrRoleNotFound
+			}
+			if err := tx.Where("role_id = ?", r.Id).Delete(&AdminRolePermission{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(permissions) == 0 {
+			return nil
+		}
+		rows := make([]AdminRolePermission, 0, len(permissions))
+		for _, p := range permissions {
+			rows = append(rows, AdminRolePermission{RoleId: r.Id, Permission: p})
+		}
+		return tx.Create(&rows).Error
+	})
+	if isDuplicate(err) {
+		return 0, ErrRoleDuplicate
+	}
+
+	return r.Id, err
+}
+
+func (dao *GormAdminDAO) DeleteRole(ctx context.Context, id uint64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&AdminRole{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&AdminRolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("role_id = ?", id).Delete(&AdminUserRole{}).Error
+	})
+}
@@ -5,13 +5,28 @@ import (
 	"fmt"
 	"gorm.io/gorm"
 	"time"
+	"yellowbook/internal/event"
 	"yellowbook/internal/pkg/gormutil"
 )
 
+var ErrArticleNotFound = gorm.ErrRecordNotFound
+
+// 文章状态，作者注销账号时按配置下架
+const (
+	ArticleStatusPublished   uint8 = 1
+	ArticleStatusUnpublished uint8 = 2
+)
+
 type IArticleDAO interface {
 	Insert(ctx context.Context, art Article) (uint64, error)
 	Update(ctx context.Context, article Article) error
 	FindList(ctx context.Context) ([]Article, int64, error)
+	FindById(ctx context.Context, id uint64) (Article, error)
+	// FindByAuthor 作者指定状态的最新 limit 篇文章，total 是这个状态的文章总数
+	FindByAuthor(ctx context.Context, authorId uint64, status uint8, limit int) ([]Article, int64, error)
+	UpdateImageList(ctx context.Context, id uint64, imageList []string) error
+	// UpdateStatus 文章不存在返回 ErrArticleNotFound
+	UpdateStatus(ctx context.Context, id uint64, status uint8) error
 }
 
 type ArticleDAO struct {
@@ -28,8 +43,15 @@ func (dao *ArticleDAO) Insert(ctx context.Context, art Article) (uint64, error)
 	now := time.Now().UnixMilli()
 	art.CreateTime = now
 	art.UpdateTime = now
+	art.Status = ArticleStatusPublished
 
-	err := dao.db.WithContext(ctx).Create(&art).Error
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&art).Error; err != nil {
+			return err
+		}
+
+		return appendOutbox(tx, articleSaved(art, true))
+	})
 
 	return art.Id, err
 }
@@ -38,25 +60,37 @@ func (dao *ArticleDAO) Update(ctx context.Context, article Article) error {
 	now := time.Now().UnixMilli()
 	article.UpdateTime = now
 
-	res := dao.db.WithContext(ctx).Model(&article).
-		Where("id = ? AND author_id = ?", article.Id, article.AuthorId).
-		Updates(map[string]any{
-			"title":       article.Title,
-			"content":     article.Content,
-			"update_time": article.UpdateTime,
-			"image_list":  article.ImageList,
-		})
-
-	if res.Error != nil {
-		return res.Error
-	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&article).
+			Where("id = ? AND author_id = ?", article.Id, article.AuthorId).
+			Updates(map[string]any{
+				"title":       article.Title,
+				"content":     article.Content,
+				"update_time": article.UpdateTime,
+				"image_list":  article.ImageList,
+			})
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			// 记录日志
+			return fmt.Errorf("更新失败")
+		}
+
+		return appendOutbox(tx, articleSaved(article, false))
+	})
+}
 
-	if res.RowsAffected == 0 {
-		// 记录日志
-		return fmt.Errorf("更新失败")
+func articleSaved(art Article, created bool) event.ArticleSaved {
+	return event.ArticleSaved{
+		ArticleId: art.Id,
+		AuthorId:  art.AuthorId,
+		Title:     art.Title,
+		ImageList: art.ImageList,
+		Created:   created,
 	}
-
-	return res.Error
 }
 
 func (dao *ArticleDAO) FindList(ctx context.Context) ([]Article, int64, error) {
@@ -73,12 +107,60 @@ func (dao *ArticleDAO) FindList(ctx context.Context) ([]Article, int64, error) {
 	return articles, total, err
 }
 
+func (dao *ArticleDAO) FindByAuthor(ctx context.Context, authorId uint64, status uint8, limit int) ([]Article, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&Article{}).
+		Where("author_id = ? AND status = ?", authorId, status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var articles []Article
+	err := query.Order("create_time DESC, id DESC").Limit(limit).Find(&articles).Error
+	return articles, total, err
+}
+
+func (dao *ArticleDAO) FindById(ctx context.Context, id uint64) (Article, error) {
+	var art Article
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&art).Error
+
+	return art, err
+}
+
+// UpdateImageList 只替换图片列表，不校验作者，给内部任务使用
+func (dao *ArticleDAO) UpdateImageList(ctx context.Context, id uint64, imageList []string) error {
+	return dao.db.WithContext(ctx).Model(&Article{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"image_list":  gormutil.StringList(imageList),
+			"update_time": time.Now().UnixMilli(),
+		}).Error
+}
+
+func (dao *ArticleDAO) UpdateStatus(ctx context.Context, id uint64, status uint8) error {
+	res := dao.db.WithContext(ctx).Model(&Article{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":      status,
+			"update_time": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
 type Article struct {
 	Id         uint64 `gorm:"primaryKey,autoIncrement"`
 	Title      string `gorm:"type=varchar(128)"`
 	Content    string `gorm:"type=varchar(1024)"`
 	ImageList  gormutil.StringList
 	AuthorId   uint64 `gorm:"index"`
+	Status     uint8  `gorm:"default:1"`
 	CreateTime int64
 	UpdateTime int64
 }
@@ -0,0 +1,89 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+	"yellowbook/internal/pkg/gormutil"
+)
+
+// AuditLog 只有插入和查询，没有更新和删除的方法
+type AuditLog struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	AdminId    uint64 `gorm:"index:idx_admin_create_time"`
+	AdminName  string `gorm:"type:varchar(64)"`
+	Action     string `gorm:"type:varchar(64)"`
+	EntityType string `gorm:"type:varchar(32);index:idx_entity"`
+	EntityId   string `gorm:"type:varchar(64);index:idx_entity"`
+	Before     string `gorm:"type:text"`
+	After      string `gorm:"type:text"`
+	Ip         string `gorm:"type:varchar(64)"`
+	Path       string `gorm:"type:varchar(255)"`
+	Status     int
+	CreateTime int64 `gorm:"index;index:idx_admin_create_time"`
+}
+
+type AuditLogFilter struct {
+	AdminId    uint64
+	Action     string
+	EntityType string
+	EntityId   string
+	// StartTime 和 EndTime 是毫秒时间戳，左闭右开，0 表示不限
+	StartTime int64
+	EndTime   int64
+	Page      int
+	PageSize  int
+}
+
+type IAuditLogDAO interface {
+	Insert(ctx context.Context, l AuditLog) error
+	// Search 按时间倒序
+	Search(ctx context.Context, filter AuditLogFilter) ([]AuditLog, int64, error)
+}
+
+type AuditLogDAO struct {
+	db *gorm.DB
+}
+
+func NewAuditLogDAO(db *gorm.DB) IAuditLogDAO {
+	return &AuditLogDAO{db: db}
+}
+
+func (dao *AuditLogDAO) Insert(ctx context.Context, l AuditLog) error {
+	l.CreateTime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&l).Error
+}
+
+func (dao *AuditLogDAO) Search(ctx context.Context, filter AuditLogFilter) ([]AuditLog, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&AuditLog{})
+	if filter.AdminId != 0 {
+		query = query.Where("admin_id = ?", filter.AdminId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityId != "" {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+	if filter.StartTime != 0 {
+		query = query.Where("create_time >= ?", filter.StartTime)
+	}
+	if filter.EndTime != 0 {
+		query = query.Where("create_time < ?", filter.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []AuditLog
+	err := query.Scopes(gormutil.Paginate(filter.Page, filter.PageSize)).
+		Order("create_time DESC, id DESC").
+		Find(&logs).Error
+
+	return logs, total, err
+}
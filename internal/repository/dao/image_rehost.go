@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ImageRehostTask struct {
+	Id            uint64 `gorm:"primaryKey,autoIncrement"`
+	ArticleId     uint64 `gorm:"index"`
+	SourceUrl     string `gorm:"type:varchar(1024)"`
+	TargetUrl     string `gorm:"type:varchar(1024)"`
+	Status        uint8  `gorm:"index:idx_status_next_retry_time"`
+	Retry         int
+	LastError     string `gorm:"type:varchar(512)"`
+	NextRetryTime int64  `gorm:"index:idx_status_next_retry_time"`
+	CreateTime    int64
+	UpdateTime    int64
+}
+
+type IImageRehostDAO interface {
+	InsertBatch(ctx context.Context, tasks []ImageRehostTask) error
+	FindDue(ctx context.Context, status uint8, now int64, limit int) ([]ImageRehostTask, error)
+	Claim(ctx context.Context, id uint64, nextRetryTime int64, leaseUntil int64) (bool, error)
+	Update(ctx context.Context, task ImageRehostTask) error
+	FindByArticleId(ctx context.Context, articleId uint64) ([]ImageRehostTask, error)
+}
+
+type ImageRehostDAO struct {
+	db *gorm.DB
+}
+
+func NewImageRehostDAO(db *gorm.DB) IImageRehostDAO {
+	return &ImageRehostDAO{db: db}
+}
+
+func (dao *ImageRehostDAO) InsertBatch(ctx context.Context, tasks []ImageRehostTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	for i := range tasks {
+		tasks[i].CreateTime = now
+		tasks[i].UpdateTime = now
+	}
+
+	return dao.db.WithContext(ctx).Create(&tasks).Error
+}
+
+func (dao *ImageRehostDAO) FindDue(ctx context.Context, status uint8, now int64, limit int) ([]ImageRehostTask, error) {
+	var tasks []ImageRehostTask
+	err := dao.db.WithContext(ctx).
+		Where("status = ? AND next_retry_time <= ?", status, now).
+		Order("next_retry_time ASC").
+		Limit(limit).
+		Find(&tasks).Error
+
+	return tasks, err
+}
+
+// Claim 用 next_retry_time 做乐观锁，多个实例同时跑时只有一个能抢到任务
+func (dao *ImageRehostDAO) Claim(ctx context.Context, id uint64, nextRetryTime int64, leaseUntil int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&ImageRehostTask{}).
+		Where("id = ? AND next_retry_time = ?", id, nextRetryTime).
+		Updates(map[string]any{
+			"next_retry_time": leaseUntil,
+			"update_time":     time.Now().UnixMilli(),
+		})
+
+	return res.RowsAffected == 1, res.Error
+}
+
+func (dao *ImageRehostDAO) Update(ctx context.Context, task ImageRehostTask) error {
+	return dao.db.WithContext(ctx).Model(&ImageRehostTask{}).
+		Where("id = ?", task.Id).
+		Updates(map[string]any{
+			"target_url":      task.TargetUrl,
+			"status":          task.Status,
+			"retry":           task.Retry,
+			"last_error":      task.LastError,
+			"next_retry_time": task.NextRetryTime,
+			"update_time":     time.Now().UnixMilli(),
+		}).Error
+}
+
+func (dao *ImageRehostDAO) FindByArticleId(ctx context.Context, articleId uint64) ([]ImageRehostTask, error) {
+	var tasks []ImageRehostTask
+	err := dao.db.WithContext(ctx).Where("article_id = ?", articleId).Order("id ASC").Find(&tasks).Error
+
+	return tasks, err
+}
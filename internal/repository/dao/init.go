@@ -3,11 +3,38 @@ package dao
 import "gorm.io/gorm"
 
 func InitTable(db *gorm.DB) error {
-	return db.AutoMigrate(
+	// 要在 AutoMigrate 加上这一列之前判断
+	backfillEmailVerified := db.Migrator().HasTable(&User{}) && !db.Migrator().HasColumn(&User{}, "email_verified")
+
+	err := db.AutoMigrate(
 		&User{},
 		&UserProfile{},
+		&UserIdentity{},
+		&UserHandleHistory{},
+		&UserTOTP{},
+		&UserRecoveryCode{},
 		&Resource{},
 		&Article{},
+		&ImageRehostTask{},
+		&OutboxMessage{},
+		&Admin{},
+		&AdminRole{},
+		&AdminRolePermission{},
+		&AdminUserRole{},
+		&AuditLog{},
 		//&SMSRetry{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if backfillEmailVerified {
+		// 上线邮箱验证之前注册的账号不再要求验证
+		err = db.Model(&User{}).Where("email IS NOT NULL").Update("email_verified", true).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return migrateGithubId(db)
 }
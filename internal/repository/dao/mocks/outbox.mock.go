@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/dao/outbox.go
+
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	dao "yellowbook/internal/repository/dao"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIOutboxDAO is a mock of IOutboxDAO interface.
+type MockIOutboxDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOutboxDAOMockRecorder
+}
+
+// MockIOutboxDAOMockRecorder is the mock recorder for MockIOutboxDAO.
+type MockIOutboxDAOMockRecorder struct {
+	mock *MockIOutboxDAO
+}
+
+// NewMockIOutboxDAO creates a new mock instance.
+func NewMockIOutboxDAO(ctrl *gomock.Controller) *MockIOutboxDAO {
+	mock := &MockIOutboxDAO{ctrl: ctrl}
+	mock.recorder = &MockIOutboxDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOutboxDAO) EXPECT() *MockIOutboxDAOMockRecorder {
+	return m.recorder
+}
+
+// PurgeSent mocks base method.
+func (m *MockIOutboxDAO) PurgeSent(ctx context.Context, before int64, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeSent", ctx, before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeSent indicates an expected call of PurgeSent.
+func (mr *MockIOutboxDAOMockRecorder) PurgeSent(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeSent", reflect.TypeOf((*MockIOutboxDAO)(nil).PurgeSent), ctx, before, limit)
+}
+
+// Relay mocks base method.
+func (m *MockIOutboxDAO) Relay(ctx context.Context, limit int, fn func([]dao.OutboxMessage) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relay", ctx, limit, fn)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Relay indicates an expected call of Relay.
+func (mr *MockIOutboxDAOMockRecorder) Relay(ctx, limit, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relay", reflect.TypeOf((*MockIOutboxDAO)(nil).Relay), ctx, limit, fn)
+}
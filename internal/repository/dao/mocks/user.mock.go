@@ -9,7 +9,6 @@ import (
 	reflect "reflect"
 	dao "yellowbook/internal/repository/dao"
 
-	proto "github.com/shenxiang11/yellowbook-proto/proto"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -36,6 +35,77 @@ func (m *MockUserDao) EXPECT() *MockUserDaoMockRecorder {
 	return m.recorder
 }
 
+// Anonymize mocks base method.
+func (m *MockUserDao) Anonymize(ctx context.Context, id, reassignTo uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Anonymize", ctx, id, reassignTo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Anonymize indicates an expected call of Anonymize.
+func (mr *MockUserDaoMockRecorder) Anonymize(ctx, id, reassignTo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Anonymize", reflect.TypeOf((*MockUserDao)(nil).Anonymize), ctx, id, reassignTo)
+}
+
+// CancelDeletion mocks base method.
+func (m *MockUserDao) CancelDeletion(ctx context.Context, id uint64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockUserDaoMockRecorder) CancelDeletion(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockUserDao)(nil).CancelDeletion), ctx, id)
+}
+
+// ClearBan mocks base method.
+func (m *MockUserDao) ClearBan(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearBan", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearBan indicates an expected call of ClearBan.
+func (mr *MockUserDaoMockRecorder) ClearBan(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearBan", reflect.TypeOf((*MockUserDao)(nil).ClearBan), ctx, id)
+}
+
+// ClearExternalIdentity mocks base method.
+func (m *MockUserDao) ClearExternalIdentity(ctx context.Context, id uint64, provider string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearExternalIdentity", ctx, id, provider)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearExternalIdentity indicates an expected call of ClearExternalIdentity.
+func (mr *MockUserDaoMockRecorder) ClearExternalIdentity(ctx, id, provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearExternalIdentity", reflect.TypeOf((*MockUserDao)(nil).ClearExternalIdentity), ctx, id, provider)
+}
+
+// ClearIdentity mocks base method.
+func (m *MockUserDao) ClearIdentity(ctx context.Context, id uint64, column string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearIdentity", ctx, id, column)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearIdentity indicates an expected call of ClearIdentity.
+func (mr *MockUserDaoMockRecorder) ClearIdentity(ctx, id, column interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearIdentity", reflect.TypeOf((*MockUserDao)(nil).ClearIdentity), ctx, id, column)
+}
+
 // FindByEmail mocks base method.
 func (m *MockUserDao) FindByEmail(ctx context.Context, email string) (dao.User, error) {
 	m.ctrl.T.Helper()
@@ -51,19 +121,19 @@ func (mr *MockUserDaoMockRecorder) FindByEmail(ctx, email interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserDao)(nil).FindByEmail), ctx, email)
 }
 
-// FindByGithubId mocks base method.
-func (m *MockUserDao) FindByGithubId(ctx context.Context, id uint64) (dao.User, error) {
+// FindByIdentity mocks base method.
+func (m *MockUserDao) FindByIdentity(ctx context.Context, provider, subject string) (dao.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByGithubId", ctx, id)
+	ret := m.ctrl.Call(m, "FindByIdentity", ctx, provider, subject)
 	ret0, _ := ret[0].(dao.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindByGithubId indicates an expected call of FindByGithubId.
-func (mr *MockUserDaoMockRecorder) FindByGithubId(ctx, id interface{}) *gomock.Call {
+// FindByIdentity indicates an expected call of FindByIdentity.
+func (mr *MockUserDaoMockRecorder) FindByIdentity(ctx, provider, subject interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByGithubId", reflect.TypeOf((*MockUserDao)(nil).FindByGithubId), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIdentity", reflect.TypeOf((*MockUserDao)(nil).FindByIdentity), ctx, provider, subject)
 }
 
 // FindByPhone mocks base method.
@@ -81,6 +151,36 @@ func (mr *MockUserDaoMockRecorder) FindByPhone(ctx, phone interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserDao)(nil).FindByPhone), ctx, phone)
 }
 
+// FindDueDeletions mocks base method.
+func (m *MockUserDao) FindDueDeletions(ctx context.Context, now int64, limit int) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDueDeletions", ctx, now, limit)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDueDeletions indicates an expected call of FindDueDeletions.
+func (mr *MockUserDaoMockRecorder) FindDueDeletions(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDueDeletions", reflect.TypeOf((*MockUserDao)(nil).FindDueDeletions), ctx, now, limit)
+}
+
+// FindIdByHandle mocks base method.
+func (m *MockUserDao) FindIdByHandle(ctx context.Context, handle string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIdByHandle", ctx, handle)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIdByHandle indicates an expected call of FindIdByHandle.
+func (mr *MockUserDaoMockRecorder) FindIdByHandle(ctx, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIdByHandle", reflect.TypeOf((*MockUserDao)(nil).FindIdByHandle), ctx, handle)
+}
+
 // FindProfileByUserId mocks base method.
 func (m *MockUserDao) FindProfileByUserId(ctx context.Context, userId uint64) (dao.User, error) {
 	m.ctrl.T.Helper()
@@ -97,11 +197,12 @@ func (mr *MockUserDaoMockRecorder) FindProfileByUserId(ctx, userId interface{})
 }
 
 // Insert mocks base method.
-func (m *MockUserDao) Insert(ctx context.Context, u dao.User) error {
+func (m *MockUserDao) Insert(ctx context.Context, u dao.User) (uint64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Insert", ctx, u)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Insert indicates an expected call of Insert.
@@ -110,8 +211,51 @@ func (mr *MockUserDaoMockRecorder) Insert(ctx, u interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUserDao)(nil).Insert), ctx, u)
 }
 
+// InsertWithIdentity mocks base method.
+func (m *MockUserDao) InsertWithIdentity(ctx context.Context, u dao.User, identity dao.UserIdentity) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertWithIdentity", ctx, u, identity)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertWithIdentity indicates an expected call of InsertWithIdentity.
+func (mr *MockUserDaoMockRecorder) InsertWithIdentity(ctx, u, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertWithIdentity", reflect.TypeOf((*MockUserDao)(nil).InsertWithIdentity), ctx, u, identity)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserDao) MarkEmailVerified(ctx context.Context, id uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserDaoMockRecorder) MarkEmailVerified(ctx, id, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserDao)(nil).MarkEmailVerified), ctx, id, email)
+}
+
+// Merge mocks base method.
+func (m *MockUserDao) Merge(ctx context.Context, fromId, toId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, fromId, toId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockUserDaoMockRecorder) Merge(ctx, fromId, toId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockUserDao)(nil).Merge), ctx, fromId, toId)
+}
+
 // QueryUsers mocks base method.
-func (m *MockUserDao) QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]dao.User, int64, error) {
+func (m *MockUserDao) QueryUsers(ctx context.Context, filter dao.UserFilter) ([]dao.User, int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "QueryUsers", ctx, filter)
 	ret0, _ := ret[0].([]dao.User)
@@ -126,6 +270,118 @@ func (mr *MockUserDaoMockRecorder) QueryUsers(ctx, filter interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryUsers", reflect.TypeOf((*MockUserDao)(nil).QueryUsers), ctx, filter)
 }
 
+// ScheduleDeletion mocks base method.
+func (m *MockUserDao) ScheduleDeletion(ctx context.Context, id uint64, deleteAfter int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, id, deleteAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockUserDaoMockRecorder) ScheduleDeletion(ctx, id, deleteAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockUserDao)(nil).ScheduleDeletion), ctx, id, deleteAfter)
+}
+
+// SetBan mocks base method.
+func (m *MockUserDao) SetBan(ctx context.Context, id uint64, until int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBan", ctx, id, until, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBan indicates an expected call of SetBan.
+func (mr *MockUserDaoMockRecorder) SetBan(ctx, id, until, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBan", reflect.TypeOf((*MockUserDao)(nil).SetBan), ctx, id, until, reason)
+}
+
+// SetExternalIdentity mocks base method.
+func (m *MockUserDao) SetExternalIdentity(ctx context.Context, id uint64, provider, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExternalIdentity", ctx, id, provider, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExternalIdentity indicates an expected call of SetExternalIdentity.
+func (mr *MockUserDaoMockRecorder) SetExternalIdentity(ctx, id, provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExternalIdentity", reflect.TypeOf((*MockUserDao)(nil).SetExternalIdentity), ctx, id, provider, subject)
+}
+
+// SetHandle mocks base method.
+func (m *MockUserDao) SetHandle(ctx context.Context, id uint64, handle string, changedBefore, redirectUntil int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHandle", ctx, id, handle, changedBefore, redirectUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHandle indicates an expected call of SetHandle.
+func (mr *MockUserDaoMockRecorder) SetHandle(ctx, id, handle, changedBefore, redirectUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHandle", reflect.TypeOf((*MockUserDao)(nil).SetHandle), ctx, id, handle, changedBefore, redirectUntil)
+}
+
+// SetIdentity mocks base method.
+func (m *MockUserDao) SetIdentity(ctx context.Context, id uint64, column string, value any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIdentity", ctx, id, column, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetIdentity indicates an expected call of SetIdentity.
+func (mr *MockUserDaoMockRecorder) SetIdentity(ctx, id, column, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIdentity", reflect.TypeOf((*MockUserDao)(nil).SetIdentity), ctx, id, column, value)
+}
+
+// UpdateAvatar mocks base method.
+func (m *MockUserDao) UpdateAvatar(ctx context.Context, p dao.UserProfile, resources []dao.Resource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAvatar", ctx, p, resources)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAvatar indicates an expected call of UpdateAvatar.
+func (mr *MockUserDaoMockRecorder) UpdateAvatar(ctx, p, resources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAvatar", reflect.TypeOf((*MockUserDao)(nil).UpdateAvatar), ctx, p, resources)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserDao) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserDaoMockRecorder) UpdatePassword(ctx, id, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserDao)(nil).UpdatePassword), ctx, id, password)
+}
+
+// UpdatePrivacy mocks base method.
+func (m *MockUserDao) UpdatePrivacy(ctx context.Context, p dao.UserProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePrivacy", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePrivacy indicates an expected call of UpdatePrivacy.
+func (mr *MockUserDaoMockRecorder) UpdatePrivacy(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePrivacy", reflect.TypeOf((*MockUserDao)(nil).UpdatePrivacy), ctx, p)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockUserDao) UpdateProfile(ctx context.Context, p dao.UserProfile) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,130 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"yellowbook/internal/event"
+)
+
+const (
+	OutboxPending uint8 = iota
+	OutboxSent
+)
+
+// OutboxMessage 和业务数据在同一个事务里写入，再由 relay 异步投递到消息队列
+type OutboxMessage struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	EventId    string `gorm:"type:varchar(64);unique"`
+	Topic      string `gorm:"type:varchar(128)"`
+	Key        string `gorm:"type:varchar(256)"`
+	Payload    []byte `gorm:"type:blob"`
+	Status     uint8  `gorm:"index:idx_status_sent_time"`
+	CreateTime int64
+	SentTime   int64 `gorm:"index:idx_status_sent_time"`
+}
+
+// OutboxPublisher 把事件写进 outbox 表，tx 必须是业务操作所在的事务，
+// 业务回滚时事件也一起回滚，不会出现丢事件或者发了不存在的事件
+type OutboxPublisher struct {
+	tx *gorm.DB
+}
+
+func NewOutboxPublisher(tx *gorm.DB) event.Publisher {
+	return &OutboxPublisher{tx: tx}
+}
+
+func (p *OutboxPublisher) Publish(ctx context.Context, evts ...event.Event) error {
+	now := time.Now()
+	msgs := make([]OutboxMessage, 0, len(evts))
+
+	for _, evt := range evts {
+		env, err := event.NewEnvelope(evt, now)
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(env)
+		if err != nil {
+			return err
+		}
+
+		msgs = append(msgs, OutboxMessage{
+			EventId:    env.Id,
+			Topic:      evt.Topic(),
+			Key:        evt.Key(),
+			Payload:    payload,
+			Status:     OutboxPending,
+			CreateTime: now.UnixMilli(),
+		})
+	}
+
+	return p.tx.WithContext(ctx).Create(&msgs).Error
+}
+
+// appendOutbox 必须传入业务操作所在的事务
+func appendOutbox(tx *gorm.DB, evts ...event.Event) error {
+	return NewOutboxPublisher(tx).Publish(tx.Statement.Context, evts...)
+}
+
+type IOutboxDAO interface {
+	Relay(ctx context.Context, limit int, fn func(msgs []OutboxMessage) error) (int, error)
+	PurgeSent(ctx context.Context, before int64, limit int) (int64, error)
+}
+
+type OutboxDAO struct {
+	db *gorm.DB
+}
+
+func NewOutboxDAO(db *gorm.DB) IOutboxDAO {
+	return &OutboxDAO{db: db}
+}
+
+// Relay 按 id 顺序锁住最早的一批未发送消息，fn 成功后标记为已发送
+// 多个实例同时运行时，后来的会阻塞在 FOR UPDATE 上，等前一个提交后再读到后面的消息，
+// 所以整体顺序不会乱，也不会重复发送（fn 成功但提交失败的极端情况除外，消费方按事件 id 去重）
+func (dao *OutboxDAO) Relay(ctx context.Context, limit int, fn func(msgs []OutboxMessage) error) (int, error) {
+	var n int
+
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var msgs []OutboxMessage
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", OutboxPending).
+			Order("id ASC").
+			Limit(limit).
+			Find(&msgs).Error
+		if err != nil || len(msgs) == 0 {
+			return err
+		}
+
+		if err = fn(msgs); err != nil {
+			return err
+		}
+
+		ids := make([]uint64, 0, len(msgs))
+		for _, m := range msgs {
+			ids = append(ids, m.Id)
+		}
+
+		n = len(msgs)
+		return tx.Model(&OutboxMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":    OutboxSent,
+				"sent_time": time.Now().UnixMilli(),
+			}).Error
+	})
+
+	return n, err
+}
+
+func (dao *OutboxDAO) PurgeSent(ctx context.Context, before int64, limit int) (int64, error) {
+	res := dao.db.WithContext(ctx).
+		Where("status = ? AND sent_time < ?", OutboxSent, before).
+		Limit(limit).
+		Delete(&OutboxMessage{})
+
+	return res.RowsAffected, res.Error
+}
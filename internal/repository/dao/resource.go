@@ -7,6 +7,7 @@ import (
 	"github.com/shenxiang11/yellowbook-proto/proto"
 	"gorm.io/gorm"
 	"time"
+	"yellowbook/internal/event"
 )
 
 var ErrResourceDuplicate = errors.New("资源冲突")
@@ -34,12 +35,31 @@ func NewResourceDAO(db *gorm.DB) IResourceDao {
 }
 
 func (dao *ResourceDao) Insert(ctx context.Context, r Resource) error {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return insertResource(tx, r)
+	})
+
+	return resourceErr(err)
+}
+
+func insertResource(tx *gorm.DB, r Resource) error {
 	now := time.Now().UnixMilli()
 	r.CreateTime = now
 	r.UpdateTime = now
 
-	err := dao.db.WithContext(ctx).Create(&r).Error
+	if err := tx.Create(&r).Error; err != nil {
+		return err
+	}
+
+	return appendOutbox(tx, event.ResourceUploaded{
+		Url:          r.Url,
+		Purpose:      int32(r.Purpose),
+		Mimetype:     r.Mimetype,
+		UploadUserId: r.UploadUserId,
+	})
+}
 
+func resourceErr(err error) error {
 	var mysqlErr *mysql.MySQLError
 	if errors.As(err, &mysqlErr) {
 		const uniqueConflictsErrNo uint16 = 1062
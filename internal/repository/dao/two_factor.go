@@ -0,0 +1,192 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	ErrTOTPNotFound = gorm.ErrRecordNotFound
+	ErrTOTPEnabled  = errors.New("两步验证已经开启")
+)
+
+type TwoFactorDAO interface {
+	FindTOTP(ctx context.Context, uid uint64) (UserTOTP, error)
+	// SavePendingTOTP 没有开启时可以反复覆盖密钥，已经开启返回 ErrTOTPEnabled
+	SavePendingTOTP(ctx context.Context, uid uint64, secret string) error
+	// EnableTOTP 开启并替换掉所有恢复码，step 是确认时用掉的时间步
+	EnableTOTP(ctx context.Context, uid uint64, step int64, codeHashes []string) error
+	// UseStep 只接受比上次大的时间步，返回 false 说明验证码已经用过
+	UseStep(ctx context.Context, uid uint64, step int64) (bool, error)
+	// UseRecoveryCode 返回 false 说明恢复码不存在或者已经用过
+	UseRecoveryCode(ctx context.Context, uid uint64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, uid uint64) (int64, error)
+	// DeleteTOTP 连同恢复码一起删除
+	DeleteTOTP(ctx context.Context, uid uint64) error
+}
+
+type GormTwoFactorDAO struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorDAO(db *gorm.DB) TwoFactorDAO {
+	return &GormTwoFactorDAO{db: db}
+}
+
+func (dao *GormTwoFactorDAO) FindTOTP(ctx context.Context, uid uint64) (UserTOTP, error) {
+	var t UserTOTP
+	err := dao.db.WithContext(ctx).Where("user_id = ?", uid).First(&t).Error
+	return t, err
+}
+
+func (dao *GormTwoFactorDAO) SavePendingTOTP(ctx context.Context, uid uint64, secret string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var t UserTOTP
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", uid).First(&t).Error
+		if err == nil && t.Enabled {
+			return ErrTOTPEnabled
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "update_time"}),
+		}).Create(&UserTOTP{
+			UserId:     uid,
+			Secret:     secret,
+			CreateTime: now,
+			UpdateTime: now,
+		}).Error
+	})
+}
+
+func (dao *GormTwoFactorDAO) EnableTOTP(ctx context.Context, uid uint64, step int64, codeHashes []string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		res := tx.Model(&UserTOTP{}).Where("user_id = ? AND enabled = ?", uid, false).Updates(map[string]any{
+			"enabled":     true,
+			"last_step":   step,
+			"update_time": now,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		// 并发确认的时候只有一个能成功，另一个当作已经开启
+		if res.RowsAffected == 0 {
+			return ErrTOTPEnabled
+		}
+
+		return replaceRecoveryCodes(tx, uid, codeHashes, now)
+	})
+}
+
+func (dao *GormTwoFactorDAO) UseStep(ctx context.Context, uid uint64, step int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&UserTOTP{}).
+		Where("user_id = ? AND last_step < ?", uid, step).
+		Updates(map[string]any{
+			"last_step":   step,
+			"update_time": time.Now().UnixMilli(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (dao *GormTwoFactorDAO) UseRecoveryCode(ctx context.Context, uid uint64, codeHash string) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_time = 0", uid, codeHash).
+		Update("used_time", time.Now().UnixMilli())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (dao *GormTwoFactorDAO) CountRecoveryCodes(ctx context.Context, uid uint64) (int64, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&UserRecoveryCode{}).
+		Where("user_id = ? AND used_time = 0", uid).Count(&count).Error
+	return count, err
+}
+
+func (dao *GormTwoFactorDAO) DeleteTOTP(ctx context.Context, uid uint64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", uid).Delete(&UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", uid).Delete(&UserTOTP{}).Error
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, uid uint64, codeHashes []string, now int64) error {
+	if err := tx.Where("user_id = ?", uid).Delete(&UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]UserRecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, UserRecoveryCode{
+			UserId:     uid,
+			CodeHash:   h,
+			CreateTime: now,
+		})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// mergeTwoFactor 目标账号没有开启两步验证时，把来源账号的转过去，否则丢掉来源账号的
+func mergeTwoFactor(tx *gorm.DB, fromId uint64, toId uint64) error {
+	var count int64
+	err := tx.Model(&UserTOTP{}).Where("user_id = ? AND enabled = ?", toId, true).Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		var from UserTOTP
+		err = tx.Where("user_id = ? AND enabled = ?", fromId, true).First(&from).Error
+		if err == nil {
+			if err = tx.Where("user_id = ?", toId).Delete(&UserRecoveryCode{}).Error; err != nil {
+				return err
+			}
+			if err = tx.Where("user_id = ?", toId).Delete(&UserTOTP{}).Error; err != nil {
+				return err
+			}
+			err = tx.Model(&UserTOTP{}).Where("user_id = ?", fromId).Update("user_id", toId).Error
+			if err != nil {
+				return err
+			}
+			return tx.Model(&UserRecoveryCode{}).Where("user_id = ?", fromId).Update("user_id", toId).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	if err = tx.Where("user_id = ?", fromId).Delete(&UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", fromId).Delete(&UserTOTP{}).Error
+}
+
+// UserTOTP 密钥要能还原出验证码，没法像密码一样只存哈希
+type UserTOTP struct {
+	UserId     uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Secret     string `gorm:"type:varchar(64)"`
+	Enabled    bool
+	LastStep   int64
+	CreateTime int64
+	UpdateTime int64
+}
+
+// UserRecoveryCode 恢复码只存 sha256，UsedTime 为 0 表示还没用过
+type UserRecoveryCode struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	UserId     uint64 `gorm:"index:idx_user_code"`
+	CodeHash   string `gorm:"type:varchar(64);index:idx_user_code"`
+	UsedTime   int64
+	CreateTime int64
+}
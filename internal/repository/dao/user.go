@@ -7,22 +7,66 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/shenxiang11/yellowbook-proto/proto"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
+	"yellowbook/internal/event"
 	"yellowbook/internal/pkg/gormutil"
 )
 
 var ErrUserDuplicate = errors.New("用户冲突")
 var ErrUserNotFound = gorm.ErrRecordNotFound
 var ErrMissingFilter = errors.New("缺少查询条件")
+var ErrLastLoginMethod = errors.New("至少保留一种登录方式")
+
+// 可以绑定和解绑的登录方式对应的列，第三方登录放在 user_identities 表里
+const (
+	IdentityPhone = "phone"
+	IdentityEmail = "email"
+)
 
 type UserDao interface {
 	FindByEmail(ctx context.Context, email string) (User, error)
-	Insert(ctx context.Context, u User) error
+	Insert(ctx context.Context, u User) (uint64, error)
+	// UpdateProfile 修改昵称、生日、简介和性别，头像只能通过 UpdateAvatar 修改
 	UpdateProfile(ctx context.Context, p UserProfile) error
 	FindProfileByUserId(ctx context.Context, userId uint64) (User, error)
 	FindByPhone(ctx context.Context, phone string) (User, error)
-	QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]User, int64, error)
-	FindByGithubId(ctx context.Context, id uint64) (User, error)
+	QueryUsers(ctx context.Context, filter UserFilter) ([]User, int64, error)
+	FindByIdentity(ctx context.Context, provider string, subject string) (User, error)
+	// InsertWithIdentity 第三方登录第一次进来时创建用户
+	InsertWithIdentity(ctx context.Context, u User, identity UserIdentity) (uint64, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	// MarkEmailVerified 邮箱已经换掉的话返回 ErrUserNotFound
+	MarkEmailVerified(ctx context.Context, id uint64, email string) error
+	// SetIdentity 绑定或者更换登录方式，column 是 Identity 开头的常量
+	// 调用方要先校验过验证码，绑定的邮箱直接标记为已验证
+	SetIdentity(ctx context.Context, id uint64, column string, value any) error
+	// ClearIdentity 解绑后没有可用的登录方式时返回 ErrLastLoginMethod
+	ClearIdentity(ctx context.Context, id uint64, column string) error
+	// SetExternalIdentity 同一个 provider 只能绑定一个，已经绑定过会替换
+	SetExternalIdentity(ctx context.Context, id uint64, provider string, subject string) error
+	ClearExternalIdentity(ctx context.Context, id uint64, provider string) error
+	// Merge 把 fromId 的登录方式、两步验证、文章和资源合并到 toId，然后删除 fromId
+	Merge(ctx context.Context, fromId uint64, toId uint64) error
+	// ScheduleDeletion 申请注销，deleteAfter 之后才会真正处理，已经注销的账号返回 ErrUserNotFound
+	ScheduleDeletion(ctx context.Context, id uint64, deleteAfter int64) error
+	// CancelDeletion 返回 false 说明没有待处理的注销申请
+	CancelDeletion(ctx context.Context, id uint64) (bool, error)
+	FindDueDeletions(ctx context.Context, now int64, limit int) ([]uint64, error)
+	// Anonymize 清空账号的登录方式和资料并删除资源，reassignTo 为 0 时下架文章，否则把文章转给它
+	// 注销申请已经取消或者还没到期返回 ErrDeletionNotDue
+	Anonymize(ctx context.Context, id uint64, reassignTo uint64) error
+	// SetBan until 为 0 表示永久封禁，已经注销的账号返回 ErrUserNotFound
+	SetBan(ctx context.Context, id uint64, until int64, reason string) error
+	ClearBan(ctx context.Context, id uint64) error
+	// UpdatePrivacy 只修改 p 里的隐私设置，还没有资料的时候会创建
+	UpdatePrivacy(ctx context.Context, p UserProfile) error
+	// UpdateAvatar 在一个事务里记录头像的各个尺寸并修改资料，只修改 p 的 Avatar 和 AvatarVariants
+	UpdateAvatar(ctx context.Context, p UserProfile, resources []Resource) error
+	// SetHandle 上次修改在 changedBefore 之后返回 ErrHandleCooldown，旧的用户名保留到 redirectUntil
+	SetHandle(ctx context.Context, id uint64, handle string, changedBefore int64, redirectUntil int64) error
+	// FindIdByHandle 找不到当前的用户名时再找还没过期的旧用户名
+	FindIdByHandle(ctx context.Context, handle string) (uint64, error)
 }
 
 type GormUserDAO struct {
@@ -40,50 +84,115 @@ func (dao *GormUserDAO) FindByEmail(ctx context.Context, email string) (User, er
 	return u, err
 }
 
-func (dao *GormUserDAO) FindByGithubId(ctx context.Context, id uint64) (User, error) {
-	var u User
-	err := dao.db.WithContext(ctx).Where("github_id = ?", id).First(&u).Error
+func (dao *GormUserDAO) Insert(ctx context.Context, u User) (uint64, error) {
+	method := event.RegisterByEmail
+	if u.Phone.Valid {
+		method = event.RegisterByPhone
+	}
+	return dao.insert(ctx, u, nil, method)
+}
 
-	return u, err
+func (dao *GormUserDAO) InsertWithIdentity(ctx context.Context, u User, identity UserIdentity) (uint64, error) {
+	return dao.insert(ctx, u, &identity, identity.Provider)
 }
 
-func (dao *GormUserDAO) Insert(ctx context.Context, u User) error {
+func (dao *GormUserDAO) insert(ctx context.Context, u User, identity *UserIdentity, method string) (uint64, error) {
 	now := time.Now().UnixMilli()
 	u.CreateTime = now
 	u.UpdateTime = now
 
-	err := dao.db.WithContext(ctx).Create(&u).Error
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
 
-	var mysqlErr *mysql.MySQLError
-	if errors.As(err, &mysqlErr) {
-		const uniqueConflictsErrNo uint16 = 1062
-		if mysqlErr.Number == uniqueConflictsErrNo {
-			return ErrUserDuplicate
+		if identity != nil {
+			identity.UserId = u.Id
+			identity.CreateTime = now
+			identity.UpdateTime = now
+			if err := tx.Create(identity).Error; err != nil {
+				return err
+			}
 		}
+
+		return appendOutbox(tx, event.UserRegistered{UserId: u.Id, Method: method})
+	})
+
+	if isDuplicate(err) {
+		return 0, ErrUserDuplicate
 	}
 
-	return err
+	return u.Id, err
 }
 
 func (dao *GormUserDAO) UpdateProfile(ctx context.Context, p UserProfile) error {
-	var profile UserProfile
-	err := dao.db.WithContext(ctx).FirstOrCreate(&profile, UserProfile{UserId: p.UserId}).Error
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		var profile UserProfile
+		err := tx.Attrs(UserProfile{CreateTime: now}).
+			FirstOrCreate(&profile, UserProfile{UserId: p.UserId}).Error
+		if err != nil {
+			return err
+		}
 
-	profile.Nickname = p.Nickname
-	profile.Birthday = p.Birthday
-	profile.Introduction = p.Introduction
-	profile.Avatar = p.Avatar
-	profile.Gender = p.Gender
+		// 只写这几列，整行 Save 会把并发上传的头像覆盖回去
+		err = tx.Model(&UserProfile{}).Where("user_id = ?", p.UserId).
+			Updates(map[string]any{
+				"nickname":     p.Nickname,
+				"birthday":     p.Birthday,
+				"introduction": p.Introduction,
+				"gender":       p.Gender,
+				"update_time":  now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return appendProfileEdited(tx, p.UserId)
+	})
+}
 
-	now := time.Now().UnixMilli()
-	if profile.CreateTime == 0 {
-		profile.CreateTime = now
+// appendProfileEdited 在改完资料的事务里重新读一遍，事件里带上最新的完整资料
+func appendProfileEdited(tx *gorm.DB, userId uint64) error {
+	var profile UserProfile
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userId).First(&profile).Error
+	if err != nil {
+		return err
+	}
+
+	var birthday string
+	if profile.Birthday != 0 {
+		birthday = time.UnixMilli(profile.Birthday).UTC().Format("2006-01-02")
 	}
-	profile.UpdateTime = now
 
-	dao.db.Where("user_id = ?", p.UserId).Save(&profile)
+	return appendOutbox(tx, event.UserProfileEdited{
+		UserId:       profile.UserId,
+		Nickname:     profile.Nickname,
+		Birthday:     birthday,
+		Introduction: profile.Introduction,
+		Avatar:       profile.Avatar,
+		Gender:       int32(profile.Gender),
+	})
+}
+
+func (dao *GormUserDAO) UpdatePrivacy(ctx context.Context, p UserProfile) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		var profile UserProfile
+		err := tx.Attrs(UserProfile{CreateTime: now}).
+			FirstOrCreate(&profile, UserProfile{UserId: p.UserId}).Error
+		if err != nil {
+			return err
+		}
 
-	return err
+		return tx.Model(&UserProfile{}).Where("user_id = ?", p.UserId).
+			Updates(map[string]any{
+				"hide_birthday": p.HideBirthday,
+				"hide_gender":   p.HideGender,
+				"private":       p.Private,
+				"update_time":   now,
+			}).Error
+	})
 }
 
 func (dao *GormUserDAO) FindProfileByUserId(ctx context.Context, userId uint64) (User, error) {
@@ -108,8 +217,184 @@ func (dao *GormUserDAO) FindByPhone(ctx context.Context, phone string) (User, er
 	return user, nil
 }
 
-func (dao *GormUserDAO) QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]User, int64, error) {
-	if filter == nil {
+func (dao *GormUserDAO) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	res := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"password":    password,
+		"update_time": time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (dao *GormUserDAO) MarkEmailVerified(ctx context.Context, id uint64, email string) error {
+	res := dao.db.WithContext(ctx).Model(&User{}).Where("id = ? AND email = ?", id, email).Updates(map[string]any{
+		"email_verified": true,
+		"update_time":    time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (dao *GormUserDAO) SetIdentity(ctx context.Context, id uint64, column string, value any) error {
+	updates := map[string]any{
+		column:        value,
+		"update_time": time.Now().UnixMilli(),
+	}
+	if column == IdentityEmail {
+		updates["email_verified"] = true
+	}
+	res := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(updates)
+	if isDuplicate(res.Error) {
+		return ErrUserDuplicate
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (dao *GormUserDAO) ClearIdentity(ctx context.Context, id uint64, column string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{
+			column:        nil,
+			"update_time": time.Now().UnixMilli(),
+		}
+		if column == IdentityEmail {
+			updates["email_verified"] = false
+		}
+		err := tx.Model(&User{}).Where("id = ?", id).Updates(updates).Error
+		if err != nil {
+			return err
+		}
+
+		return ensureLoginMethod(tx, id)
+	})
+}
+
+// ensureLoginMethod 加锁读，并发解绑两种方式时后一个能看到前一个的结果
+func ensureLoginMethod(tx *gorm.DB, id uint64) error {
+	var u User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&u).Error
+	if err != nil {
+		return err
+	}
+
+	var identities int64
+	err = tx.Model(&UserIdentity{}).Where("user_id = ?", id).Count(&identities).Error
+	if err != nil {
+		return err
+	}
+
+	// 邮箱需要配合密码才能登录
+	if u.Phone.Valid || identities > 0 || (u.Email.Valid && u.Password != "") {
+		return nil
+	}
+	return ErrLastLoginMethod
+}
+
+func (dao *GormUserDAO) Merge(ctx context.Context, fromId uint64, toId uint64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 按 id 顺序加锁，避免和反方向的合并死锁
+		var users []User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Profile").
+			Where("id IN ?", []uint64{fromId, toId}).Order("id").Find(&users).Error
+		if err != nil {
+			return err
+		}
+		if len(users) != 2 {
+			return ErrUserNotFound
+		}
+		from, to := users[0], users[1]
+		if from.Id != fromId {
+			from, to = to, from
+		}
+
+		// 目标账号已有的登录方式保留，缺少的用来源账号的补上
+		updates := map[string]any{"update_time": time.Now().UnixMilli()}
+		if !to.Phone.Valid && from.Phone.Valid {
+			updates[IdentityPhone] = from.Phone
+		}
+		if !to.Email.Valid && from.Email.Valid {
+			updates[IdentityEmail] = from.Email
+			updates["email_verified"] = from.EmailVerified
+		}
+		if to.Password == "" && from.Password != "" {
+			updates["password"] = from.Password
+		}
+		if !to.Handle.Valid && from.Handle.Valid {
+			updates["handle"] = from.Handle
+			updates["handle_update_time"] = from.HandleUpdateTime
+		}
+
+		if err = mergeIdentities(tx, fromId, toId); err != nil {
+			return err
+		}
+		if err = mergeTwoFactor(tx, fromId, toId); err != nil {
+			return err
+		}
+
+		// 资料表有指向 users 的外键，要在删除来源账号之前处理
+		if to.Profile == nil && from.Profile != nil {
+			err = tx.Model(&UserProfile{}).Where("user_id = ?", fromId).Update("user_id", toId).Error
+		} else {
+			err = tx.Where("user_id = ?", fromId).Delete(&UserProfile{}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		// 先删掉来源账号，唯一索引上的值才能转移
+		if err = tx.Delete(&User{}, fromId).Error; err != nil {
+			return err
+		}
+		if err = tx.Model(&User{}).Where("id = ?", toId).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&Article{}).Where("author_id = ?", fromId).Update("author_id", toId).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Resource{}).Where("upload_user_id = ?", fromId).Update("upload_user_id", toId).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&UserHandleHistory{}).Where("user_id = ?", fromId).Update("user_id", toId).Error
+		if err != nil {
+			return err
+		}
+
+		return appendOutbox(tx, event.UserMerged{FromId: fromId, ToId: toId})
+	})
+}
+
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	const uniqueConflictsErrNo uint16 = 1062
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == uniqueConflictsErrNo
+}
+
+// UserFilter proto 里没有的查询条件放在外面
+type UserFilter struct {
+	*proto.GetUserListRequest
+	Handle string
+}
+
+func (dao *GormUserDAO) QueryUsers(ctx context.Context, filter UserFilter) ([]User, int64, error) {
+	if filter.GetUserListRequest == nil {
 		return nil, 0, ErrMissingFilter
 	}
 
@@ -131,6 +416,9 @@ func (dao *GormUserDAO) QueryUsers(ctx context.Context, filter *proto.GetUserLis
 	if filter.Email != "" {
 		query = query.Where("email LIKE ?", "%"+filter.Email+"%")
 	}
+	if filter.Handle != "" {
+		query = query.Where("handle LIKE ?", "%"+filter.Handle+"%")
+	}
 	if filter.Nickname != "" {
 		query = query.Where("Profile.nickname like ?", "%"+filter.Nickname+"%")
 	}
@@ -156,9 +444,21 @@ func (dao *GormUserDAO) QueryUsers(ctx context.Context, filter *proto.GetUserLis
 type User struct {
 	Id            uint64         `gorm:"primaryKey,autoIncrement"`
 	Email         sql.NullString `gorm:"unique"`
+	EmailVerified bool
 	Phone         sql.NullString `gorm:"unique"`
-	Password      string
-	GithubId      sql.NullInt64 `gorm:"unique"`
+	// Handle 用户自己设置的唯一用户名，全部小写，没有设置时是 NULL
+	Handle sql.NullString `gorm:"type:varchar(32);unique"`
+	// HandleUpdateTime 上次修改用户名的时间，用来限制修改频率
+	HandleUpdateTime int64
+	Password         string
+	// DeleteAfter 注销冷静期结束的时间，0 表示没有申请注销
+	DeleteAfter int64 `gorm:"index"`
+	// DeletedTime 匿名化完成的时间，非 0 表示账号已经注销
+	DeletedTime int64
+	Banned      bool
+	// BanUntil 封禁结束的时间，0 表示永久
+	BanUntil      int64
+	BanReason     string `gorm:"type:varchar(255)"`
 	CreateTime    int64
 	UpdateTime    int64
 	Profile       *UserProfile
@@ -166,12 +466,18 @@ type User struct {
 }
 
 type UserProfile struct {
-	UserId       uint64 `gorm:"unique"`
-	Nickname     string
-	Birthday     int64
-	Avatar       string
-	Gender       proto.Gender
-	Introduction string
+	UserId   uint64 `gorm:"unique"`
+	Nickname string
+	Birthday int64
+	Avatar   string
+	// AvatarVariants 上传头像生成的各个尺寸，JSON 格式，换成别的头像地址时清空
+	AvatarVariants string `gorm:"type:text"`
+	Gender         proto.Gender
+	Introduction   string
+	// 公开主页的隐私设置，UpdateProfile 不会修改
+	HideBirthday bool
+	HideGender   bool
+	Private      bool
 	CreateTime   int64
 	UpdateTime   int64
 }
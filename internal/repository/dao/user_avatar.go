@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+func (dao *GormUserDAO) UpdateAvatar(ctx context.Context, p UserProfile, resources []Resource) error {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, r := range resources {
+			if err := insertResource(tx, r); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UnixMilli()
+		var profile UserProfile
+		err := tx.Attrs(UserProfile{CreateTime: now}).
+			FirstOrCreate(&profile, UserProfile{UserId: p.UserId}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&UserProfile{}).Where("user_id = ?", p.UserId).
+			Updates(map[string]any{
+				"avatar":          p.Avatar,
+				"avatar_variants": p.AvatarVariants,
+				"update_time":     now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return appendProfileEdited(tx, p.UserId)
+	})
+
+	return resourceErr(err)
+}
@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"context"
+	"time"
+)
+
+func (dao *GormUserDAO) SetBan(ctx context.Context, id uint64, until int64, reason string) error {
+	return dao.updateBan(ctx, id, map[string]any{
+		"banned":      true,
+		"ban_until":   until,
+		"ban_reason":  reason,
+		"update_time": time.Now().UnixMilli(),
+	})
+}
+
+func (dao *GormUserDAO) ClearBan(ctx context.Context, id uint64) error {
+	return dao.updateBan(ctx, id, map[string]any{
+		"banned":      false,
+		"ban_until":   0,
+		"ban_reason":  "",
+		"update_time": time.Now().UnixMilli(),
+	})
+}
+
+// updateBan 已经注销的账号不用再封禁
+func (dao *GormUserDAO) updateBan(ctx context.Context, id uint64, updates map[string]any) error {
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND deleted_time = ?", id, 0).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
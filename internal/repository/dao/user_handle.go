@@ -0,0 +1,105 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	ErrHandleDuplicate = errors.New("用户名已被占用")
+	ErrHandleCooldown  = errors.New("修改用户名太频繁")
+)
+
+// UserHandleHistory 改名以后旧的用户名在 ExpireTime 之前还会跳转到这个账号，别人也不能占用
+type UserHandleHistory struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	Handle     string `gorm:"type:varchar(32);index"`
+	UserId     uint64 `gorm:"index"`
+	ExpireTime int64
+	CreateTime int64
+}
+
+func (dao *GormUserDAO) SetHandle(ctx context.Context, id uint64, handle string, changedBefore int64, redirectUntil int64) error {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var u User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_time = ?", id, 0).First(&u).Error
+		if err != nil {
+			return err
+		}
+		if u.Handle.Valid && u.Handle.String == handle {
+			return nil
+		}
+		if u.Handle.Valid && u.HandleUpdateTime > changedBefore {
+			return ErrHandleCooldown
+		}
+
+		// 锁住这个用户名的历史记录，包括还不存在的间隙，并发改名插入同名的历史记录要等这个事务结束
+		now := time.Now().UnixMilli()
+		var histories []UserHandleHistory
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("handle = ?", handle).Find(&histories).Error
+		if err != nil {
+			return err
+		}
+		for _, h := range histories {
+			if h.UserId != id && h.ExpireTime > now {
+				return ErrHandleDuplicate
+			}
+		}
+
+		// 改回自己以前的用户名，不再需要跳转
+		if err = tx.Where("user_id = ? AND handle = ?", id, handle).Delete(&UserHandleHistory{}).Error; err != nil {
+			return err
+		}
+		// 先保留旧的用户名再放开，别人不会在中间抢到
+		if u.Handle.Valid {
+			err = tx.Create(&UserHandleHistory{
+				Handle:     u.Handle.String,
+				UserId:     id,
+				ExpireTime: redirectUntil,
+				CreateTime: now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+			"handle":             handle,
+			"handle_update_time": now,
+			"update_time":        now,
+		}).Error
+	})
+
+	if isDuplicate(err) {
+		return ErrHandleDuplicate
+	}
+	return err
+}
+
+func (dao *GormUserDAO) FindIdByHandle(ctx context.Context, handle string) (uint64, error) {
+	var u User
+	err := dao.db.WithContext(ctx).
+		Where("handle = ? AND deleted_time = ?", handle, 0).
+		First(&u).Error
+	if err == nil {
+		return u.Id, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	var h UserHandleHistory
+	err = dao.db.WithContext(ctx).
+		Where("handle = ? AND expire_time > ?", handle, time.Now().UnixMilli()).
+		Order("id DESC").
+		First(&h).Error
+	if err != nil {
+		return 0, err
+	}
+	return h.UserId, nil
+}
@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// UserIdentity 第三方登录的身份，每个 provider 一行，不再给每个 provider 加一列
+type UserIdentity struct {
+	Id         uint64 `gorm:"primaryKey,autoIncrement"`
+	UserId     uint64 `gorm:"uniqueIndex:uk_user_provider"`
+	Provider   string `gorm:"type:varchar(32);uniqueIndex:uk_user_provider;uniqueIndex:uk_provider_subject"`
+	Subject    string `gorm:"type:varchar(255);uniqueIndex:uk_provider_subject"`
+	CreateTime int64
+	UpdateTime int64
+}
+
+func (dao *GormUserDAO) FindByIdentity(ctx context.Context, provider string, subject string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).
+		Joins("JOIN user_identities ON user_identities.user_id = users.id").
+		Where("user_identities.provider = ? AND user_identities.subject = ?", provider, subject).
+		First(&u).Error
+
+	return u, err
+}
+
+func (dao *GormUserDAO) SetExternalIdentity(ctx context.Context, id uint64, provider string, subject string) error {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var u User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&u).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ? AND provider = ?", id, provider).Delete(&UserIdentity{}).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		err = tx.Create(&UserIdentity{
+			UserId:     id,
+			Provider:   provider,
+			Subject:    subject,
+			CreateTime: now,
+			UpdateTime: now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&User{}).Where("id = ?", id).Update("update_time", now).Error
+	})
+	if isDuplicate(err) {
+		return ErrUserDuplicate
+	}
+	return err
+}
+
+func (dao *GormUserDAO) ClearExternalIdentity(ctx context.Context, id uint64, provider string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先锁住用户，和解绑手机号、邮箱的请求串行
+		var u User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&u).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ? AND provider = ?", id, provider).Delete(&UserIdentity{}).Error
+		if err != nil {
+			return err
+		}
+
+		return ensureLoginMethod(tx, id)
+	})
+}
+
+// mergeIdentities 目标账号没有的 provider 转过去，两边都有的保留目标账号的
+func mergeIdentities(tx *gorm.DB, fromId uint64, toId uint64) error {
+	var providers []string
+	err := tx.Model(&UserIdentity{}).Where("user_id = ?", toId).Pluck("provider", &providers).Error
+	if err != nil {
+		return err
+	}
+
+	query := tx.Model(&UserIdentity{}).Where("user_id = ?", fromId)
+	if len(providers) > 0 {
+		query = query.Where("provider NOT IN ?", providers)
+	}
+	err = query.Updates(map[string]any{
+		"user_id":     toId,
+		"update_time": time.Now().UnixMilli(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Where("user_id = ?", fromId).Delete(&UserIdentity{}).Error
+}
+
+// migrateGithubId 把旧的 users.github_id 列搬到 user_identities 里，然后删掉这一列
+// DDL 会隐式提交，没法放进事务，INSERT IGNORE 保证中途失败后重跑不会重复
+func migrateGithubId(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&User{}, "github_id") {
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	err := db.Exec(`INSERT IGNORE INTO user_identities (user_id, provider, subject, create_time, update_time)
+SELECT id, 'github', CAST(github_id AS CHAR), ?, ? FROM users WHERE github_id IS NOT NULL`, now, now).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Migrator().DropColumn(&User{}, "github_id")
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"github.com/shenxiang11/zippo/slice"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository/dao"
+)
+
+type IImageRehostRepository interface {
+	CreateBatch(ctx context.Context, tasks []domain.ImageRehostTask) error
+	FindDue(ctx context.Context, now time.Time, limit int) ([]domain.ImageRehostTask, error)
+	Claim(ctx context.Context, task domain.ImageRehostTask, leaseUntil time.Time) (bool, error)
+	Update(ctx context.Context, task domain.ImageRehostTask) error
+	FindByArticleId(ctx context.Context, articleId uint64) ([]domain.ImageRehostTask, error)
+}
+
+type ImageRehostRepository struct {
+	dao dao.IImageRehostDAO
+}
+
+func NewImageRehostRepository(dao dao.IImageRehostDAO) IImageRehostRepository {
+	return &ImageRehostRepository{dao: dao}
+}
+
+func (r *ImageRehostRepository) CreateBatch(ctx context.Context, tasks []domain.ImageRehostTask) error {
+	return r.dao.InsertBatch(ctx, slice.Map[domain.ImageRehostTask, dao.ImageRehostTask](tasks, func(el domain.ImageRehostTask, index int) dao.ImageRehostTask {
+		return r.domainToEntity(el)
+	}))
+}
+
+func (r *ImageRehostRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.ImageRehostTask, error) {
+	tasks, err := r.dao.FindDue(ctx, uint8(domain.ImageRehostPending), now.UnixMilli(), limit)
+	if err != nil {
+		return []domain.ImageRehostTask{}, err
+	}
+
+	return slice.Map[dao.ImageRehostTask, domain.ImageRehostTask](tasks, func(el dao.ImageRehostTask, index int) domain.ImageRehostTask {
+		return r.entityToDomain(el)
+	}), nil
+}
+
+func (r *ImageRehostRepository) Claim(ctx context.Context, task domain.ImageRehostTask, leaseUntil time.Time) (bool, error) {
+	return r.dao.Claim(ctx, task.Id, task.NextRetryTime.UnixMilli(), leaseUntil.UnixMilli())
+}
+
+func (r *ImageRehostRepository) Update(ctx context.Context, task domain.ImageRehostTask) error {
+	return r.dao.Update(ctx, r.domainToEntity(task))
+}
+
+func (r *ImageRehostRepository) FindByArticleId(ctx context.Context, articleId uint64) ([]domain.ImageRehostTask, error) {
+	tasks, err := r.dao.FindByArticleId(ctx, articleId)
+	if err != nil {
+		return []domain.ImageRehostTask{}, err
+	}
+
+	return slice.Map[dao.ImageRehostTask, domain.ImageRehostTask](tasks, func(el dao.ImageRehostTask, index int) domain.ImageRehostTask {
+		return r.entityToDomain(el)
+	}), nil
+}
+
+func (r *ImageRehostRepository) domainToEntity(t domain.ImageRehostTask) dao.ImageRehostTask {
+	return dao.ImageRehostTask{
+		Id:            t.Id,
+		ArticleId:     t.ArticleId,
+		SourceUrl:     t.SourceUrl,
+		TargetUrl:     t.TargetUrl,
+		Status:        uint8(t.Status),
+		Retry:         t.Retry,
+		LastError:     t.LastError,
+		NextRetryTime: t.NextRetryTime.UnixMilli(),
+	}
+}
+
+func (r *ImageRehostRepository) entityToDomain(t dao.ImageRehostTask) domain.ImageRehostTask {
+	return domain.ImageRehostTask{
+		Id:            t.Id,
+		ArticleId:     t.ArticleId,
+		SourceUrl:     t.SourceUrl,
+		TargetUrl:     t.TargetUrl,
+		Status:        domain.ImageRehostStatus(t.Status),
+		Retry:         t.Retry,
+		LastError:     t.LastError,
+		NextRetryTime: time.UnixMilli(t.NextRetryTime).UTC(),
+		CreateTime:    time.UnixMilli(t.CreateTime).UTC(),
+		UpdateTime:    time.UnixMilli(t.UpdateTime).UTC(),
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository/cache"
+)
+
+type LoginTarget = cache.LoginTarget
+type LoginLimit = cache.LoginLimit
+
+type ILoginLimitRepository interface {
+	Check(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) (time.Duration, bool, error)
+	RecordFailure(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) ([]int, []bool, error)
+	Clear(ctx context.Context, kind string, value string) error
+	AddLockout(ctx context.Context, l domain.LoginLockout) error
+	Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error)
+}
+
+type CachedLoginLimitRepository struct {
+	cache cache.LoginLimitCache
+}
+
+func NewLoginLimitRepository(c cache.LoginLimitCache) ILoginLimitRepository {
+	return &CachedLoginLimitRepository{cache: c}
+}
+
+func (repo *CachedLoginLimitRepository) Check(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) (time.Duration, bool, error) {
+	return repo.cache.Check(ctx, targets, limit, now)
+}
+
+func (repo *CachedLoginLimitRepository) RecordFailure(ctx context.Context, targets []LoginTarget, limit LoginLimit, now time.Time) ([]int, []bool, error) {
+	return repo.cache.RecordFailure(ctx, targets, limit, now)
+}
+
+func (repo *CachedLoginLimitRepository) Clear(ctx context.Context, kind string, value string) error {
+	return repo.cache.Clear(ctx, kind, value)
+}
+
+func (repo *CachedLoginLimitRepository) AddLockout(ctx context.Context, l domain.LoginLockout) error {
+	return repo.cache.AddLockout(ctx, l)
+}
+
+func (repo *CachedLoginLimitRepository) Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error) {
+	return repo.cache.Lockouts(ctx, limit)
+}
@@ -0,0 +1,183 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/admin.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIAdminRepository is a mock of IAdminRepository interface.
+type MockIAdminRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAdminRepositoryMockRecorder
+}
+
+// MockIAdminRepositoryMockRecorder is the mock recorder for MockIAdminRepository.
+type MockIAdminRepositoryMockRecorder struct {
+	mock *MockIAdminRepository
+}
+
+// NewMockIAdminRepository creates a new mock instance.
+func NewMockIAdminRepository(ctrl *gomock.Controller) *MockIAdminRepository {
+	mock := &MockIAdminRepository{ctrl: ctrl}
+	mock.recorder = &MockIAdminRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAdminRepository) EXPECT() *MockIAdminRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockIAdminRepository) Count(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockIAdminRepositoryMockRecorder) Count(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockIAdminRepository)(nil).Count), ctx)
+}
+
+// Create mocks base method.
+func (m *MockIAdminRepository) Create(ctx context.Context, a domain.Admin, roleIds []uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, a, roleIds)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIAdminRepositoryMockRecorder) Create(ctx, a, roleIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIAdminRepository)(nil).Create), ctx, a, roleIds)
+}
+
+// DeleteRole mocks base method.
+func (m *MockIAdminRepository) DeleteRole(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockIAdminRepositoryMockRecorder) DeleteRole(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockIAdminRepository)(nil).DeleteRole), ctx, id)
+}
+
+// FindById mocks base method.
+func (m *MockIAdminRepository) FindById(ctx context.Context, id uint64) (domain.Admin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindById", ctx, id)
+	ret0, _ := ret[0].(domain.Admin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindById indicates an expected call of FindById.
+func (mr *MockIAdminRepositoryMockRecorder) FindById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockIAdminRepository)(nil).FindById), ctx, id)
+}
+
+// FindByUsername mocks base method.
+func (m *MockIAdminRepository) FindByUsername(ctx context.Context, username string) (domain.Admin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUsername", ctx, username)
+	ret0, _ := ret[0].(domain.Admin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUsername indicates an expected call of FindByUsername.
+func (mr *MockIAdminRepositoryMockRecorder) FindByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUsername", reflect.TypeOf((*MockIAdminRepository)(nil).FindByUsername), ctx, username)
+}
+
+// List mocks base method.
+func (m *MockIAdminRepository) List(ctx context.Context) ([]domain.Admin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.Admin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIAdminRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIAdminRepository)(nil).List), ctx)
+}
+
+// ListRoles mocks base method.
+func (m *MockIAdminRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", ctx)
+	ret0, _ := ret[0].([]domain.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockIAdminRepositoryMockRecorder) ListRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockIAdminRepository)(nil).ListRoles), ctx)
+}
+
+// SaveRole mocks base method.
+func (m *MockIAdminRepository) SaveRole(ctx context.Context, r domain.Role) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRole", ctx, r)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveRole indicates an expected call of SaveRole.
+func (mr *MockIAdminRepositoryMockRecorder) SaveRole(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRole", reflect.TypeOf((*MockIAdminRepository)(nil).SaveRole), ctx, r)
+}
+
+// SetDisabled mocks base method.
+func (m *MockIAdminRepository) SetDisabled(ctx context.Context, id uint64, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, id, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockIAdminRepositoryMockRecorder) SetDisabled(ctx, id, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockIAdminRepository)(nil).SetDisabled), ctx, id, disabled)
+}
+
+// SetRoles mocks base method.
+func (m *MockIAdminRepository) SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRoles", ctx, adminId, roleIds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRoles indicates an expected call of SetRoles.
+func (mr *MockIAdminRepositoryMockRecorder) SetRoles(ctx, adminId, roleIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRoles", reflect.TypeOf((*MockIAdminRepository)(nil).SetRoles), ctx, adminId, roleIds)
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/admin_session.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIAdminSessionRepository is a mock of IAdminSessionRepository interface.
+type MockIAdminSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAdminSessionRepositoryMockRecorder
+}
+
+// MockIAdminSessionRepositoryMockRecorder is the mock recorder for MockIAdminSessionRepository.
+type MockIAdminSessionRepositoryMockRecorder struct {
+	mock *MockIAdminSessionRepository
+}
+
+// NewMockIAdminSessionRepository creates a new mock instance.
+func NewMockIAdminSessionRepository(ctrl *gomock.Controller) *MockIAdminSessionRepository {
+	mock := &MockIAdminSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockIAdminSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAdminSessionRepository) EXPECT() *MockIAdminSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIAdminSessionRepository) Create(ctx context.Context, id string, adminId uint64, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, id, adminId, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIAdminSessionRepositoryMockRecorder) Create(ctx, id, adminId, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIAdminSessionRepository)(nil).Create), ctx, id, adminId, ttl)
+}
+
+// Delete mocks base method.
+func (m *MockIAdminSessionRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIAdminSessionRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIAdminSessionRepository)(nil).Delete), ctx, id)
+}
+
+// FindAdminId mocks base method.
+func (m *MockIAdminSessionRepository) FindAdminId(ctx context.Context, id string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAdminId", ctx, id)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAdminId indicates an expected call of FindAdminId.
+func (mr *MockIAdminSessionRepositoryMockRecorder) FindAdminId(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAdminId", reflect.TypeOf((*MockIAdminSessionRepository)(nil).FindAdminId), ctx, id)
+}
@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/article.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIArticleRepository is a mock of IArticleRepository interface.
+type MockIArticleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIArticleRepositoryMockRecorder
+}
+
+// MockIArticleRepositoryMockRecorder is the mock recorder for MockIArticleRepository.
+type MockIArticleRepositoryMockRecorder struct {
+	mock *MockIArticleRepository
+}
+
+// NewMockIArticleRepository creates a new mock instance.
+func NewMockIArticleRepository(ctrl *gomock.Controller) *MockIArticleRepository {
+	mock := &MockIArticleRepository{ctrl: ctrl}
+	mock.recorder = &MockIArticleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIArticleRepository) EXPECT() *MockIArticleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIArticleRepository) Create(ctx context.Context, domain domain.Article) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, domain)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIArticleRepositoryMockRecorder) Create(ctx, domain interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIArticleRepository)(nil).Create), ctx, domain)
+}
+
+// FindById mocks base method.
+func (m *MockIArticleRepository) FindById(ctx context.Context, id uint64) (domain.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindById", ctx, id)
+	ret0, _ := ret[0].(domain.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindById indicates an expected call of FindById.
+func (mr *MockIArticleRepositoryMockRecorder) FindById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockIArticleRepository)(nil).FindById), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockIArticleRepository) List(ctx context.Context) ([]domain.Article, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.Article)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockIArticleRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIArticleRepository)(nil).List), ctx)
+}
+
+// ListPublishedByAuthor mocks base method.
+func (m *MockIArticleRepository) ListPublishedByAuthor(ctx context.Context, authorId uint64, limit int) ([]domain.Article, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPublishedByAuthor", ctx, authorId, limit)
+	ret0, _ := ret[0].([]domain.Article)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListPublishedByAuthor indicates an expected call of ListPublishedByAuthor.
+func (mr *MockIArticleRepositoryMockRecorder) ListPublishedByAuthor(ctx, authorId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublishedByAuthor", reflect.TypeOf((*MockIArticleRepository)(nil).ListPublishedByAuthor), ctx, authorId, limit)
+}
+
+// Update mocks base method.
+func (m *MockIArticleRepository) Update(ctx context.Context, domain domain.Article) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, domain)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIArticleRepositoryMockRecorder) Update(ctx, domain interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIArticleRepository)(nil).Update), ctx, domain)
+}
+
+// UpdateImageList mocks base method.
+func (m *MockIArticleRepository) UpdateImageList(ctx context.Context, id uint64, imageList []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateImageList", ctx, id, imageList)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateImageList indicates an expected call of UpdateImageList.
+func (mr *MockIArticleRepositoryMockRecorder) UpdateImageList(ctx, id, imageList interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateImageList", reflect.TypeOf((*MockIArticleRepository)(nil).UpdateImageList), ctx, id, imageList)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockIArticleRepository) UpdateStatus(ctx context.Context, id uint64, status uint8) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockIArticleRepositoryMockRecorder) UpdateStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockIArticleRepository)(nil).UpdateStatus), ctx, id, status)
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/image_rehost.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIImageRehostRepository is a mock of IImageRehostRepository interface.
+type MockIImageRehostRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIImageRehostRepositoryMockRecorder
+}
+
+// MockIImageRehostRepositoryMockRecorder is the mock recorder for MockIImageRehostRepository.
+type MockIImageRehostRepositoryMockRecorder struct {
+	mock *MockIImageRehostRepository
+}
+
+// NewMockIImageRehostRepository creates a new mock instance.
+func NewMockIImageRehostRepository(ctrl *gomock.Controller) *MockIImageRehostRepository {
+	mock := &MockIImageRehostRepository{ctrl: ctrl}
+	mock.recorder = &MockIImageRehostRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIImageRehostRepository) EXPECT() *MockIImageRehostRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockIImageRehostRepository) Claim(ctx context.Context, task domain.ImageRehostTask, leaseUntil time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, task, leaseUntil)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIImageRehostRepositoryMockRecorder) Claim(ctx, task, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIImageRehostRepository)(nil).Claim), ctx, task, leaseUntil)
+}
+
+// CreateBatch mocks base method.
+func (m *MockIImageRehostRepository) CreateBatch(ctx context.Context, tasks []domain.ImageRehostTask) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, tasks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockIImageRehostRepositoryMockRecorder) CreateBatch(ctx, tasks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockIImageRehostRepository)(nil).CreateBatch), ctx, tasks)
+}
+
+// FindByArticleId mocks base method.
+func (m *MockIImageRehostRepository) FindByArticleId(ctx context.Context, articleId uint64) ([]domain.ImageRehostTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByArticleId", ctx, articleId)
+	ret0, _ := ret[0].([]domain.ImageRehostTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByArticleId indicates an expected call of FindByArticleId.
+func (mr *MockIImageRehostRepositoryMockRecorder) FindByArticleId(ctx, articleId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByArticleId", reflect.TypeOf((*MockIImageRehostRepository)(nil).FindByArticleId), ctx, articleId)
+}
+
+// FindDue mocks base method.
+func (m *MockIImageRehostRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.ImageRehostTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDue", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.ImageRehostTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDue indicates an expected call of FindDue.
+func (mr *MockIImageRehostRepositoryMockRecorder) FindDue(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDue", reflect.TypeOf((*MockIImageRehostRepository)(nil).FindDue), ctx, now, limit)
+}
+
+// Update mocks base method.
+func (m *MockIImageRehostRepository) Update(ctx context.Context, task domain.ImageRehostTask) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, task)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIImageRehostRepositoryMockRecorder) Update(ctx, task interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIImageRehostRepository)(nil).Update), ctx, task)
+}
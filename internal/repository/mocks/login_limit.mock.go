@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/login_limit.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "yellowbook/internal/domain"
+	repository "yellowbook/internal/repository"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILoginLimitRepository is a mock of ILoginLimitRepository interface.
+type MockILoginLimitRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockILoginLimitRepositoryMockRecorder
+}
+
+// MockILoginLimitRepositoryMockRecorder is the mock recorder for MockILoginLimitRepository.
+type MockILoginLimitRepositoryMockRecorder struct {
+	mock *MockILoginLimitRepository
+}
+
+// NewMockILoginLimitRepository creates a new mock instance.
+func NewMockILoginLimitRepository(ctrl *gomock.Controller) *MockILoginLimitRepository {
+	mock := &MockILoginLimitRepository{ctrl: ctrl}
+	mock.recorder = &MockILoginLimitRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILoginLimitRepository) EXPECT() *MockILoginLimitRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddLockout mocks base method.
+func (m *MockILoginLimitRepository) AddLockout(ctx context.Context, l domain.LoginLockout) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLockout", ctx, l)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddLockout indicates an expected call of AddLockout.
+func (mr *MockILoginLimitRepositoryMockRecorder) AddLockout(ctx, l interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLockout", reflect.TypeOf((*MockILoginLimitRepository)(nil).AddLockout), ctx, l)
+}
+
+// Check mocks base method.
+func (m *MockILoginLimitRepository) Check(ctx context.Context, targets []repository.LoginTarget, limit repository.LoginLimit, now time.Time) (time.Duration, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, targets, limit, now)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockILoginLimitRepositoryMockRecorder) Check(ctx, targets, limit, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockILoginLimitRepository)(nil).Check), ctx, targets, limit, now)
+}
+
+// Clear mocks base method.
+func (m *MockILoginLimitRepository) Clear(ctx context.Context, kind, value string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, kind, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockILoginLimitRepositoryMockRecorder) Clear(ctx, kind, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockILoginLimitRepository)(nil).Clear), ctx, kind, value)
+}
+
+// Lockouts mocks base method.
+func (m *MockILoginLimitRepository) Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lockouts", ctx, limit)
+	ret0, _ := ret[0].([]domain.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lockouts indicates an expected call of Lockouts.
+func (mr *MockILoginLimitRepositoryMockRecorder) Lockouts(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lockouts", reflect.TypeOf((*MockILoginLimitRepository)(nil).Lockouts), ctx, limit)
+}
+
+// RecordFailure mocks base method.
+func (m *MockILoginLimitRepository) RecordFailure(ctx context.Context, targets []repository.LoginTarget, limit repository.LoginLimit, now time.Time) ([]int, []bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", ctx, targets, limit, now)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].([]bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockILoginLimitRepositoryMockRecorder) RecordFailure(ctx, targets, limit, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockILoginLimitRepository)(nil).RecordFailure), ctx, targets, limit, now)
+}
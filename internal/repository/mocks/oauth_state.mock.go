@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/oauth_state.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIOAuthStateRepository is a mock of IOAuthStateRepository interface.
+type MockIOAuthStateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOAuthStateRepositoryMockRecorder
+}
+
+// MockIOAuthStateRepositoryMockRecorder is the mock recorder for MockIOAuthStateRepository.
+type MockIOAuthStateRepositoryMockRecorder struct {
+	mock *MockIOAuthStateRepository
+}
+
+// NewMockIOAuthStateRepository creates a new mock instance.
+func NewMockIOAuthStateRepository(ctrl *gomock.Controller) *MockIOAuthStateRepository {
+	mock := &MockIOAuthStateRepository{ctrl: ctrl}
+	mock.recorder = &MockIOAuthStateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOAuthStateRepository) EXPECT() *MockIOAuthStateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// MarkUsed mocks base method.
+func (m *MockIOAuthStateRepository) MarkUsed(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUsed", ctx, nonce, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkUsed indicates an expected call of MarkUsed.
+func (mr *MockIOAuthStateRepositoryMockRecorder) MarkUsed(ctx, nonce, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsed", reflect.TypeOf((*MockIOAuthStateRepository)(nil).MarkUsed), ctx, nonce, ttl)
+}
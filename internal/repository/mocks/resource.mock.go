@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/resource.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIResourceRepository is a mock of IResourceRepository interface.
+type MockIResourceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIResourceRepositoryMockRecorder
+}
+
+// MockIResourceRepositoryMockRecorder is the mock recorder for MockIResourceRepository.
+type MockIResourceRepositoryMockRecorder struct {
+	mock *MockIResourceRepository
+}
+
+// NewMockIResourceRepository creates a new mock instance.
+func NewMockIResourceRepository(ctrl *gomock.Controller) *MockIResourceRepository {
+	mock := &MockIResourceRepository{ctrl: ctrl}
+	mock.recorder = &MockIResourceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIResourceRepository) EXPECT() *MockIResourceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIResourceRepository) Create(ctx context.Context, domain domain.Resource, uploadUserId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, domain, uploadUserId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIResourceRepositoryMockRecorder) Create(ctx, domain, uploadUserId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIResourceRepository)(nil).Create), ctx, domain, uploadUserId)
+}
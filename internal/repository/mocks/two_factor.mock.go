@@ -0,0 +1,197 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/two_factor.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockITwoFactorRepository is a mock of ITwoFactorRepository interface.
+type MockITwoFactorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockITwoFactorRepositoryMockRecorder
+}
+
+// MockITwoFactorRepositoryMockRecorder is the mock recorder for MockITwoFactorRepository.
+type MockITwoFactorRepositoryMockRecorder struct {
+	mock *MockITwoFactorRepository
+}
+
+// NewMockITwoFactorRepository creates a new mock instance.
+func NewMockITwoFactorRepository(ctrl *gomock.Controller) *MockITwoFactorRepository {
+	mock := &MockITwoFactorRepository{ctrl: ctrl}
+	mock.recorder = &MockITwoFactorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITwoFactorRepository) EXPECT() *MockITwoFactorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockITwoFactorRepository) CountRecoveryCodes(ctx context.Context, uid uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", ctx, uid)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockITwoFactorRepositoryMockRecorder) CountRecoveryCodes(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockITwoFactorRepository)(nil).CountRecoveryCodes), ctx, uid)
+}
+
+// CreateChallenge mocks base method.
+func (m *MockITwoFactorRepository) CreateChallenge(ctx context.Context, c domain.TwoFactorChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChallenge", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChallenge indicates an expected call of CreateChallenge.
+func (mr *MockITwoFactorRepositoryMockRecorder) CreateChallenge(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChallenge", reflect.TypeOf((*MockITwoFactorRepository)(nil).CreateChallenge), ctx, c)
+}
+
+// DeleteChallenge mocks base method.
+func (m *MockITwoFactorRepository) DeleteChallenge(ctx context.Context, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChallenge", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteChallenge indicates an expected call of DeleteChallenge.
+func (mr *MockITwoFactorRepositoryMockRecorder) DeleteChallenge(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChallenge", reflect.TypeOf((*MockITwoFactorRepository)(nil).DeleteChallenge), ctx, id)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockITwoFactorRepository) DeleteTOTP(ctx context.Context, uid uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockITwoFactorRepositoryMockRecorder) DeleteTOTP(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockITwoFactorRepository)(nil).DeleteTOTP), ctx, uid)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockITwoFactorRepository) EnableTOTP(ctx context.Context, uid uint64, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, uid, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockITwoFactorRepositoryMockRecorder) EnableTOTP(ctx, uid, step, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockITwoFactorRepository)(nil).EnableTOTP), ctx, uid, step, codeHashes)
+}
+
+// FindChallenge mocks base method.
+func (m *MockITwoFactorRepository) FindChallenge(ctx context.Context, id string) (domain.TwoFactorChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindChallenge", ctx, id)
+	ret0, _ := ret[0].(domain.TwoFactorChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindChallenge indicates an expected call of FindChallenge.
+func (mr *MockITwoFactorRepositoryMockRecorder) FindChallenge(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindChallenge", reflect.TypeOf((*MockITwoFactorRepository)(nil).FindChallenge), ctx, id)
+}
+
+// FindTOTP mocks base method.
+func (m *MockITwoFactorRepository) FindTOTP(ctx context.Context, uid uint64) (domain.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTOTP", ctx, uid)
+	ret0, _ := ret[0].(domain.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTOTP indicates an expected call of FindTOTP.
+func (mr *MockITwoFactorRepositoryMockRecorder) FindTOTP(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTOTP", reflect.TypeOf((*MockITwoFactorRepository)(nil).FindTOTP), ctx, uid)
+}
+
+// IncrChallengeAttempts mocks base method.
+func (m *MockITwoFactorRepository) IncrChallengeAttempts(ctx context.Context, id string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrChallengeAttempts", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrChallengeAttempts indicates an expected call of IncrChallengeAttempts.
+func (mr *MockITwoFactorRepositoryMockRecorder) IncrChallengeAttempts(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrChallengeAttempts", reflect.TypeOf((*MockITwoFactorRepository)(nil).IncrChallengeAttempts), ctx, id)
+}
+
+// SavePendingTOTP mocks base method.
+func (m *MockITwoFactorRepository) SavePendingTOTP(ctx context.Context, uid uint64, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePendingTOTP", ctx, uid, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePendingTOTP indicates an expected call of SavePendingTOTP.
+func (mr *MockITwoFactorRepositoryMockRecorder) SavePendingTOTP(ctx, uid, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePendingTOTP", reflect.TypeOf((*MockITwoFactorRepository)(nil).SavePendingTOTP), ctx, uid, secret)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockITwoFactorRepository) UseRecoveryCode(ctx context.Context, uid uint64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, uid, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockITwoFactorRepositoryMockRecorder) UseRecoveryCode(ctx, uid, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockITwoFactorRepository)(nil).UseRecoveryCode), ctx, uid, codeHash)
+}
+
+// UseStep mocks base method.
+func (m *MockITwoFactorRepository) UseStep(ctx context.Context, uid uint64, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseStep", ctx, uid, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseStep indicates an expected call of UseStep.
+func (mr *MockITwoFactorRepositoryMockRecorder) UseStep(ctx, uid, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseStep", reflect.TypeOf((*MockITwoFactorRepository)(nil).UseStep), ctx, uid, step)
+}
@@ -7,9 +7,9 @@ package repomocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	domain "yellowbook/internal/domain"
 
-	proto "github.com/shenxiang11/yellowbook-proto/proto"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -36,20 +36,121 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Create mocks base method.
-func (m *MockUserRepository) Create(ctx context.Context, u domain.User) error {
+// Anonymize mocks base method.
+func (m *MockUserRepository) Anonymize(ctx context.Context, id, reassignTo uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, u)
+	ret := m.ctrl.Call(m, "Anonymize", ctx, id, reassignTo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Anonymize indicates an expected call of Anonymize.
+func (mr *MockUserRepositoryMockRecorder) Anonymize(ctx, id, reassignTo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Anonymize", reflect.TypeOf((*MockUserRepository)(nil).Anonymize), ctx, id, reassignTo)
+}
+
+// Ban mocks base method.
+func (m *MockUserRepository) Ban(ctx context.Context, id uint64, until time.Time, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ban", ctx, id, until, reason)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Ban indicates an expected call of Ban.
+func (mr *MockUserRepositoryMockRecorder) Ban(ctx, id, until, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ban", reflect.TypeOf((*MockUserRepository)(nil).Ban), ctx, id, until, reason)
+}
+
+// BindEmail mocks base method.
+func (m *MockUserRepository) BindEmail(ctx context.Context, id uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindEmail", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindEmail indicates an expected call of BindEmail.
+func (mr *MockUserRepositoryMockRecorder) BindEmail(ctx, id, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindEmail", reflect.TypeOf((*MockUserRepository)(nil).BindEmail), ctx, id, email)
+}
+
+// BindIdentity mocks base method.
+func (m *MockUserRepository) BindIdentity(ctx context.Context, id uint64, identity domain.ExternalIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindIdentity", ctx, id, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindIdentity indicates an expected call of BindIdentity.
+func (mr *MockUserRepositoryMockRecorder) BindIdentity(ctx, id, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindIdentity", reflect.TypeOf((*MockUserRepository)(nil).BindIdentity), ctx, id, identity)
+}
+
+// BindPhone mocks base method.
+func (m *MockUserRepository) BindPhone(ctx context.Context, id uint64, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindPhone", ctx, id, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindPhone indicates an expected call of BindPhone.
+func (mr *MockUserRepositoryMockRecorder) BindPhone(ctx, id, phone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindPhone", reflect.TypeOf((*MockUserRepository)(nil).BindPhone), ctx, id, phone)
+}
+
+// CancelDeletion mocks base method.
+func (m *MockUserRepository) CancelDeletion(ctx context.Context, id uint64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockUserRepositoryMockRecorder) CancelDeletion(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockUserRepository)(nil).CancelDeletion), ctx, id)
+}
+
+// Create mocks base method.
+func (m *MockUserRepository) Create(ctx context.Context, u domain.User) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, u)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Create indicates an expected call of Create.
 func (mr *MockUserRepositoryMockRecorder) Create(ctx, u interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, u)
 }
 
+// CreateWithIdentity mocks base method.
+func (m *MockUserRepository) CreateWithIdentity(ctx context.Context, u domain.User, identity domain.ExternalIdentity) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithIdentity", ctx, u, identity)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWithIdentity indicates an expected call of CreateWithIdentity.
+func (mr *MockUserRepositoryMockRecorder) CreateWithIdentity(ctx, u, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithIdentity", reflect.TypeOf((*MockUserRepository)(nil).CreateWithIdentity), ctx, u, identity)
+}
+
 // FindByEmail mocks base method.
 func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -65,19 +166,19 @@ func (mr *MockUserRepositoryMockRecorder) FindByEmail(ctx, email interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserRepository)(nil).FindByEmail), ctx, email)
 }
 
-// FindByGithubId mocks base method.
-func (m *MockUserRepository) FindByGithubId(ctx context.Context, id uint64) (domain.User, error) {
+// FindByIdentity mocks base method.
+func (m *MockUserRepository) FindByIdentity(ctx context.Context, provider, subject string) (domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByGithubId", ctx, id)
+	ret := m.ctrl.Call(m, "FindByIdentity", ctx, provider, subject)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindByGithubId indicates an expected call of FindByGithubId.
-func (mr *MockUserRepositoryMockRecorder) FindByGithubId(ctx, id interface{}) *gomock.Call {
+// FindByIdentity indicates an expected call of FindByIdentity.
+func (mr *MockUserRepositoryMockRecorder) FindByIdentity(ctx, provider, subject interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByGithubId", reflect.TypeOf((*MockUserRepository)(nil).FindByGithubId), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIdentity", reflect.TypeOf((*MockUserRepository)(nil).FindByIdentity), ctx, provider, subject)
 }
 
 // FindByPhone mocks base method.
@@ -95,6 +196,50 @@ func (mr *MockUserRepositoryMockRecorder) FindByPhone(ctx, phone interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserRepository)(nil).FindByPhone), ctx, phone)
 }
 
+// FindDueDeletions mocks base method.
+func (m *MockUserRepository) FindDueDeletions(ctx context.Context, now time.Time, limit int) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDueDeletions", ctx, now, limit)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDueDeletions indicates an expected call of FindDueDeletions.
+func (mr *MockUserRepositoryMockRecorder) FindDueDeletions(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDueDeletions", reflect.TypeOf((*MockUserRepository)(nil).FindDueDeletions), ctx, now, limit)
+}
+
+// FindIdByHandle mocks base method.
+func (m *MockUserRepository) FindIdByHandle(ctx context.Context, handle string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIdByHandle", ctx, handle)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIdByHandle indicates an expected call of FindIdByHandle.
+func (mr *MockUserRepositoryMockRecorder) FindIdByHandle(ctx, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIdByHandle", reflect.TypeOf((*MockUserRepository)(nil).FindIdByHandle), ctx, handle)
+}
+
+// Merge mocks base method.
+func (m *MockUserRepository) Merge(ctx context.Context, fromId, toId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, fromId, toId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockUserRepositoryMockRecorder) Merge(ctx, fromId, toId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockUserRepository)(nil).Merge), ctx, fromId, toId)
+}
+
 // QueryProfile mocks base method.
 func (m *MockUserRepository) QueryProfile(ctx context.Context, uid uint64) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -111,7 +256,7 @@ func (mr *MockUserRepositoryMockRecorder) QueryProfile(ctx, uid interface{}) *go
 }
 
 // QueryUsers mocks base method.
-func (m *MockUserRepository) QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]domain.User, int64, error) {
+func (m *MockUserRepository) QueryUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "QueryUsers", ctx, filter)
 	ret0, _ := ret[0].([]domain.User)
@@ -126,6 +271,118 @@ func (mr *MockUserRepositoryMockRecorder) QueryUsers(ctx, filter interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryUsers", reflect.TypeOf((*MockUserRepository)(nil).QueryUsers), ctx, filter)
 }
 
+// ScheduleDeletion mocks base method.
+func (m *MockUserRepository) ScheduleDeletion(ctx context.Context, id uint64, deleteAfter time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, id, deleteAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockUserRepositoryMockRecorder) ScheduleDeletion(ctx, id, deleteAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockUserRepository)(nil).ScheduleDeletion), ctx, id, deleteAfter)
+}
+
+// SetAvatar mocks base method.
+func (m *MockUserRepository) SetAvatar(ctx context.Context, uid uint64, url string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvatar", ctx, uid, url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAvatar indicates an expected call of SetAvatar.
+func (mr *MockUserRepositoryMockRecorder) SetAvatar(ctx, uid, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvatar", reflect.TypeOf((*MockUserRepository)(nil).SetAvatar), ctx, uid, url)
+}
+
+// SetHandle mocks base method.
+func (m *MockUserRepository) SetHandle(ctx context.Context, uid uint64, handle string, changedBefore, redirectUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHandle", ctx, uid, handle, changedBefore, redirectUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHandle indicates an expected call of SetHandle.
+func (mr *MockUserRepositoryMockRecorder) SetHandle(ctx, uid, handle, changedBefore, redirectUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHandle", reflect.TypeOf((*MockUserRepository)(nil).SetHandle), ctx, uid, handle, changedBefore, redirectUntil)
+}
+
+// Unban mocks base method.
+func (m *MockUserRepository) Unban(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unban", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unban indicates an expected call of Unban.
+func (mr *MockUserRepositoryMockRecorder) Unban(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unban", reflect.TypeOf((*MockUserRepository)(nil).Unban), ctx, id)
+}
+
+// Unbind mocks base method.
+func (m *MockUserRepository) Unbind(ctx context.Context, id uint64, kind string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unbind", ctx, id, kind)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unbind indicates an expected call of Unbind.
+func (mr *MockUserRepositoryMockRecorder) Unbind(ctx, id, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unbind", reflect.TypeOf((*MockUserRepository)(nil).Unbind), ctx, id, kind)
+}
+
+// UpdateAvatar mocks base method.
+func (m *MockUserRepository) UpdateAvatar(ctx context.Context, uid uint64, avatars map[int]domain.Resource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAvatar", ctx, uid, avatars)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAvatar indicates an expected call of UpdateAvatar.
+func (mr *MockUserRepositoryMockRecorder) UpdateAvatar(ctx, uid, avatars interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAvatar", reflect.TypeOf((*MockUserRepository)(nil).UpdateAvatar), ctx, uid, avatars)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(ctx, id, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), ctx, id, password)
+}
+
+// UpdatePrivacy mocks base method.
+func (m *MockUserRepository) UpdatePrivacy(ctx context.Context, uid uint64, privacy domain.Privacy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePrivacy", ctx, uid, privacy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePrivacy indicates an expected call of UpdatePrivacy.
+func (mr *MockUserRepositoryMockRecorder) UpdatePrivacy(ctx, uid, privacy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePrivacy", reflect.TypeOf((*MockUserRepository)(nil).UpdatePrivacy), ctx, uid, privacy)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockUserRepository) UpdateProfile(ctx context.Context, u domain.Profile) error {
 	m.ctrl.T.Helper()
@@ -139,3 +396,17 @@ func (mr *MockUserRepositoryMockRecorder) UpdateProfile(ctx, u interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserRepository)(nil).UpdateProfile), ctx, u)
 }
+
+// VerifyEmail mocks base method.
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserRepositoryMockRecorder) VerifyEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserRepository)(nil).VerifyEmail), ctx, email)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/repository/cache"
+)
+
+type IOAuthStateRepository interface {
+	MarkUsed(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+type CachedOAuthStateRepository struct {
+	cache cache.OAuthStateCache
+}
+
+func NewOAuthStateRepository(c cache.OAuthStateCache) IOAuthStateRepository {
+	return &CachedOAuthStateRepository{cache: c}
+}
+
+func (repo *CachedOAuthStateRepository) MarkUsed(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return repo.cache.MarkUsed(ctx, nonce, ttl)
+}
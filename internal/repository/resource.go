@@ -6,6 +6,8 @@ import (
 	"yellowbook/internal/repository/dao"
 )
 
+var ErrResourceDuplicate = dao.ErrResourceDuplicate
+
 type IResourceRepository interface {
 	Create(ctx context.Context, domain domain.Resource, uploadUserId uint64) error
 }
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository/cache"
+)
+
+var (
+	ErrSessionNotFound      = cache.ErrSessionNotFound
+	ErrRefreshTokenMismatch = cache.ErrRefreshTokenMismatch
+)
+
+type ISessionRepository interface {
+	Create(ctx context.Context, s domain.Session) error
+	FindById(ctx context.Context, id string) (domain.Session, error)
+	FindByUserId(ctx context.Context, uid uint64) ([]domain.Session, error)
+	RotateRefresh(ctx context.Context, id string, expected string, next string) error
+	Touch(ctx context.Context, id string, lastSeen time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+type CachedSessionRepository struct {
+	cache cache.SessionCache
+}
+
+func NewSessionRepository(c cache.SessionCache) ISessionRepository {
+	return &CachedSessionRepository{cache: c}
+}
+
+func (repo *CachedSessionRepository) Create(ctx context.Context, s domain.Session) error {
+	return repo.cache.Set(ctx, s)
+}
+
+func (repo *CachedSessionRepository) FindById(ctx context.Context, id string) (domain.Session, error) {
+	return repo.cache.Get(ctx, id)
+}
+
+func (repo *CachedSessionRepository) FindByUserId(ctx context.Context, uid uint64) ([]domain.Session, error) {
+	return repo.cache.ListByUserId(ctx, uid)
+}
+
+func (repo *CachedSessionRepository) Touch(ctx context.Context, id string, lastSeen time.Time) error {
+	return repo.cache.Touch(ctx, id, lastSeen)
+}
+
+func (repo *CachedSessionRepository) RotateRefresh(ctx context.Context, id string, expected string, next string) error {
+	return repo.cache.RotateRefresh(ctx, id, expected, next)
+}
+
+func (repo *CachedSessionRepository) Delete(ctx context.Context, id string) error {
+	return repo.cache.Delete(ctx, id)
+}
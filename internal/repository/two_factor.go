@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository/cache"
+	"yellowbook/internal/repository/dao"
+)
+
+var (
+	ErrTOTPNotFound      = dao.ErrTOTPNotFound
+	ErrTOTPEnabled       = dao.ErrTOTPEnabled
+	ErrChallengeNotFound = cache.ErrChallengeNotFound
+)
+
+type ITwoFactorRepository interface {
+	FindTOTP(ctx context.Context, uid uint64) (domain.TOTP, error)
+	SavePendingTOTP(ctx context.Context, uid uint64, secret string) error
+	EnableTOTP(ctx context.Context, uid uint64, step int64, codeHashes []string) error
+	UseStep(ctx context.Context, uid uint64, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, uid uint64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, uid uint64) (int64, error)
+	DeleteTOTP(ctx context.Context, uid uint64) error
+
+	CreateChallenge(ctx context.Context, c domain.TwoFactorChallenge) error
+	FindChallenge(ctx context.Context, id string) (domain.TwoFactorChallenge, error)
+	IncrChallengeAttempts(ctx context.Context, id string) (int, error)
+	DeleteChallenge(ctx context.Context, id string) (bool, error)
+}
+
+type TwoFactorRepository struct {
+	dao   dao.TwoFactorDAO
+	cache cache.TwoFactorChallengeCache
+}
+
+func NewTwoFactorRepository(d dao.TwoFactorDAO, c cache.TwoFactorChallengeCache) ITwoFactorRepository {
+	return &TwoFactorRepository{dao: d, cache: c}
+}
+
+func (repo *TwoFactorRepository) FindTOTP(ctx context.Context, uid uint64) (domain.TOTP, error) {
+	t, err := repo.dao.FindTOTP(ctx, uid)
+	if err != nil {
+		return domain.TOTP{}, err
+	}
+
+	return domain.TOTP{
+		UserId:     t.UserId,
+		Secret:     t.Secret,
+		Enabled:    t.Enabled,
+		LastStep:   t.LastStep,
+		CreateTime: time.UnixMilli(t.CreateTime),
+		UpdateTime: time.UnixMilli(t.UpdateTime),
+	}, nil
+}
+
+func (repo *TwoFactorRepository) SavePendingTOTP(ctx context.Context, uid uint64, secret string) error {
+	return repo.dao.SavePendingTOTP(ctx, uid, secret)
+}
+
+func (repo *TwoFactorRepository) EnableTOTP(ctx context.Context, uid uint64, step int64, codeHashes []string) error {
+	return repo.dao.EnableTOTP(ctx, uid, step, codeHashes)
+}
+
+func (repo *TwoFactorRepository) UseStep(ctx context.Context, uid uint64, step int64) (bool, error) {
+	return repo.dao.UseStep(ctx, uid, step)
+}
+
+func (repo *TwoFactorRepository) UseRecoveryCode(ctx context.Context, uid uint64, codeHash string) (bool, error) {
+	return repo.dao.UseRecoveryCode(ctx, uid, codeHash)
+}
+
+func (repo *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, uid uint64) (int64, error) {
+	return repo.dao.CountRecoveryCodes(ctx, uid)
+}
+
+func (repo *TwoFactorRepository) DeleteTOTP(ctx context.Context, uid uint64) error {
+	return repo.dao.DeleteTOTP(ctx, uid)
+}
+
+func (repo *TwoFactorRepository) CreateChallenge(ctx context.Context, c domain.TwoFactorChallenge) error {
+	return repo.cache.Set(ctx, c)
+}
+
+func (repo *TwoFactorRepository) FindChallenge(ctx context.Context, id string) (domain.TwoFactorChallenge, error) {
+	return repo.cache.Get(ctx, id)
+}
+
+func (repo *TwoFactorRepository) IncrChallengeAttempts(ctx context.Context, id string) (int, error) {
+	return repo.cache.IncrAttempts(ctx, id)
+}
+
+func (repo *TwoFactorRepository) DeleteChallenge(ctx context.Context, id string) (bool, error) {
+	return repo.cache.Delete(ctx, id)
+}
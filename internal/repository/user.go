@@ -3,10 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"github.com/shenxiang11/yellowbook-proto/proto"
 	"github.com/shenxiang11/zippo/slice"
 	"log"
+	"sort"
 	"time"
 	"yellowbook/internal/domain"
 	"yellowbook/internal/repository/cache"
@@ -16,15 +17,48 @@ import (
 var ErrUserDuplicate = dao.ErrUserDuplicate
 var ErrUserNotFound = dao.ErrUserNotFound
 var ErrUserBirthdayFormat = errors.New("输入的生日格式不符合规则")
+var ErrLastLoginMethod = dao.ErrLastLoginMethod
+var ErrUnknownIdentity = errors.New("不支持的登录方式")
+var ErrHandleDuplicate = dao.ErrHandleDuplicate
+var ErrHandleCooldown = dao.ErrHandleCooldown
+var ErrDeletionNotDue = dao.ErrDeletionNotDue
 
 type UserRepository interface {
 	FindByEmail(ctx context.Context, email string) (domain.User, error)
-	Create(ctx context.Context, u domain.User) error
+	Create(ctx context.Context, u domain.User) (uint64, error)
+	// UpdateProfile 不修改头像，头像用 UpdateAvatar 或者 SetAvatar
 	UpdateProfile(ctx context.Context, u domain.Profile) error
 	QueryProfile(ctx context.Context, uid uint64) (domain.User, error)
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
-	QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]domain.User, int64, error)
-	FindByGithubId(ctx context.Context, id uint64) (domain.User, error)
+	QueryUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int64, error)
+	FindByIdentity(ctx context.Context, provider string, subject string) (domain.User, error)
+	CreateWithIdentity(ctx context.Context, u domain.User, identity domain.ExternalIdentity) (uint64, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	VerifyEmail(ctx context.Context, email string) error
+	BindPhone(ctx context.Context, id uint64, phone string) error
+	BindEmail(ctx context.Context, id uint64, email string) error
+	BindIdentity(ctx context.Context, id uint64, identity domain.ExternalIdentity) error
+	// Unbind kind 是 domain.Identity 开头的常量，或者第三方登录的 provider
+	Unbind(ctx context.Context, id uint64, kind string) error
+	Merge(ctx context.Context, fromId uint64, toId uint64) error
+	ScheduleDeletion(ctx context.Context, id uint64, deleteAfter time.Time) error
+	// CancelDeletion 返回 false 说明没有待处理的注销申请
+	CancelDeletion(ctx context.Context, id uint64) (bool, error)
+	FindDueDeletions(ctx context.Context, now time.Time, limit int) ([]uint64, error)
+	// Anonymize reassignTo 为 0 时下架文章，否则把文章转给它
+	Anonymize(ctx context.Context, id uint64, reassignTo uint64) error
+	// Ban until 为零值表示永久封禁
+	Ban(ctx context.Context, id uint64, until time.Time, reason string) error
+	Unban(ctx context.Context, id uint64) error
+	UpdatePrivacy(ctx context.Context, uid uint64, privacy domain.Privacy) error
+	// UpdateAvatar avatars 的 key 是边长，资料里的头像换成 domain.AvatarDefaultSize 那一张
+	UpdateAvatar(ctx context.Context, uid uint64, avatars map[int]domain.Resource) error
+	// SetAvatar 直接使用外部的头像地址，没有其它尺寸
+	SetAvatar(ctx context.Context, uid uint64, url string) error
+	// SetHandle 上次修改在 changedBefore 之后返回 ErrHandleCooldown，旧的用户名保留到 redirectUntil
+	SetHandle(ctx context.Context, uid uint64, handle string, changedBefore time.Time, redirectUntil time.Time) error
+	// FindIdByHandle 也能找到还在跳转期内的旧用户名
+	FindIdByHandle(ctx context.Context, handle string) (uint64, error)
 }
 
 type CachedUserRepository struct {
@@ -48,8 +82,8 @@ func (r *CachedUserRepository) FindByEmail(ctx context.Context, email string) (d
 	return r.entityToDomain(u), nil
 }
 
-func (r *CachedUserRepository) FindByGithubId(ctx context.Context, id uint64) (domain.User, error) {
-	u, err := r.dao.FindByGithubId(ctx, id)
+func (r *CachedUserRepository) FindByIdentity(ctx context.Context, provider string, subject string) (domain.User, error) {
+	u, err := r.dao.FindByIdentity(ctx, provider, subject)
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -57,21 +91,14 @@ func (r *CachedUserRepository) FindByGithubId(ctx context.Context, id uint64) (d
 	return r.entityToDomain(u), nil
 }
 
-func (r *CachedUserRepository) Create(ctx context.Context, u domain.User) error {
-	return r.dao.Insert(ctx, dao.User{
-		Email: sql.NullString{
-			String: u.Email,
-			Valid:  u.Email != "",
-		},
-		GithubId: sql.NullInt64{
-			Int64: int64(u.GithubId),
-			Valid: u.GithubId != 0,
-		},
-		Phone: sql.NullString{
-			String: u.Phone,
-			Valid:  u.Phone != "",
-		},
-		Password: u.Password,
+func (r *CachedUserRepository) Create(ctx context.Context, u domain.User) (uint64, error) {
+	return r.dao.Insert(ctx, r.domainToEntity(u))
+}
+
+func (r *CachedUserRepository) CreateWithIdentity(ctx context.Context, u domain.User, identity domain.ExternalIdentity) (uint64, error) {
+	return r.dao.InsertWithIdentity(ctx, r.domainToEntity(u), dao.UserIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
 	})
 }
 
@@ -83,7 +110,6 @@ func (r *CachedUserRepository) UpdateProfile(ctx context.Context, u domain.Profi
 		UserId:       u.UserId,
 		Nickname:     u.Nickname,
 		Introduction: u.Introduction,
-		Avatar:       u.Avatar,
 		Gender:       u.Gender,
 	}
 
@@ -95,13 +121,12 @@ func (r *CachedUserRepository) UpdateProfile(ctx context.Context, u domain.Profi
 		up.Birthday = t.UnixMilli()
 	}
 
-	// 为了一致性，删除对应的缓存
-	err = r.cache.Delete(ctx, u.UserId)
-	if err != nil {
-		log.Printf("缓存删除失败：%v\n", err)
+	if err = r.dao.UpdateProfile(ctx, up); err != nil {
+		return err
 	}
-
-	return r.dao.UpdateProfile(ctx, up)
+	// 写库成功以后再删缓存，先删的话并发的读请求会把旧资料写回缓存
+	r.deleteCache(ctx, u.UserId)
+	return nil
 }
 
 func (r *CachedUserRepository) QueryProfile(ctx context.Context, uid uint64) (domain.User, error) {
@@ -135,8 +160,219 @@ func (r *CachedUserRepository) FindByPhone(ctx context.Context, phone string) (d
 	return r.entityToDomain(u), nil
 }
 
-func (r *CachedUserRepository) QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]domain.User, int64, error) {
-	users, total, err := r.dao.QueryUsers(ctx, filter)
+func (r *CachedUserRepository) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	err := r.dao.UpdatePassword(ctx, id, password)
+	if err != nil {
+		return err
+	}
+
+	// 缓存里也有密码哈希
+	if err = r.cache.Delete(ctx, id); err != nil {
+		log.Printf("缓存删除失败：%v\n", err)
+	}
+	return nil
+}
+
+func (r *CachedUserRepository) VerifyEmail(ctx context.Context, email string) error {
+	u, err := r.dao.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if err = r.dao.MarkEmailVerified(ctx, u.Id, email); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, u.Id)
+	return nil
+}
+
+func (r *CachedUserRepository) BindPhone(ctx context.Context, id uint64, phone string) error {
+	return r.setIdentity(ctx, id, dao.IdentityPhone, phone)
+}
+
+func (r *CachedUserRepository) BindEmail(ctx context.Context, id uint64, email string) error {
+	return r.setIdentity(ctx, id, dao.IdentityEmail, email)
+}
+
+func (r *CachedUserRepository) BindIdentity(ctx context.Context, id uint64, identity domain.ExternalIdentity) error {
+	if err := r.dao.SetExternalIdentity(ctx, id, identity.Provider, identity.Subject); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) setIdentity(ctx context.Context, id uint64, column string, value any) error {
+	if err := r.dao.SetIdentity(ctx, id, column, value); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) Unbind(ctx context.Context, id uint64, kind string) error {
+	if kind == "" {
+		return ErrUnknownIdentity
+	}
+
+	columns := map[string]string{
+		domain.IdentityPhone: dao.IdentityPhone,
+		domain.IdentityEmail: dao.IdentityEmail,
+	}
+	var err error
+	if column, ok := columns[kind]; ok {
+		err = r.dao.ClearIdentity(ctx, id, column)
+	} else {
+		// 是不是支持的 provider 由调用方判断
+		err = r.dao.ClearExternalIdentity(ctx, id, kind)
+	}
+	if err != nil {
+		return err
+	}
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) Merge(ctx context.Context, fromId uint64, toId uint64) error {
+	if err := r.dao.Merge(ctx, fromId, toId); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, fromId)
+	r.deleteCache(ctx, toId)
+	return nil
+}
+
+func (r *CachedUserRepository) ScheduleDeletion(ctx context.Context, id uint64, deleteAfter time.Time) error {
+	if err := r.dao.ScheduleDeletion(ctx, id, deleteAfter.UnixMilli()); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) CancelDeletion(ctx context.Context, id uint64) (bool, error) {
+	ok, err := r.dao.CancelDeletion(ctx, id)
+	if ok {
+		r.deleteCache(ctx, id)
+	}
+	return ok, err
+}
+
+func (r *CachedUserRepository) FindDueDeletions(ctx context.Context, now time.Time, limit int) ([]uint64, error) {
+	return r.dao.FindDueDeletions(ctx, now.UnixMilli(), limit)
+}
+
+func (r *CachedUserRepository) Anonymize(ctx context.Context, id uint64, reassignTo uint64) error {
+	if err := r.dao.Anonymize(ctx, id, reassignTo); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) Ban(ctx context.Context, id uint64, until time.Time, reason string) error {
+	var untilMs int64
+	if !until.IsZero() {
+		untilMs = until.UnixMilli()
+	}
+	if err := r.dao.SetBan(ctx, id, untilMs, reason); err != nil {
+		return err
+	}
+	// 中间件每个请求都走缓存判断封禁，不删的话要等缓存过期才生效
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) Unban(ctx context.Context, id uint64) error {
+	if err := r.dao.ClearBan(ctx, id); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, id)
+	return nil
+}
+
+func (r *CachedUserRepository) UpdatePrivacy(ctx context.Context, uid uint64, privacy domain.Privacy) error {
+	err := r.dao.UpdatePrivacy(ctx, dao.UserProfile{
+		UserId:       uid,
+		HideBirthday: privacy.HideBirthday,
+		HideGender:   privacy.HideGender,
+		Private:      privacy.Private,
+	})
+	if err != nil {
+		return err
+	}
+	r.deleteCache(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) UpdateAvatar(ctx context.Context, uid uint64, avatars map[int]domain.Resource) error {
+	sizes := make([]int, 0, len(avatars))
+	for size := range avatars {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	urls := make(map[int]string, len(avatars))
+	resources := make([]dao.Resource, 0, len(avatars))
+	for _, size := range sizes {
+		res := avatars[size]
+		urls[size] = res.Url
+		resources = append(resources, dao.Resource{
+			Url:          res.Url,
+			Purpose:      res.Purpose,
+			Mimetype:     res.Mimetype,
+			UploadUserId: uid,
+		})
+	}
+	variants, err := json.Marshal(urls)
+	if err != nil {
+		return err
+	}
+
+	err = r.dao.UpdateAvatar(ctx, dao.UserProfile{
+		UserId:         uid,
+		Avatar:         urls[domain.AvatarDefaultSize],
+		AvatarVariants: string(variants),
+	}, resources)
+	if err != nil {
+		return err
+	}
+	r.deleteCache(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) SetAvatar(ctx context.Context, uid uint64, url string) error {
+	if err := r.dao.UpdateAvatar(ctx, dao.UserProfile{UserId: uid, Avatar: url}, nil); err != nil {
+		return err
+	}
+	r.deleteCache(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) SetHandle(ctx context.Context, uid uint64, handle string, changedBefore time.Time, redirectUntil time.Time) error {
+	err := r.dao.SetHandle(ctx, uid, handle, changedBefore.UnixMilli(), redirectUntil.UnixMilli())
+	if err != nil {
+		return err
+	}
+	r.deleteCache(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) FindIdByHandle(ctx context.Context, handle string) (uint64, error) {
+	return r.dao.FindIdByHandle(ctx, handle)
+}
+
+func (r *CachedUserRepository) deleteCache(ctx context.Context, id uint64) {
+	if err := r.cache.Delete(ctx, id); err != nil {
+		log.Printf("缓存删除失败：%v\n", err)
+	}
+}
+
+func (r *CachedUserRepository) QueryUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int64, error) {
+	users, total, err := r.dao.QueryUsers(ctx, dao.UserFilter{
+		GetUserListRequest: filter.GetUserListRequest,
+		Handle:             filter.Handle,
+	})
 	if err != nil {
 		return []domain.User{}, total, err
 	}
@@ -146,29 +382,78 @@ func (r *CachedUserRepository) QueryUsers(ctx context.Context, filter *proto.Get
 	}), total, nil
 }
 
+func (r *CachedUserRepository) domainToEntity(u domain.User) dao.User {
+	return dao.User{
+		Email: sql.NullString{
+			String: u.Email,
+			Valid:  u.Email != "",
+		},
+		Phone: sql.NullString{
+			String: u.Phone,
+			Valid:  u.Phone != "",
+		},
+		Password: u.Password,
+	}
+}
+
 func (r *CachedUserRepository) entityToDomain(u dao.User) domain.User {
 	e := domain.User{
-		Id:         u.Id,
-		Email:      u.Email.String,
-		Phone:      u.Phone.String,
-		Password:   u.Password,
-		GithubId:   uint64(u.GithubId.Int64),
-		CreateTime: time.UnixMilli(u.CreateTime).UTC(),
-		UpdateTime: time.UnixMilli(u.UpdateTime).UTC(),
+		Id:            u.Id,
+		Email:         u.Email.String,
+		EmailVerified: u.EmailVerified,
+		Phone:         u.Phone.String,
+		Handle:        u.Handle.String,
+		Password:      u.Password,
+		CreateTime:    time.UnixMilli(u.CreateTime).UTC(),
+		UpdateTime:    time.UnixMilli(u.UpdateTime).UTC(),
+	}
+	if u.HandleUpdateTime != 0 {
+		e.HandleUpdateTime = time.UnixMilli(u.HandleUpdateTime).UTC()
+	}
+	if u.DeleteAfter != 0 {
+		e.DeleteAfter = time.UnixMilli(u.DeleteAfter).UTC()
+	}
+	if u.Banned {
+		e.Banned = true
+		e.BanReason = u.BanReason
+		if u.BanUntil != 0 {
+			e.BanUntil = time.UnixMilli(u.BanUntil).UTC()
+		}
 	}
 
 	if u.Profile != nil {
 		e.Profile = &domain.Profile{
 			UserId:       u.Profile.UserId,
 			Nickname:     u.Profile.Nickname,
-			Birthday:     time.UnixMilli(u.Profile.Birthday).UTC().Format("2006-01-02"),
 			Introduction: u.Profile.Introduction,
 			Avatar:       u.Profile.Avatar,
+			Avatars:      avatarVariants(u.Profile.AvatarVariants),
 			Gender:       u.Profile.Gender,
-			CreateTime:   time.UnixMilli(u.Profile.CreateTime).UTC(),
-			UpdateTime:   time.UnixMilli(u.Profile.UpdateTime).UTC(),
+			Privacy: domain.Privacy{
+				HideBirthday: u.Profile.HideBirthday,
+				HideGender:   u.Profile.HideGender,
+				Private:      u.Profile.Private,
+			},
+			CreateTime: time.UnixMilli(u.Profile.CreateTime).UTC(),
+			UpdateTime: time.UnixMilli(u.Profile.UpdateTime).UTC(),
+		}
+		// 0 是没填过生日，不能显示成 1970-01-01
+		if u.Profile.Birthday != 0 {
+			e.Profile.Birthday = time.UnixMilli(u.Profile.Birthday).UTC().Format("2006-01-02")
 		}
 	}
 
 	return e
 }
+
+func avatarVariants(s string) map[int]string {
+	if s == "" {
+		return nil
+	}
+	var res map[int]string
+	if err := json.Unmarshal([]byte(s), &res); err != nil {
+		log.Printf("头像尺寸解析失败：%v\n", err)
+		return nil
+	}
+	return res
+}
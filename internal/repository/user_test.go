@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/google/go-cmp/cmp"
+	"github.com/shenxiang11/yellowbook-proto/proto"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
@@ -95,7 +96,7 @@ func TestCachedUserRepository_Create(t *testing.T) {
 				d := daomocks.NewMockUserDao(ctrl)
 				c := cachemocks.NewMockUserCache(ctrl)
 
-				d.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(nil)
+				d.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(uint64(1), nil)
 
 				return d, c
 			},
@@ -113,7 +114,7 @@ func TestCachedUserRepository_Create(t *testing.T) {
 			d, c := tc.mock(ctrl)
 			repo := NewCachedUserRepository(d, c)
 
-			err := repo.Create(context.Background(), tc.user)
+			_, err := repo.Create(context.Background(), tc.user)
 			assert.Equal(t, err, tc.wantErr)
 		})
 	}
@@ -194,6 +195,375 @@ func TestCachedUserRepository_UpdateProfile(t *testing.T) {
 	}
 }
 
+func TestCachedUserRepository_UpdatePassword(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "更新成功，删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(nil)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+
+				return d, c
+			},
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(dao.ErrUserNotFound)
+
+				return d, c
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+
+			err := repo.UpdatePassword(context.Background(), 1, "hash")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_VerifyEmail(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "验证成功，删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(dao.User{Id: 1}, nil)
+				d.EXPECT().MarkEmailVerified(gomock.Any(), uint64(1), "a@qq.com").Return(nil)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+
+				return d, c
+			},
+		},
+		{
+			name: "邮箱未注册",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(dao.User{}, dao.ErrUserNotFound)
+
+				return d, c
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "查到以后邮箱被换掉了",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(dao.User{Id: 1}, nil)
+				d.EXPECT().MarkEmailVerified(gomock.Any(), uint64(1), "a@qq.com").Return(dao.ErrUserNotFound)
+
+				return d, c
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+
+			err := repo.VerifyEmail(context.Background(), "a@qq.com")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_Unbind(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		kind    string
+		wantErr error
+	}{
+		{
+			name: "解绑 github",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().ClearExternalIdentity(gomock.Any(), uint64(1), "github").Return(nil)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+
+				return d, c
+			},
+			kind: "github",
+		},
+		{
+			name: "最后一种登录方式",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().ClearIdentity(gomock.Any(), uint64(1), "phone").Return(dao.ErrLastLoginMethod)
+
+				return d, c
+			},
+			kind:    domain.IdentityPhone,
+			wantErr: ErrLastLoginMethod,
+		},
+		{
+			name: "不支持的登录方式",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				return daomocks.NewMockUserDao(ctrl), cachemocks.NewMockUserCache(ctrl)
+			},
+			kind:    "",
+			wantErr: ErrUnknownIdentity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+
+			err := repo.Unbind(context.Background(), 1, tc.kind)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_Merge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := daomocks.NewMockUserDao(ctrl)
+	c := cachemocks.NewMockUserCache(ctrl)
+	d.EXPECT().Merge(gomock.Any(), uint64(2), uint64(1)).Return(nil)
+	// 两个账号的缓存都要删
+	c.EXPECT().Delete(gomock.Any(), uint64(2)).Return(nil)
+	c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(errors.New("模拟错误"))
+
+	repo := NewCachedUserRepository(d, c)
+	assert.NoError(t, repo.Merge(context.Background(), 2, 1))
+}
+
+func TestCachedUserRepository_Anonymize(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "匿名化后删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().Anonymize(gomock.Any(), uint64(1), uint64(0)).Return(nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return d, c
+			},
+		},
+		{
+			name: "申请已经取消，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().Anonymize(gomock.Any(), uint64(1), uint64(0)).Return(dao.ErrDeletionNotDue)
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrDeletionNotDue,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+			err := repo.Anonymize(context.Background(), 1, 0)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_Ban(t *testing.T) {
+	until := time.UnixMilli(1694575373000)
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		until   time.Time
+		wantErr error
+	}{
+		{
+			name: "临时封禁后删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().SetBan(gomock.Any(), uint64(1), int64(1694575373000), "发布广告").Return(nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return d, c
+			},
+			until: until,
+		},
+		{
+			name: "永久封禁",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().SetBan(gomock.Any(), uint64(1), int64(0), "发布广告").Return(nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return d, c
+			},
+		},
+		{
+			name: "账号不存在，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().SetBan(gomock.Any(), uint64(1), int64(0), "发布广告").Return(dao.ErrUserNotFound)
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+			err := repo.Ban(context.Background(), 1, tc.until, "发布广告")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_UpdateAvatar(t *testing.T) {
+	avatars := map[int]domain.Resource{
+		256: {Url: "https://oss.com/256", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/png"},
+		64:  {Url: "https://oss.com/64", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/png"},
+	}
+	profile := dao.UserProfile{
+		UserId:         1,
+		Avatar:         "https://oss.com/256",
+		AvatarVariants: `{"256":"https://oss.com/256","64":"https://oss.com/64"}`,
+	}
+	resources := []dao.Resource{
+		{Url: "https://oss.com/64", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/png", UploadUserId: 1},
+		{Url: "https://oss.com/256", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/png", UploadUserId: 1},
+	}
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "更新后删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().UpdateAvatar(gomock.Any(), profile, resources).Return(nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return d, c
+			},
+		},
+		{
+			name: "资源冲突，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().UpdateAvatar(gomock.Any(), profile, resources).Return(dao.ErrResourceDuplicate)
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrResourceDuplicate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+			err := repo.UpdateAvatar(context.Background(), 1, avatars)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_SetHandle(t *testing.T) {
+	changedBefore := time.UnixMilli(1694575373000)
+	redirectUntil := time.UnixMilli(1702351373000)
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "修改后删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().SetHandle(gomock.Any(), uint64(1), "hehei", int64(1694575373000), int64(1702351373000)).Return(nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return d, c
+			},
+		},
+		{
+			name: "已被占用，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				d.EXPECT().SetHandle(gomock.Any(), uint64(1), "hehei", gomock.Any(), gomock.Any()).Return(dao.ErrHandleDuplicate)
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrHandleDuplicate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedUserRepository(d, c)
+			err := repo.SetHandle(context.Background(), 1, "hehei", changedBefore, redirectUntil)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
 func TestCachedUserRepository_QueryProfile(t *testing.T) {
 	now := time.UnixMilli(time.Now().UTC().UnixMilli()).UTC()
 
@@ -245,6 +615,40 @@ func TestCachedUserRepository_QueryProfile(t *testing.T) {
 				UpdateTime: now,
 			},
 		},
+		{
+			name:   "没填生日",
+			userId: 1,
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				d := daomocks.NewMockUserDao(ctrl)
+				c := cachemocks.NewMockUserCache(ctrl)
+
+				d.EXPECT().FindProfileByUserId(gomock.Any(), uint64(1)).Return(dao.User{
+					Id: 1,
+					Profile: &dao.UserProfile{
+						UserId:     1,
+						CreateTime: now.UnixMilli(),
+						UpdateTime: now.UnixMilli(),
+					},
+					CreateTime: now.UnixMilli(),
+					UpdateTime: now.UnixMilli(),
+				}, nil)
+
+				c.EXPECT().Get(gomock.Any(), gomock.Any()).Return(domain.User{}, errors.New("模拟错误"))
+				c.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil)
+
+				return d, c
+			},
+			wantUser: domain.User{
+				Id: 1,
+				Profile: &domain.Profile{
+					UserId:     1,
+					CreateTime: now,
+					UpdateTime: now,
+				},
+				CreateTime: now,
+				UpdateTime: now,
+			},
+		},
 		{
 			name:   "命中缓存",
 			userId: 1,
@@ -503,7 +907,7 @@ func TestCachedUserRepository_QueryUsers(t *testing.T) {
 			d, c := tc.mock(ctrl)
 			repo := NewCachedUserRepository(d, c)
 
-			users, total, err := repo.QueryUsers(context.Background(), nil)
+			users, total, err := repo.QueryUsers(context.Background(), domain.UserFilter{})
 
 			assert.Equal(t, err, tc.wantErr)
 			assert.Equal(t, total, tc.wantTotal)
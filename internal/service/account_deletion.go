@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yellowbook/internal/repository"
+	"yellowbook/pkg/logger"
+)
+
+type IAccountDeletionService interface {
+	// Request 设置过密码的账号需要验证密码，没有密码的账号由调用方先验证短信、邮件或者两步验证码，
+	// 申请后所有设备退出登录，返回冷静期结束的时间
+	Request(ctx context.Context, uid uint64, password string) (time.Time, error)
+	// Cancel 冷静期内登录成功时调用，返回 true 说明取消了注销申请
+	Cancel(ctx context.Context, uid uint64) (bool, error)
+	// ProcessDue 匿名化一批冷静期已过的账号，返回实际处理的数量
+	ProcessDue(ctx context.Context, limit int) (int, error)
+}
+
+// AccountDeletionPolicy 注销的冷静期和文章的处理方式
+type AccountDeletionPolicy struct {
+	CoolingOff time.Duration
+	// ReassignTo 为 0 时下架注销账号的文章，否则把文章转给这个账号
+	ReassignTo uint64
+}
+
+type AccountDeletionService struct {
+	repo       repository.UserRepository
+	userSvc    IUserService
+	sessionSvc ISessionService
+	l          logger.Logger
+	policy     AccountDeletionPolicy
+	nowFunc    func() time.Time
+}
+
+func NewAccountDeletionService(
+	repo repository.UserRepository,
+	userSvc IUserService,
+	sessionSvc ISessionService,
+	l logger.Logger,
+	policy AccountDeletionPolicy,
+) IAccountDeletionService {
+	return &AccountDeletionService{
+		repo:       repo,
+		userSvc:    userSvc,
+		sessionSvc: sessionSvc,
+		l:          l,
+		policy:     policy,
+		nowFunc:    time.Now,
+	}
+}
+
+func (s *AccountDeletionService) Request(ctx context.Context, uid uint64, password string) (time.Time, error) {
+	u, err := s.repo.QueryProfile(ctx, uid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// 只用手机号或者第三方登录的账号没有密码，在 web 层验证过验证码才会走到这里
+	if u.Password != "" && s.userSvc.CompareHashAndPassword(ctx, []byte(u.Password), []byte(password)) != nil {
+		return time.Time{}, ErrInvalidUserOrPassword
+	}
+
+	deleteAfter := s.nowFunc().Add(s.policy.CoolingOff)
+	if err = s.repo.ScheduleDeletion(ctx, uid, deleteAfter); err != nil {
+		return time.Time{}, err
+	}
+
+	// 退出所有设备，再次登录就是取消注销
+	if err = s.sessionSvc.RevokeAll(ctx, uid); err != nil {
+		return time.Time{}, err
+	}
+	return deleteAfter, nil
+}
+
+func (s *AccountDeletionService) Cancel(ctx context.Context, uid uint64) (bool, error) {
+	return s.repo.CancelDeletion(ctx, uid)
+}
+
+func (s *AccountDeletionService) ProcessDue(ctx context.Context, limit int) (int, error) {
+	ids, err := s.repo.FindDueDeletions(ctx, s.nowFunc(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, id := range ids {
+		err = s.repo.Anonymize(ctx, id, s.policy.ReassignTo)
+		if errors.Is(err, repository.ErrDeletionNotDue) {
+			continue
+		}
+		if err != nil {
+			return processed, err
+		}
+		processed++
+
+		// 匿名化之前签发的 token 也要失效
+		if err = s.sessionSvc.RevokeAll(ctx, id); err != nil {
+			s.l.Error("注销账号清理会话失败",
+				logger.Field{Key: "user_id", Value: id},
+				logger.Field{Key: "error", Value: err.Error()},
+			)
+		}
+	}
+
+	return processed, nil
+}
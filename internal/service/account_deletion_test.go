@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/pkg/logger"
+)
+
+var testDeletionNow = time.UnixMilli(1694575373863)
+
+func newTestAccountDeletionService(repo repository.UserRepository, sessionRepo repository.ISessionRepository, reassignTo uint64) *AccountDeletionService {
+	l := logger.NewZapLogger(zap.NewNop())
+	userSvc := NewUserServiceForTest(repo, func(hashedPassword []byte, password []byte) error {
+		if string(hashedPassword) != "hash:"+string(password) {
+			return errors.New("密码不匹配")
+		}
+		return nil
+	}, nil)
+
+	svc := NewAccountDeletionService(repo, userSvc, NewSessionService(sessionRepo, l), l, AccountDeletionPolicy{
+		CoolingOff: time.Hour * 24 * 7,
+		ReassignTo: reassignTo,
+	}).(*AccountDeletionService)
+	svc.nowFunc = func() time.Time {
+		return testDeletionNow
+	}
+	return svc
+}
+
+func TestAccountDeletionService_Request(t *testing.T) {
+	deleteAfter := testDeletionNow.Add(time.Hour * 24 * 7)
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository)
+		password string
+		wantTime time.Time
+		wantErr  error
+	}{
+		{
+			name: "申请成功",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Password: "hash:hello#world123"}, nil)
+				repo.EXPECT().ScheduleDeletion(gomock.Any(), uint64(1), deleteAfter).Return(nil)
+				sessionRepo := repomocks.NewMockISessionRepository(ctrl)
+				sessionRepo.EXPECT().FindByUserId(gomock.Any(), uint64(1)).Return([]domain.Session{{Id: "s1", UserId: 1}}, nil)
+				sessionRepo.EXPECT().Delete(gomock.Any(), "s1").Return(nil)
+				return repo, sessionRepo
+			},
+			password: "hello#world123",
+			wantTime: deleteAfter,
+		},
+		{
+			name: "没有设置密码",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Phone: "13800138000"}, nil)
+				repo.EXPECT().ScheduleDeletion(gomock.Any(), uint64(1), deleteAfter).Return(nil)
+				sessionRepo := repomocks.NewMockISessionRepository(ctrl)
+				sessionRepo.EXPECT().FindByUserId(gomock.Any(), uint64(1)).Return(nil, nil)
+				return repo, sessionRepo
+			},
+			wantTime: deleteAfter,
+		},
+		{
+			name: "密码错误",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Password: "hash:hello#world123"}, nil)
+				return repo, repomocks.NewMockISessionRepository(ctrl)
+			},
+			password: "wrong",
+			wantErr:  ErrInvalidUserOrPassword,
+		},
+		{
+			name: "账号已经注销",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().ScheduleDeletion(gomock.Any(), uint64(1), deleteAfter).Return(repository.ErrUserNotFound)
+				return repo, repomocks.NewMockISessionRepository(ctrl)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, sessionRepo := tc.mock(ctrl)
+			svc := newTestAccountDeletionService(repo, sessionRepo, 0)
+
+			got, err := svc.Request(context.Background(), 1, tc.password)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantTime, got)
+		})
+	}
+}
+
+func TestAccountDeletionService_ProcessDue(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mock       func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository)
+		reassignTo uint64
+		wantN      int
+		wantErr    error
+	}{
+		{
+			name: "下架文章",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindDueDeletions(gomock.Any(), testDeletionNow, 10).Return([]uint64{1, 2}, nil)
+				repo.EXPECT().Anonymize(gomock.Any(), uint64(1), uint64(0)).Return(nil)
+				repo.EXPECT().Anonymize(gomock.Any(), uint64(2), uint64(0)).Return(nil)
+				sessionRepo := repomocks.NewMockISessionRepository(ctrl)
+				sessionRepo.EXPECT().FindByUserId(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+				return repo, sessionRepo
+			},
+			wantN: 2,
+		},
+		{
+			name: "文章转给指定账号",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindDueDeletions(gomock.Any(), testDeletionNow, 10).Return([]uint64{1}, nil)
+				repo.EXPECT().Anonymize(gomock.Any(), uint64(1), uint64(99)).Return(nil)
+				sessionRepo := repomocks.NewMockISessionRepository(ctrl)
+				sessionRepo.EXPECT().FindByUserId(gomock.Any(), uint64(1)).Return(nil, nil)
+				return repo, sessionRepo
+			},
+			reassignTo: 99,
+			wantN:      1,
+		},
+		{
+			name: "登录取消了申请",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindDueDeletions(gomock.Any(), testDeletionNow, 10).Return([]uint64{1, 2}, nil)
+				repo.EXPECT().Anonymize(gomock.Any(), uint64(1), uint64(0)).Return(repository.ErrDeletionNotDue)
+				repo.EXPECT().Anonymize(gomock.Any(), uint64(2), uint64(0)).Return(nil)
+				sessionRepo := repomocks.NewMockISessionRepository(ctrl)
+				sessionRepo.EXPECT().FindByUserId(gomock.Any(), uint64(2)).Return(nil, nil)
+				return repo, sessionRepo
+			},
+			wantN: 1,
+		},
+		{
+			name: "匿名化失败",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ISessionRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindDueDeletions(gomock.Any(), testDeletionNow, 10).Return([]uint64{1, 2}, nil)
+				repo.EXPECT().Anonymize(gomock.Any(), uint64(1), uint64(0)).Return(errors.New("db error"))
+				return repo, repomocks.NewMockISessionRepository(ctrl)
+			},
+			wantErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, sessionRepo := tc.mock(ctrl)
+			svc := newTestAccountDeletionService(repo, sessionRepo, tc.reassignTo)
+
+			n, err := svc.ProcessDue(context.Background(), 10)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantN, n)
+		})
+	}
+}
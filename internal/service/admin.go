@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+)
+
+var (
+	ErrAdminNotFound          = repository.ErrAdminNotFound
+	ErrAdminDuplicate         = repository.ErrAdminDuplicate
+	ErrRoleNotFound           = repository.ErrRoleNotFound
+	ErrRoleDuplicate          = repository.ErrRoleDuplicate
+	ErrInvalidAdminOrPassword = errors.New("用户名或密码不正确")
+	ErrAdminDisabled          = errors.New("管理员已停用")
+	ErrDisableSelf            = errors.New("不能停用自己")
+	ErrUnknownPermission      = errors.New("不支持的权限")
+)
+
+// superAdminRole 初始化时创建的角色，拥有所有权限
+const superAdminRole = "超级管理员"
+
+type IAdminService interface {
+	Login(ctx context.Context, username string, password string) (domain.Admin, error)
+	// Authenticate 管理后台每个请求调用，返回带角色和权限的管理员，停用的返回 ErrAdminDisabled
+	Authenticate(ctx context.Context, id uint64) (domain.Admin, error)
+	// Bootstrap 还没有任何管理员时创建一个超级管理员，已经有了就什么都不做，返回这次有没有创建
+	Bootstrap(ctx context.Context, username string, password string) (bool, error)
+	Create(ctx context.Context, username string, password string, roleIds []uint64) (uint64, error)
+	List(ctx context.Context) ([]domain.Admin, error)
+	// Find 带上角色，停用的也能查到
+	Find(ctx context.Context, id uint64) (domain.Admin, error)
+	// SetDisabled 停用以后已经签发的 token 马上失效
+	SetDisabled(ctx context.Context, operatorId uint64, id uint64, disabled bool) error
+	SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	// SaveRole Id 为 0 时新建，权限只能是 domain.Permissions 里的
+	SaveRole(ctx context.Context, role domain.Role) (uint64, error)
+	DeleteRole(ctx context.Context, id uint64) error
+}
+
+type AdminService struct {
+	repo repository.IAdminRepository
+	cost int
+}
+
+func NewAdminService(repo repository.IAdminRepository, policy PasswordPolicy) IAdminService {
+	cost := policy.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &AdminService{
+		repo: repo,
+		cost: cost,
+	}
+}
+
+func (s *AdminService) Login(ctx context.Context, username string, password string) (domain.Admin, error) {
+	a, err := s.repo.FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrAdminNotFound) {
+		return domain.Admin{}, ErrInvalidAdminOrPassword
+	}
+	if err != nil {
+		return domain.Admin{}, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(password)) != nil {
+		return domain.Admin{}, ErrInvalidAdminOrPassword
+	}
+	if a.Disabled {
+		return domain.Admin{}, ErrAdminDisabled
+	}
+	return a, nil
+}
+
+func (s *AdminService) Authenticate(ctx context.Context, id uint64) (domain.Admin, error) {
+	a, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return domain.Admin{}, err
+	}
+	if a.Disabled {
+		return domain.Admin{}, ErrAdminDisabled
+	}
+	return a, nil
+}
+
+func (s *AdminService) Bootstrap(ctx context.Context, username string, password string) (bool, error) {
+	count, err := s.repo.Count(ctx)
+	if err != nil || count > 0 {
+		return false, err
+	}
+
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return false, err
+	}
+	var roleId uint64
+	for _, r := range roles {
+		if r.Name == superAdminRole {
+			roleId = r.Id
+		}
+	}
+	if roleId == 0 {
+		roleId, err = s.repo.SaveRole(ctx, domain.Role{
+			Name:        superAdminRole,
+			Permissions: []string{domain.PermissionAll},
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = s.Create(ctx, username, password, []uint64{roleId})
+	// 多个实例同时启动，别的实例已经创建了
+	if errors.Is(err, ErrAdminDuplicate) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *AdminService) Create(ctx context.Context, username string, password string, roleIds []uint64) (uint64, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return 0, ErrGeneratePassword
+	}
+
+	return s.repo.Create(ctx, domain.Admin{
+		Username: username,
+		Password: string(hash),
+	}, roleIds)
+}
+
+func (s *AdminService) List(ctx context.Context) ([]domain.Admin, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *AdminService) Find(ctx context.Context, id uint64) (domain.Admin, error) {
+	return s.repo.FindById(ctx, id)
+}
+
+func (s *AdminService) SetDisabled(ctx context.Context, operatorId uint64, id uint64, disabled bool) error {
+	if disabled && operatorId == id {
+		return ErrDisableSelf
+	}
+	return s.repo.SetDisabled(ctx, id, disabled)
+}
+
+func (s *AdminService) SetRoles(ctx context.Context, adminId uint64, roleIds []uint64) error {
+	return s.repo.SetRoles(ctx, adminId, roleIds)
+}
+
+func (s *AdminService) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+func (s *AdminService) SaveRole(ctx context.Context, role domain.Role) (uint64, error) {
+	known := make(map[string]struct{}, len(domain.Permissions))
+	for _, p := range domain.Permissions {
+		known[p.Code] = struct{}{}
+	}
+
+	seen := make(map[string]struct{}, len(role.Permissions))
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		if _, ok := known[p]; !ok {
+			return 0, ErrUnknownPermission
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		permissions = append(permissions, p)
+	}
+	role.Permissions = permissions
+
+	return s.repo.SaveRole(ctx, role)
+}
+
+func (s *AdminService) DeleteRole(ctx context.Context, id uint64) error {
+	return s.repo.DeleteRole(ctx, id)
+}
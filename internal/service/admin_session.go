@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yellowbook/internal/repository"
+)
+
+// AdminSessionExpire 后台会话和 token 的有效期
+const AdminSessionExpire = time.Hour * 2
+
+// IAdminSessionService 后台 token 的 jti 对应一个会话，退出登录以后 token 马上失效
+type IAdminSessionService interface {
+	// Create 登录成功后调用，返回会话 id
+	Create(ctx context.Context, adminId uint64) (string, error)
+	// Check 会话不存在或者不属于这个管理员时返回 ErrSessionRevoked
+	Check(ctx context.Context, id string, adminId uint64) error
+	Revoke(ctx context.Context, id string) error
+}
+
+type AdminSessionService struct {
+	repo repository.IAdminSessionRepository
+}
+
+func NewAdminSessionService(repo repository.IAdminSessionRepository) IAdminSessionService {
+	return &AdminSessionService{repo: repo}
+}
+
+func (s *AdminSessionService) Create(ctx context.Context, adminId uint64) (string, error) {
+	id, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+	if err = s.repo.Create(ctx, id, adminId, AdminSessionExpire); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *AdminSessionService) Check(ctx context.Context, id string, adminId uint64) error {
+	owner, err := s.repo.FindAdminId(ctx, id)
+	if errors.Is(err, repository.ErrAdminSessionNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+	if owner != adminId {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *AdminSessionService) Revoke(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
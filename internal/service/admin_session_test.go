@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+)
+
+func TestAdminSessionService_Check(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.IAdminSessionRepository
+		wantErr error
+	}{
+		{
+			name: "会话有效",
+			mock: func(ctrl *gomock.Controller) repository.IAdminSessionRepository {
+				repo := repomocks.NewMockIAdminSessionRepository(ctrl)
+				repo.EXPECT().FindAdminId(gomock.Any(), "sid").Return(uint64(1), nil)
+				return repo
+			},
+		},
+		{
+			name: "已经退出登录",
+			mock: func(ctrl *gomock.Controller) repository.IAdminSessionRepository {
+				repo := repomocks.NewMockIAdminSessionRepository(ctrl)
+				repo.EXPECT().FindAdminId(gomock.Any(), "sid").Return(uint64(0), repository.ErrAdminSessionNotFound)
+				return repo
+			},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "别人的会话",
+			mock: func(ctrl *gomock.Controller) repository.IAdminSessionRepository {
+				repo := repomocks.NewMockIAdminSessionRepository(ctrl)
+				repo.EXPECT().FindAdminId(gomock.Any(), "sid").Return(uint64(2), nil)
+				return repo
+			},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "Redis 出错",
+			mock: func(ctrl *gomock.Controller) repository.IAdminSessionRepository {
+				repo := repomocks.NewMockIAdminSessionRepository(ctrl)
+				repo.EXPECT().FindAdminId(gomock.Any(), "sid").Return(uint64(0), errors.New("redis error"))
+				return repo
+			},
+			wantErr: errors.New("redis error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAdminSessionService(tc.mock(ctrl))
+			err := svc.Check(context.Background(), "sid", 1)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+)
+
+func TestAdminService_Login(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("admin@123456"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.IAdminRepository
+		password string
+		wantId   uint64
+		wantErr  error
+	}{
+		{
+			name: "登录成功",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().FindByUsername(gomock.Any(), "admin").Return(domain.Admin{Id: 1, Password: string(hash)}, nil)
+				return repo
+			},
+			password: "admin@123456",
+			wantId:   1,
+		},
+		{
+			name: "用户名不存在",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().FindByUsername(gomock.Any(), "admin").Return(domain.Admin{}, repository.ErrAdminNotFound)
+				return repo
+			},
+			password: "admin@123456",
+			wantErr:  ErrInvalidAdminOrPassword,
+		},
+		{
+			name: "密码错误",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().FindByUsername(gomock.Any(), "admin").Return(domain.Admin{Id: 1, Password: string(hash)}, nil)
+				return repo
+			},
+			password: "wrong",
+			wantErr:  ErrInvalidAdminOrPassword,
+		},
+		{
+			name: "已停用",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().FindByUsername(gomock.Any(), "admin").
+					Return(domain.Admin{Id: 1, Password: string(hash), Disabled: true}, nil)
+				return repo
+			},
+			password: "admin@123456",
+			wantErr:  ErrAdminDisabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAdminService(tc.mock(ctrl), PasswordPolicy{BcryptCost: bcrypt.MinCost})
+			a, err := svc.Login(context.Background(), "admin", tc.password)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantId, a.Id)
+		})
+	}
+}
+
+func TestAdminService_Bootstrap(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mock        func(ctrl *gomock.Controller) repository.IAdminRepository
+		wantCreated bool
+	}{
+		{
+			name: "已经有管理员",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().Count(gomock.Any()).Return(int64(1), nil)
+				return repo
+			},
+		},
+		{
+			name: "创建超级管理员",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().Count(gomock.Any()).Return(int64(0), nil)
+				repo.EXPECT().ListRoles(gomock.Any()).Return(nil, nil)
+				repo.EXPECT().SaveRole(gomock.Any(), domain.Role{
+					Name:        superAdminRole,
+					Permissions: []string{domain.PermissionAll},
+				}).Return(uint64(3), nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any(), []uint64{3}).
+					DoAndReturn(func(ctx context.Context, a domain.Admin, roleIds []uint64) (uint64, error) {
+						assert.Equal(t, "admin", a.Username)
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(a.Password), []byte("admin@123456")))
+						return 1, nil
+					})
+				return repo
+			},
+			wantCreated: true,
+		},
+		{
+			name: "角色已经存在",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().Count(gomock.Any()).Return(int64(0), nil)
+				repo.EXPECT().ListRoles(gomock.Any()).Return([]domain.Role{{Id: 3, Name: superAdminRole}}, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any(), []uint64{3}).Return(uint64(1), nil)
+				return repo
+			},
+			wantCreated: true,
+		},
+		{
+			name: "别的实例已经创建",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().Count(gomock.Any()).Return(int64(0), nil)
+				repo.EXPECT().ListRoles(gomock.Any()).Return([]domain.Role{{Id: 3, Name: superAdminRole}}, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any(), []uint64{3}).Return(uint64(0), repository.ErrAdminDuplicate)
+				return repo
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAdminService(tc.mock(ctrl), PasswordPolicy{BcryptCost: bcrypt.MinCost})
+			created, err := svc.Bootstrap(context.Background(), "admin", "admin@123456")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantCreated, created)
+		})
+	}
+}
+
+func TestAdminService_SaveRole(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mock        func(ctrl *gomock.Controller) repository.IAdminRepository
+		permissions []string
+		wantErr     error
+	}{
+		{
+			name: "去掉重复的权限",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				repo := repomocks.NewMockIAdminRepository(ctrl)
+				repo.EXPECT().SaveRole(gomock.Any(), domain.Role{
+					Name:        "运营",
+					Permissions: []string{domain.PermissionUserRead, domain.PermissionArticleModerate},
+				}).Return(uint64(1), nil)
+				return repo
+			},
+			permissions: []string{domain.PermissionUserRead, domain.PermissionArticleModerate, domain.PermissionUserRead},
+		},
+		{
+			name: "不支持的权限",
+			mock: func(ctrl *gomock.Controller) repository.IAdminRepository {
+				return repomocks.NewMockIAdminRepository(ctrl)
+			},
+			permissions: []string{domain.PermissionUserRead, "user:delete"},
+			wantErr:     ErrUnknownPermission,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAdminService(tc.mock(ctrl), PasswordPolicy{})
+			_, err := svc.SaveRole(context.Background(), domain.Role{Name: "运营", Permissions: tc.permissions})
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestAdminService_SetDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockIAdminRepository(ctrl)
+	repo.EXPECT().SetDisabled(gomock.Any(), uint64(1), false).Return(nil)
+	svc := NewAdminService(repo, PasswordPolicy{})
+
+	assert.Equal(t, ErrDisableSelf, svc.SetDisabled(context.Background(), 1, 1, true))
+	// 启用自己没有意义，但是不拦
+	assert.NoError(t, svc.SetDisabled(context.Background(), 1, 1, false))
+}
@@ -7,9 +7,14 @@ import (
 	"yellowbook/pkg/logger"
 )
 
+var ErrArticleNotFound = repository.ErrArticleNotFound
+
 type IArticleService interface {
 	Save(ctx context.Context, article domain.Article) (uint64, error)
 	List(ctx context.Context) ([]domain.Article, int64, error)
+	FindById(ctx context.Context, id uint64) (domain.Article, error)
+	// SetStatus 管理后台上下架文章
+	SetStatus(ctx context.Context, id uint64, status uint8) error
 }
 
 type ArticleService struct {
@@ -36,3 +41,11 @@ func (a *ArticleService) Save(ctx context.Context, article domain.Article) (uint
 func (a *ArticleService) List(ctx context.Context) ([]domain.Article, int64, error) {
 	return a.repo.List(ctx)
 }
+
+func (a *ArticleService) FindById(ctx context.Context, id uint64) (domain.Article, error) {
+	return a.repo.FindById(ctx, id)
+}
+
+func (a *ArticleService) SetStatus(ctx context.Context, id uint64, status uint8) error {
+	return a.repo.UpdateStatus(ctx, id, status)
+}
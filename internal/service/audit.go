@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+)
+
+// maxAuditSnapshot 快照存在 text 列里，超过 64KB 存不下
+const maxAuditSnapshot = 60 * 1024
+
+type IAuditService interface {
+	Record(ctx context.Context, l domain.AuditLog) error
+	Search(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, int64, error)
+}
+
+type AuditService struct {
+	repo repository.IAuditRepository
+}
+
+func NewAuditService(repo repository.IAuditRepository) IAuditService {
+	return &AuditService{repo: repo}
+}
+
+func (s *AuditService) Record(ctx context.Context, l domain.AuditLog) error {
+	l.Before = truncateSnapshot(l.Before)
+	l.After = truncateSnapshot(l.After)
+	return s.repo.Create(ctx, l)
+}
+
+func (s *AuditService) Search(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+// truncateSnapshot 太大的快照只记大小，截断一半的 JSON 查出来也没法用
+func truncateSnapshot(snapshot json.RawMessage) json.RawMessage {
+	if len(snapshot) <= maxAuditSnapshot {
+		return snapshot
+	}
+	res, _ := json.Marshal(map[string]any{
+		"truncated": true,
+		"size":      len(snapshot),
+	})
+	return res
+}
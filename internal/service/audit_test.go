@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+)
+
+func TestAuditService_Record(t *testing.T) {
+	large := json.RawMessage(`"` + strings.Repeat("a", maxAuditSnapshot) + `"`)
+
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.IAuditRepository
+		log  domain.AuditLog
+	}{
+		{
+			name: "原样保存",
+			mock: func(ctrl *gomock.Controller) repository.IAuditRepository {
+				repo := repomocks.NewMockIAuditRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), domain.AuditLog{
+					Action: "article.status",
+					Before: json.RawMessage(`{"status":1}`),
+					After:  json.RawMessage(`{"status":2}`),
+				}).Return(nil)
+				return repo
+			},
+			log: domain.AuditLog{
+				Action: "article.status",
+				Before: json.RawMessage(`{"status":1}`),
+				After:  json.RawMessage(`{"status":2}`),
+			},
+		},
+		{
+			name: "快照太大只记大小",
+			mock: func(ctrl *gomock.Controller) repository.IAuditRepository {
+				repo := repomocks.NewMockIAuditRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), domain.AuditLog{
+					Action: "user.list",
+					After:  json.RawMessage(`{"size":61442,"truncated":true}`),
+				}).Return(nil)
+				return repo
+			},
+			log: domain.AuditLog{
+				Action: "user.list",
+				After:  large,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAuditService(tc.mock(ctrl))
+			assert.NoError(t, svc.Record(context.Background(), tc.log))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/imageutil"
+	"yellowbook/internal/repository"
+	"yellowbook/internal/service/oss"
+)
+
+var (
+	ErrAvatarTooLarge  = errors.New("头像文件不能超过 10MB")
+	ErrAvatarFormat    = errors.New("头像只支持 JPEG、PNG、GIF 格式")
+	ErrAvatarDimension = errors.New("图片宽高需要在 128 到 4096 像素之间")
+	ErrAvatarCrop      = errors.New("裁剪范围不合法")
+)
+
+const (
+	avatarMaxBytes = 10 << 20
+	// 先看图片头里的宽高再解码，避免很小的文件解码出超大的图片，4096 解码出来最多 64MB
+	avatarMaxSide = 4096
+	avatarMinSide = 128
+)
+
+// AvatarCrop 正方形裁剪框，坐标以原图左上角为原点
+type AvatarCrop struct {
+	X    int
+	Y    int
+	Size int
+}
+
+type IAvatarService interface {
+	// Upload 裁剪后生成 domain.AvatarSizes 里的各个尺寸，返回的 key 是边长
+	Upload(ctx context.Context, uid uint64, r io.Reader, crop AvatarCrop) (map[int]string, error)
+}
+
+type AvatarService struct {
+	ossSrv oss.IService
+	repo   repository.UserRepository
+}
+
+func NewAvatarService(ossSrv oss.IService, repo repository.UserRepository) IAvatarService {
+	return &AvatarService{
+		ossSrv: ossSrv,
+		repo:   repo,
+	}
+}
+
+func (s *AvatarService) Upload(ctx context.Context, uid uint64, r io.Reader, crop AvatarCrop) (map[int]string, error) {
+	// 多读一个字节，用来判断是否超过限制
+	data, err := io.ReadAll(io.LimitReader(r, avatarMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > avatarMaxBytes {
+		return nil, ErrAvatarTooLarge
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrAvatarFormat
+	}
+	if cfg.Width < avatarMinSide || cfg.Height < avatarMinSide ||
+		cfg.Width > avatarMaxSide || cfg.Height > avatarMaxSide {
+		return nil, ErrAvatarDimension
+	}
+	if crop.Size < avatarMinSide || crop.X < 0 || crop.Y < 0 ||
+		crop.X+crop.Size > cfg.Width || crop.Y+crop.Size > cfg.Height {
+		return nil, ErrAvatarCrop
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrAvatarFormat
+	}
+	origin := img.Bounds().Min
+	square := imageutil.Crop(img, image.Rect(crop.X, crop.Y, crop.X+crop.Size, crop.Y+crop.Size).Add(origin))
+
+	// JPEG 没有透明通道，其它格式统一存成 PNG 保留透明
+	ext, mimetype := ".png", "image/png"
+	if format == "jpeg" {
+		ext, mimetype = ".jpg", "image/jpeg"
+	}
+
+	now := time.Now().UnixMilli()
+	avatars := make(map[int]domain.Resource, len(domain.AvatarSizes))
+	for _, size := range domain.AvatarSizes {
+		var buf bytes.Buffer
+		variant := imageutil.Resize(square, size, size)
+		if format == "jpeg" {
+			err = jpeg.Encode(&buf, variant, &jpeg.Options{Quality: 90})
+		} else {
+			err = png.Encode(&buf, variant)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 中途失败的话已经上传的文件不会被引用，oss 这边没有删除接口
+		filename := fmt.Sprintf("avatar_%d_%d_%d%s", uid, now, size, ext)
+		url, err := s.ossSrv.UploadReader(ctx, filename, &buf)
+		if err != nil {
+			return nil, err
+		}
+		avatars[size] = domain.Resource{
+			Url:      url,
+			Purpose:  proto.ResourcePurpose_UserAvatar,
+			Mimetype: mimetype,
+		}
+	}
+
+	if err = s.repo.UpdateAvatar(ctx, uid, avatars); err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]string, len(avatars))
+	for size, avatar := range avatars {
+		res[size] = avatar.Url
+	}
+	return res, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/internal/service/oss"
+	ossmocks "yellowbook/internal/service/oss/mocks"
+)
+
+func TestAvatarService_Upload(t *testing.T) {
+	encode := func(t *testing.T, w int, h int, format string) []byte {
+		var buf bytes.Buffer
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		var err error
+		if format == "png" {
+			err = png.Encode(&buf, img)
+		} else {
+			err = jpeg.Encode(&buf, img, nil)
+		}
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+
+	// uploaded 检查每个尺寸上传的确实是对应边长的图片
+	uploaded := func(t *testing.T, ossSrv *ossmocks.MockIService, format string) {
+		ossSrv.EXPECT().UploadReader(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(len(domain.AvatarSizes)).
+			DoAndReturn(func(ctx context.Context, filename string, r io.Reader) (string, error) {
+				cfg, f, err := image.DecodeConfig(r)
+				require.NoError(t, err)
+				assert.Equal(t, format, f)
+				assert.Equal(t, cfg.Width, cfg.Height)
+				assert.Contains(t, filename, fmt.Sprintf("_%d.", cfg.Width))
+				return fmt.Sprintf("https://oss.com/%d", cfg.Width), nil
+			})
+	}
+
+	testCases := []struct {
+		name    string
+		mock    func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository)
+		data    func(t *testing.T) []byte
+		crop    AvatarCrop
+		want    map[int]string
+		wantErr error
+	}{
+		{
+			name: "上传成功",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				ossSrv := ossmocks.NewMockIService(ctrl)
+				uploaded(t, ossSrv, "jpeg")
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateAvatar(gomock.Any(), uint64(1), map[int]domain.Resource{
+					64:   {Url: "https://oss.com/64", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/jpeg"},
+					256:  {Url: "https://oss.com/256", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/jpeg"},
+					1024: {Url: "https://oss.com/1024", Purpose: proto.ResourcePurpose_UserAvatar, Mimetype: "image/jpeg"},
+				}).Return(nil)
+				return ossSrv, repo
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 300, 200, "jpeg")
+			},
+			crop: AvatarCrop{X: 100, Y: 0, Size: 200},
+			want: map[int]string{
+				64:   "https://oss.com/64",
+				256:  "https://oss.com/256",
+				1024: "https://oss.com/1024",
+			},
+		},
+		{
+			name: "PNG 保持 PNG",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				ossSrv := ossmocks.NewMockIService(ctrl)
+				uploaded(t, ossSrv, "png")
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateAvatar(gomock.Any(), uint64(1), gomock.Any()).Return(nil)
+				return ossSrv, repo
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 128, 128, "png")
+			},
+			crop: AvatarCrop{Size: 128},
+			want: map[int]string{
+				64:   "https://oss.com/64",
+				256:  "https://oss.com/256",
+				1024: "https://oss.com/1024",
+			},
+		},
+		{
+			name: "不是图片",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				return nil, nil
+			},
+			data: func(t *testing.T) []byte {
+				return []byte("<html></html>")
+			},
+			crop:    AvatarCrop{Size: 128},
+			wantErr: ErrAvatarFormat,
+		},
+		{
+			name: "图片太小",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				return nil, nil
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 100, 300, "png")
+			},
+			crop:    AvatarCrop{Size: 100},
+			wantErr: ErrAvatarDimension,
+		},
+		{
+			name: "图片边长超过限制",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				return nil, nil
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, avatarMaxSide+1, 128, "png")
+			},
+			crop:    AvatarCrop{Size: 128},
+			wantErr: ErrAvatarDimension,
+		},
+		{
+			name: "文件太大",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				return nil, nil
+			},
+			data: func(t *testing.T) []byte {
+				return make([]byte, avatarMaxBytes+1)
+			},
+			wantErr: ErrAvatarTooLarge,
+		},
+		{
+			name: "裁剪超出图片",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				return nil, nil
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 300, 200, "png")
+			},
+			crop:    AvatarCrop{X: 150, Y: 0, Size: 200},
+			wantErr: ErrAvatarCrop,
+		},
+		{
+			name: "裁剪太小",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				return nil, nil
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 300, 200, "png")
+			},
+			crop:    AvatarCrop{Size: 64},
+			wantErr: ErrAvatarCrop,
+		},
+		{
+			name: "上传失败不修改资料",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				ossSrv := ossmocks.NewMockIService(ctrl)
+				ossSrv.EXPECT().UploadReader(gomock.Any(), gomock.Any(), gomock.Any()).Return("", oss.ErrUploadFailed)
+				return ossSrv, repomocks.NewMockUserRepository(ctrl)
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 128, 128, "png")
+			},
+			crop:    AvatarCrop{Size: 128},
+			wantErr: oss.ErrUploadFailed,
+		},
+		{
+			name: "保存失败",
+			mock: func(t *testing.T, ctrl *gomock.Controller) (oss.IService, repository.UserRepository) {
+				ossSrv := ossmocks.NewMockIService(ctrl)
+				uploaded(t, ossSrv, "png")
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateAvatar(gomock.Any(), uint64(1), gomock.Any()).Return(errors.New("模拟错误"))
+				return ossSrv, repo
+			},
+			data: func(t *testing.T) []byte {
+				return encode(t, 128, 128, "png")
+			},
+			crop:    AvatarCrop{Size: 128},
+			wantErr: errors.New("模拟错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ossSrv, repo := tc.mock(t, ctrl)
+			svc := NewAvatarService(ossSrv, repo)
+
+			got, err := svc.Upload(context.Background(), 1, bytes.NewReader(tc.data(t)), tc.crop)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"yellowbook/internal/service/email"
+)
+
+type Message struct {
+	Subject string
+	Body    string
+	To      []string
+}
+
+// Service 不真正发送，本地开发和测试时可以从 Messages 里取验证码
+type Service struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+var _ email.Service = (*Service)(nil)
+
+func (s *Service) Send(ctx context.Context, subject string, body string, to ...string) error {
+	fmt.Println(subject, body, to)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, Message{
+		Subject: subject,
+		Body:    body,
+		To:      to,
+	})
+	return nil
+}
+
+func (s *Service) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]Message, len(s.messages))
+	copy(res, s.messages)
+	return res
+}
@@ -0,0 +1,19 @@
+package memory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_Send(t *testing.T) {
+	srv := NewService()
+
+	err := srv.Send(context.Background(), "验证码", "1234", "a@qq.com")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Message{
+		{Subject: "验证码", Body: "1234", To: []string{"a@qq.com"}},
+	}, srv.Messages())
+}
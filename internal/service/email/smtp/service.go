@@ -0,0 +1,78 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+	"yellowbook/internal/service/email"
+)
+
+var ErrInvalidAddress = errors.New("邮件地址不合法")
+
+type Service struct {
+	addr string
+	from string
+	auth smtp.Auth
+	// sendMail 测试时替换掉，不用真的连 SMTP 服务器
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	nowFunc  func() time.Time
+}
+
+// NewService username 为空时不认证，比如内网的中继服务器
+func NewService(addr string, username string, password string, from string) email.Service {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Service{
+		addr:     addr,
+		from:     from,
+		auth:     auth,
+		sendMail: smtp.SendMail,
+		nowFunc:  time.Now,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, subject string, body string, to ...string) error {
+	if len(to) == 0 {
+		return ErrInvalidAddress
+	}
+	// 防止地址里带换行注入额外的邮件头
+	for _, addr := range append([]string{s.from}, to...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return ErrInvalidAddress
+		}
+	}
+
+	return s.sendMail(s.addr, s.auth, s.from, to, s.message(subject, body, to))
+}
+
+func (s *Service) message(subject string, body string, to []string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", s.nowFunc().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	// 每行 76 个字符
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,75 @@
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_Send(t *testing.T) {
+	testCases := []struct {
+		name    string
+		to      []string
+		sendErr error
+		wantErr error
+	}{
+		{
+			name: "正常发送",
+			to:   []string{"a@qq.com"},
+		},
+		{
+			name:    "SMTP 出错",
+			to:      []string{"a@qq.com"},
+			sendErr: errors.New("连接被拒绝"),
+			wantErr: errors.New("连接被拒绝"),
+		},
+		{
+			name:    "没有收件人",
+			wantErr: ErrInvalidAddress,
+		},
+		{
+			name:    "收件人带换行",
+			to:      []string{"a@qq.com\r\nBcc: b@qq.com"},
+			wantErr: ErrInvalidAddress,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotMsg []byte
+			svc := NewService("smtp.example.com:587", "user", "pass", "noreply@example.com").(*Service)
+			svc.nowFunc = func() time.Time {
+				return time.Unix(1694575373, 0).UTC()
+			}
+			svc.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				assert.Equal(t, "smtp.example.com:587", addr)
+				assert.NotNil(t, a)
+				assert.Equal(t, "noreply@example.com", from)
+				assert.Equal(t, tc.to, to)
+				gotMsg = msg
+				return tc.sendErr
+			}
+
+			err := svc.Send(context.Background(), "黄皮书验证码", "你的验证码是 1234", tc.to...)
+			assert.Equal(t, tc.wantErr, err)
+			if tc.wantErr != nil {
+				return
+			}
+
+			header, body, ok := strings.Cut(string(gotMsg), "\r\n\r\n")
+			require.True(t, ok)
+			assert.Contains(t, header, "To: a@qq.com\r\n")
+			assert.Contains(t, header, "Subject: =?UTF-8?b?")
+			assert.Contains(t, header, "Date: Wed, 13 Sep 2023 03:22:53 +0000\r\n")
+			decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+			require.NoError(t, err)
+			assert.Equal(t, "你的验证码是 1234", string(decoded))
+		})
+	}
+}
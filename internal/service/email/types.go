@@ -0,0 +1,8 @@
+package email
+
+import "context"
+
+// Service 发送纯文本邮件，模板由调用方渲染好
+type Service interface {
+	Send(ctx context.Context, subject string, body string, to ...string) error
+}
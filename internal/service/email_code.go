@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"yellowbook/internal/repository"
+	"yellowbook/internal/service/email"
+)
+
+// EmailCodeService 和 CodeService 一样，验证码改成通过邮件发送，target 是邮箱
+type EmailCodeService interface {
+	CodeService
+}
+
+type emailCodeService struct {
+	codeService
+	emailSvc email.Service
+}
+
+func NewEmailCodeService(repo repository.CodeRepository, emailSvc email.Service) EmailCodeService {
+	return &emailCodeService{
+		codeService: codeService{repo: repo},
+		emailSvc:    emailSvc,
+	}
+}
+
+func (svc *emailCodeService) Send(ctx context.Context, biz string, target string) error {
+	code := svc.GenerateCode()
+	err := svc.repo.Store(ctx, biz, target, code)
+	if err != nil {
+		return err
+	}
+
+	return svc.emailSvc.Send(ctx, "黄皮书验证码",
+		fmt.Sprintf("你的验证码是 %s，10 分钟内有效。如果不是你本人操作，请忽略这封邮件。", code), target)
+}
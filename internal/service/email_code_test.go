@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/internal/service/email/memory"
+)
+
+func TestEmailCodeService_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored string
+	repo := repomocks.NewMockCodeRepository(ctrl)
+	repo.EXPECT().Store(gomock.Any(), "verify_email", "a@qq.com", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, biz string, target string, code string) error {
+			stored = code
+			return nil
+		})
+	emailSvc := memory.NewService()
+
+	svc := NewEmailCodeService(repo, emailSvc)
+	err := svc.Send(context.Background(), "verify_email", "a@qq.com")
+	require.NoError(t, err)
+
+	messages := emailSvc.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"a@qq.com"}, messages[0].To)
+	assert.Contains(t, messages[0].Body, stored)
+}
+
+func TestEmailCodeService_SendTooMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockCodeRepository(ctrl)
+	repo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(ErrCodeSendTooMany)
+	emailSvc := memory.NewService()
+
+	svc := NewEmailCodeService(repo, emailSvc)
+	err := svc.Send(context.Background(), "verify_email", "a@qq.com")
+	assert.True(t, errors.Is(err, ErrCodeSendTooMany))
+	assert.Empty(t, emailSvc.Messages())
+}
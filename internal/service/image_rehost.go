@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/imagefetcher"
+	"yellowbook/internal/repository"
+	"yellowbook/internal/service/oss"
+	"yellowbook/pkg/logger"
+)
+
+type IImageRehostService interface {
+	Enqueue(ctx context.Context, articleId uint64, urls []string) error
+	ProcessDue(ctx context.Context, limit int) (int, error)
+}
+
+type ImageFetcher interface {
+	Fetch(ctx context.Context, url string) (imagefetcher.Image, error)
+}
+
+type ImageRehostService struct {
+	repo         repository.IImageRehostRepository
+	articleRepo  repository.IArticleRepository
+	resourceRepo repository.IResourceRepository
+	ossSrv       oss.IService
+	fetcher      ImageFetcher
+	l            logger.Logger
+
+	maxRetry     int
+	backoff      time.Duration
+	leaseTimeout time.Duration
+	nowFunc      func() time.Time
+}
+
+func NewImageRehostService(
+	repo repository.IImageRehostRepository,
+	articleRepo repository.IArticleRepository,
+	resourceRepo repository.IResourceRepository,
+	ossSrv oss.IService,
+	fetcher ImageFetcher,
+	l logger.Logger,
+) IImageRehostService {
+	return &ImageRehostService{
+		repo:         repo,
+		articleRepo:  articleRepo,
+		resourceRepo: resourceRepo,
+		ossSrv:       ossSrv,
+		fetcher:      fetcher,
+		l:            l,
+		maxRetry:     5,
+		backoff:      time.Second * 30,
+		leaseTimeout: time.Minute * 2,
+		nowFunc:      time.Now,
+	}
+}
+
+func (s *ImageRehostService) Enqueue(ctx context.Context, articleId uint64, urls []string) error {
+	now := s.nowFunc()
+	seen := make(map[string]struct{}, len(urls))
+	tasks := make([]domain.ImageRehostTask, 0, len(urls))
+
+	for _, u := range urls {
+		if _, ok := seen[u]; ok || u == "" {
+			continue
+		}
+		seen[u] = struct{}{}
+
+		tasks = append(tasks, domain.ImageRehostTask{
+			ArticleId:     articleId,
+			SourceUrl:     u,
+			Status:        domain.ImageRehostPending,
+			NextRetryTime: now,
+		})
+	}
+
+	return s.repo.CreateBatch(ctx, tasks)
+}
+
+// ProcessDue 处理一批到期的任务，返回实际处理的数量
+func (s *ImageRehostService) ProcessDue(ctx context.Context, limit int) (int, error) {
+	now := s.nowFunc()
+
+	tasks, err := s.repo.FindDue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, task := range tasks {
+		// 先续租，保证别的实例不会同时处理这个任务
+		ok, err := s.repo.Claim(ctx, task, now.Add(s.leaseTimeout))
+		if err != nil {
+			return processed, err
+		}
+		if !ok {
+			continue
+		}
+
+		s.process(ctx, task)
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (s *ImageRehostService) process(ctx context.Context, task domain.ImageRehostTask) {
+	url, err := s.rehost(ctx, task)
+	if err == nil {
+		task.Status = domain.ImageRehostSucceeded
+		task.TargetUrl = url
+		task.LastError = ""
+	} else {
+		task.Retry++
+		task.LastError = err.Error()
+		permanent := imagefetcher.IsPermanent(err) || errors.Is(err, repository.ErrArticleNotFound)
+		if permanent || task.Retry >= s.maxRetry {
+			task.Status = domain.ImageRehostFailed
+			s.l.Warn("图片转存失败，不再重试",
+				logger.Field{Key: "article_id", Value: task.ArticleId},
+				logger.Field{Key: "url", Value: task.SourceUrl},
+				logger.Field{Key: "error", Value: task.LastError},
+			)
+		} else {
+			// 指数退避
+			task.NextRetryTime = s.nowFunc().Add(s.backoff << (task.Retry - 1))
+		}
+	}
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		s.l.Error("图片转存任务更新失败",
+			logger.Field{Key: "task_id", Value: task.Id},
+			logger.Field{Key: "error", Value: err.Error()},
+		)
+		return
+	}
+
+	if task.Status != domain.ImageRehostPending {
+		s.rewriteIfDone(ctx, task.ArticleId)
+	}
+}
+
+func (s *ImageRehostService) rehost(ctx context.Context, task domain.ImageRehostTask) (string, error) {
+	art, err := s.articleRepo.FindById(ctx, task.ArticleId)
+	if err != nil {
+		return "", err
+	}
+
+	img, err := s.fetcher.Fetch(ctx, task.SourceUrl)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := s.ossSrv.UploadReader(ctx, img.Filename, img.Reader())
+	if err != nil {
+		return "", err
+	}
+
+	err = s.resourceRepo.Create(ctx, domain.Resource{
+		Url:      url,
+		Purpose:  proto.ResourcePurpose_UserContent,
+		Mimetype: img.Mimetype,
+	}, art.Author.Id)
+	if err != nil && !errors.Is(err, repository.ErrResourceDuplicate) {
+		s.l.Warn("OSS 上传成功，系统记录失败", logger.Field{
+			Key:   "url",
+			Value: url,
+		})
+	}
+
+	return url, nil
+}
+
+// rewriteIfDone 文章下所有图片都有结果后，一次性替换 ImageList，失败的图片保留原地址
+func (s *ImageRehostService) rewriteIfDone(ctx context.Context, articleId uint64) {
+	tasks, err := s.repo.FindByArticleId(ctx, articleId)
+	if err != nil {
+		s.l.Error("查询图片转存任务失败", logger.Field{Key: "article_id", Value: articleId})
+		return
+	}
+
+	replaced := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		if t.Status == domain.ImageRehostPending {
+			return
+		}
+		if t.Status == domain.ImageRehostSucceeded {
+			replaced[t.SourceUrl] = t.TargetUrl
+		}
+	}
+	if len(replaced) == 0 {
+		return
+	}
+
+	art, err := s.articleRepo.FindById(ctx, articleId)
+	if err != nil {
+		s.l.Error("查询文章失败", logger.Field{Key: "article_id", Value: articleId})
+		return
+	}
+
+	// 以文章当前的图片列表为准，期间作者可能编辑过
+	imageList := make([]string, 0, len(art.ImageList))
+	for _, u := range art.ImageList {
+		if target, ok := replaced[u]; ok {
+			u = target
+		}
+		imageList = append(imageList, u)
+	}
+
+	if err := s.articleRepo.UpdateImageList(ctx, articleId, imageList); err != nil {
+		s.l.Error("替换文章图片失败", logger.Field{Key: "article_id", Value: articleId})
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/imagefetcher"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/internal/service/oss"
+	ossmocks "yellowbook/internal/service/oss/mocks"
+	"yellowbook/pkg/logger"
+)
+
+type fakeFetcher map[string]error
+
+func (f fakeFetcher) Fetch(ctx context.Context, url string) (imagefetcher.Image, error) {
+	if err := f[url]; err != nil {
+		return imagefetcher.Image{}, err
+	}
+	return imagefetcher.Image{Data: []byte("img"), Mimetype: "image/png", Filename: "a.png"}, nil
+}
+
+func TestImageRehostService_ProcessDue(t *testing.T) {
+	now := time.UnixMilli(1694575373863).UTC()
+	article := domain.Article{
+		Id:        1,
+		ImageList: []string{"http://cdn/a.png", "http://cdn/b.png"},
+		Author:    domain.Author{Id: 9},
+	}
+
+	testCases := []struct {
+		name    string
+		fetcher fakeFetcher
+		mock    func(ctrl *gomock.Controller) (repository.IImageRehostRepository, repository.IArticleRepository, repository.IResourceRepository, oss.IService)
+		wantN   int
+	}{
+		{
+			name:    "转存成功并替换图片",
+			fetcher: fakeFetcher{},
+			mock: func(ctrl *gomock.Controller) (repository.IImageRehostRepository, repository.IArticleRepository, repository.IResourceRepository, oss.IService) {
+				repo := repomocks.NewMockIImageRehostRepository(ctrl)
+				articleRepo := repomocks.NewMockIArticleRepository(ctrl)
+				resourceRepo := repomocks.NewMockIResourceRepository(ctrl)
+				ossSrv := ossmocks.NewMockIService(ctrl)
+
+				task := domain.ImageRehostTask{Id: 1, ArticleId: 1, SourceUrl: "http://cdn/a.png", NextRetryTime: now}
+				repo.EXPECT().FindDue(gomock.Any(), now, 10).Return([]domain.ImageRehostTask{task}, nil)
+				repo.EXPECT().Claim(gomock.Any(), task, now.Add(time.Minute*2)).Return(true, nil)
+				articleRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(article, nil).Times(2)
+				ossSrv.EXPECT().UploadReader(gomock.Any(), "a.png", gomock.Any()).Return("http://oss/a.png", nil)
+				resourceRepo.EXPECT().Create(gomock.Any(), gomock.Any(), uint64(9)).Return(nil)
+
+				done := task
+				done.Status = domain.ImageRehostSucceeded
+				done.TargetUrl = "http://oss/a.png"
+				repo.EXPECT().Update(gomock.Any(), done).Return(nil)
+				repo.EXPECT().FindByArticleId(gomock.Any(), uint64(1)).Return([]domain.ImageRehostTask{
+					done,
+					{Id: 2, ArticleId: 1, SourceUrl: "http://cdn/b.png", Status: domain.ImageRehostFailed},
+				}, nil)
+				articleRepo.EXPECT().UpdateImageList(gomock.Any(), uint64(1), []string{"http://oss/a.png", "http://cdn/b.png"}).Return(nil)
+
+				return repo, articleRepo, resourceRepo, ossSrv
+			},
+			wantN: 1,
+		},
+		{
+			name:    "临时错误，退避重试",
+			fetcher: fakeFetcher{"http://cdn/a.png": errors.New("timeout")},
+			mock: func(ctrl *gomock.Controller) (repository.IImageRehostRepository, repository.IArticleRepository, repository.IResourceRepository, oss.IService) {
+				repo := repomocks.NewMockIImageRehostRepository(ctrl)
+				articleRepo := repomocks.NewMockIArticleRepository(ctrl)
+
+				task := domain.ImageRehostTask{Id: 1, ArticleId: 1, SourceUrl: "http://cdn/a.png", Retry: 1, NextRetryTime: now}
+				repo.EXPECT().FindDue(gomock.Any(), now, 10).Return([]domain.ImageRehostTask{task}, nil)
+				repo.EXPECT().Claim(gomock.Any(), task, gomock.Any()).Return(true, nil)
+				articleRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(article, nil)
+
+				retry := task
+				retry.Retry = 2
+				retry.LastError = "timeout"
+				retry.NextRetryTime = now.Add(time.Minute)
+				repo.EXPECT().Update(gomock.Any(), retry).Return(nil)
+
+				return repo, articleRepo, nil, nil
+			},
+			wantN: 1,
+		},
+		{
+			name:    "永久错误，记录失败",
+			fetcher: fakeFetcher{"http://cdn/a.png": imagefetcher.ErrUnsupportedType},
+			mock: func(ctrl *gomock.Controller) (repository.IImageRehostRepository, repository.IArticleRepository, repository.IResourceRepository, oss.IService) {
+				repo := repomocks.NewMockIImageRehostRepository(ctrl)
+				articleRepo := repomocks.NewMockIArticleRepository(ctrl)
+
+				task := domain.ImageRehostTask{Id: 1, ArticleId: 1, SourceUrl: "http://cdn/a.png", NextRetryTime: now}
+				repo.EXPECT().FindDue(gomock.Any(), now, 10).Return([]domain.ImageRehostTask{task}, nil)
+				repo.EXPECT().Claim(gomock.Any(), task, gomock.Any()).Return(true, nil)
+				articleRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(article, nil)
+
+				failed := task
+				failed.Retry = 1
+				failed.Status = domain.ImageRehostFailed
+				failed.LastError = imagefetcher.ErrUnsupportedType.Error()
+				repo.EXPECT().Update(gomock.Any(), failed).Return(nil)
+				// 还有任务没完成，不替换
+				repo.EXPECT().FindByArticleId(gomock.Any(), uint64(1)).Return([]domain.ImageRehostTask{
+					failed,
+					{Id: 2, ArticleId: 1, SourceUrl: "http://cdn/b.png", Status: domain.ImageRehostPending},
+				}, nil)
+
+				return repo, articleRepo, nil, nil
+			},
+			wantN: 1,
+		},
+		{
+			name:    "被其他实例抢走",
+			fetcher: fakeFetcher{},
+			mock: func(ctrl *gomock.Controller) (repository.IImageRehostRepository, repository.IArticleRepository, repository.IResourceRepository, oss.IService) {
+				repo := repomocks.NewMockIImageRehostRepository(ctrl)
+
+				task := domain.ImageRehostTask{Id: 1, ArticleId: 1, SourceUrl: "http://cdn/a.png", NextRetryTime: now}
+				repo.EXPECT().FindDue(gomock.Any(), now, 10).Return([]domain.ImageRehostTask{task}, nil)
+				repo.EXPECT().Claim(gomock.Any(), task, gomock.Any()).Return(false, nil)
+
+				return repo, nil, nil, nil
+			},
+			wantN: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, articleRepo, resourceRepo, ossSrv := tc.mock(ctrl)
+			svc := NewImageRehostService(repo, articleRepo, resourceRepo, ossSrv, tc.fetcher, logger.NewZapLogger(zap.NewNop())).(*ImageRehostService)
+			svc.nowFunc = func() time.Time {
+				return now
+			}
+
+			n, err := svc.ProcessDue(context.Background(), 10)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantN, n)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	"yellowbook/pkg/logger"
+)
+
+// LoginLimitedError 登录尝试被拦下，Locked 为 false 时只是需要等 RetryAfter 再试
+type LoginLimitedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginLimitedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("登录失败次数过多，已锁定，%s 后解锁", e.RetryAfter)
+	}
+	return fmt.Sprintf("登录太频繁，%s 后再试", e.RetryAfter)
+}
+
+type ILoginLimitService interface {
+	// Check 登录前调用，kind 是 LockoutByAccount 或者 LockoutByAdmin，被限制时返回 *LoginLimitedError
+	Check(ctx context.Context, kind string, account string, ip string) error
+	Fail(ctx context.Context, kind string, account string, ip string) error
+	// Succeed 登录成功清掉账号的失败记录，IP 的不清，避免用一个自己的账号给 IP 洗白
+	Succeed(ctx context.Context, kind string, account string) error
+	// Unlock 用户通过短信验证，或者管理员手动解锁
+	Unlock(ctx context.Context, kind string, value string) error
+	Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error)
+}
+
+type LoginLimitService struct {
+	repo  repository.ILoginLimitRepository
+	l     logger.Logger
+	limit repository.LoginLimit
+	// 同一个 IP 后面可能有很多用户，阈值比账号高
+	accountThreshold int
+	ipThreshold      int
+	nowFunc          func() time.Time
+}
+
+func NewLoginLimitService(repo repository.ILoginLimitRepository, l logger.Logger) ILoginLimitService {
+	return &LoginLimitService{
+		repo: repo,
+		l:    l,
+		limit: repository.LoginLimit{
+			Window:       time.Minute * 15,
+			FreeAttempts: 3,
+			BaseDelay:    time.Second,
+			MaxDelay:     time.Minute,
+			LockDuration: time.Minute * 30,
+		},
+		accountThreshold: 10,
+		ipThreshold:      50,
+		nowFunc:          time.Now,
+	}
+}
+
+func (s *LoginLimitService) Check(ctx context.Context, kind string, account string, ip string) error {
+	wait, locked, err := s.repo.Check(ctx, s.targets(kind, account, ip), s.limit, s.nowFunc())
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &LoginLimitedError{Locked: locked, RetryAfter: wait}
+	}
+	return nil
+}
+
+func (s *LoginLimitService) Fail(ctx context.Context, kind string, account string, ip string) error {
+	now := s.nowFunc()
+	targets := s.targets(kind, account, ip)
+	failures, locked, err := s.repo.RecordFailure(ctx, targets, s.limit, now)
+	if err != nil {
+		return err
+	}
+
+	for i, t := range targets {
+		if !locked[i] {
+			continue
+		}
+
+		lockout := domain.LoginLockout{
+			Kind:        t.Kind,
+			Value:       t.Value,
+			Failures:    failures[i],
+			Ip:          ip,
+			LockedUntil: now.Add(s.limit.LockDuration),
+			CreateTime:  now,
+		}
+		s.l.Warn("登录失败次数过多，已锁定",
+			logger.Field{Key: "kind", Value: lockout.Kind},
+			logger.Field{Key: "value", Value: lockout.Value},
+			logger.Field{Key: "failures", Value: lockout.Failures},
+			logger.Field{Key: "ip", Value: ip},
+			logger.Field{Key: "locked_until", Value: lockout.LockedUntil})
+		if err = s.repo.AddLockout(ctx, lockout); err != nil {
+			s.l.Error("保存锁定记录失败", logger.Field{Key: "error", Value: err})
+		}
+	}
+	return nil
+}
+
+func (s *LoginLimitService) Succeed(ctx context.Context, kind string, account string) error {
+	return s.repo.Clear(ctx, kind, normalizeAccount(account))
+}
+
+func (s *LoginLimitService) Unlock(ctx context.Context, kind string, value string) error {
+	if kind != domain.LockoutByIp {
+		value = normalizeAccount(value)
+	}
+	s.l.Info("解除登录锁定", logger.Field{Key: "kind", Value: kind}, logger.Field{Key: "value", Value: value})
+	return s.repo.Clear(ctx, kind, value)
+}
+
+func (s *LoginLimitService) Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error) {
+	return s.repo.Lockouts(ctx, limit)
+}
+
+func (s *LoginLimitService) targets(kind string, account string, ip string) []repository.LoginTarget {
+	return []repository.LoginTarget{
+		{Kind: kind, Value: normalizeAccount(account), LockThreshold: s.accountThreshold},
+		{Kind: domain.LockoutByIp, Value: ip, LockThreshold: s.ipThreshold},
+	}
+}
+
+// normalizeAccount 邮箱大小写不同也算同一个账号
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/pkg/logger"
+)
+
+func TestLoginLimitService_Check(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.ILoginLimitRepository
+		wantErr error
+	}{
+		{
+			name: "没有限制",
+			mock: func(ctrl *gomock.Controller) repository.ILoginLimitRepository {
+				repo := repomocks.NewMockILoginLimitRepository(ctrl)
+				repo.EXPECT().Check(gomock.Any(), []repository.LoginTarget{
+					{Kind: domain.LockoutByAccount, Value: "a@qq.com", LockThreshold: 10},
+					{Kind: domain.LockoutByIp, Value: "1.2.3.4", LockThreshold: 50},
+				}, gomock.Any(), gomock.Any()).Return(time.Duration(0), false, nil)
+				return repo
+			},
+		},
+		{
+			name: "需要等待",
+			mock: func(ctrl *gomock.Controller) repository.ILoginLimitRepository {
+				repo := repomocks.NewMockILoginLimitRepository(ctrl)
+				repo.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(time.Second*2, false, nil)
+				return repo
+			},
+			wantErr: &LoginLimitedError{RetryAfter: time.Second * 2},
+		},
+		{
+			name: "已锁定",
+			mock: func(ctrl *gomock.Controller) repository.ILoginLimitRepository {
+				repo := repomocks.NewMockILoginLimitRepository(ctrl)
+				repo.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(time.Minute*20, true, nil)
+				return repo
+			},
+			wantErr: &LoginLimitedError{Locked: true, RetryAfter: time.Minute * 20},
+		},
+		{
+			name: "Redis 出错",
+			mock: func(ctrl *gomock.Controller) repository.ILoginLimitRepository {
+				repo := repomocks.NewMockILoginLimitRepository(ctrl)
+				repo.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(time.Duration(0), false, errors.New("模拟错误"))
+				return repo
+			},
+			wantErr: errors.New("模拟错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewLoginLimitService(tc.mock(ctrl), logger.NewZapLogger(zap.NewNop()))
+			// 邮箱不区分大小写
+			err := svc.Check(context.Background(), domain.LockoutByAccount, " A@qq.com", "1.2.3.4")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestLoginLimitService_Fail(t *testing.T) {
+	now := time.UnixMilli(1694575373000)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockILoginLimitRepository(ctrl)
+	repo.EXPECT().RecordFailure(gomock.Any(), gomock.Any(), gomock.Any(), now).
+		Return([]int{10, 12}, []bool{true, false}, nil)
+	// 只有触发锁定的维度才留记录
+	repo.EXPECT().AddLockout(gomock.Any(), domain.LoginLockout{
+		Kind:        domain.LockoutByAccount,
+		Value:       "a@qq.com",
+		Failures:    10,
+		Ip:          "1.2.3.4",
+		LockedUntil: now.Add(time.Minute * 30),
+		CreateTime:  now,
+	}).Return(nil)
+
+	svc := NewLoginLimitService(repo, logger.NewZapLogger(zap.NewNop())).(*LoginLimitService)
+	svc.nowFunc = func() time.Time {
+		return now
+	}
+
+	assert.NoError(t, svc.Fail(context.Background(), domain.LockoutByAccount, "a@qq.com", "1.2.3.4"))
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/account_deletion.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIAccountDeletionService is a mock of IAccountDeletionService interface.
+type MockIAccountDeletionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAccountDeletionServiceMockRecorder
+}
+
+// MockIAccountDeletionServiceMockRecorder is the mock recorder for MockIAccountDeletionService.
+type MockIAccountDeletionServiceMockRecorder struct {
+	mock *MockIAccountDeletionService
+}
+
+// NewMockIAccountDeletionService creates a new mock instance.
+func NewMockIAccountDeletionService(ctrl *gomock.Controller) *MockIAccountDeletionService {
+	mock := &MockIAccountDeletionService{ctrl: ctrl}
+	mock.recorder = &MockIAccountDeletionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAccountDeletionService) EXPECT() *MockIAccountDeletionServiceMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockIAccountDeletionService) Cancel(ctx context.Context, uid uint64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, uid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockIAccountDeletionServiceMockRecorder) Cancel(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockIAccountDeletionService)(nil).Cancel), ctx, uid)
+}
+
+// ProcessDue mocks base method.
+func (m *MockIAccountDeletionService) ProcessDue(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessDue", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessDue indicates an expected call of ProcessDue.
+func (mr *MockIAccountDeletionServiceMockRecorder) ProcessDue(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessDue", reflect.TypeOf((*MockIAccountDeletionService)(nil).ProcessDue), ctx, limit)
+}
+
+// Request mocks base method.
+func (m *MockIAccountDeletionService) Request(ctx context.Context, uid uint64, password string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request", ctx, uid, password)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockIAccountDeletionServiceMockRecorder) Request(ctx, uid, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockIAccountDeletionService)(nil).Request), ctx, uid, password)
+}
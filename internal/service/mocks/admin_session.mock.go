@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/admin_session.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIAdminSessionService is a mock of IAdminSessionService interface.
+type MockIAdminSessionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAdminSessionServiceMockRecorder
+}
+
+// MockIAdminSessionServiceMockRecorder is the mock recorder for MockIAdminSessionService.
+type MockIAdminSessionServiceMockRecorder struct {
+	mock *MockIAdminSessionService
+}
+
+// NewMockIAdminSessionService creates a new mock instance.
+func NewMockIAdminSessionService(ctrl *gomock.Controller) *MockIAdminSessionService {
+	mock := &MockIAdminSessionService{ctrl: ctrl}
+	mock.recorder = &MockIAdminSessionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAdminSessionService) EXPECT() *MockIAdminSessionServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockIAdminSessionService) Check(ctx context.Context, id string, adminId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, id, adminId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockIAdminSessionServiceMockRecorder) Check(ctx, id, adminId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockIAdminSessionService)(nil).Check), ctx, id, adminId)
+}
+
+// Create mocks base method.
+func (m *MockIAdminSessionService) Create(ctx context.Context, adminId uint64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, adminId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIAdminSessionServiceMockRecorder) Create(ctx, adminId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIAdminSessionService)(nil).Create), ctx, adminId)
+}
+
+// Revoke mocks base method.
+func (m *MockIAdminSessionService) Revoke(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockIAdminSessionServiceMockRecorder) Revoke(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockIAdminSessionService)(nil).Revoke), ctx, id)
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/article.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIArticleService is a mock of IArticleService interface.
+type MockIArticleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIArticleServiceMockRecorder
+}
+
+// MockIArticleServiceMockRecorder is the mock recorder for MockIArticleService.
+type MockIArticleServiceMockRecorder struct {
+	mock *MockIArticleService
+}
+
+// NewMockIArticleService creates a new mock instance.
+func NewMockIArticleService(ctrl *gomock.Controller) *MockIArticleService {
+	mock := &MockIArticleService{ctrl: ctrl}
+	mock.recorder = &MockIArticleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIArticleService) EXPECT() *MockIArticleServiceMockRecorder {
+	return m.recorder
+}
+
+// FindById mocks base method.
+func (m *MockIArticleService) FindById(ctx context.Context, id uint64) (domain.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindById", ctx, id)
+	ret0, _ := ret[0].(domain.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindById indicates an expected call of FindById.
+func (mr *MockIArticleServiceMockRecorder) FindById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockIArticleService)(nil).FindById), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockIArticleService) List(ctx context.Context) ([]domain.Article, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.Article)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockIArticleServiceMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIArticleService)(nil).List), ctx)
+}
+
+// Save mocks base method.
+func (m *MockIArticleService) Save(ctx context.Context, article domain.Article) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, article)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockIArticleServiceMockRecorder) Save(ctx, article interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockIArticleService)(nil).Save), ctx, article)
+}
+
+// SetStatus mocks base method.
+func (m *MockIArticleService) SetStatus(ctx context.Context, id uint64, status uint8) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockIArticleServiceMockRecorder) SetStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockIArticleService)(nil).SetStatus), ctx, id, status)
+}
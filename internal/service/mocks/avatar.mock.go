@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/avatar.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	service "yellowbook/internal/service"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIAvatarService is a mock of IAvatarService interface.
+type MockIAvatarService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAvatarServiceMockRecorder
+}
+
+// MockIAvatarServiceMockRecorder is the mock recorder for MockIAvatarService.
+type MockIAvatarServiceMockRecorder struct {
+	mock *MockIAvatarService
+}
+
+// NewMockIAvatarService creates a new mock instance.
+func NewMockIAvatarService(ctrl *gomock.Controller) *MockIAvatarService {
+	mock := &MockIAvatarService{ctrl: ctrl}
+	mock.recorder = &MockIAvatarServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAvatarService) EXPECT() *MockIAvatarServiceMockRecorder {
+	return m.recorder
+}
+
+// Upload mocks base method.
+func (m *MockIAvatarService) Upload(ctx context.Context, uid uint64, r io.Reader, crop service.AvatarCrop) (map[int]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, uid, r, crop)
+	ret0, _ := ret[0].(map[int]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockIAvatarServiceMockRecorder) Upload(ctx, uid, r, crop interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockIAvatarService)(nil).Upload), ctx, uid, r, crop)
+}
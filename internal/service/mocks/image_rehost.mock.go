@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/image_rehost.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	imagefetcher "yellowbook/internal/pkg/imagefetcher"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIImageRehostService is a mock of IImageRehostService interface.
+type MockIImageRehostService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIImageRehostServiceMockRecorder
+}
+
+// MockIImageRehostServiceMockRecorder is the mock recorder for MockIImageRehostService.
+type MockIImageRehostServiceMockRecorder struct {
+	mock *MockIImageRehostService
+}
+
+// NewMockIImageRehostService creates a new mock instance.
+func NewMockIImageRehostService(ctrl *gomock.Controller) *MockIImageRehostService {
+	mock := &MockIImageRehostService{ctrl: ctrl}
+	mock.recorder = &MockIImageRehostServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIImageRehostService) EXPECT() *MockIImageRehostServiceMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockIImageRehostService) Enqueue(ctx context.Context, articleId uint64, urls []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, articleId, urls)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockIImageRehostServiceMockRecorder) Enqueue(ctx, articleId, urls interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockIImageRehostService)(nil).Enqueue), ctx, articleId, urls)
+}
+
+// ProcessDue mocks base method.
+func (m *MockIImageRehostService) ProcessDue(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessDue", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessDue indicates an expected call of ProcessDue.
+func (mr *MockIImageRehostServiceMockRecorder) ProcessDue(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessDue", reflect.TypeOf((*MockIImageRehostService)(nil).ProcessDue), ctx, limit)
+}
+
+// MockImageFetcher is a mock of ImageFetcher interface.
+type MockImageFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageFetcherMockRecorder
+}
+
+// MockImageFetcherMockRecorder is the mock recorder for MockImageFetcher.
+type MockImageFetcherMockRecorder struct {
+	mock *MockImageFetcher
+}
+
+// NewMockImageFetcher creates a new mock instance.
+func NewMockImageFetcher(ctrl *gomock.Controller) *MockImageFetcher {
+	mock := &MockImageFetcher{ctrl: ctrl}
+	mock.recorder = &MockImageFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImageFetcher) EXPECT() *MockImageFetcherMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method.
+func (m *MockImageFetcher) Fetch(ctx context.Context, url string) (imagefetcher.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fetch", ctx, url)
+	ret0, _ := ret[0].(imagefetcher.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch.
+func (mr *MockImageFetcherMockRecorder) Fetch(ctx, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockImageFetcher)(nil).Fetch), ctx, url)
+}
@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/login_limit.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILoginLimitService is a mock of ILoginLimitService interface.
+type MockILoginLimitService struct {
+	ctrl     *gomock.Controller
+	recorder *MockILoginLimitServiceMockRecorder
+}
+
+// MockILoginLimitServiceMockRecorder is the mock recorder for MockILoginLimitService.
+type MockILoginLimitServiceMockRecorder struct {
+	mock *MockILoginLimitService
+}
+
+// NewMockILoginLimitService creates a new mock instance.
+func NewMockILoginLimitService(ctrl *gomock.Controller) *MockILoginLimitService {
+	mock := &MockILoginLimitService{ctrl: ctrl}
+	mock.recorder = &MockILoginLimitServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILoginLimitService) EXPECT() *MockILoginLimitServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockILoginLimitService) Check(ctx context.Context, kind, account, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, kind, account, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockILoginLimitServiceMockRecorder) Check(ctx, kind, account, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockILoginLimitService)(nil).Check), ctx, kind, account, ip)
+}
+
+// Fail mocks base method.
+func (m *MockILoginLimitService) Fail(ctx context.Context, kind, account, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, kind, account, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockILoginLimitServiceMockRecorder) Fail(ctx, kind, account, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockILoginLimitService)(nil).Fail), ctx, kind, account, ip)
+}
+
+// Lockouts mocks base method.
+func (m *MockILoginLimitService) Lockouts(ctx context.Context, limit int) ([]domain.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lockouts", ctx, limit)
+	ret0, _ := ret[0].([]domain.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lockouts indicates an expected call of Lockouts.
+func (mr *MockILoginLimitServiceMockRecorder) Lockouts(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lockouts", reflect.TypeOf((*MockILoginLimitService)(nil).Lockouts), ctx, limit)
+}
+
+// Succeed mocks base method.
+func (m *MockILoginLimitService) Succeed(ctx context.Context, kind, account string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Succeed", ctx, kind, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Succeed indicates an expected call of Succeed.
+func (mr *MockILoginLimitServiceMockRecorder) Succeed(ctx, kind, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeed", reflect.TypeOf((*MockILoginLimitService)(nil).Succeed), ctx, kind, account)
+}
+
+// Unlock mocks base method.
+func (m *MockILoginLimitService) Unlock(ctx context.Context, kind, value string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, kind, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockILoginLimitServiceMockRecorder) Unlock(ctx, kind, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockILoginLimitService)(nil).Unlock), ctx, kind, value)
+}
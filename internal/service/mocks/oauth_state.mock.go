@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/oauth_state.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIOAuthStateService is a mock of IOAuthStateService interface.
+type MockIOAuthStateService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOAuthStateServiceMockRecorder
+}
+
+// MockIOAuthStateServiceMockRecorder is the mock recorder for MockIOAuthStateService.
+type MockIOAuthStateServiceMockRecorder struct {
+	mock *MockIOAuthStateService
+}
+
+// NewMockIOAuthStateService creates a new mock instance.
+func NewMockIOAuthStateService(ctrl *gomock.Controller) *MockIOAuthStateService {
+	mock := &MockIOAuthStateService{ctrl: ctrl}
+	mock.recorder = &MockIOAuthStateServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOAuthStateService) EXPECT() *MockIOAuthStateServiceMockRecorder {
+	return m.recorder
+}
+
+// Generate mocks base method.
+func (m *MockIOAuthStateService) Generate(ctx context.Context, uid uint64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Generate", ctx, uid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Generate indicates an expected call of Generate.
+func (mr *MockIOAuthStateServiceMockRecorder) Generate(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockIOAuthStateService)(nil).Generate), ctx, uid)
+}
+
+// Peek mocks base method.
+func (m *MockIOAuthStateService) Peek(ctx context.Context, state string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Peek", ctx, state)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Peek indicates an expected call of Peek.
+func (mr *MockIOAuthStateServiceMockRecorder) Peek(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Peek", reflect.TypeOf((*MockIOAuthStateService)(nil).Peek), ctx, state)
+}
+
+// Verify mocks base method.
+func (m *MockIOAuthStateService) Verify(ctx context.Context, state string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, state)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockIOAuthStateServiceMockRecorder) Verify(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockIOAuthStateService)(nil).Verify), ctx, state)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/profile.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIProfileService is a mock of IProfileService interface.
+type MockIProfileService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIProfileServiceMockRecorder
+}
+
+// MockIProfileServiceMockRecorder is the mock recorder for MockIProfileService.
+type MockIProfileServiceMockRecorder struct {
+	mock *MockIProfileService
+}
+
+// NewMockIProfileService creates a new mock instance.
+func NewMockIProfileService(ctrl *gomock.Controller) *MockIProfileService {
+	mock := &MockIProfileService{ctrl: ctrl}
+	mock.recorder = &MockIProfileServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIProfileService) EXPECT() *MockIProfileServiceMockRecorder {
+	return m.recorder
+}
+
+// Public mocks base method.
+func (m *MockIProfileService) Public(ctx context.Context, uid uint64) (domain.PublicProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Public", ctx, uid)
+	ret0, _ := ret[0].(domain.PublicProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Public indicates an expected call of Public.
+func (mr *MockIProfileServiceMockRecorder) Public(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Public", reflect.TypeOf((*MockIProfileService)(nil).Public), ctx, uid)
+}
+
+// PublicByHandle mocks base method.
+func (m *MockIProfileService) PublicByHandle(ctx context.Context, handle string) (domain.PublicProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublicByHandle", ctx, handle)
+	ret0, _ := ret[0].(domain.PublicProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublicByHandle indicates an expected call of PublicByHandle.
+func (mr *MockIProfileServiceMockRecorder) PublicByHandle(ctx, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublicByHandle", reflect.TypeOf((*MockIProfileService)(nil).PublicByHandle), ctx, handle)
+}
+
+// SetHandle mocks base method.
+func (m *MockIProfileService) SetHandle(ctx context.Context, uid uint64, handle string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHandle", ctx, uid, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHandle indicates an expected call of SetHandle.
+func (mr *MockIProfileServiceMockRecorder) SetHandle(ctx, uid, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHandle", reflect.TypeOf((*MockIProfileService)(nil).SetHandle), ctx, uid, handle)
+}
+
+// UpdatePrivacy mocks base method.
+func (m *MockIProfileService) UpdatePrivacy(ctx context.Context, uid uint64, privacy domain.Privacy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePrivacy", ctx, uid, privacy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePrivacy indicates an expected call of UpdatePrivacy.
+func (mr *MockIProfileServiceMockRecorder) UpdatePrivacy(ctx, uid, privacy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePrivacy", reflect.TypeOf((*MockIProfileService)(nil).UpdatePrivacy), ctx, uid, privacy)
+}
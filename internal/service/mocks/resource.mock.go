@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/resource.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	multipart "mime/multipart"
+	reflect "reflect"
+
+	proto "github.com/shenxiang11/yellowbook-proto/proto"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIResourceService is a mock of IResourceService interface.
+type MockIResourceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIResourceServiceMockRecorder
+}
+
+// MockIResourceServiceMockRecorder is the mock recorder for MockIResourceService.
+type MockIResourceServiceMockRecorder struct {
+	mock *MockIResourceService
+}
+
+// NewMockIResourceService creates a new mock instance.
+func NewMockIResourceService(ctrl *gomock.Controller) *MockIResourceService {
+	mock := &MockIResourceService{ctrl: ctrl}
+	mock.recorder = &MockIResourceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIResourceService) EXPECT() *MockIResourceServiceMockRecorder {
+	return m.recorder
+}
+
+// GetResourceCategoryList mocks base method.
+func (m *MockIResourceService) GetResourceCategoryList() any {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResourceCategoryList")
+	ret0, _ := ret[0].(any)
+	return ret0
+}
+
+// GetResourceCategoryList indicates an expected call of GetResourceCategoryList.
+func (mr *MockIResourceServiceMockRecorder) GetResourceCategoryList() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResourceCategoryList", reflect.TypeOf((*MockIResourceService)(nil).GetResourceCategoryList))
+}
+
+// Import mocks base method.
+func (m *MockIResourceService) Import(ctx context.Context, sourceUrl string, purpose proto.ResourcePurpose, uid uint64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, sourceUrl, purpose, uid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockIResourceServiceMockRecorder) Import(ctx, sourceUrl, purpose, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockIResourceService)(nil).Import), ctx, sourceUrl, purpose, uid)
+}
+
+// Upload mocks base method.
+func (m *MockIResourceService) Upload(ctx context.Context, f *multipart.FileHeader, purpose proto.ResourcePurpose, uid uint64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, f, purpose, uid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockIResourceServiceMockRecorder) Upload(ctx, f, purpose, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockIResourceService)(nil).Upload), ctx, f, purpose, uid)
+}
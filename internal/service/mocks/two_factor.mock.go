@@ -0,0 +1,154 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/two_factor.go
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "yellowbook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockITwoFactorService is a mock of ITwoFactorService interface.
+type MockITwoFactorService struct {
+	ctrl     *gomock.Controller
+	recorder *MockITwoFactorServiceMockRecorder
+}
+
+// MockITwoFactorServiceMockRecorder is the mock recorder for MockITwoFactorService.
+type MockITwoFactorServiceMockRecorder struct {
+	mock *MockITwoFactorService
+}
+
+// NewMockITwoFactorService creates a new mock instance.
+func NewMockITwoFactorService(ctrl *gomock.Controller) *MockITwoFactorService {
+	mock := &MockITwoFactorService{ctrl: ctrl}
+	mock.recorder = &MockITwoFactorServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITwoFactorService) EXPECT() *MockITwoFactorServiceMockRecorder {
+	return m.recorder
+}
+
+// Challenge mocks base method.
+func (m *MockITwoFactorService) Challenge(ctx context.Context, uid uint64, loginMethod string) (domain.TwoFactorChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Challenge", ctx, uid, loginMethod)
+	ret0, _ := ret[0].(domain.TwoFactorChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Challenge indicates an expected call of Challenge.
+func (mr *MockITwoFactorServiceMockRecorder) Challenge(ctx, uid, loginMethod interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Challenge", reflect.TypeOf((*MockITwoFactorService)(nil).Challenge), ctx, uid, loginMethod)
+}
+
+// Confirm mocks base method.
+func (m *MockITwoFactorService) Confirm(ctx context.Context, uid uint64, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", ctx, uid, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Confirm indicates an expected call of Confirm.
+func (mr *MockITwoFactorServiceMockRecorder) Confirm(ctx, uid, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockITwoFactorService)(nil).Confirm), ctx, uid, code)
+}
+
+// Disable mocks base method.
+func (m *MockITwoFactorService) Disable(ctx context.Context, uid uint64, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disable", ctx, uid, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disable indicates an expected call of Disable.
+func (mr *MockITwoFactorServiceMockRecorder) Disable(ctx, uid, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockITwoFactorService)(nil).Disable), ctx, uid, code)
+}
+
+// Enabled mocks base method.
+func (m *MockITwoFactorService) Enabled(ctx context.Context, uid uint64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enabled", ctx, uid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enabled indicates an expected call of Enabled.
+func (mr *MockITwoFactorServiceMockRecorder) Enabled(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enabled", reflect.TypeOf((*MockITwoFactorService)(nil).Enabled), ctx, uid)
+}
+
+// Enroll mocks base method.
+func (m *MockITwoFactorService) Enroll(ctx context.Context, uid uint64) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enroll", ctx, uid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Enroll indicates an expected call of Enroll.
+func (mr *MockITwoFactorServiceMockRecorder) Enroll(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockITwoFactorService)(nil).Enroll), ctx, uid)
+}
+
+// Reset mocks base method.
+func (m *MockITwoFactorService) Reset(ctx context.Context, uid uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockITwoFactorServiceMockRecorder) Reset(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockITwoFactorService)(nil).Reset), ctx, uid)
+}
+
+// Verify mocks base method.
+func (m *MockITwoFactorService) Verify(ctx context.Context, uid uint64, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, uid, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockITwoFactorServiceMockRecorder) Verify(ctx, uid, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockITwoFactorService)(nil).Verify), ctx, uid, code)
+}
+
+// VerifyChallenge mocks base method.
+func (m *MockITwoFactorService) VerifyChallenge(ctx context.Context, id, code string) (domain.TwoFactorChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyChallenge", ctx, id, code)
+	ret0, _ := ret[0].(domain.TwoFactorChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyChallenge indicates an expected call of VerifyChallenge.
+func (mr *MockITwoFactorServiceMockRecorder) VerifyChallenge(ctx, id, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyChallenge", reflect.TypeOf((*MockITwoFactorService)(nil).VerifyChallenge), ctx, id, code)
+}
@@ -7,9 +7,9 @@ package svcmocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	domain "yellowbook/internal/domain"
 
-	proto "github.com/shenxiang11/yellowbook-proto/proto"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -36,6 +36,90 @@ func (m *MockIUserService) EXPECT() *MockIUserServiceMockRecorder {
 	return m.recorder
 }
 
+// Ban mocks base method.
+func (m *MockIUserService) Ban(ctx context.Context, uid uint64, until time.Time, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ban", ctx, uid, until, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ban indicates an expected call of Ban.
+func (mr *MockIUserServiceMockRecorder) Ban(ctx, uid, until, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ban", reflect.TypeOf((*MockIUserService)(nil).Ban), ctx, uid, until, reason)
+}
+
+// BindEmail mocks base method.
+func (m *MockIUserService) BindEmail(ctx context.Context, uid uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindEmail", ctx, uid, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindEmail indicates an expected call of BindEmail.
+func (mr *MockIUserServiceMockRecorder) BindEmail(ctx, uid, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindEmail", reflect.TypeOf((*MockIUserService)(nil).BindEmail), ctx, uid, email)
+}
+
+// BindIdentity mocks base method.
+func (m *MockIUserService) BindIdentity(ctx context.Context, uid uint64, identity domain.ExternalIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindIdentity", ctx, uid, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindIdentity indicates an expected call of BindIdentity.
+func (mr *MockIUserServiceMockRecorder) BindIdentity(ctx, uid, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindIdentity", reflect.TypeOf((*MockIUserService)(nil).BindIdentity), ctx, uid, identity)
+}
+
+// BindPhone mocks base method.
+func (m *MockIUserService) BindPhone(ctx context.Context, uid uint64, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindPhone", ctx, uid, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindPhone indicates an expected call of BindPhone.
+func (mr *MockIUserServiceMockRecorder) BindPhone(ctx, uid, phone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindPhone", reflect.TypeOf((*MockIUserService)(nil).BindPhone), ctx, uid, phone)
+}
+
+// ChangePassword mocks base method.
+func (m *MockIUserService) ChangePassword(ctx context.Context, uid uint64, oldPassword, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, uid, oldPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockIUserServiceMockRecorder) ChangePassword(ctx, uid, oldPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockIUserService)(nil).ChangePassword), ctx, uid, oldPassword, newPassword)
+}
+
+// CheckBan mocks base method.
+func (m *MockIUserService) CheckBan(ctx context.Context, uid uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBan", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckBan indicates an expected call of CheckBan.
+func (mr *MockIUserServiceMockRecorder) CheckBan(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBan", reflect.TypeOf((*MockIUserService)(nil).CheckBan), ctx, uid)
+}
+
 // CompareHashAndPassword mocks base method.
 func (m *MockIUserService) CompareHashAndPassword(ctx context.Context, hashedPassword, password []byte) error {
 	m.ctrl.T.Helper()
@@ -64,19 +148,49 @@ func (mr *MockIUserServiceMockRecorder) EditProfile(ctx, u interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditProfile", reflect.TypeOf((*MockIUserService)(nil).EditProfile), ctx, u)
 }
 
-// FindOrCreateByGithubId mocks base method.
-func (m *MockIUserService) FindOrCreateByGithubId(ctx context.Context, githubId uint64) (domain.User, error) {
+// FindByEmail mocks base method.
+func (m *MockIUserService) FindByEmail(ctx context.Context, email string) (domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindOrCreateByGithubId", ctx, githubId)
+	ret := m.ctrl.Call(m, "FindByEmail", ctx, email)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindOrCreateByGithubId indicates an expected call of FindOrCreateByGithubId.
-func (mr *MockIUserServiceMockRecorder) FindOrCreateByGithubId(ctx, githubId interface{}) *gomock.Call {
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockIUserServiceMockRecorder) FindByEmail(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrCreateByGithubId", reflect.TypeOf((*MockIUserService)(nil).FindOrCreateByGithubId), ctx, githubId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockIUserService)(nil).FindByEmail), ctx, email)
+}
+
+// FindByPhone mocks base method.
+func (m *MockIUserService) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByPhone", ctx, phone)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByPhone indicates an expected call of FindByPhone.
+func (mr *MockIUserServiceMockRecorder) FindByPhone(ctx, phone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockIUserService)(nil).FindByPhone), ctx, phone)
+}
+
+// FindOrCreateByIdentity mocks base method.
+func (m *MockIUserService) FindOrCreateByIdentity(ctx context.Context, identity domain.ExternalIdentity) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOrCreateByIdentity", ctx, identity)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOrCreateByIdentity indicates an expected call of FindOrCreateByIdentity.
+func (mr *MockIUserServiceMockRecorder) FindOrCreateByIdentity(ctx, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrCreateByIdentity", reflect.TypeOf((*MockIUserService)(nil).FindOrCreateByIdentity), ctx, identity)
 }
 
 // FindOrCreateByPhone mocks base method.
@@ -124,6 +238,35 @@ func (mr *MockIUserServiceMockRecorder) Login(ctx, email, password interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockIUserService)(nil).Login), ctx, email, password)
 }
 
+// LoginByPhone mocks base method.
+func (m *MockIUserService) LoginByPhone(ctx context.Context, phone, password string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginByPhone", ctx, phone, password)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginByPhone indicates an expected call of LoginByPhone.
+func (mr *MockIUserServiceMockRecorder) LoginByPhone(ctx, phone, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginByPhone", reflect.TypeOf((*MockIUserService)(nil).LoginByPhone), ctx, phone, password)
+}
+
+// Merge mocks base method.
+func (m *MockIUserService) Merge(ctx context.Context, fromId, toId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, fromId, toId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockIUserServiceMockRecorder) Merge(ctx, fromId, toId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockIUserService)(nil).Merge), ctx, fromId, toId)
+}
+
 // QueryProfile mocks base method.
 func (m *MockIUserService) QueryProfile(ctx context.Context, userId uint64) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -140,7 +283,7 @@ func (mr *MockIUserServiceMockRecorder) QueryProfile(ctx, userId interface{}) *g
 }
 
 // QueryUsers mocks base method.
-func (m *MockIUserService) QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]domain.User, int64, error) {
+func (m *MockIUserService) QueryUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "QueryUsers", ctx, filter)
 	ret0, _ := ret[0].([]domain.User)
@@ -155,6 +298,50 @@ func (mr *MockIUserServiceMockRecorder) QueryUsers(ctx, filter interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryUsers", reflect.TypeOf((*MockIUserService)(nil).QueryUsers), ctx, filter)
 }
 
+// ResetPassword mocks base method.
+func (m *MockIUserService) ResetPassword(ctx context.Context, phone, password string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, phone, password)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockIUserServiceMockRecorder) ResetPassword(ctx, phone, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockIUserService)(nil).ResetPassword), ctx, phone, password)
+}
+
+// ResetPasswordByEmail mocks base method.
+func (m *MockIUserService) ResetPasswordByEmail(ctx context.Context, email, password string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPasswordByEmail", ctx, email, password)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPasswordByEmail indicates an expected call of ResetPasswordByEmail.
+func (mr *MockIUserServiceMockRecorder) ResetPasswordByEmail(ctx, email, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordByEmail", reflect.TypeOf((*MockIUserService)(nil).ResetPasswordByEmail), ctx, email, password)
+}
+
+// SetAvatar mocks base method.
+func (m *MockIUserService) SetAvatar(ctx context.Context, uid uint64, url string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvatar", ctx, uid, url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAvatar indicates an expected call of SetAvatar.
+func (mr *MockIUserServiceMockRecorder) SetAvatar(ctx, uid, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvatar", reflect.TypeOf((*MockIUserService)(nil).SetAvatar), ctx, uid, url)
+}
+
 // SignUp mocks base method.
 func (m *MockIUserService) SignUp(ctx context.Context, u domain.User) error {
 	m.ctrl.T.Helper()
@@ -168,3 +355,45 @@ func (mr *MockIUserServiceMockRecorder) SignUp(ctx, u interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockIUserService)(nil).SignUp), ctx, u)
 }
+
+// Unban mocks base method.
+func (m *MockIUserService) Unban(ctx context.Context, uid uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unban", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unban indicates an expected call of Unban.
+func (mr *MockIUserServiceMockRecorder) Unban(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unban", reflect.TypeOf((*MockIUserService)(nil).Unban), ctx, uid)
+}
+
+// Unbind mocks base method.
+func (m *MockIUserService) Unbind(ctx context.Context, uid uint64, kind string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unbind", ctx, uid, kind)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unbind indicates an expected call of Unbind.
+func (mr *MockIUserServiceMockRecorder) Unbind(ctx, uid, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unbind", reflect.TypeOf((*MockIUserService)(nil).Unbind), ctx, uid, kind)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockIUserService) VerifyEmail(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockIUserServiceMockRecorder) VerifyEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockIUserService)(nil).VerifyEmail), ctx, email)
+}
@@ -0,0 +1,84 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"yellowbook/internal/domain"
+)
+
+const ProviderGithub = "github"
+
+type GithubProvider struct {
+	clientId     string
+	clientSecret string
+	redirectURL  string
+	// 测试时指向本地的假服务
+	authURL  string
+	tokenURL string
+	userURL  string
+	client   *http.Client
+}
+
+func NewGithubProvider(clientId string, clientSecret string, redirectURL string) Provider {
+	return &GithubProvider{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		userURL:      "https://api.github.com/user",
+		client:       httpClient,
+	}
+}
+
+func (p *GithubProvider) Name() string {
+	return ProviderGithub
+}
+
+func (p *GithubProvider) AuthURL(ctx context.Context, state string) (string, error) {
+	return buildURL(p.authURL, url.Values{
+		"client_id":    {p.clientId},
+		"redirect_uri": {p.redirectURL},
+		"scope":        {"read:user"},
+		"state":        {state},
+	}), nil
+}
+
+func (p *GithubProvider) Exchange(ctx context.Context, code string) (domain.ExternalIdentity, error) {
+	token, err := exchangeToken(ctx, p.client, p.tokenURL, url.Values{
+		"client_id":     {p.clientId},
+		"client_secret": {p.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+	})
+	if err != nil {
+		return domain.ExternalIdentity{}, err
+	}
+
+	var info struct {
+		Id        uint64 `json:"id"`
+		Login     string `json:"login"`
+		AvatarUrl string `json:"avatar_url"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+	}
+	err = getJSON(ctx, p.client, p.userURL, token, &info)
+	if err != nil {
+		return domain.ExternalIdentity{}, err
+	}
+	if info.Id == 0 {
+		return domain.ExternalIdentity{}, fmt.Errorf("github 用户信息缺少 id")
+	}
+
+	return domain.ExternalIdentity{
+		Provider:  ProviderGithub,
+		Subject:   strconv.FormatUint(info.Id, 10),
+		Login:     info.Login,
+		Name:      info.Name,
+		AvatarUrl: info.AvatarUrl,
+		Email:     info.Email,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package oauth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"yellowbook/internal/domain"
+)
+
+func newTestGithubProvider(server *fakeServer) *GithubProvider {
+	p := NewGithubProvider("id", "secret", "http://localhost/users/oauth/github/callback").(*GithubProvider)
+	p.authURL = server.URL + "/authorize"
+	p.tokenURL = server.URL + "/token"
+	p.userURL = server.URL + "/userinfo"
+	p.client = server.Client()
+	return p
+}
+
+func TestGithubProvider_AuthURL(t *testing.T) {
+	p := NewGithubProvider("id", "secret", "http://localhost/callback")
+
+	got, err := p.AuthURL(context.Background(), "s.1.sig")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/login/oauth/authorize?client_id=id&redirect_uri=http%3A%2F%2Flocalhost%2Fcallback&scope=read%3Auser&state=s.1.sig", got)
+}
+
+func TestGithubProvider_Exchange(t *testing.T) {
+	testCases := []struct {
+		name         string
+		code         string
+		userinfo     map[string]any
+		wantIdentity domain.ExternalIdentity
+		wantErr      string
+	}{
+		{
+			name: "成功",
+			code: "good",
+			userinfo: map[string]any{
+				"id":         1,
+				"login":      "octocat",
+				"name":       "The Octocat",
+				"avatar_url": "https://avatars/1",
+				"email":      "o@github.com",
+			},
+			wantIdentity: domain.ExternalIdentity{
+				Provider:  "github",
+				Subject:   "1",
+				Login:     "octocat",
+				Name:      "The Octocat",
+				AvatarUrl: "https://avatars/1",
+				Email:     "o@github.com",
+			},
+		},
+		{
+			name:    "code 不对，GitHub 依然返回 200",
+			code:    "bad",
+			wantErr: "oauth: invalid_grant: code 已过期",
+		},
+		{
+			name:     "用户信息缺少 id",
+			code:     "good",
+			userinfo: map[string]any{"login": "octocat"},
+			wantErr:  "github 用户信息缺少 id",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeServer(t, tc.userinfo)
+			server.githubStyle = true
+			p := newTestGithubProvider(server)
+
+			identity, err := p.Exchange(context.Background(), tc.code)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantIdentity, identity)
+		})
+	}
+}
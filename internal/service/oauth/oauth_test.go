@@ -0,0 +1,73 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeServer 本地的授权服务器，code 为 good 时发 token，其它返回 invalid_grant
+type fakeServer struct {
+	*httptest.Server
+	// githubStyle 出错时和 GitHub 一样返回 200
+	githubStyle bool
+	userinfo    map[string]any
+	// discoveryDown 为 true 时 discovery 文档返回 503，discoveryHits 记录它被请求的次数
+	discoveryDown bool
+	discoveryHits int
+}
+
+func newFakeServer(t *testing.T, userinfo map[string]any) *fakeServer {
+	s := &fakeServer{userinfo: userinfo}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		s.discoveryHits++
+		if s.discoveryDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                 s.URL,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"userinfo_endpoint":      s.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("client_secret") != "secret" {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_client"})
+			return
+		}
+		if r.FormValue("code") != "good" {
+			status := http.StatusBadRequest
+			if s.githubStyle {
+				status = http.StatusOK
+			}
+			writeJSON(w, status, map[string]any{
+				"error":             "invalid_grant",
+				"error_description": "code 已过期",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"access_token": "token", "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"message": "Bad credentials"})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.userinfo)
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,191 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"yellowbook/internal/domain"
+)
+
+// discoveryRetryInterval discovery 失败后这段时间内直接返回上次的错误，不再请求 issuer
+const discoveryRetryInterval = 30 * time.Second
+
+type OIDCConfig struct {
+	// Name 区分不同的 OIDC 服务，不能和内置的 github 重名
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes 为空时用 openid profile email
+	Scopes []string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// OIDCProvider 通用的 OpenID Connect 登录，端点从 issuer 的 discovery 文档里读
+// 用户信息从 userinfo 接口取，access token 是服务端直接从 token 接口拿的，所以不再校验 id_token
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	// fetching 不为空说明已经有请求在拉 discovery，其它请求等它关闭
+	fetching chan struct{}
+	lastErr  error
+	retryAt  time.Time
+	nowFunc  func() time.Time
+}
+
+func NewOIDCProvider(cfg OIDCConfig) Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+
+	return &OIDCProvider{
+		cfg:     cfg,
+		client:  httpClient,
+		nowFunc: time.Now,
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthURL(ctx context.Context, state string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return buildURL(d.AuthorizationEndpoint, url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.cfg.ClientId},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"scope":         {strings.Join(p.cfg.Scopes, " ")},
+		"state":         {state},
+	}), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string) (domain.ExternalIdentity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return domain.ExternalIdentity{}, err
+	}
+
+	token, err := exchangeToken(ctx, p.client, d.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {p.cfg.ClientId},
+		"client_secret": {p.cfg.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+	})
+	if err != nil {
+		return domain.ExternalIdentity{}, err
+	}
+
+	var info struct {
+		Sub               string `json:"sub"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+		Picture           string `json:"picture"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+	}
+	err = getJSON(ctx, p.client, d.UserinfoEndpoint, token, &info)
+	if err != nil {
+		return domain.ExternalIdentity{}, err
+	}
+	if info.Sub == "" {
+		return domain.ExternalIdentity{}, fmt.Errorf("%s 用户信息缺少 sub", p.cfg.Name)
+	}
+
+	identity := domain.ExternalIdentity{
+		Provider:  p.cfg.Name,
+		Subject:   info.Sub,
+		Login:     info.PreferredUsername,
+		Name:      info.Name,
+		AvatarUrl: info.Picture,
+	}
+	// 没验证过的邮箱不可信
+	if info.EmailVerified {
+		identity.Email = info.Email
+	}
+	return identity, nil
+}
+
+// discover 成功后缓存，失败了在 discoveryRetryInterval 之后再试
+// 请求 issuer 时不持有锁，同一时间只有一个请求去拉，其它请求等它的结果
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	for {
+		p.mu.Lock()
+		if p.discovery != nil {
+			d := p.discovery
+			p.mu.Unlock()
+			return d, nil
+		}
+		if p.lastErr != nil && p.nowFunc().Before(p.retryAt) {
+			err := p.lastErr
+			p.mu.Unlock()
+			return nil, err
+		}
+		if p.fetching == nil {
+			break
+		}
+		fetching := p.fetching
+		p.mu.Unlock()
+
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	fetching := make(chan struct{})
+	p.fetching = fetching
+	p.mu.Unlock()
+
+	// 结果要给其它等待的请求用，不能因为当前请求被取消就失败，超时由 client 兜住
+	d, err := p.fetchDiscovery(context.WithoutCancel(ctx))
+
+	p.mu.Lock()
+	if err != nil {
+		p.lastErr = err
+		p.retryAt = p.nowFunc().Add(discoveryRetryInterval)
+	} else {
+		p.discovery = d
+		p.lastErr = nil
+	}
+	p.fetching = nil
+	close(fetching)
+	p.mu.Unlock()
+	return d, err
+}
+
+func (p *OIDCProvider) fetchDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	var d oidcDiscovery
+	err := getJSON(ctx, p.client, p.cfg.Issuer+"/.well-known/openid-configuration", "", &d)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery 文档的 issuer %q 和配置的 %q 不一致", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("%s 的 discovery 文档缺少端点", p.cfg.Issuer)
+	}
+	return &d, nil
+}
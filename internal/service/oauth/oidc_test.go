@@ -0,0 +1,160 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+)
+
+func newTestOIDCProvider(server *fakeServer, issuer string) *OIDCProvider {
+	p := NewOIDCProvider(OIDCConfig{
+		Name:         "sso",
+		Issuer:       issuer,
+		ClientId:     "id",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/users/oauth/sso/callback",
+	}).(*OIDCProvider)
+	p.client = server.Client()
+	return p
+}
+
+func TestOIDCProvider_AuthURL(t *testing.T) {
+	server := newFakeServer(t, nil)
+	// 配置里多写了结尾的斜杠也能对上 issuer
+	p := newTestOIDCProvider(server, server.URL+"/")
+
+	got, err := p.AuthURL(context.Background(), "s.1.sig")
+	require.NoError(t, err)
+
+	u, err := url.Parse(got)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, url.Values{
+		"response_type": {"code"},
+		"client_id":     {"id"},
+		"redirect_uri":  {"http://localhost/users/oauth/sso/callback"},
+		"scope":         {"openid profile email"},
+		"state":         {"s.1.sig"},
+	}, u.Query())
+}
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	testCases := []struct {
+		name         string
+		code         string
+		userinfo     map[string]any
+		wantIdentity domain.ExternalIdentity
+		wantErr      error
+	}{
+		{
+			name: "成功",
+			code: "good",
+			userinfo: map[string]any{
+				"sub":                "abc",
+				"preferred_username": "alice",
+				"name":               "Alice",
+				"picture":            "https://avatars/alice",
+				"email":              "alice@example.com",
+				"email_verified":     true,
+			},
+			wantIdentity: domain.ExternalIdentity{
+				Provider:  "sso",
+				Subject:   "abc",
+				Login:     "alice",
+				Name:      "Alice",
+				AvatarUrl: "https://avatars/alice",
+				Email:     "alice@example.com",
+			},
+		},
+		{
+			name: "邮箱没验证过",
+			code: "good",
+			userinfo: map[string]any{
+				"sub":            "abc",
+				"email":          "alice@example.com",
+				"email_verified": false,
+			},
+			wantIdentity: domain.ExternalIdentity{
+				Provider: "sso",
+				Subject:  "abc",
+			},
+		},
+		{
+			name:    "code 过期",
+			code:    "bad",
+			wantErr: &Error{Code: "invalid_grant", Description: "code 已过期"},
+		},
+		{
+			name:     "缺少 sub",
+			code:     "good",
+			userinfo: map[string]any{"name": "Alice"},
+			wantErr:  errors.New("sso 用户信息缺少 sub"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeServer(t, tc.userinfo)
+			p := newTestOIDCProvider(server, server.URL)
+
+			identity, err := p.Exchange(context.Background(), tc.code)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantIdentity, identity)
+		})
+	}
+}
+
+func TestOIDCProvider_IssuerMismatch(t *testing.T) {
+	server := newFakeServer(t, nil)
+	p := newTestOIDCProvider(server, server.URL)
+	p.cfg.Issuer = server.URL + "/realms/other"
+
+	_, err := p.AuthURL(context.Background(), "state")
+	assert.Error(t, err)
+	assert.Nil(t, p.discovery)
+}
+
+func TestOIDCProvider_DiscoveryRetry(t *testing.T) {
+	server := newFakeServer(t, nil)
+	p := newTestOIDCProvider(server, server.URL)
+	now := time.Now()
+	p.nowFunc = func() time.Time { return now }
+	server.discoveryDown = true
+
+	// 失败之后的一段时间内直接返回上次的错误
+	_, err := p.AuthURL(context.Background(), "state")
+	require.Error(t, err)
+	_, err2 := p.AuthURL(context.Background(), "state")
+	assert.Equal(t, err, err2)
+	assert.Equal(t, 1, server.discoveryHits)
+
+	// 过了重试间隔再去请求，成功之后缓存下来
+	server.discoveryDown = false
+	now = now.Add(discoveryRetryInterval)
+	_, err = p.AuthURL(context.Background(), "state")
+	require.NoError(t, err)
+	_, err = p.AuthURL(context.Background(), "state")
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.discoveryHits)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(
+		NewOIDCProvider(OIDCConfig{Name: "sso"}),
+		NewGithubProvider("id", "secret", ""),
+	)
+
+	assert.Equal(t, []string{"github", "sso"}, r.Names())
+
+	p, err := r.Get("sso")
+	require.NoError(t, err)
+	assert.Equal(t, "sso", p.Name())
+
+	_, err = r.Get("gitlab")
+	assert.Equal(t, ErrUnknownProvider, err)
+}
@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"yellowbook/internal/domain"
+)
+
+var ErrUnknownProvider = errors.New("不支持的第三方登录")
+
+// Error 授权服务器返回的错误，比如 code 过期或者已经用过
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("oauth: %s: %s", e.Code, e.Description)
+}
+
+// Provider 一个第三方登录渠道，Exchange 把授权码换成统一格式的外部身份
+type Provider interface {
+	// Name 用在路由 /users/oauth/{name} 里，同时也是 user_identities 里的 provider
+	Name() string
+	AuthURL(ctx context.Context, state string) (string, error)
+	Exchange(ctx context.Context, code string) (domain.ExternalIdentity, error)
+}
+
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"yellowbook/internal/repository"
+)
+
+var ErrInvalidOAuthState = errors.New("oauth state 不合法")
+
+// IOAuthStateService 第三方登录和绑定的 state，防止 CSRF
+type IOAuthStateService interface {
+	// Generate uid 是发起绑定的用户，登录时传 0
+	Generate(ctx context.Context, uid uint64) (string, error)
+	// Verify 校验签名和有效期，每个 state 只能用一次，返回 Generate 时的 uid
+	Verify(ctx context.Context, state string) (uint64, error)
+	// Peek 和 Verify 一样校验，但是不消耗 state，回调时用来区分登录还是绑定
+	Peek(ctx context.Context, state string) (uint64, error)
+}
+
+type OAuthStateService struct {
+	repo    repository.IOAuthStateRepository
+	key     []byte
+	expire  time.Duration
+	nowFunc func() time.Time
+}
+
+func NewOAuthStateService(repo repository.IOAuthStateRepository, key []byte) IOAuthStateService {
+	return &OAuthStateService{
+		repo:    repo,
+		key:     key,
+		expire:  time.Minute * 10,
+		nowFunc: time.Now,
+	}
+}
+
+// Generate 格式是 nonce.过期时间.uid.签名
+func (s *OAuthStateService) Generate(ctx context.Context, uid uint64) (string, error) {
+	nonce, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	payload := nonce + "." + strconv.FormatInt(s.nowFunc().Add(s.expire).Unix(), 10) +
+		"." + strconv.FormatUint(uid, 10)
+	return payload + "." + s.sign(payload), nil
+}
+
+func (s *OAuthStateService) Verify(ctx context.Context, state string) (uint64, error) {
+	nonce, uid, ttl, err := s.parse(state)
+	if err != nil {
+		return 0, err
+	}
+
+	// 标记保留到 state 过期，之后签名校验就过不了了
+	ok, err := s.repo.MarkUsed(ctx, nonce, ttl)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrInvalidOAuthState
+	}
+	return uid, nil
+}
+
+func (s *OAuthStateService) Peek(ctx context.Context, state string) (uint64, error) {
+	_, uid, _, err := s.parse(state)
+	return uid, err
+}
+
+func (s *OAuthStateService) parse(state string) (string, uint64, time.Duration, error) {
+	idx := strings.LastIndexByte(state, '.')
+	if idx < 0 {
+		return "", 0, 0, ErrInvalidOAuthState
+	}
+	payload, sig := state[:idx], state[idx+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return "", 0, 0, ErrInvalidOAuthState
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 {
+		return "", 0, 0, ErrInvalidOAuthState
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, 0, ErrInvalidOAuthState
+	}
+	uid, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, ErrInvalidOAuthState
+	}
+	ttl := time.Unix(exp, 0).Sub(s.nowFunc())
+	if ttl <= 0 {
+		return "", 0, 0, ErrInvalidOAuthState
+	}
+	return parts[0], uid, ttl, nil
+}
+
+func (s *OAuthStateService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
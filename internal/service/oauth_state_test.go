@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+	repomocks "yellowbook/internal/repository/mocks"
+)
+
+func TestOAuthStateService_Verify(t *testing.T) {
+	now := time.Unix(1694575373, 0)
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository
+		state   func(state string) string
+		after   time.Duration
+		wantErr error
+	}{
+		{
+			name: "校验通过",
+			mock: func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository {
+				repo := repomocks.NewMockIOAuthStateRepository(ctrl)
+				repo.EXPECT().MarkUsed(gomock.Any(), gomock.Any(), time.Minute*9).Return(true, nil)
+				return repo
+			},
+			state: func(state string) string {
+				return state
+			},
+			after: time.Minute,
+		},
+		{
+			name: "已经用过",
+			mock: func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository {
+				repo := repomocks.NewMockIOAuthStateRepository(ctrl)
+				repo.EXPECT().MarkUsed(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+				return repo
+			},
+			state: func(state string) string {
+				return state
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "过期",
+			mock: func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository {
+				return repomocks.NewMockIOAuthStateRepository(ctrl)
+			},
+			state: func(state string) string {
+				return state
+			},
+			after:   time.Minute * 11,
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "篡改了过期时间",
+			mock: func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository {
+				return repomocks.NewMockIOAuthStateRepository(ctrl)
+			},
+			state: func(state string) string {
+				parts := strings.Split(state, ".")
+				parts[1] = "9999999999"
+				return strings.Join(parts, ".")
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "篡改了 uid",
+			mock: func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository {
+				return repomocks.NewMockIOAuthStateRepository(ctrl)
+			},
+			state: func(state string) string {
+				parts := strings.Split(state, ".")
+				parts[2] = "8"
+				return strings.Join(parts, ".")
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "格式不对",
+			mock: func(ctrl *gomock.Controller) *repomocks.MockIOAuthStateRepository {
+				return repomocks.NewMockIOAuthStateRepository(ctrl)
+			},
+			state: func(state string) string {
+				return "garbage"
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewOAuthStateService(tc.mock(ctrl), []byte("key")).(*OAuthStateService)
+			svc.nowFunc = func() time.Time {
+				return now
+			}
+			state, err := svc.Generate(context.Background(), 7)
+			require.NoError(t, err)
+
+			svc.nowFunc = func() time.Time {
+				return now.Add(tc.after)
+			}
+			uid, err := svc.Verify(context.Background(), tc.state(state))
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, uint64(7), uid)
+			}
+		})
+	}
+}
+
+func TestOAuthStateService_Peek(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Peek 不会标记已使用，之后 Verify 还能通过
+	repo := repomocks.NewMockIOAuthStateRepository(ctrl)
+	repo.EXPECT().MarkUsed(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	svc := NewOAuthStateService(repo, []byte("key"))
+
+	state, err := svc.Generate(context.Background(), 7)
+	require.NoError(t, err)
+
+	uid, err := svc.Peek(context.Background(), state)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), uid)
+
+	uid, err = svc.Verify(context.Background(), state)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), uid)
+
+	_, err = svc.Peek(context.Background(), state+"x")
+	assert.Equal(t, ErrInvalidOAuthState, err)
+}
@@ -2,7 +2,9 @@ package oss
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/spf13/viper"
 	"io"
 	"mime/multipart"
@@ -10,8 +12,11 @@ import (
 	"path/filepath"
 )
 
+var ErrUploadFailed = errors.New("上传失败")
+
 type IService interface {
 	Upload(f *multipart.FileHeader) (string, error)
+	UploadReader(ctx context.Context, filename string, r io.Reader) (string, error)
 }
 
 type Service struct {
@@ -30,18 +35,27 @@ type baiPiaoResp struct {
 }
 
 func (s *Service) Upload(f *multipart.FileHeader) (string, error) {
+	file, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return s.UploadReader(context.Background(), f.Filename, file)
+}
+
+func (s *Service) UploadReader(ctx context.Context, filename string, r io.Reader) (string, error) {
 	url := "https://front-gateway.mollybox.com/service-person-center/api/pet/uploadPetAvatar"
 	method := "POST"
 
 	payload := &bytes.Buffer{}
 	writer := multipart.NewWriter(payload)
 
-	file, err := f.Open()
-	defer file.Close()
-
-	part1,
-		err := writer.CreateFormFile("avatar", filepath.Base(f.Filename))
-	_, err = io.Copy(part1, file)
+	part1, err := writer.CreateFormFile("avatar", filepath.Base(filename))
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(part1, r)
 	if err != nil {
 		return "", err
 	}
@@ -50,7 +64,7 @@ func (s *Service) Upload(f *multipart.FileHeader) (string, error) {
 		return "", err
 	}
 
-	req, err := http.NewRequest(method, url, payload)
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
 
 	if err != nil {
 		return "", err
@@ -88,5 +102,9 @@ func (s *Service) Upload(f *multipart.FileHeader) (string, error) {
 		return "", err
 	}
 
+	if !resp.Success || resp.Data == "" {
+		return "", ErrUploadFailed
+	}
+
 	return resp.Data, nil
 }
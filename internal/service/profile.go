@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+)
+
+// recentArticleLimit 公开主页上展示的最新文章数量
+const recentArticleLimit = 10
+
+const (
+	// handleCooldown 两次修改用户名的最小间隔，第一次设置不受限制
+	handleCooldown = 30 * 24 * time.Hour
+	// handleRedirectTTL 改名以后旧用户名继续跳转的时间，期间别人不能占用
+	handleRedirectTTL = 90 * 24 * time.Hour
+)
+
+var (
+	ErrHandleInvalid  = errors.New("用户名只能包含小写字母、数字和下划线，以字母开头，3-20 个字符")
+	ErrHandleReserved = errors.New("这个用户名不能使用")
+	ErrHandleTaken    = repository.ErrHandleDuplicate
+)
+
+// HandleCooldownError Until 是可以再次修改的时间，并发修改时可能是零值
+type HandleCooldownError struct {
+	Until time.Time
+}
+
+func (e *HandleCooldownError) Error() string {
+	if e.Until.IsZero() {
+		return "修改用户名太频繁"
+	}
+	return fmt.Sprintf("修改用户名太频繁，%s 以后才能再次修改", e.Until.Format("2006-01-02 15:04"))
+}
+
+var handleExp = regexp.MustCompile(`^[a-z][a-z0-9_]{2,19}$`)
+
+// reservedHandles 和路由、官方账号容易混淆的用户名
+var reservedHandles = map[string]struct{}{
+	"admin": {}, "administrator": {}, "root": {}, "system": {}, "support": {},
+	"help": {}, "about": {}, "api": {}, "www": {}, "official": {},
+	"yellowbook": {}, "staff": {}, "moderator": {}, "security": {}, "privacy": {},
+	"terms": {}, "users": {}, "user": {}, "login": {}, "signup": {},
+	"profile": {}, "settings": {}, "me": {}, "null": {}, "undefined": {},
+}
+
+type IProfileService interface {
+	// Public 别人看到的主页，按隐私设置隐藏字段，私密账号只有昵称和头像
+	Public(ctx context.Context, uid uint64) (domain.PublicProfile, error)
+	// PublicByHandle handle 是旧用户名时返回的 Handle 是现在的用户名，调用方用来跳转
+	PublicByHandle(ctx context.Context, handle string) (domain.PublicProfile, error)
+	UpdatePrivacy(ctx context.Context, uid uint64, privacy domain.Privacy) error
+	// SetHandle handle 会转成小写，不合规则返回 ErrHandleInvalid 或 ErrHandleReserved
+	SetHandle(ctx context.Context, uid uint64, handle string) error
+}
+
+type ProfileService struct {
+	userRepo    repository.UserRepository
+	articleRepo repository.IArticleRepository
+}
+
+func NewProfileService(userRepo repository.UserRepository, articleRepo repository.IArticleRepository) IProfileService {
+	return &ProfileService{
+		userRepo:    userRepo,
+		articleRepo: articleRepo,
+	}
+}
+
+func (s *ProfileService) Public(ctx context.Context, uid uint64) (domain.PublicProfile, error) {
+	u, err := s.userRepo.QueryProfile(ctx, uid)
+	if err != nil {
+		return domain.PublicProfile{}, err
+	}
+
+	// 只挑公开的字段，不要整个 User 往外传
+	res := domain.PublicProfile{UserId: u.Id, Handle: u.Handle}
+	if u.Profile == nil {
+		return res, nil
+	}
+	p := u.Profile
+	res.Nickname = p.Nickname
+	res.Avatar = p.Avatar
+	res.Avatars = p.Avatars
+	if p.Privacy.Private {
+		res.Private = true
+		return res, nil
+	}
+
+	res.Introduction = p.Introduction
+	if !p.Privacy.HideBirthday {
+		res.Birthday = p.Birthday
+	}
+	if !p.Privacy.HideGender {
+		res.Gender = p.Gender
+	}
+
+	res.RecentArticles, res.ArticleCount, err = s.articleRepo.ListPublishedByAuthor(ctx, uid, recentArticleLimit)
+	if err != nil {
+		return domain.PublicProfile{}, err
+	}
+	return res, nil
+}
+
+func (s *ProfileService) UpdatePrivacy(ctx context.Context, uid uint64, privacy domain.Privacy) error {
+	return s.userRepo.UpdatePrivacy(ctx, uid, privacy)
+}
+
+func (s *ProfileService) PublicByHandle(ctx context.Context, handle string) (domain.PublicProfile, error) {
+	uid, err := s.userRepo.FindIdByHandle(ctx, strings.ToLower(handle))
+	if err != nil {
+		return domain.PublicProfile{}, err
+	}
+	return s.Public(ctx, uid)
+}
+
+func (s *ProfileService) SetHandle(ctx context.Context, uid uint64, handle string) error {
+	handle = strings.ToLower(handle)
+	if !handleExp.MatchString(handle) {
+		return ErrHandleInvalid
+	}
+	if _, ok := reservedHandles[handle]; ok {
+		return ErrHandleReserved
+	}
+
+	u, err := s.userRepo.QueryProfile(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.Handle == handle {
+		return nil
+	}
+	now := time.Now()
+	if u.Handle != "" && now.Before(u.HandleUpdateTime.Add(handleCooldown)) {
+		return &HandleCooldownError{Until: u.HandleUpdateTime.Add(handleCooldown)}
+	}
+
+	err = s.userRepo.SetHandle(ctx, uid, handle, now.Add(-handleCooldown), now.Add(handleRedirectTTL))
+	if errors.Is(err, repository.ErrHandleCooldown) {
+		return &HandleCooldownError{}
+	}
+	return err
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+)
+
+func TestProfileService_Public(t *testing.T) {
+	profile := func(privacy domain.Privacy) *domain.Profile {
+		return &domain.Profile{
+			UserId:       1,
+			Nickname:     "和黑",
+			Birthday:     "1993-12-11",
+			Introduction: "我不想自我介绍",
+			Avatar:       "https://example.com/avatar.png",
+			Gender:       proto.Gender_Male,
+			Privacy:      privacy,
+		}
+	}
+	articles := []domain.Article{{Id: 3, Title: "标题"}}
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository)
+		want    domain.PublicProfile
+		wantErr error
+	}{
+		{
+			name: "全部公开",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{
+					Id:      1,
+					Email:   "863@qq.com",
+					Phone:   "13800000000",
+					Profile: profile(domain.Privacy{}),
+				}, nil)
+				articleRepo := repomocks.NewMockIArticleRepository(ctrl)
+				articleRepo.EXPECT().ListPublishedByAuthor(gomock.Any(), uint64(1), recentArticleLimit).Return(articles, int64(12), nil)
+				return userRepo, articleRepo
+			},
+			want: domain.PublicProfile{
+				UserId:         1,
+				Nickname:       "和黑",
+				Avatar:         "https://example.com/avatar.png",
+				Introduction:   "我不想自我介绍",
+				Birthday:       "1993-12-11",
+				Gender:         proto.Gender_Male,
+				ArticleCount:   12,
+				RecentArticles: articles,
+			},
+		},
+		{
+			name: "隐藏生日和性别",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{
+					Id:      1,
+					Profile: profile(domain.Privacy{HideBirthday: true, HideGender: true}),
+				}, nil)
+				articleRepo := repomocks.NewMockIArticleRepository(ctrl)
+				articleRepo.EXPECT().ListPublishedByAuthor(gomock.Any(), uint64(1), recentArticleLimit).Return(nil, int64(0), nil)
+				return userRepo, articleRepo
+			},
+			want: domain.PublicProfile{
+				UserId:       1,
+				Nickname:     "和黑",
+				Avatar:       "https://example.com/avatar.png",
+				Introduction: "我不想自我介绍",
+			},
+		},
+		{
+			name: "私密账号不查文章",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{
+					Id:      1,
+					Profile: profile(domain.Privacy{Private: true}),
+				}, nil)
+				return userRepo, repomocks.NewMockIArticleRepository(ctrl)
+			},
+			want: domain.PublicProfile{
+				UserId:   1,
+				Nickname: "和黑",
+				Avatar:   "https://example.com/avatar.png",
+				Private:  true,
+			},
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{}, repository.ErrUserNotFound)
+				return userRepo, repomocks.NewMockIArticleRepository(ctrl)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewProfileService(tc.mock(ctrl))
+			p, err := svc.Public(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, p)
+		})
+	}
+}
+
+func TestProfileService_SetHandle(t *testing.T) {
+	changed := time.Now().Add(-handleCooldown - time.Hour)
+	recent := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.UserRepository
+		handle  string
+		wantErr error
+	}{
+		{
+			name: "第一次设置",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().SetHandle(gomock.Any(), uint64(1), "hehei_93", gomock.Any(), gomock.Any()).Return(nil)
+				return repo
+			},
+			handle: "HeHei_93",
+		},
+		{
+			name: "过了冷却期可以修改",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Handle: "old", HandleUpdateTime: changed}, nil)
+				repo.EXPECT().SetHandle(gomock.Any(), uint64(1), "hehei", gomock.Any(), gomock.Any()).Return(nil)
+				return repo
+			},
+			handle: "hehei",
+		},
+		{
+			name: "没有变化",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Handle: "hehei", HandleUpdateTime: recent}, nil)
+				return repo
+			},
+			handle: "hehei",
+		},
+		{
+			name: "冷却期内",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Handle: "old", HandleUpdateTime: recent}, nil)
+				return repo
+			},
+			handle:  "hehei",
+			wantErr: &HandleCooldownError{Until: recent.Add(handleCooldown)},
+		},
+		{
+			name: "并发修改撞上冷却期",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().SetHandle(gomock.Any(), uint64(1), "hehei", gomock.Any(), gomock.Any()).Return(repository.ErrHandleCooldown)
+				return repo
+			},
+			handle:  "hehei",
+			wantErr: &HandleCooldownError{},
+		},
+		{
+			name: "已被占用",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1}, nil)
+				repo.EXPECT().SetHandle(gomock.Any(), uint64(1), "hehei", gomock.Any(), gomock.Any()).Return(repository.ErrHandleDuplicate)
+				return repo
+			},
+			handle:  "hehei",
+			wantErr: ErrHandleTaken,
+		},
+		{
+			name:    "太短",
+			mock:    func(ctrl *gomock.Controller) repository.UserRepository { return nil },
+			handle:  "ab",
+			wantErr: ErrHandleInvalid,
+		},
+		{
+			name:    "数字开头",
+			mock:    func(ctrl *gomock.Controller) repository.UserRepository { return nil },
+			handle:  "9hehei",
+			wantErr: ErrHandleInvalid,
+		},
+		{
+			name:    "包含不允许的字符",
+			mock:    func(ctrl *gomock.Controller) repository.UserRepository { return nil },
+			handle:  "he-hei",
+			wantErr: ErrHandleInvalid,
+		},
+		{
+			name:    "保留的用户名",
+			mock:    func(ctrl *gomock.Controller) repository.UserRepository { return nil },
+			handle:  "Admin",
+			wantErr: ErrHandleReserved,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewProfileService(tc.mock(ctrl), nil)
+			err := svc.SetHandle(context.Background(), 1, tc.handle)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestProfileService_PublicByHandle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 旧用户名找到的是现在的用户名
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindIdByHandle(gomock.Any(), "old").Return(uint64(1), nil)
+	userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{
+		Id:      1,
+		Handle:  "hehei",
+		Profile: &domain.Profile{Nickname: "和黑", Privacy: domain.Privacy{Private: true}},
+	}, nil)
+
+	svc := NewProfileService(userRepo, repomocks.NewMockIArticleRepository(ctrl))
+	p, err := svc.PublicByHandle(context.Background(), "OLD")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PublicProfile{UserId: 1, Handle: "hehei", Nickname: "和黑", Private: true}, p)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/shenxiang11/yellowbook-proto/proto"
 	"github.com/spf13/viper"
 	"mime/multipart"
@@ -13,20 +14,23 @@ import (
 
 type IResourceService interface {
 	Upload(ctx context.Context, f *multipart.FileHeader, purpose proto.ResourcePurpose, uid uint64) (string, error)
+	Import(ctx context.Context, sourceUrl string, purpose proto.ResourcePurpose, uid uint64) (string, error)
 	GetResourceCategoryList() any
 }
 
 type ResourceService struct {
-	ossSrv oss.IService
-	repo   repository.IResourceRepository
-	l      logger.Logger
+	ossSrv  oss.IService
+	repo    repository.IResourceRepository
+	fetcher ImageFetcher
+	l       logger.Logger
 }
 
-func NewResourceService(ossSrv oss.IService, repo repository.IResourceRepository, l logger.Logger) IResourceService {
+func NewResourceService(ossSrv oss.IService, repo repository.IResourceRepository, fetcher ImageFetcher, l logger.Logger) IResourceService {
 	return &ResourceService{
-		ossSrv: ossSrv,
-		repo:   repo,
-		l:      l,
+		ossSrv:  ossSrv,
+		repo:    repo,
+		fetcher: fetcher,
+		l:       l,
 	}
 }
 
@@ -53,6 +57,30 @@ func (s *ResourceService) Upload(ctx context.Context, f *multipart.FileHeader, p
 	return url, nil
 }
 
+// Import 把外部图片转存到 OSS 并记录，给批量导入使用
+func (s *ResourceService) Import(ctx context.Context, sourceUrl string, purpose proto.ResourcePurpose, uid uint64) (string, error) {
+	img, err := s.fetcher.Fetch(ctx, sourceUrl)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := s.ossSrv.UploadReader(ctx, img.Filename, img.Reader())
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.Create(ctx, domain.Resource{
+		Url:      url,
+		Purpose:  purpose,
+		Mimetype: img.Mimetype,
+	}, uid)
+	if err != nil && !errors.Is(err, repository.ErrResourceDuplicate) {
+		return "", err
+	}
+
+	return url, nil
+}
+
 func (s *ResourceService) GetResourceCategoryList() any {
 	c := viper.Get("dict_resource_type")
 	return c
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	"yellowbook/pkg/logger"
+)
+
+var (
+	ErrSessionRevoked      = errors.New("登录已失效")
+	ErrSessionNotFound     = errors.New("会话不存在")
+	ErrInvalidRefreshToken = errors.New("refresh token 不合法")
+)
+
+type ISessionService interface {
+	// Create 登录成功后创建会话，s 里带上用户和设备信息，返回 refresh token
+	Create(ctx context.Context, s domain.Session) (domain.Session, string, error)
+	// Refresh 校验并轮换 refresh token，换了 User-Agent 也视为不合法
+	Refresh(ctx context.Context, refreshToken string, userAgent string) (domain.Session, string, error)
+	Check(ctx context.Context, id string, uid uint64, userAgent string) error
+	List(ctx context.Context, uid uint64) ([]domain.Session, error)
+	Revoke(ctx context.Context, uid uint64, id string) error
+	// RevokeOthers 只保留 keepId 这一个会话
+	RevokeOthers(ctx context.Context, uid uint64, keepId string) error
+	// RevokeAll 所有设备都需要重新登录，比如重置密码以后
+	RevokeAll(ctx context.Context, uid uint64) error
+}
+
+type SessionService struct {
+	repo          repository.ISessionRepository
+	l             logger.Logger
+	refreshExpire time.Duration
+	// touchInterval 最后活跃时间不需要很精确，避免每个请求都写 Redis
+	touchInterval time.Duration
+	nowFunc       func() time.Time
+}
+
+func NewSessionService(repo repository.ISessionRepository, l logger.Logger) ISessionService {
+	return &SessionService{
+		repo:          repo,
+		l:             l,
+		refreshExpire: time.Hour * 24 * 7,
+		touchInterval: time.Minute,
+		nowFunc:       time.Now,
+	}
+}
+
+func (s *SessionService) Create(ctx context.Context, sess domain.Session) (domain.Session, string, error) {
+	id, err := randomString(16)
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+
+	now := s.nowFunc()
+	sess.Id = id
+	sess.RefreshHash = hashSecret(secret)
+	sess.CreateTime = now
+	sess.ExpireTime = now.Add(s.refreshExpire)
+	sess.LastSeen = now
+	if err = s.repo.Create(ctx, sess); err != nil {
+		return domain.Session{}, "", err
+	}
+
+	return sess, refreshToken(id, secret), nil
+}
+
+func (s *SessionService) Refresh(ctx context.Context, token string, userAgent string) (domain.Session, string, error) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" {
+		return domain.Session{}, "", ErrInvalidRefreshToken
+	}
+
+	sess, err := s.repo.FindById(ctx, id)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return domain.Session{}, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+	if sess.UserAgent != userAgent {
+		return domain.Session{}, "", s.revokeStolen(ctx, sess, "User-Agent 不一致")
+	}
+
+	next, err := randomString(32)
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+
+	err = s.repo.RotateRefresh(ctx, id, hashSecret(secret), hashSecret(next))
+	switch {
+	case err == nil:
+		sess.RefreshHash = hashSecret(next)
+		return sess, refreshToken(id, next), nil
+	case errors.Is(err, repository.ErrRefreshTokenMismatch):
+		// 用过的 refresh token 又出现了
+		return domain.Session{}, "", s.revokeStolen(ctx, sess, "refresh token 重复使用")
+	case errors.Is(err, repository.ErrSessionNotFound):
+		return domain.Session{}, "", ErrInvalidRefreshToken
+	default:
+		return domain.Session{}, "", err
+	}
+}
+
+// revokeStolen token 可能被盗，直接让整个会话失效
+func (s *SessionService) revokeStolen(ctx context.Context, sess domain.Session, reason string) error {
+	s.l.Warn("会话疑似被盗用，撤销会话",
+		logger.Field{Key: "session_id", Value: sess.Id},
+		logger.Field{Key: "user_id", Value: sess.UserId},
+		logger.Field{Key: "reason", Value: reason},
+	)
+	if err := s.repo.Delete(ctx, sess.Id); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+func (s *SessionService) Check(ctx context.Context, id string, uid uint64, userAgent string) error {
+	sess, err := s.repo.FindById(ctx, id)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+	if sess.UserId != uid || sess.UserAgent != userAgent {
+		return ErrSessionRevoked
+	}
+
+	now := s.nowFunc()
+	if now.Sub(sess.LastSeen) >= s.touchInterval {
+		if err = s.repo.Touch(ctx, id, now); err != nil {
+			s.l.Warn("更新会话活跃时间失败", logger.Field{Key: "session_id", Value: id})
+		}
+	}
+	return nil
+}
+
+// List 最近活跃的排在前面
+func (s *SessionService) List(ctx context.Context, uid uint64) ([]domain.Session, error) {
+	sessions, err := s.repo.FindByUserId(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+func (s *SessionService) Revoke(ctx context.Context, uid uint64, id string) error {
+	sess, err := s.repo.FindById(ctx, id)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// 不能踢别人的设备
+	if sess.UserId != uid {
+		return ErrSessionNotFound
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *SessionService) RevokeOthers(ctx context.Context, uid uint64, keepId string) error {
+	sessions, err := s.repo.FindByUserId(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	for _, sess := range sessions {
+		if sess.Id == keepId {
+			continue
+		}
+		if err = s.repo.Delete(ctx, sess.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SessionService) RevokeAll(ctx context.Context, uid uint64) error {
+	return s.RevokeOthers(ctx, uid, "")
+}
+
+func refreshToken(id string, secret string) string {
+	return id + "." + secret
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/pkg/logger"
+)
+
+func TestSessionService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.UnixMilli(1694575373863)
+	repo := repomocks.NewMockISessionRepository(ctrl)
+	var saved domain.Session
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, s domain.Session) error {
+		saved = s
+		return nil
+	})
+
+	svc := NewSessionService(repo, logger.NewZapLogger(zap.NewNop())).(*SessionService)
+	svc.nowFunc = func() time.Time {
+		return now
+	}
+
+	sess, token, err := svc.Create(context.Background(), domain.Session{
+		UserId:      1,
+		Device:      "iPhone",
+		UserAgent:   "ua",
+		Ip:          "127.0.0.1",
+		LoginMethod: domain.LoginBySMS,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, saved, sess)
+	assert.Equal(t, uint64(1), sess.UserId)
+	assert.Equal(t, "iPhone", sess.Device)
+	assert.Equal(t, domain.LoginBySMS, sess.LoginMethod)
+	assert.Equal(t, now, sess.LastSeen)
+	assert.Equal(t, now.Add(time.Hour*24*7), sess.ExpireTime)
+
+	id, secret, ok := strings.Cut(token, ".")
+	require.True(t, ok)
+	assert.Equal(t, sess.Id, id)
+	// 只保存哈希
+	assert.Equal(t, hashSecret(secret), sess.RefreshHash)
+	assert.NotContains(t, sess.RefreshHash, secret)
+}
+
+func TestSessionService_Refresh(t *testing.T) {
+	sess := domain.Session{Id: "ssid", UserId: 1, UserAgent: "ua", RefreshHash: hashSecret("old")}
+
+	testCases := []struct {
+		name    string
+		token   string
+		ua      string
+		mock    func(ctrl *gomock.Controller) repository.ISessionRepository
+		wantErr error
+	}{
+		{
+			name:  "轮换成功",
+			token: "ssid.old",
+			ua:    "ua",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(sess, nil)
+				repo.EXPECT().RotateRefresh(gomock.Any(), "ssid", hashSecret("old"), gomock.Any()).Return(nil)
+				return repo
+			},
+		},
+		{
+			name:  "格式不对",
+			token: "garbage",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				return repomocks.NewMockISessionRepository(ctrl)
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name:  "会话已失效",
+			token: "ssid.old",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(domain.Session{}, repository.ErrSessionNotFound)
+				return repo
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name:  "旧 token 重复使用，撤销会话",
+			token: "ssid.stale",
+			ua:    "ua",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(sess, nil)
+				repo.EXPECT().RotateRefresh(gomock.Any(), "ssid", hashSecret("stale"), gomock.Any()).
+					Return(repository.ErrRefreshTokenMismatch)
+				repo.EXPECT().Delete(gomock.Any(), "ssid").Return(nil)
+				return repo
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name:  "换了设备，撤销会话",
+			token: "ssid.old",
+			ua:    "other",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(sess, nil)
+				repo.EXPECT().Delete(gomock.Any(), "ssid").Return(nil)
+				return repo
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewSessionService(tc.mock(ctrl), logger.NewZapLogger(zap.NewNop()))
+			got, token, err := svc.Refresh(context.Background(), tc.token, tc.ua)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr != nil {
+				return
+			}
+
+			assert.Equal(t, uint64(1), got.UserId)
+			_, secret, _ := strings.Cut(token, ".")
+			assert.NotEqual(t, "old", secret)
+			assert.Equal(t, hashSecret(secret), got.RefreshHash)
+		})
+	}
+}
+
+func TestSessionService_Check(t *testing.T) {
+	now := time.UnixMilli(1694575373863)
+	sess := domain.Session{Id: "ssid", UserId: 1, UserAgent: "ua", LastSeen: now.Add(-time.Second)}
+
+	testCases := []struct {
+		name    string
+		id      string
+		uid     uint64
+		ua      string
+		mock    func(ctrl *gomock.Controller) repository.ISessionRepository
+		wantErr error
+	}{
+		{
+			name: "刚活跃过，不更新",
+			id:   "ssid",
+			uid:  1,
+			ua:   "ua",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(sess, nil)
+				return repo
+			},
+		},
+		{
+			name: "更新最后活跃时间",
+			id:   "ssid",
+			uid:  1,
+			ua:   "ua",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				stale := sess
+				stale.LastSeen = now.Add(-time.Hour)
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(stale, nil)
+				repo.EXPECT().Touch(gomock.Any(), "ssid", now).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "会话不属于这个用户",
+			id:   "ssid",
+			uid:  2,
+			ua:   "ua",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(sess, nil)
+				return repo
+			},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "User-Agent 不一致",
+			id:   "ssid",
+			uid:  1,
+			ua:   "other",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "ssid").Return(sess, nil)
+				return repo
+			},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "已被踢下线",
+			id:   "gone",
+			uid:  1,
+			ua:   "ua",
+			mock: func(ctrl *gomock.Controller) repository.ISessionRepository {
+				repo := repomocks.NewMockISessionRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), "gone").Return(domain.Session{}, repository.ErrSessionNotFound)
+				return repo
+			},
+			wantErr: ErrSessionRevoked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewSessionService(tc.mock(ctrl), logger.NewZapLogger(zap.NewNop())).(*SessionService)
+			svc.nowFunc = func() time.Time {
+				return now
+			}
+
+			err := svc.Check(context.Background(), tc.id, tc.uid, tc.ua)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSessionService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockISessionRepository(ctrl)
+	repo.EXPECT().FindByUserId(gomock.Any(), uint64(1)).Return([]domain.Session{
+		{Id: "a", LastSeen: time.UnixMilli(1)},
+		{Id: "b", LastSeen: time.UnixMilli(3)},
+		{Id: "c", LastSeen: time.UnixMilli(2)},
+	}, nil)
+
+	svc := NewSessionService(repo, logger.NewZapLogger(zap.NewNop()))
+	sessions, err := svc.List(context.Background(), 1)
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.Id)
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, ids)
+}
+
+func TestSessionService_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockISessionRepository(ctrl)
+	repo.EXPECT().FindById(gomock.Any(), "mine").Return(domain.Session{Id: "mine", UserId: 1}, nil)
+	repo.EXPECT().Delete(gomock.Any(), "mine").Return(nil)
+	repo.EXPECT().FindById(gomock.Any(), "theirs").Return(domain.Session{Id: "theirs", UserId: 2}, nil)
+	repo.EXPECT().FindById(gomock.Any(), "gone").Return(domain.Session{}, repository.ErrSessionNotFound)
+
+	svc := NewSessionService(repo, logger.NewZapLogger(zap.NewNop()))
+	assert.NoError(t, svc.Revoke(context.Background(), 1, "mine"))
+	// 不能踢别人的设备
+	assert.ErrorIs(t, svc.Revoke(context.Background(), 1, "theirs"), ErrSessionNotFound)
+	assert.ErrorIs(t, svc.Revoke(context.Background(), 1, "gone"), ErrSessionNotFound)
+}
+
+func TestSessionService_RevokeOthers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockISessionRepository(ctrl)
+	repo.EXPECT().FindByUserId(gomock.Any(), uint64(1)).Return([]domain.Session{
+		{Id: "a"}, {Id: "keep"}, {Id: "b"},
+	}, nil)
+	repo.EXPECT().Delete(gomock.Any(), "a").Return(nil)
+	repo.EXPECT().Delete(gomock.Any(), "b").Return(nil)
+
+	svc := NewSessionService(repo, logger.NewZapLogger(zap.NewNop()))
+	assert.NoError(t, svc.RevokeOthers(context.Background(), 1, "keep"))
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	"yellowbook/pkg/totp"
+)
+
+var (
+	ErrTwoFactorEnabled     = repository.ErrTOTPEnabled
+	ErrTwoFactorNotEnabled  = errors.New("没有开启两步验证")
+	ErrTwoFactorNotEnrolled = errors.New("还没有生成两步验证密钥")
+	ErrInvalidTwoFactorCode = errors.New("两步验证码错误")
+	ErrInvalidChallenge     = errors.New("两步验证已失效")
+)
+
+const (
+	recoveryCodeCount = 10
+	// 去掉了容易看错的 0、1、i、l、o
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type ITwoFactorService interface {
+	Enabled(ctx context.Context, uid uint64) (bool, error)
+	// Enroll 生成新的密钥，返回给验证器扫码的 otpauth URI，Confirm 之前不生效
+	Enroll(ctx context.Context, uid uint64) (secret string, uri string, err error)
+	// Confirm 用验证器上的第一个验证码确认，返回只展示这一次的恢复码
+	Confirm(ctx context.Context, uid uint64, code string) ([]string, error)
+	// Disable code 可以是验证码，也可以是恢复码
+	Disable(ctx context.Context, uid uint64, code string) error
+	// Verify 敏感操作前再确认一次，code 和 Disable 一样，没有开启时返回 ErrTwoFactorNotEnabled
+	Verify(ctx context.Context, uid uint64, code string) error
+	// Reset 管理后台使用，用户手机和恢复码都丢了的时候
+	Reset(ctx context.Context, uid uint64) error
+	// Challenge 第一步验证通过以后调用，返回的 challenge 有效期很短
+	Challenge(ctx context.Context, uid uint64, loginMethod string) (domain.TwoFactorChallenge, error)
+	// VerifyChallenge 验证通过以后 challenge 作废，失败太多次也会作废
+	VerifyChallenge(ctx context.Context, id string, code string) (domain.TwoFactorChallenge, error)
+}
+
+type TwoFactorService struct {
+	repo        repository.ITwoFactorRepository
+	users       repository.UserRepository
+	issuer      string
+	expire      time.Duration
+	maxAttempts int
+	nowFunc     func() time.Time
+}
+
+func NewTwoFactorService(repo repository.ITwoFactorRepository, users repository.UserRepository) ITwoFactorService {
+	return &TwoFactorService{
+		repo:        repo,
+		users:       users,
+		issuer:      "yellowbook",
+		expire:      time.Minute * 5,
+		maxAttempts: 5,
+		nowFunc:     time.Now,
+	}
+}
+
+func (svc *TwoFactorService) Enabled(ctx context.Context, uid uint64) (bool, error) {
+	t, err := svc.repo.FindTOTP(ctx, uid)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return t.Enabled, nil
+}
+
+func (svc *TwoFactorService) Enroll(ctx context.Context, uid uint64) (string, string, error) {
+	u, err := svc.users.QueryProfile(ctx, uid)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err = svc.repo.SavePendingTOTP(ctx, uid, secret); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.URI(svc.issuer, accountLabel(u), secret), nil
+}
+
+func (svc *TwoFactorService) Confirm(ctx context.Context, uid uint64, code string) ([]string, error) {
+	t, err := svc.repo.FindTOTP(ctx, uid)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(t.Secret, code, svc.nowFunc())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		c, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+
+	if err = svc.repo.EnableTOTP(ctx, uid, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (svc *TwoFactorService) Disable(ctx context.Context, uid uint64, code string) error {
+	if err := svc.Verify(ctx, uid, code); err != nil {
+		return err
+	}
+	return svc.repo.DeleteTOTP(ctx, uid)
+}
+
+func (svc *TwoFactorService) Verify(ctx context.Context, uid uint64, code string) error {
+	t, err := svc.repo.FindTOTP(ctx, uid)
+	if errors.Is(err, repository.ErrTOTPNotFound) || err == nil && !t.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	return svc.verifyCode(ctx, t, code)
+}
+
+func (svc *TwoFactorService) Reset(ctx context.Context, uid uint64) error {
+	return svc.repo.DeleteTOTP(ctx, uid)
+}
+
+func (svc *TwoFactorService) Challenge(ctx context.Context, uid uint64, loginMethod string) (domain.TwoFactorChallenge, error) {
+	id, err := randomString(16)
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+
+	c := domain.TwoFactorChallenge{
+		Id:          id,
+		UserId:      uid,
+		LoginMethod: loginMethod,
+		ExpireTime:  svc.nowFunc().Add(svc.expire),
+	}
+	if err = svc.repo.CreateChallenge(ctx, c); err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+	return c, nil
+}
+
+func (svc *TwoFactorService) VerifyChallenge(ctx context.Context, id string, code string) (domain.TwoFactorChallenge, error) {
+	c, err := svc.repo.FindChallenge(ctx, id)
+	if errors.Is(err, repository.ErrChallengeNotFound) {
+		return domain.TwoFactorChallenge{}, ErrInvalidChallenge
+	}
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+
+	// 登录到一半被管理员重置了，让用户重新登录
+	t, err := svc.repo.FindTOTP(ctx, c.UserId)
+	if errors.Is(err, repository.ErrTOTPNotFound) || err == nil && !t.Enabled {
+		return domain.TwoFactorChallenge{}, ErrInvalidChallenge
+	}
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+
+	err = svc.verifyCode(ctx, t, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		attempts, err := svc.repo.IncrChallengeAttempts(ctx, id)
+		if errors.Is(err, repository.ErrChallengeNotFound) {
+			return domain.TwoFactorChallenge{}, ErrInvalidChallenge
+		}
+		if err != nil {
+			return domain.TwoFactorChallenge{}, err
+		}
+		if attempts >= svc.maxAttempts {
+			if _, err = svc.repo.DeleteChallenge(ctx, id); err != nil {
+				return domain.TwoFactorChallenge{}, err
+			}
+			return domain.TwoFactorChallenge{}, ErrInvalidChallenge
+		}
+		return domain.TwoFactorChallenge{}, ErrInvalidTwoFactorCode
+	}
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+
+	// 删除成功的那个请求才算通过，防止同一个 challenge 并发换出多个 token
+	ok, err := svc.repo.DeleteChallenge(ctx, id)
+	if err != nil {
+		return domain.TwoFactorChallenge{}, err
+	}
+	if !ok {
+		return domain.TwoFactorChallenge{}, ErrInvalidChallenge
+	}
+	return c, nil
+}
+
+// verifyCode 6 位数字按验证码处理，其它的按恢复码处理
+func (svc *TwoFactorService) verifyCode(ctx context.Context, t domain.TOTP, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(t.Secret, code, svc.nowFunc())
+		if !ok || step <= t.LastStep {
+			return ErrInvalidTwoFactorCode
+		}
+		ok, err := svc.repo.UseStep(ctx, t.UserId, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	ok, err := svc.repo.UseRecoveryCode(ctx, t.UserId, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// accountLabel 验证器里显示的账号名
+func accountLabel(u domain.User) string {
+	switch {
+	case u.Email != "":
+		return u.Email
+	case u.Phone != "":
+		return u.Phone
+	case u.Profile != nil && u.Profile.Nickname != "":
+		return u.Profile.Nickname
+	default:
+		return "user"
+	}
+}
+
+// generateRecoveryCode 格式是 xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// hashRecoveryCode 恢复码本身是随机生成的，不需要 bcrypt，忽略大小写和连字符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
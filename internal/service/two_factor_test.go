@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/pkg/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+var testTOTPNow = time.Unix(1694575373, 0)
+
+func testTOTPCode(t *testing.T, offset int64) string {
+	code, err := totp.Code(testTOTPSecret, totp.Step(testTOTPNow)+offset)
+	require.NoError(t, err)
+	return code
+}
+
+func TestTwoFactorService_Confirm(t *testing.T) {
+	step := totp.Step(testTOTPNow)
+
+	testCases := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) repository.ITwoFactorRepository
+		code      string
+		wantCodes int
+		wantErr   error
+	}{
+		{
+			name: "确认成功",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(domain.TOTP{UserId: 1, Secret: testTOTPSecret}, nil)
+				repo.EXPECT().EnableTOTP(gomock.Any(), uint64(1), step, gomock.Len(recoveryCodeCount)).Return(nil)
+				return repo
+			},
+			code:      testTOTPCode(t, 0),
+			wantCodes: recoveryCodeCount,
+		},
+		{
+			name: "还没有生成密钥",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(domain.TOTP{}, repository.ErrTOTPNotFound)
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: ErrTwoFactorNotEnrolled,
+		},
+		{
+			name: "已经开启",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).
+					Return(domain.TOTP{UserId: 1, Secret: testTOTPSecret, Enabled: true}, nil)
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: ErrTwoFactorEnabled,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(domain.TOTP{UserId: 1, Secret: testTOTPSecret}, nil)
+				return repo
+			},
+			code:    testTOTPCode(t, 3),
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewTwoFactorService(tc.mock(ctrl), nil).(*TwoFactorService)
+			svc.nowFunc = func() time.Time {
+				return testTOTPNow
+			}
+
+			codes, err := svc.Confirm(context.Background(), 1, tc.code)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Len(t, codes, tc.wantCodes)
+		})
+	}
+}
+
+func TestTwoFactorService_VerifyChallenge(t *testing.T) {
+	step := totp.Step(testTOTPNow)
+	challenge := domain.TwoFactorChallenge{Id: "c1", UserId: 1, LoginMethod: domain.LoginByEmail}
+	enabled := domain.TOTP{UserId: 1, Secret: testTOTPSecret, Enabled: true, LastStep: step - 10}
+
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.ITwoFactorRepository
+		code    string
+		want    domain.TwoFactorChallenge
+		wantErr error
+	}{
+		{
+			name: "验证码通过",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").Return(challenge, nil)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(enabled, nil)
+				repo.EXPECT().UseStep(gomock.Any(), uint64(1), step).Return(true, nil)
+				repo.EXPECT().DeleteChallenge(gomock.Any(), "c1").Return(true, nil)
+				return repo
+			},
+			code: testTOTPCode(t, 0),
+			want: challenge,
+		},
+		{
+			name: "恢复码通过，忽略大小写",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").Return(challenge, nil)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(enabled, nil)
+				repo.EXPECT().UseRecoveryCode(gomock.Any(), uint64(1), hashRecoveryCode("abcde-fghjk")).Return(true, nil)
+				repo.EXPECT().DeleteChallenge(gomock.Any(), "c1").Return(true, nil)
+				return repo
+			},
+			code: "ABCDE-FGHJK",
+			want: challenge,
+		},
+		{
+			name: "验证码已经用过",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").Return(challenge, nil)
+				used := enabled
+				used.LastStep = step
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(used, nil)
+				repo.EXPECT().IncrChallengeAttempts(gomock.Any(), "c1").Return(1, nil)
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "错误太多次，作废",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").Return(challenge, nil)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(enabled, nil)
+				repo.EXPECT().UseRecoveryCode(gomock.Any(), uint64(1), gomock.Any()).Return(false, nil)
+				repo.EXPECT().IncrChallengeAttempts(gomock.Any(), "c1").Return(5, nil)
+				repo.EXPECT().DeleteChallenge(gomock.Any(), "c1").Return(true, nil)
+				return repo
+			},
+			code:    "wrong",
+			wantErr: ErrInvalidChallenge,
+		},
+		{
+			name: "并发使用同一个 challenge",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").Return(challenge, nil)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(enabled, nil)
+				repo.EXPECT().UseStep(gomock.Any(), uint64(1), step).Return(true, nil)
+				repo.EXPECT().DeleteChallenge(gomock.Any(), "c1").Return(false, nil)
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: ErrInvalidChallenge,
+		},
+		{
+			name: "challenge 不存在",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").
+					Return(domain.TwoFactorChallenge{}, repository.ErrChallengeNotFound)
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: ErrInvalidChallenge,
+		},
+		{
+			name: "已经被管理员重置",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").Return(challenge, nil)
+				repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(domain.TOTP{}, repository.ErrTOTPNotFound)
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: ErrInvalidChallenge,
+		},
+		{
+			name: "Redis 出错",
+			mock: func(ctrl *gomock.Controller) repository.ITwoFactorRepository {
+				repo := repomocks.NewMockITwoFactorRepository(ctrl)
+				repo.EXPECT().FindChallenge(gomock.Any(), "c1").
+					Return(domain.TwoFactorChallenge{}, errors.New("模拟错误"))
+				return repo
+			},
+			code:    testTOTPCode(t, 0),
+			wantErr: errors.New("模拟错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewTwoFactorService(tc.mock(ctrl), nil).(*TwoFactorService)
+			svc.nowFunc = func() time.Time {
+				return testTOTPNow
+			}
+
+			c, err := svc.VerifyChallenge(context.Background(), "c1", tc.code)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, c)
+		})
+	}
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockITwoFactorRepository(ctrl)
+	repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(domain.TOTP{}, repository.ErrTOTPNotFound)
+
+	svc := NewTwoFactorService(repo, nil)
+	err := svc.Disable(context.Background(), 1, "123456")
+	assert.Equal(t, ErrTwoFactorNotEnabled, err)
+}
+
+func TestTwoFactorService_Verify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockITwoFactorRepository(ctrl)
+	repo.EXPECT().FindTOTP(gomock.Any(), uint64(1)).Return(domain.TOTP{UserId: 1, Enabled: true}, nil)
+	repo.EXPECT().UseRecoveryCode(gomock.Any(), uint64(1), hashRecoveryCode("abcd-efgh")).Return(false, nil)
+
+	svc := NewTwoFactorService(repo, nil)
+	err := svc.Verify(context.Background(), 1, "abcd-efgh")
+	assert.Equal(t, ErrInvalidTwoFactorCode, err)
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	code, err := generateRecoveryCode()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+	assert.Equal(t, hashRecoveryCode(code), hashRecoveryCode(" "+code+" "))
+}
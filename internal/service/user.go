@@ -3,41 +3,108 @@ package service
 import (
 	"context"
 	"errors"
-	"github.com/shenxiang11/yellowbook-proto/proto"
+	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"log"
+	"strings"
+	"time"
 	"yellowbook/internal/domain"
 	"yellowbook/internal/repository"
 )
 
 var (
 	ErrUserDuplicate         = repository.ErrUserDuplicate
+	ErrUserNotFound          = repository.ErrUserNotFound
+	ErrLastLoginMethod       = repository.ErrLastLoginMethod
+	ErrUnknownIdentity       = repository.ErrUnknownIdentity
+	ErrMergeSameUser         = errors.New("不能合并同一个账号")
+	ErrUserBirthdayFormat    = repository.ErrUserBirthdayFormat
 	ErrInvalidUserOrPassword = errors.New("账号、邮箱或密码不正确")
 	ErrGeneratePassword      = errors.New("生成密码报错")
 )
 
+// UserBannedError 账号被封禁，Until 为零值表示永久
+type UserBannedError struct {
+	Reason string
+	Until  time.Time
+}
+
+func (e *UserBannedError) Error() string {
+	msg := "账号已被封禁"
+	if !e.Until.IsZero() {
+		msg = fmt.Sprintf("账号已被封禁至 %s", e.Until.Local().Format("2006-01-02 15:04"))
+	}
+	if e.Reason != "" {
+		msg += "，原因：" + e.Reason
+	}
+	return msg
+}
+
 type IUserService interface {
 	Login(ctx context.Context, email string, password string) (domain.User, error)
+	// LoginByPhone 手机号加密码登录，需要先通过 ResetPassword 设置过密码
+	LoginByPhone(ctx context.Context, phone string, password string) (domain.User, error)
+	// ResetPassword 调用前需要先校验验证码，手机号注册的用户第一次设置密码也用它
+	ResetPassword(ctx context.Context, phone string, password string) (domain.User, error)
+	// ResetPasswordByEmail 和 ResetPassword 一样，验证码是发到邮箱的
+	ResetPasswordByEmail(ctx context.Context, email string, password string) (domain.User, error)
+	// VerifyEmail 调用前需要先校验发到邮箱的验证码
+	VerifyEmail(ctx context.Context, email string) error
+	// ChangePassword 登录状态下修改密码，需要验证原密码
+	ChangePassword(ctx context.Context, uid uint64, oldPassword string, newPassword string) error
+	// BindPhone 调用前需要先校验验证码，已经绑定过会换成新的手机号
+	BindPhone(ctx context.Context, uid uint64, phone string) error
+	// BindEmail 调用前需要先校验发到这个邮箱的验证码，绑定以后就是已验证的状态
+	BindEmail(ctx context.Context, uid uint64, email string) error
+	// BindIdentity 同一个第三方登录只能绑定一个账号，已经绑定过会替换
+	BindIdentity(ctx context.Context, uid uint64, identity domain.ExternalIdentity) error
+	// Unbind 不能解绑最后一种可用的登录方式
+	Unbind(ctx context.Context, uid uint64, kind string) error
+	// Merge 管理后台使用，fromId 的数据归到 toId 下，fromId 会被删除
+	Merge(ctx context.Context, fromId uint64, toId uint64) error
 	SignUp(ctx context.Context, u domain.User) error
+	// EditProfile 不修改头像，用户上传走 IAvatarService，其它来源用 SetAvatar
 	EditProfile(ctx context.Context, u domain.Profile) error
+	SetAvatar(ctx context.Context, uid uint64, url string) error
 	QueryProfile(ctx context.Context, userId uint64) (domain.User, error)
+	FindByPhone(ctx context.Context, phone string) (domain.User, error)
+	FindByEmail(ctx context.Context, email string) (domain.User, error)
 	FindOrCreateByPhone(ctx context.Context, phone string) (domain.User, error)
-	FindOrCreateByGithubId(ctx context.Context, githubId uint64) (domain.User, error)
+	// FindOrCreateByIdentity 第一次登录时用第三方的昵称和头像初始化资料
+	FindOrCreateByIdentity(ctx context.Context, identity domain.ExternalIdentity) (domain.User, error)
 	CompareHashAndPassword(ctx context.Context, hashedPassword []byte, password []byte) error
 	GenerateFromPassword(ctx context.Context, password []byte) ([]byte, error)
-	QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]domain.User, int64, error)
+	QueryUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int64, error)
+	// Ban 管理后台使用，until 为零值表示永久封禁，已经签发的 token 需要调用方另外下线
+	Ban(ctx context.Context, uid uint64, until time.Time, reason string) error
+	Unban(ctx context.Context, uid uint64) error
+	// CheckBan 封禁中返回 *UserBannedError，走用户缓存
+	CheckBan(ctx context.Context, uid uint64) error
+}
+
+// PasswordPolicy 密码哈希的参数，调高 BcryptCost 以后旧密码会在登录时升级
+type PasswordPolicy struct {
+	BcryptCost int
 }
 
 type UserService struct {
 	repo                   repository.UserRepository
 	compareHashAndPassword func(hashedPassword []byte, password []byte) error
 	generateFromPassword   func(password []byte, cost int) ([]byte, error)
+	cost                   int
 }
 
-func NewUserService(repo repository.UserRepository) IUserService {
+func NewUserService(repo repository.UserRepository, policy PasswordPolicy) IUserService {
+	cost := policy.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+
 	return &UserService{
 		repo:                   repo,
 		compareHashAndPassword: bcrypt.CompareHashAndPassword,
 		generateFromPassword:   bcrypt.GenerateFromPassword,
+		cost:                   cost,
 	}
 }
 
@@ -50,11 +117,21 @@ func NewUserServiceForTest(
 		repo:                   repo,
 		compareHashAndPassword: compareHashAndPassword,
 		generateFromPassword:   generateFromPassword,
+		cost:                   bcrypt.DefaultCost,
 	}
 }
 
 func (svc *UserService) Login(ctx context.Context, email string, password string) (domain.User, error) {
 	u, err := svc.repo.FindByEmail(ctx, email)
+	return svc.checkPassword(ctx, u, err, password)
+}
+
+func (svc *UserService) LoginByPhone(ctx context.Context, phone string, password string) (domain.User, error) {
+	u, err := svc.repo.FindByPhone(ctx, phone)
+	return svc.checkPassword(ctx, u, err, password)
+}
+
+func (svc *UserService) checkPassword(ctx context.Context, u domain.User, err error, password string) (domain.User, error) {
 	if errors.Is(err, repository.ErrUserNotFound) {
 		return domain.User{}, ErrInvalidUserOrPassword
 	}
@@ -67,9 +144,130 @@ func (svc *UserService) Login(ctx context.Context, email string, password string
 		return domain.User{}, ErrInvalidUserOrPassword
 	}
 
+	// 只有登录时能拿到明文密码，借这个机会升级旧的哈希
+	if svc.needsRehash(u.Password) {
+		hash, err := svc.GenerateFromPassword(ctx, []byte(password))
+		if err == nil {
+			err = svc.repo.UpdatePassword(ctx, u.Id, string(hash))
+		}
+		if err != nil {
+			log.Printf("升级密码哈希失败：%v\n", err)
+		} else {
+			u.Password = string(hash)
+		}
+	}
+
+	return u, nil
+}
+
+// needsRehash 哈希自带算法标识（bcrypt 是 $2a$ 这类前缀，argon2id 是 $argon2id$），
+// 以后换算法也按前缀判断，不认识的格式不处理
+func (svc *UserService) needsRehash(hash string) bool {
+	if !strings.HasPrefix(hash, "$2") {
+		return false
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < svc.cost
+}
+
+func (svc *UserService) ChangePassword(ctx context.Context, uid uint64, oldPassword string, newPassword string) error {
+	u, err := svc.repo.QueryProfile(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	// 没有设置过密码的用户需要走重置密码
+	if u.Password == "" || svc.CompareHashAndPassword(ctx, []byte(u.Password), []byte(oldPassword)) != nil {
+		return ErrInvalidUserOrPassword
+	}
+
+	hash, err := svc.GenerateFromPassword(ctx, []byte(newPassword))
+	if err != nil {
+		return ErrGeneratePassword
+	}
+
+	return svc.repo.UpdatePassword(ctx, uid, string(hash))
+}
+
+func (svc *UserService) ResetPassword(ctx context.Context, phone string, password string) (domain.User, error) {
+	u, err := svc.repo.FindByPhone(ctx, phone)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return svc.resetPassword(ctx, u, password)
+}
+
+func (svc *UserService) ResetPasswordByEmail(ctx context.Context, email string, password string) (domain.User, error) {
+	u, err := svc.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return svc.resetPassword(ctx, u, password)
+}
+
+func (svc *UserService) resetPassword(ctx context.Context, u domain.User, password string) (domain.User, error) {
+	hash, err := svc.GenerateFromPassword(ctx, []byte(password))
+	if err != nil {
+		return domain.User{}, ErrGeneratePassword
+	}
+
+	if err = svc.repo.UpdatePassword(ctx, u.Id, string(hash)); err != nil {
+		return domain.User{}, err
+	}
+	u.Password = string(hash)
 	return u, nil
 }
 
+func (svc *UserService) VerifyEmail(ctx context.Context, email string) error {
+	return svc.repo.VerifyEmail(ctx, email)
+}
+
+func (svc *UserService) BindPhone(ctx context.Context, uid uint64, phone string) error {
+	return svc.repo.BindPhone(ctx, uid, phone)
+}
+
+func (svc *UserService) BindEmail(ctx context.Context, uid uint64, email string) error {
+	return svc.repo.BindEmail(ctx, uid, email)
+}
+
+func (svc *UserService) BindIdentity(ctx context.Context, uid uint64, identity domain.ExternalIdentity) error {
+	return svc.repo.BindIdentity(ctx, uid, identity)
+}
+
+func (svc *UserService) Unbind(ctx context.Context, uid uint64, kind string) error {
+	return svc.repo.Unbind(ctx, uid, kind)
+}
+
+func (svc *UserService) Merge(ctx context.Context, fromId uint64, toId uint64) error {
+	if fromId == toId {
+		return ErrMergeSameUser
+	}
+	return svc.repo.Merge(ctx, fromId, toId)
+}
+
+func (svc *UserService) Ban(ctx context.Context, uid uint64, until time.Time, reason string) error {
+	return svc.repo.Ban(ctx, uid, until, reason)
+}
+
+func (svc *UserService) Unban(ctx context.Context, uid uint64) error {
+	return svc.repo.Unban(ctx, uid)
+}
+
+func (svc *UserService) CheckBan(ctx context.Context, uid uint64) error {
+	u, err := svc.repo.QueryProfile(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.BannedAt(time.Now()) {
+		return &UserBannedError{
+			Reason: u.BanReason,
+			Until:  u.BanUntil,
+		}
+	}
+	return nil
+}
+
 func (svc *UserService) SignUp(ctx context.Context, u domain.User) error {
 	hash, err := svc.GenerateFromPassword(ctx, []byte(u.Password))
 	if err != nil {
@@ -77,17 +275,30 @@ func (svc *UserService) SignUp(ctx context.Context, u domain.User) error {
 	}
 	u.Password = string(hash)
 
-	return svc.repo.Create(ctx, u)
+	_, err = svc.repo.Create(ctx, u)
+	return err
 }
 
 func (svc *UserService) EditProfile(ctx context.Context, u domain.Profile) error {
 	return svc.repo.UpdateProfile(ctx, u)
 }
 
+func (svc *UserService) SetAvatar(ctx context.Context, uid uint64, url string) error {
+	return svc.repo.SetAvatar(ctx, uid, url)
+}
+
 func (svc *UserService) QueryProfile(ctx context.Context, userId uint64) (domain.User, error) {
 	return svc.repo.QueryProfile(ctx, userId)
 }
 
+func (svc *UserService) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
+	return svc.repo.FindByPhone(ctx, phone)
+}
+
+func (svc *UserService) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	return svc.repo.FindByEmail(ctx, email)
+}
+
 func (svc *UserService) FindOrCreateByPhone(ctx context.Context, phone string) (domain.User, error) {
 	u, err := svc.repo.FindByPhone(ctx, phone)
 	if !errors.Is(err, repository.ErrUserNotFound) {
@@ -95,7 +306,7 @@ func (svc *UserService) FindOrCreateByPhone(ctx context.Context, phone string) (
 	}
 
 	u = domain.User{Phone: phone}
-	err = svc.repo.Create(ctx, u)
+	_, err = svc.repo.Create(ctx, u)
 	if err != nil && !errors.Is(err, repository.ErrUserDuplicate) {
 		return domain.User{}, err
 	}
@@ -103,19 +314,36 @@ func (svc *UserService) FindOrCreateByPhone(ctx context.Context, phone string) (
 	return svc.repo.FindByPhone(ctx, phone)
 }
 
-func (svc *UserService) FindOrCreateByGithubId(ctx context.Context, githubId uint64) (domain.User, error) {
-	u, err := svc.repo.FindByGithubId(ctx, githubId)
+func (svc *UserService) FindOrCreateByIdentity(ctx context.Context, identity domain.ExternalIdentity) (domain.User, error) {
+	u, err := svc.repo.FindByIdentity(ctx, identity.Provider, identity.Subject)
 	if !errors.Is(err, repository.ErrUserNotFound) {
 		return u, err
 	}
 
-	u = domain.User{GithubId: githubId}
-	err = svc.repo.Create(ctx, u)
+	id, err := svc.repo.CreateWithIdentity(ctx, domain.User{}, identity)
 	if err != nil && !errors.Is(err, repository.ErrUserDuplicate) {
 		return domain.User{}, err
 	}
 
-	return svc.repo.FindByGithubId(ctx, githubId)
+	// 并发登录时可能是别的请求创建的，只有自己创建的才初始化资料
+	if err == nil {
+		nickname := identity.Name
+		if nickname == "" {
+			nickname = identity.Login
+		}
+		err = svc.repo.UpdateProfile(ctx, domain.Profile{
+			UserId:   id,
+			Nickname: nickname,
+		})
+		if err == nil && identity.AvatarUrl != "" {
+			err = svc.repo.SetAvatar(ctx, id, identity.AvatarUrl)
+		}
+		if err != nil {
+			log.Printf("初始化 %s 用户资料失败：%v\n", identity.Provider, err)
+		}
+	}
+
+	return svc.repo.FindByIdentity(ctx, identity.Provider, identity.Subject)
 }
 
 func (svc *UserService) CompareHashAndPassword(ctx context.Context, hashedPassword []byte, password []byte) error {
@@ -123,9 +351,9 @@ func (svc *UserService) CompareHashAndPassword(ctx context.Context, hashedPasswo
 }
 
 func (svc *UserService) GenerateFromPassword(ctx context.Context, password []byte) ([]byte, error) {
-	return svc.generateFromPassword(password, bcrypt.DefaultCost)
+	return svc.generateFromPassword(password, svc.cost)
 }
 
-func (svc *UserService) QueryUsers(ctx context.Context, filter *proto.GetUserListRequest) ([]domain.User, int64, error) {
+func (svc *UserService) QueryUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int64, error) {
 	return svc.repo.QueryUsers(ctx, filter)
 }
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
 	"testing"
+	"time"
 	"yellowbook/internal/domain"
 	"yellowbook/internal/repository"
 	repomocks "yellowbook/internal/repository/mocks"
@@ -107,7 +108,7 @@ func TestUserService_Login(t *testing.T) {
 			var svc IUserService
 
 			if tc.compareHashAndPasswordErr != nil {
-				svc = NewUserService(repo)
+				svc = NewUserService(repo, PasswordPolicy{})
 			} else {
 				svc = NewUserServiceForTest(repo, func(hashedPassword []byte, password []byte) error {
 					return nil
@@ -152,7 +153,7 @@ func TestUserService_QueryProfile(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := tc.mock(ctrl)
-			svc := NewUserService(repo)
+			svc := NewUserService(repo, PasswordPolicy{})
 
 			user, err := svc.QueryProfile(tc.ctx, tc.userId)
 			assert.Equal(t, err, tc.wantErr)
@@ -188,7 +189,7 @@ func TestUserService_EditProfile(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := tc.mock(ctrl)
-			svc := NewUserService(repo)
+			svc := NewUserService(repo, PasswordPolicy{})
 
 			err := svc.EditProfile(tc.ctx, tc.profile)
 			assert.Equal(t, err, tc.wantErr)
@@ -231,7 +232,7 @@ func TestUserService_CompareHashAndPassword(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := tc.mock(ctrl)
-			svc := NewUserService(repo)
+			svc := NewUserService(repo, PasswordPolicy{})
 
 			err := svc.CompareHashAndPassword(context.Background(), tc.hash, tc.password)
 			assert.Equal(t, err, tc.wantErr)
@@ -239,6 +240,147 @@ func TestUserService_CompareHashAndPassword(t *testing.T) {
 	}
 }
 
+func TestUserService_LoginRehash(t *testing.T) {
+	const (
+		password = "hello#world@123"
+		// cost 为 10 的旧哈希
+		oldHash = "$2a$10$Rpn7CTskQtFCovsAjox7SOYUpzQA9Z29oLs7LIO/6YOPN90dr.EV2"
+	)
+
+	testCases := []struct {
+		name     string
+		cost     int
+		mock     func(ctrl *gomock.Controller) (repository.UserRepository, *string)
+		wantCost int
+	}{
+		{
+			name: "cost 低于配置，登录时升级",
+			cost: 11,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, *string) {
+				var saved string
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").
+					Return(domain.User{Id: 1, Password: oldHash}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id uint64, hash string) error {
+						saved = hash
+						return nil
+					})
+				return repo, &saved
+			},
+			wantCost: 11,
+		},
+		{
+			name: "cost 已经达到配置，不升级",
+			cost: 10,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, *string) {
+				saved := oldHash
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").
+					Return(domain.User{Id: 1, Password: oldHash}, nil)
+				return repo, &saved
+			},
+			wantCost: 10,
+		},
+		{
+			name: "升级失败不影响登录",
+			cost: 11,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, *string) {
+				saved := oldHash
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").
+					Return(domain.User{Id: 1, Password: oldHash}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), gomock.Any()).
+					Return(errors.New("模拟错误"))
+				return repo, &saved
+			},
+			wantCost: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, saved := tc.mock(ctrl)
+			svc := NewUserService(repo, PasswordPolicy{BcryptCost: tc.cost})
+
+			user, err := svc.Login(context.Background(), "a@qq.com", password)
+			require.NoError(t, err)
+			assert.Equal(t, *saved, user.Password)
+
+			cost, err := bcrypt.Cost([]byte(user.Password))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCost, cost)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)))
+		})
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) repository.UserRepository
+		compareFn func(hashedPassword []byte, password []byte) error
+		wantErr   error
+	}{
+		{
+			name: "修改成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).
+					Return(domain.User{Id: 1, Password: "old"}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(nil)
+				return repo
+			},
+			compareFn: func(hashedPassword []byte, password []byte) error {
+				return nil
+			},
+		},
+		{
+			name: "原密码错误",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).
+					Return(domain.User{Id: 1, Password: "old"}, nil)
+				return repo
+			},
+			compareFn: func(hashedPassword []byte, password []byte) error {
+				return bcrypt.ErrMismatchedHashAndPassword
+			},
+			wantErr: ErrInvalidUserOrPassword,
+		},
+		{
+			name: "还没有设置过密码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).
+					Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+			compareFn: func(hashedPassword []byte, password []byte) error {
+				return nil
+			},
+			wantErr: ErrInvalidUserOrPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserServiceForTest(tc.mock(ctrl), tc.compareFn, func(password []byte, cost int) ([]byte, error) {
+				return []byte("hash"), nil
+			})
+
+			err := svc.ChangePassword(context.Background(), 1, "old#password1", "new#password1")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
 func TestUserService_GenerateFromPassword(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -293,7 +435,7 @@ func TestUserService_SignUp(t *testing.T) {
 				repo := repomocks.NewMockUserRepository(ctrl)
 
 				repo.EXPECT().Create(context.Background(), gomock.Any()).
-					Return(nil)
+					Return(uint64(1), nil)
 
 				return repo
 			},
@@ -347,6 +489,182 @@ func TestUserService_SignUp(t *testing.T) {
 	}
 }
 
+func TestUserService_LoginByPhone(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) repository.UserRepository
+		compareFn func(hashedPassword []byte, password []byte) error
+		wantErr   error
+		wantUser  domain.User
+	}{
+		{
+			name: "登录成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{Id: 1, Phone: "13800000000", Password: "hash"}, nil)
+				return repo
+			},
+			compareFn: func(hashedPassword []byte, password []byte) error {
+				return nil
+			},
+			wantUser: domain.User{Id: 1, Phone: "13800000000", Password: "hash"},
+		},
+		{
+			name: "还没有设置密码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{Id: 1, Phone: "13800000000"}, nil)
+				return repo
+			},
+			compareFn: bcrypt.CompareHashAndPassword,
+			wantErr:   ErrInvalidUserOrPassword,
+		},
+		{
+			name: "手机号不存在",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				return repo
+			},
+			compareFn: bcrypt.CompareHashAndPassword,
+			wantErr:   ErrInvalidUserOrPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserServiceForTest(tc.mock(ctrl), tc.compareFn, bcrypt.GenerateFromPassword)
+
+			user, err := svc.LoginByPhone(context.Background(), "13800000000", "hello#world123")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mock       func(ctrl *gomock.Controller) repository.UserRepository
+		generateFn func(password []byte, cost int) ([]byte, error)
+		wantErr    error
+		wantUser   domain.User
+	}{
+		{
+			name: "手机号用户第一次设置密码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{Id: 1, Phone: "13800000000"}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(nil)
+				return repo
+			},
+			generateFn: func(password []byte, cost int) ([]byte, error) {
+				return []byte("hash"), nil
+			},
+			wantUser: domain.User{Id: 1, Phone: "13800000000", Password: "hash"},
+		},
+		{
+			name: "手机号不存在",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				return repo
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "生成密码错误",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{Id: 1, Phone: "13800000000"}, nil)
+				return repo
+			},
+			generateFn: func(password []byte, cost int) ([]byte, error) {
+				return nil, errors.New("模拟错误")
+			},
+			wantErr: ErrGeneratePassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserServiceForTest(tc.mock(ctrl), bcrypt.CompareHashAndPassword, tc.generateFn)
+
+			user, err := svc.ResetPassword(context.Background(), "13800000000", "hello#world123")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
+
+func TestUserService_Merge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().Merge(gomock.Any(), uint64(2), uint64(1)).Return(nil)
+
+	svc := NewUserService(repo, PasswordPolicy{})
+	assert.NoError(t, svc.Merge(context.Background(), 2, 1))
+	assert.Equal(t, ErrMergeSameUser, svc.Merge(context.Background(), 1, 1))
+}
+
+func TestUserService_CheckBan(t *testing.T) {
+	testCases := []struct {
+		name    string
+		user    domain.User
+		wantErr error
+	}{
+		{
+			name: "没有封禁",
+			user: domain.User{Id: 1},
+		},
+		{
+			name:    "永久封禁",
+			user:    domain.User{Id: 1, Banned: true, BanReason: "发布广告"},
+			wantErr: &UserBannedError{Reason: "发布广告"},
+		},
+		{
+			name: "临时封禁中",
+			user: domain.User{Id: 1, Banned: true, BanUntil: time.UnixMilli(4102444800000), BanReason: "发布广告"},
+			wantErr: &UserBannedError{
+				Reason: "发布广告",
+				Until:  time.UnixMilli(4102444800000),
+			},
+		},
+		{
+			name: "临时封禁已经到期",
+			user: domain.User{Id: 1, Banned: true, BanUntil: time.UnixMilli(1694575373000), BanReason: "发布广告"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUserRepository(ctrl)
+			repo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(tc.user, nil)
+			svc := NewUserService(repo, PasswordPolicy{})
+
+			err := svc.CheckBan(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
 func TestUserService_FindOrCreate(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -373,7 +691,7 @@ func TestUserService_FindOrCreate(t *testing.T) {
 				repo.EXPECT().FindByPhone(gomock.Any(), gomock.Any()).
 					Return(domain.User{}, repository.ErrUserNotFound)
 				repo.EXPECT().Create(gomock.Any(), gomock.Any()).
-					Return(errors.New("模拟错误"))
+					Return(uint64(0), errors.New("模拟错误"))
 				return repo
 			},
 			phone:   "13800000000",
@@ -386,7 +704,7 @@ func TestUserService_FindOrCreate(t *testing.T) {
 				repo.EXPECT().FindByPhone(gomock.Any(), gomock.Any()).
 					Return(domain.User{}, repository.ErrUserNotFound)
 				repo.EXPECT().Create(gomock.Any(), gomock.Any()).
-					Return(nil)
+					Return(uint64(1), nil)
 				repo.EXPECT().FindByPhone(gomock.Any(), gomock.Any()).
 					Return(domain.User{
 						Id:       1,
@@ -413,7 +731,7 @@ func TestUserService_FindOrCreate(t *testing.T) {
 
 			repo := tc.mock(ctrl)
 
-			svc := NewUserService(repo)
+			svc := NewUserService(repo, PasswordPolicy{})
 
 			user, err := svc.FindOrCreateByPhone(context.Background(), tc.phone)
 			assert.Equal(t, err, tc.wantErr)
@@ -422,6 +740,72 @@ func TestUserService_FindOrCreate(t *testing.T) {
 	}
 }
 
+func TestUserService_FindOrCreateByIdentity(t *testing.T) {
+	identity := domain.ExternalIdentity{
+		Provider:  "github",
+		Subject:   "1",
+		Login:     "octocat",
+		AvatarUrl: "https://avatars/1",
+	}
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.UserRepository
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "已经登录过",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByIdentity(gomock.Any(), "github", "1").Return(domain.User{Id: 1}, nil)
+				return repo
+			},
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "第一次登录，用登录名做昵称",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByIdentity(gomock.Any(), "github", "1").Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().CreateWithIdentity(gomock.Any(), domain.User{}, identity).Return(uint64(2), nil)
+				repo.EXPECT().UpdateProfile(gomock.Any(), domain.Profile{
+					UserId:   2,
+					Nickname: "octocat",
+				}).Return(nil)
+				repo.EXPECT().SetAvatar(gomock.Any(), uint64(2), "https://avatars/1").Return(nil)
+				repo.EXPECT().FindByIdentity(gomock.Any(), "github", "1").Return(domain.User{Id: 2}, nil)
+				return repo
+			},
+			wantUser: domain.User{Id: 2},
+		},
+		{
+			name: "并发登录，别的请求已经创建",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByIdentity(gomock.Any(), "github", "1").Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().CreateWithIdentity(gomock.Any(), domain.User{}, identity).Return(uint64(0), repository.ErrUserDuplicate)
+				repo.EXPECT().FindByIdentity(gomock.Any(), "github", "1").Return(domain.User{Id: 3}, nil)
+				return repo
+			},
+			wantUser: domain.User{Id: 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserService(tc.mock(ctrl), PasswordPolicy{})
+
+			user, err := svc.FindOrCreateByIdentity(context.Background(), identity)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
+
 func TestUserService_QueryUsers(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -455,9 +839,9 @@ func TestUserService_QueryUsers(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := tc.mock(ctrl)
-			svc := NewUserService(repo)
+			svc := NewUserService(repo, PasswordPolicy{})
 
-			users, total, err := svc.QueryUsers(context.Background(), nil)
+			users, total, err := svc.QueryUsers(context.Background(), domain.UserFilter{})
 			assert.Equal(t, err, tc.wantErr)
 			assert.Equal(t, total, tc.wantTotal)
 			assert.Equal(t, users, tc.wantUsers)
@@ -0,0 +1,51 @@
+package spider
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/service"
+	"yellowbook/pkg/logger"
+)
+
+type ArticleMessage struct {
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	ImageList []string `json:"imageList"`
+}
+
+func (m ArticleMessage) Validate() error {
+	if m.Title == "" || m.Content == "" {
+		return errors.New("标题和内容不能为空")
+	}
+	if utf8.RuneCountInString(m.Title) > 128 {
+		return errors.New("标题过长")
+	}
+	return nil
+}
+
+// NewArticleHandler 爬取的文章统一挂在 authorId 名下
+func NewArticleHandler(srv service.IArticleService, rehostSvc service.IImageRehostService, authorId uint64, l logger.Logger) Handler {
+	return JSONHandler(func(ctx context.Context, msg ArticleMessage) error {
+		aid, err := srv.Save(ctx, domain.Article{
+			Title:     msg.Title,
+			Content:   msg.Content,
+			ImageList: msg.ImageList,
+			Author: domain.Author{
+				Id: authorId,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		// 第三方图片经常失效或者防盗链，异步转存到自己的 OSS
+		// 文章已经保存了，这里失败不能重试，否则会重复创建文章
+		err = rehostSvc.Enqueue(ctx, aid, msg.ImageList)
+		if err != nil {
+			l.Error("图片转存任务创建失败", logger.Field{Key: "article_id", Value: aid})
+		}
+		return nil
+	})
+}
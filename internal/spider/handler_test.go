@@ -0,0 +1,121 @@
+package spider
+
+import (
+	"context"
+	"errors"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/pkg/imagefetcher"
+	svcmocks "yellowbook/internal/service/mocks"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/mq"
+)
+
+func TestArticleHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	articleSvc := svcmocks.NewMockIArticleService(ctrl)
+	articleSvc.EXPECT().Save(gomock.Any(), domain.Article{
+		Title:     "标题",
+		Content:   "内容",
+		ImageList: []string{"http://cdn/a.png"},
+		Author:    domain.Author{Id: 1},
+	}).Return(uint64(7), nil)
+
+	rehostSvc := svcmocks.NewMockIImageRehostService(ctrl)
+	// 转存任务创建失败不影响文章
+	rehostSvc.EXPECT().Enqueue(gomock.Any(), uint64(7), []string{"http://cdn/a.png"}).Return(errors.New("db down"))
+
+	h := NewArticleHandler(articleSvc, rehostSvc, 1, logger.NewZapLogger(zap.NewNop()))
+	err := h.Handle(context.Background(), mq.Message{
+		Value: []byte(`{"title":"标题","content":"内容","imageList":["http://cdn/a.png"]}`),
+	})
+	assert.NoError(t, err)
+}
+
+func TestUserImportHandler(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		mock    func(svc *svcmocks.MockIUserService)
+		wantErr error
+	}{
+		{
+			name:  "新用户写入资料",
+			value: `{"phone":"13800000000","nickname":"小黄","gender":1}`,
+			mock: func(svc *svcmocks.MockIUserService) {
+				svc.EXPECT().FindOrCreateByPhone(gomock.Any(), "13800000000").Return(domain.User{Id: 3}, nil)
+				svc.EXPECT().QueryProfile(gomock.Any(), uint64(3)).Return(domain.User{Id: 3}, nil)
+				svc.EXPECT().EditProfile(gomock.Any(), domain.Profile{
+					UserId:   3,
+					Nickname: "小黄",
+					Gender:   proto.Gender(1),
+				}).Return(nil)
+			},
+		},
+		{
+			name:  "带头像的单独设置头像",
+			value: `{"phone":"13800000000","nickname":"小黄","avatar":"http://cdn/a.png"}`,
+			mock: func(svc *svcmocks.MockIUserService) {
+				svc.EXPECT().FindOrCreateByPhone(gomock.Any(), "13800000000").Return(domain.User{Id: 3}, nil)
+				svc.EXPECT().QueryProfile(gomock.Any(), uint64(3)).Return(domain.User{Id: 3}, nil)
+				svc.EXPECT().EditProfile(gomock.Any(), domain.Profile{
+					UserId:   3,
+					Nickname: "小黄",
+				}).Return(nil)
+				svc.EXPECT().SetAvatar(gomock.Any(), uint64(3), "http://cdn/a.png").Return(nil)
+			},
+		},
+		{
+			name:  "已有资料不覆盖",
+			value: `{"phone":"13800000000","nickname":"小黄"}`,
+			mock: func(svc *svcmocks.MockIUserService) {
+				svc.EXPECT().FindOrCreateByPhone(gomock.Any(), "13800000000").Return(domain.User{Id: 3}, nil)
+				svc.EXPECT().QueryProfile(gomock.Any(), uint64(3)).Return(domain.User{Id: 3, Profile: &domain.Profile{UserId: 3}}, nil)
+			},
+		},
+		{
+			name:    "手机号不合法",
+			value:   `{"phone":"123"}`,
+			mock:    func(svc *svcmocks.MockIUserService) {},
+			wantErr: ErrInvalidMessage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := svcmocks.NewMockIUserService(ctrl)
+			tc.mock(svc)
+
+			err := NewUserImportHandler(svc).Handle(context.Background(), mq.Message{Value: []byte(tc.value)})
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestResourceImportHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := svcmocks.NewMockIResourceService(ctrl)
+	svc.EXPECT().Import(gomock.Any(), "http://cdn/a.png", proto.ResourcePurpose_UserContent, uint64(9)).
+		Return("", imagefetcher.ErrUnsupportedType)
+
+	err := NewResourceImportHandler(svc).Handle(context.Background(), mq.Message{
+		Value: []byte(`{"url":"http://cdn/a.png","purpose":2,"uploadUserId":9}`),
+	})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+
+	err = NewResourceImportHandler(svc).Handle(context.Background(), mq.Message{
+		Value: []byte(`{"url":"ftp://cdn/a.png","purpose":2,"uploadUserId":9}`),
+	})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
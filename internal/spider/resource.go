@@ -0,0 +1,41 @@
+package spider
+
+import (
+	"context"
+	"errors"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"net/url"
+	"yellowbook/internal/pkg/imagefetcher"
+	"yellowbook/internal/service"
+)
+
+type ResourceImportMessage struct {
+	Url          string `json:"url"`
+	Purpose      int32  `json:"purpose"`
+	UploadUserId uint64 `json:"uploadUserId"`
+}
+
+func (m ResourceImportMessage) Validate() error {
+	u, err := url.Parse(m.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("资源地址不正确")
+	}
+	if _, ok := proto.ResourcePurpose_name[m.Purpose]; !ok || m.Purpose == 0 {
+		return errors.New("资源用途不正确")
+	}
+	if m.UploadUserId == 0 {
+		return errors.New("缺少上传用户")
+	}
+	return nil
+}
+
+func NewResourceImportHandler(srv service.IResourceService) Handler {
+	return JSONHandler(func(ctx context.Context, msg ResourceImportMessage) error {
+		_, err := srv.Import(ctx, msg.Url, proto.ResourcePurpose(msg.Purpose), msg.UploadUserId)
+		// 图片不存在、类型不支持之类的错误重试也没用
+		if imagefetcher.IsPermanent(err) {
+			return errors.Join(ErrInvalidMessage, err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,317 @@
+package spider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/mq"
+)
+
+const defaultGroup = "yellowbook_spider"
+
+// Router 按 topic 把消息分发给注册的 Handler，每个 topic 独立消费
+type Router struct {
+	newConsumer ConsumerFactory
+	producer    mq.Producer
+	throttle    *Throttle
+	l           logger.Logger
+	routes      []Route
+	backoff     time.Duration
+
+	adjustInterval time.Duration
+	reportInterval time.Duration
+
+	mu        sync.Mutex
+	consumers map[string]mq.Consumer
+}
+
+func NewRouter(newConsumer ConsumerFactory, producer mq.Producer, throttle *Throttle, l logger.Logger) *Router {
+	return &Router{
+		newConsumer:    newConsumer,
+		producer:       producer,
+		throttle:       throttle,
+		l:              l,
+		backoff:        time.Second,
+		adjustInterval: time.Second,
+		reportInterval: time.Second * 30,
+		consumers:      map[string]mq.Consumer{},
+	}
+}
+
+// Register 需要在 Run 之前调用，同一个 topic 只能注册一次
+func (r *Router) Register(route Route) {
+	if route.Topic == "" || route.Handler == nil {
+		panic("spider: route 缺少 topic 或 handler")
+	}
+	for _, rt := range r.routes {
+		if rt.Topic == route.Topic {
+			panic(fmt.Sprintf("spider: topic %s 重复注册", route.Topic))
+		}
+	}
+
+	if route.Group == "" {
+		route.Group = defaultGroup
+	}
+	if route.Concurrency <= 0 {
+		route.Concurrency = 1
+	}
+
+	r.routes = append(r.routes, route)
+}
+
+func (r *Router) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.monitor(ctx)
+	}()
+
+	for _, route := range r.routes {
+		wg.Add(1)
+		go func(route Route) {
+			defer wg.Done()
+			r.consume(ctx, route)
+		}(route)
+	}
+	wg.Wait()
+}
+
+func (r *Router) consume(ctx context.Context, route Route) {
+	consumer := r.newConsumer(route.Topic, route.Group)
+	defer consumer.Close()
+
+	r.mu.Lock()
+	r.consumers[route.Topic] = consumer
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.consumers, route.Topic)
+		r.mu.Unlock()
+	}()
+
+	tracker := newOffsetTracker()
+	sem := make(chan struct{}, route.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		if err := r.throttle.Wait(ctx); err != nil {
+			<-sem
+			return
+		}
+
+		m, err := consumer.Fetch(ctx)
+		if err != nil {
+			<-sem
+			if ctx.Err() != nil || errors.Is(err, mq.ErrClosed) {
+				return
+			}
+			r.l.Error("读取爬虫消息失败",
+				logger.Field{Key: "topic", Value: route.Topic},
+				logger.Field{Key: "error", Value: err.Error()},
+			)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		e := tracker.add(m)
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if !r.process(ctx, route, m) {
+				return
+			}
+
+			// 并发处理时只提交连续处理完的部分，保证重启后不会漏消息
+			tracker.complete(e, func(last mq.Message) {
+				if err := consumer.Commit(ctx, last); err != nil {
+					r.l.Error("提交爬虫消息失败",
+						logger.Field{Key: "topic", Value: route.Topic},
+						logger.Field{Key: "offset", Value: last.Offset},
+					)
+				}
+			})
+		}()
+	}
+}
+
+// monitor 定期根据数据库状态调整速率，并打印各 topic 的积压
+func (r *Router) monitor(ctx context.Context) {
+	adjust := time.NewTicker(r.adjustInterval)
+	defer adjust.Stop()
+	report := time.NewTicker(r.reportInterval)
+	defer report.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-adjust.C:
+			r.throttle.Adjust()
+		case <-report.C:
+			for topic, lag := range r.lags() {
+				r.l.Info("爬虫消费积压",
+					logger.Field{Key: "topic", Value: topic},
+					logger.Field{Key: "lag", Value: lag},
+				)
+			}
+		}
+	}
+}
+
+func (r *Router) lags() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lags := make(map[string]int64, len(r.consumers))
+	for topic, c := range r.consumers {
+		lags[topic] = c.Lag()
+	}
+	return lags
+}
+
+func (r *Router) Pause() {
+	r.throttle.Pause()
+}
+
+func (r *Router) Resume() {
+	r.throttle.Resume()
+}
+
+type Status struct {
+	ThrottleStatus
+	Lags map[string]int64 `json:"lags"`
+}
+
+func (r *Router) Status() Status {
+	return Status{
+		ThrottleStatus: r.throttle.Status(),
+		Lags:           r.lags(),
+	}
+}
+
+// process 返回 false 表示消息没有处理完（程序正在退出），不能提交
+func (r *Router) process(ctx context.Context, route Route, m mq.Message) bool {
+	err := route.Handler.Handle(ctx, m)
+
+	retryable := route.Policy != SkipOnError && !errors.Is(err, ErrInvalidMessage)
+	for attempt := 0; err != nil && retryable && attempt < route.MaxRetry; attempt++ {
+		if !r.sleep(ctx, r.backoff<<attempt) {
+			return false
+		}
+		err = route.Handler.Handle(ctx, m)
+	}
+
+	if err == nil {
+		return true
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	r.l.Warn("爬虫消息处理失败",
+		logger.Field{Key: "topic", Value: route.Topic},
+		logger.Field{Key: "offset", Value: m.Offset},
+		logger.Field{Key: "error", Value: err.Error()},
+	)
+
+	if route.Policy == DeadLetterOnError {
+		return r.deadLetter(ctx, route, m)
+	}
+	return true
+}
+
+// deadLetter 死信投递失败会一直重试，不能把消息丢掉
+func (r *Router) deadLetter(ctx context.Context, route Route, m mq.Message) bool {
+	dl := mq.Message{
+		Topic: DeadLetterTopic(route.Topic),
+		Key:   m.Key,
+		Value: m.Value,
+	}
+
+	for {
+		err := r.producer.Produce(ctx, dl)
+		if err == nil {
+			return true
+		}
+
+		r.l.Error("死信投递失败",
+			logger.Field{Key: "topic", Value: dl.Topic},
+			logger.Field{Key: "offset", Value: m.Offset},
+			logger.Field{Key: "error", Value: err.Error()},
+		)
+		if !r.sleep(ctx, r.backoff) {
+			return false
+		}
+	}
+}
+
+func (r *Router) sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+type pendingMessage struct {
+	m    mq.Message
+	done bool
+}
+
+// offsetTracker 按分区记录正在处理的消息
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending map[int][]*pendingMessage
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{pending: map[int][]*pendingMessage{}}
+}
+
+func (t *offsetTracker) add(m mq.Message) *pendingMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e := &pendingMessage{m: m}
+	t.pending[m.Partition] = append(t.pending[m.Partition], e)
+	return e
+}
+
+// complete 在锁里提交，避免较小的 offset 覆盖已经提交的较大 offset
+func (t *offsetTracker) complete(e *pendingMessage, commit func(last mq.Message)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e.done = true
+	list := t.pending[e.m.Partition]
+	i := 0
+	for i < len(list) && list[i].done {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+
+	t.pending[e.m.Partition] = list[i:]
+	commit(list[i-1].m)
+}
@@ -0,0 +1,181 @@
+package spider
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/mq"
+	"yellowbook/pkg/mq/memory"
+)
+
+func newTestRouter(b *memory.Broker) *Router {
+	r := NewRouter(b.Consumer, b.Producer(), NewThrottle(0, 0, nil), logger.NewZapLogger(zap.NewNop()))
+	r.backoff = time.Millisecond
+	return r
+}
+
+func produce(t *testing.T, b *memory.Broker, topic string, values ...string) {
+	msgs := make([]mq.Message, 0, len(values))
+	for _, v := range values {
+		msgs = append(msgs, mq.Message{Topic: topic, Value: []byte(v)})
+	}
+	require.NoError(t, b.Producer().Produce(context.Background(), msgs...))
+}
+
+// assertDrained 同一个 group 的新消费者读不到消息，说明都已经提交
+func assertDrained(t *testing.T, b *memory.Broker, topic string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := b.Consumer(topic, defaultGroup).Fetch(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRouter_Route(t *testing.T) {
+	b := memory.NewBroker()
+	produce(t, b, "a", "a1", "a2")
+	produce(t, b, "b", "b1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	got := map[string][]string{}
+	var remaining atomic.Int32
+	remaining.Store(3)
+	record := func(topic string) Handler {
+		return HandlerFunc(func(ctx context.Context, m mq.Message) error {
+			mu.Lock()
+			got[topic] = append(got[topic], string(m.Value))
+			mu.Unlock()
+			if remaining.Add(-1) == 0 {
+				cancel()
+			}
+			return nil
+		})
+	}
+
+	r := newTestRouter(b)
+	r.Register(Route{Topic: "a", Handler: record("a")})
+	r.Register(Route{Topic: "b", Handler: record("b")})
+	r.Run(ctx)
+
+	assert.Equal(t, map[string][]string{"a": {"a1", "a2"}, "b": {"b1"}}, got)
+	assertDrained(t, b, "a")
+	assertDrained(t, b, "b")
+}
+
+func TestRouter_RegisterDuplicate(t *testing.T) {
+	r := newTestRouter(memory.NewBroker())
+	r.Register(Route{Topic: "a", Handler: HandlerFunc(nil)})
+	assert.Panics(t, func() {
+		r.Register(Route{Topic: "a", Handler: HandlerFunc(nil)})
+	})
+}
+
+func TestRouter_ErrorPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		policy    ErrorPolicy
+		err       error
+		wantCalls int32
+		wantDead  bool
+	}{
+		{name: "跳过", policy: SkipOnError, err: errors.New("db down"), wantCalls: 1},
+		{name: "重试后跳过", policy: RetryOnError, err: errors.New("db down"), wantCalls: 3},
+		{name: "重试后进入死信", policy: DeadLetterOnError, err: errors.New("db down"), wantCalls: 3, wantDead: true},
+		{name: "不合法的消息不重试", policy: DeadLetterOnError, err: ErrInvalidMessage, wantCalls: 1, wantDead: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := memory.NewBroker()
+			produce(t, b, "a", "bad", "good")
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			var calls atomic.Int32
+			r := newTestRouter(b)
+			r.Register(Route{
+				Topic:    "a",
+				Policy:   tc.policy,
+				MaxRetry: 2,
+				Handler: HandlerFunc(func(ctx context.Context, m mq.Message) error {
+					if string(m.Value) == "good" {
+						cancel()
+						return nil
+					}
+					calls.Add(1)
+					return tc.err
+				}),
+			})
+			r.Run(ctx)
+
+			assert.Equal(t, tc.wantCalls, calls.Load())
+			assertDrained(t, b, "a")
+
+			fetchCtx, fetchCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer fetchCancel()
+			m, err := b.Consumer(DeadLetterTopic("a"), "test").Fetch(fetchCtx)
+			if tc.wantDead {
+				require.NoError(t, err)
+				assert.Equal(t, "bad", string(m.Value))
+			} else {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+func TestRouter_ConcurrentCommitInOrder(t *testing.T) {
+	b := memory.NewBroker()
+	produce(t, b, "a", "slow", "fast")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fastDone := make(chan struct{})
+	r := newTestRouter(b)
+	r.Register(Route{
+		Topic:       "a",
+		Concurrency: 2,
+		Handler: HandlerFunc(func(ctx context.Context, m mq.Message) error {
+			if string(m.Value) == "fast" {
+				close(fastDone)
+				return nil
+			}
+			<-fastDone
+			// slow 还没处理完就退出，fast 虽然处理完了也不能提交
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	})
+	r.Run(ctx)
+
+	m, err := b.Consumer("a", defaultGroup).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "slow", string(m.Value))
+}
+
+func TestJSONHandler(t *testing.T) {
+	h := JSONHandler(func(ctx context.Context, msg ArticleMessage) error {
+		return nil
+	})
+
+	err := h.Handle(context.Background(), mq.Message{Value: []byte("not json")})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+
+	err = h.Handle(context.Background(), mq.Message{Value: []byte(`{"title":"","content":"内容"}`)})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+
+	err = h.Handle(context.Background(), mq.Message{Value: []byte(`{"title":"标题","content":"内容"}`)})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,138 @@
+package spider
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+// HealthSource 提供数据库的压力指标
+type HealthSource interface {
+	Latency() time.Duration
+	ErrorRate() float64
+}
+
+// Throttle 所有 topic 共用的限流器
+// 数据库变慢或者错误变多时成倍降低速率，恢复后再逐步加回来（AIMD）
+type Throttle struct {
+	base    rate.Limit
+	limiter *rate.Limiter
+	health  HealthSource
+
+	targetLatency time.Duration
+	maxErrorRate  float64
+	minFactor     float64
+	step          float64
+
+	mu     sync.Mutex
+	factor float64
+	// paused 不为 nil 表示暂停中，恢复时关闭
+	paused chan struct{}
+}
+
+// NewThrottle limit 是每秒处理的消息数，小于等于 0 表示不限速，此时也不做自适应调整
+func NewThrottle(limit float64, burst int, health HealthSource) *Throttle {
+	base := rate.Limit(limit)
+	if limit <= 0 {
+		base = rate.Inf
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &Throttle{
+		base:          base,
+		limiter:       rate.NewLimiter(base, burst),
+		health:        health,
+		targetLatency: time.Millisecond * 100,
+		maxErrorRate:  0.05,
+		minFactor:     0.05,
+		step:          0.1,
+		factor:        1,
+	}
+}
+
+// Wait 暂停时一直阻塞，直到恢复或者 ctx 结束
+func (t *Throttle) Wait(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		paused := t.paused
+		t.mu.Unlock()
+		if paused == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-paused:
+		}
+	}
+
+	return t.limiter.Wait(ctx)
+}
+
+func (t *Throttle) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused == nil {
+		t.paused = make(chan struct{})
+	}
+}
+
+func (t *Throttle) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused != nil {
+		close(t.paused)
+		t.paused = nil
+	}
+}
+
+// Adjust 根据数据库的状态调整速率，需要定期调用
+func (t *Throttle) Adjust() {
+	if t.base == rate.Inf || t.health == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.health.Latency() > t.targetLatency || t.health.ErrorRate() > t.maxErrorRate {
+		t.factor = max(t.factor/2, t.minFactor)
+	} else {
+		t.factor = min(t.factor+t.step, 1)
+	}
+
+	t.limiter.SetLimit(t.base * rate.Limit(t.factor))
+}
+
+type ThrottleStatus struct {
+	Paused bool `json:"paused"`
+	// Limit 当前每秒处理的消息数，0 表示不限速
+	Limit       float64 `json:"limit"`
+	Factor      float64 `json:"factor"`
+	DBLatencyMs int64   `json:"dbLatencyMs"`
+	DBErrorRate float64 `json:"dbErrorRate"`
+}
+
+func (t *Throttle) Status() ThrottleStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := ThrottleStatus{
+		Paused: t.paused != nil,
+		Factor: t.factor,
+	}
+	if t.base != rate.Inf {
+		s.Limit = float64(t.limiter.Limit())
+	}
+	if t.health != nil {
+		s.DBLatencyMs = t.health.Latency().Milliseconds()
+		s.DBErrorRate = t.health.ErrorRate()
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package spider
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeHealth struct {
+	latency   time.Duration
+	errorRate float64
+}
+
+func (h *fakeHealth) Latency() time.Duration {
+	return h.latency
+}
+
+func (h *fakeHealth) ErrorRate() float64 {
+	return h.errorRate
+}
+
+func TestThrottle_Adjust(t *testing.T) {
+	health := &fakeHealth{}
+	th := NewThrottle(100, 10, health)
+
+	// 数据库变慢，速率减半
+	health.latency = time.Second
+	th.Adjust()
+	assert.Equal(t, float64(50), th.Status().Limit)
+	th.Adjust()
+	assert.Equal(t, float64(25), th.Status().Limit)
+
+	// 错误率高同样减速，但不会低于下限
+	health.latency = 0
+	health.errorRate = 0.5
+	for i := 0; i < 10; i++ {
+		th.Adjust()
+	}
+	assert.InDelta(t, 5, th.Status().Limit, 0.001)
+
+	// 恢复后逐步加回来，不超过配置的速率
+	health.errorRate = 0
+	th.Adjust()
+	assert.InDelta(t, 15, th.Status().Limit, 0.001)
+	for i := 0; i < 20; i++ {
+		th.Adjust()
+	}
+	assert.InDelta(t, 100, th.Status().Limit, 0.001)
+}
+
+func TestThrottle_PauseResume(t *testing.T) {
+	th := NewThrottle(0, 0, nil)
+	th.Pause()
+	assert.True(t, th.Status().Paused)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, th.Wait(ctx), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() {
+		done <- th.Wait(context.Background())
+	}()
+	th.Resume()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("恢复后没有继续")
+	}
+	assert.False(t, th.Status().Paused)
+}
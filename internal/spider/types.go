@@ -0,0 +1,68 @@
+package spider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"yellowbook/pkg/mq"
+)
+
+// ErrInvalidMessage 消息格式或内容不合法，重试也不会成功
+var ErrInvalidMessage = errors.New("消息不合法")
+
+type Handler interface {
+	Handle(ctx context.Context, m mq.Message) error
+}
+
+type HandlerFunc func(ctx context.Context, m mq.Message) error
+
+func (f HandlerFunc) Handle(ctx context.Context, m mq.Message) error {
+	return f(ctx, m)
+}
+
+// Validator 消息结构自己负责校验内容
+type Validator interface {
+	Validate() error
+}
+
+// JSONHandler 解析 JSON 并校验后再交给 fn，解析或校验失败统一包装成 ErrInvalidMessage
+func JSONHandler[T Validator](fn func(ctx context.Context, msg T) error) Handler {
+	return HandlerFunc(func(ctx context.Context, m mq.Message) error {
+		var msg T
+		if err := json.Unmarshal(m.Value, &msg); err != nil {
+			return errors.Join(ErrInvalidMessage, err)
+		}
+		if err := msg.Validate(); err != nil {
+			return errors.Join(ErrInvalidMessage, err)
+		}
+		return fn(ctx, msg)
+	})
+}
+
+type ErrorPolicy uint8
+
+const (
+	// SkipOnError 出错直接跳过
+	SkipOnError ErrorPolicy = iota
+	// RetryOnError 重试 MaxRetry 次后跳过
+	RetryOnError
+	// DeadLetterOnError 重试 MaxRetry 次后投递到死信 topic
+	DeadLetterOnError
+)
+
+// ConsumerFactory 每个 topic 单独创建消费者
+type ConsumerFactory func(topic string, group string) mq.Consumer
+
+type Route struct {
+	Topic   string
+	Group   string
+	Handler Handler
+	// Concurrency 同时处理的消息数，默认 1，此时严格按顺序处理
+	Concurrency int
+	Policy      ErrorPolicy
+	MaxRetry    int
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + "_dead_letter"
+}
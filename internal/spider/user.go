@@ -0,0 +1,68 @@
+package spider
+
+import (
+	"context"
+	"errors"
+	regexp "github.com/dlclark/regexp2"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"unicode/utf8"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/service"
+)
+
+var phoneExp = regexp.MustCompile(domain.PhoneRegexPattern, regexp.None)
+
+type UserImportMessage struct {
+	Phone        string `json:"phone"`
+	Nickname     string `json:"nickname"`
+	Birthday     string `json:"birthday"`
+	Introduction string `json:"introduction"`
+	Avatar       string `json:"avatar"`
+	Gender       int32  `json:"gender"`
+}
+
+func (m UserImportMessage) Validate() error {
+	if ok, _ := phoneExp.MatchString(m.Phone); !ok {
+		return errors.New("手机号格式不正确")
+	}
+	if utf8.RuneCountInString(m.Nickname) > 32 {
+		return errors.New("昵称过长")
+	}
+	if _, ok := proto.Gender_name[m.Gender]; !ok {
+		return errors.New("性别不正确")
+	}
+	return nil
+}
+
+// NewUserImportHandler 按手机号导入用户，已经填写过资料的用户不覆盖
+func NewUserImportHandler(srv service.IUserService) Handler {
+	return JSONHandler(func(ctx context.Context, msg UserImportMessage) error {
+		u, err := srv.FindOrCreateByPhone(ctx, msg.Phone)
+		if err != nil {
+			return err
+		}
+
+		u, err = srv.QueryProfile(ctx, u.Id)
+		if err != nil {
+			return err
+		}
+		if u.Profile != nil {
+			return nil
+		}
+
+		err = srv.EditProfile(ctx, domain.Profile{
+			UserId:       u.Id,
+			Nickname:     msg.Nickname,
+			Birthday:     msg.Birthday,
+			Introduction: msg.Introduction,
+			Gender:       proto.Gender(msg.Gender),
+		})
+		if errors.Is(err, service.ErrUserBirthdayFormat) {
+			return errors.Join(ErrInvalidMessage, err)
+		}
+		if err != nil || msg.Avatar == "" {
+			return err
+		}
+		return srv.SetAvatar(ctx, u.Id, msg.Avatar)
+	})
+}
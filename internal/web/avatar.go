@@ -0,0 +1,102 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"yellowbook/internal/service"
+)
+
+// AvatarHandler 上传头像，和 UserHandler 共用 /users 分组，需要先验证邮箱
+type AvatarHandler struct {
+	svc service.IAvatarService
+}
+
+func NewAvatarHandler(svc service.IAvatarService) *AvatarHandler {
+	return &AvatarHandler{
+		svc: svc,
+	}
+}
+
+func (h *AvatarHandler) RegisterRoutes(ug *gin.RouterGroup) {
+	ug.POST("/avatar", h.Upload)
+}
+
+// avatarMaxBodyBytes 比 service 里 10MB 的文件限制多留一点给 multipart 的其它部分
+const avatarMaxBodyBytes = 11 << 20
+
+// AvatarReq 图片放在 multipart 的 file 字段，裁剪框必须是正方形
+type AvatarReq struct {
+	X    int `form:"x"`
+	Y    int `form:"y"`
+	Size int `form:"size"`
+}
+
+func (h *AvatarHandler) Upload(ctx *gin.Context) {
+	// 不限制的话 multipart 解析会把整个请求先落到临时文件里
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, avatarMaxBodyBytes)
+
+	var req AvatarReq
+	if err := ctx.Bind(&req); err != nil {
+		h.badForm(ctx, err)
+		return
+	}
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		h.badForm(ctx, err)
+		return
+	}
+	file, err := fh.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	defer file.Close()
+
+	avatars, err := h.svc.Upload(ctx, ctx.GetUint64("UserId"), file, service.AvatarCrop{
+		X:    req.X,
+		Y:    req.Y,
+		Size: req.Size,
+	})
+	switch {
+	case errors.Is(err, service.ErrAvatarTooLarge),
+		errors.Is(err, service.ErrAvatarFormat),
+		errors.Is(err, service.ErrAvatarDimension),
+		errors.Is(err, service.ErrAvatarCrop):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  err.Error(),
+		})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "上传失败",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg:  "上传成功",
+		Data: avatars,
+	})
+}
+
+func (h *AvatarHandler) badForm(ctx *gin.Context, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  service.ErrAvatarTooLarge.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusBadRequest, Result{
+		Code: 4,
+		Msg:  "输入错误",
+	})
+}
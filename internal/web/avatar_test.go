@@ -0,0 +1,117 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yellowbook/internal/service"
+	svcmocks "yellowbook/internal/service/mocks"
+)
+
+func TestAvatarHandler_Upload(t *testing.T) {
+	form := func(t *testing.T, fileSize int) *http.Request {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		require.NoError(t, w.WriteField("x", "10"))
+		require.NoError(t, w.WriteField("y", "20"))
+		require.NoError(t, w.WriteField("size", "300"))
+		if fileSize > 0 {
+			part, err := w.CreateFormFile("file", "a.png")
+			require.NoError(t, err)
+			_, err = part.Write(make([]byte, fileSize))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		req, err := http.NewRequest(http.MethodPost, "/users/avatar", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) service.IAvatarService
+		fileSize int
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "上传成功",
+			mock: func(ctrl *gomock.Controller) service.IAvatarService {
+				svc := svcmocks.NewMockIAvatarService(ctrl)
+				svc.EXPECT().Upload(gomock.Any(), uint64(1), gomock.Any(), service.AvatarCrop{X: 10, Y: 20, Size: 300}).
+					Return(map[int]string{64: "https://oss.com/64", 256: "https://oss.com/256"}, nil)
+				return svc
+			},
+			fileSize: 1024,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"上传成功","data":{"256":"https://oss.com/256","64":"https://oss.com/64"}}`,
+		},
+		{
+			name: "没有文件",
+			mock: func(ctrl *gomock.Controller) service.IAvatarService {
+				return svcmocks.NewMockIAvatarService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"输入错误","data":null}`,
+		},
+		{
+			name: "请求体太大",
+			mock: func(ctrl *gomock.Controller) service.IAvatarService {
+				return svcmocks.NewMockIAvatarService(ctrl)
+			},
+			fileSize: avatarMaxBodyBytes,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"头像文件不能超过 10MB","data":null}`,
+		},
+		{
+			name: "裁剪范围不合法",
+			mock: func(ctrl *gomock.Controller) service.IAvatarService {
+				svc := svcmocks.NewMockIAvatarService(ctrl)
+				svc.EXPECT().Upload(gomock.Any(), uint64(1), gomock.Any(), gomock.Any()).Return(nil, service.ErrAvatarCrop)
+				return svc
+			},
+			fileSize: 1024,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"裁剪范围不合法","data":null}`,
+		},
+		{
+			name: "上传失败",
+			mock: func(ctrl *gomock.Controller) service.IAvatarService {
+				svc := svcmocks.NewMockIAvatarService(ctrl)
+				svc.EXPECT().Upload(gomock.Any(), uint64(1), gomock.Any(), gomock.Any()).Return(nil, errors.New("模拟错误"))
+				return svc
+			},
+			fileSize: 1024,
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":5,"msg":"上传失败","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			NewAvatarHandler(tc.mock(ctrl)).RegisterRoutes(server.Group("/users"))
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, form(t, tc.fileSize))
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
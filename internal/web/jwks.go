@@ -0,0 +1,26 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"yellowbook/internal/pkg/jwt_generator"
+)
+
+// JWKSHandler 公开校验 token 用的公钥，其它服务可以自己校验
+type JWKSHandler struct {
+	keys *jwt_generator.KeySet
+}
+
+func NewJWKSHandler(keys *jwt_generator.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+func (h *JWKSHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/.well-known/jwks.json", h.JWKS)
+}
+
+// JWKS 按 RFC 7517 的格式返回，不包 Result
+func (h *JWKSHandler) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"yellowbook/internal/web"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/ratelimit"
+)
+
+// 限流的维度
+const (
+	RateLimitByIp    = "ip"
+	RateLimitByUser  = "user"
+	RateLimitByRoute = "route"
+)
+
+type RateLimitRule struct {
+	// Prefix 按路径前缀匹配，"/users/login" 不匹配 "/users/login_sms"，空字符串匹配所有请求
+	Prefix string
+	// Key 可选 ip、user、route，user 没有登录时按 ip 算，route 是所有人共用一个额度
+	Key      string
+	Limit    int
+	Interval time.Duration
+}
+
+func (r RateLimitRule) match(path string) bool {
+	if r.Prefix == "" || path == r.Prefix {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(r.Prefix, "/")+"/")
+}
+
+type RateLimitMiddlewareBuilder struct {
+	limiter  ratelimit.Limiter
+	rules    func() []RateLimitRule
+	interval time.Duration
+	keys     map[string]bool
+	l        logger.Logger
+
+	mu       sync.RWMutex
+	cached   []RateLimitRule
+	loadTime time.Time
+}
+
+// NewRateLimitMiddlewareBuilder rules 会被定期重新调用，修改配置以后不用重启
+func NewRateLimitMiddlewareBuilder(limiter ratelimit.Limiter, rules func() []RateLimitRule, l logger.Logger) *RateLimitMiddlewareBuilder {
+	return &RateLimitMiddlewareBuilder{
+		limiter:  limiter,
+		rules:    rules,
+		interval: time.Second * 5,
+		l:        l,
+	}
+}
+
+// ReloadInterval 多久重新读一次规则
+func (b *RateLimitMiddlewareBuilder) ReloadInterval(interval time.Duration) *RateLimitMiddlewareBuilder {
+	b.interval = interval
+	return b
+}
+
+// Keys 只处理这些维度的规则，不设置时处理全部，Key 为空的规则按 ip 算。
+// 按 ip 和 route 的规则放在登录校验前面，被拒绝的请求也要计数；按 user 的放在后面才能拿到 UserId
+func (b *RateLimitMiddlewareBuilder) Keys(keys ...string) *RateLimitMiddlewareBuilder {
+	b.keys = make(map[string]bool, len(keys))
+	for _, k := range keys {
+		b.keys[k] = true
+	}
+	return b
+}
+
+func (b *RateLimitMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		path := ctx.Request.URL.Path
+		for _, rule := range b.currentRules() {
+			if !rule.match(path) || !b.handles(rule) {
+				continue
+			}
+
+			limited, err := b.limiter.Limit(ctx, b.key(ctx, rule), rule.Limit, rule.Interval)
+			if err != nil {
+				// 限流器本身出问题时放行，保证服务可用
+				b.l.Error("限流出错", logger.Field{Key: "error", Value: err})
+				continue
+			}
+			if limited {
+				ctx.AbortWithStatusJSON(http.StatusTooManyRequests, web.Result{
+					Code: 4,
+					Msg:  "请求太频繁，请稍后再试",
+				})
+				return
+			}
+		}
+	}
+}
+
+func (b *RateLimitMiddlewareBuilder) handles(rule RateLimitRule) bool {
+	if b.keys == nil {
+		return true
+	}
+	key := rule.Key
+	if key == "" {
+		key = RateLimitByIp
+	}
+	return b.keys[key]
+}
+
+func (b *RateLimitMiddlewareBuilder) currentRules() []RateLimitRule {
+	b.mu.RLock()
+	if b.cached != nil && time.Since(b.loadTime) < b.interval {
+		defer b.mu.RUnlock()
+		return b.cached
+	}
+	b.mu.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cached != nil && time.Since(b.loadTime) < b.interval {
+		return b.cached
+	}
+
+	rules := make([]RateLimitRule, 0)
+	for _, r := range b.rules() {
+		if r.Limit <= 0 || r.Interval <= 0 {
+			b.l.Warn("忽略不合法的限流规则", logger.Field{Key: "rule", Value: r})
+			continue
+		}
+		rules = append(rules, r)
+	}
+	b.cached = rules
+	b.loadTime = time.Now()
+	return b.cached
+}
+
+func (b *RateLimitMiddlewareBuilder) key(ctx *gin.Context, rule RateLimitRule) string {
+	var id string
+	switch rule.Key {
+	case RateLimitByRoute:
+		id = "*"
+	case RateLimitByUser:
+		if uid := ctx.GetUint64("UserId"); uid != 0 {
+			id = fmt.Sprintf("uid:%d", uid)
+			break
+		}
+		id = ctx.ClientIP()
+	default:
+		id = ctx.ClientIP()
+	}
+	// 同一个前缀下不同维度的规则互不影响
+	return fmt.Sprintf("ratelimit:%s:%s:%s", rule.Prefix, rule.Key, id)
+}
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yellowbook/pkg/logger"
+	ratelimitmocks "yellowbook/pkg/ratelimit/mocks"
+)
+
+func TestRateLimitMiddlewareBuilder_Build(t *testing.T) {
+	rules := []RateLimitRule{
+		{Prefix: "", Key: RateLimitByIp, Limit: 100, Interval: time.Second},
+		{Prefix: "/users/login", Key: RateLimitByIp, Limit: 30, Interval: time.Minute},
+		{Prefix: "/users", Key: RateLimitByUser, Limit: 600, Interval: time.Minute},
+		// 不合法的规则被忽略
+		{Prefix: "/articles", Key: RateLimitByRoute},
+	}
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter
+		path     string
+		uid      uint64
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "按前缀匹配，没登录按 IP 算",
+			mock: func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter {
+				limiter := ratelimitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit::ip:1.2.3.4", 100, time.Second).Return(false, nil)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit:/users/login:ip:1.2.3.4", 30, time.Minute).Return(false, nil)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit:/users:user:1.2.3.4", 600, time.Minute).Return(false, nil)
+				return limiter
+			},
+			path:     "/users/login",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "login_sms 不算 login 的前缀，登录以后按用户算",
+			mock: func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter {
+				limiter := ratelimitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit::ip:1.2.3.4", 100, time.Second).Return(false, nil)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit:/users:user:uid:1", 600, time.Minute).Return(false, nil)
+				return limiter
+			},
+			path:     "/users/login_sms",
+			uid:      1,
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "被限流",
+			mock: func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter {
+				limiter := ratelimitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit::ip:1.2.3.4", 100, time.Second).Return(true, nil)
+				return limiter
+			},
+			path:     "/articles/list",
+			wantCode: http.StatusTooManyRequests,
+			wantBody: `{"code":4,"msg":"请求太频繁，请稍后再试","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			builder := NewRateLimitMiddlewareBuilder(tc.mock(ctrl), func() []RateLimitRule {
+				return rules
+			}, logger.NewZapLogger(zap.NewNop()))
+
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				if tc.uid != 0 {
+					ctx.Set("UserId", tc.uid)
+				}
+			})
+			server.Use(builder.Build())
+			server.Any("/*path", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, nil)
+			require.NoError(t, err)
+			req.RemoteAddr = "1.2.3.4:5678"
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestRateLimitMiddlewareBuilder_Keys(t *testing.T) {
+	rules := []RateLimitRule{
+		// Key 为空按 ip 算
+		{Prefix: "", Limit: 100, Interval: time.Second},
+		{Prefix: "/articles", Key: RateLimitByRoute, Limit: 1000, Interval: time.Second},
+		{Prefix: "/users", Key: RateLimitByUser, Limit: 600, Interval: time.Minute},
+	}
+
+	testCases := []struct {
+		name string
+		keys []string
+		mock func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter
+		path string
+	}{
+		{
+			name: "登录前只处理 ip 和 route",
+			keys: []string{RateLimitByIp, RateLimitByRoute},
+			mock: func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter {
+				limiter := ratelimitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit:::1.2.3.4", 100, time.Second).Return(false, nil)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit:/articles:route:*", 1000, time.Second).Return(false, nil)
+				return limiter
+			},
+			path: "/articles/list",
+		},
+		{
+			name: "登录后只处理 user",
+			keys: []string{RateLimitByUser},
+			mock: func(ctrl *gomock.Controller) *ratelimitmocks.MockLimiter {
+				limiter := ratelimitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), "ratelimit:/users:user:1.2.3.4", 600, time.Minute).Return(false, nil)
+				return limiter
+			},
+			path: "/users/profile",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler := NewRateLimitMiddlewareBuilder(tc.mock(ctrl), func() []RateLimitRule {
+				return rules
+			}, logger.NewZapLogger(zap.NewNop())).Keys(tc.keys...).Build()
+
+			server := gin.New()
+			server.Use(handler)
+			server.Any("/*path", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, nil)
+			require.NoError(t, err)
+			req.RemoteAddr = "1.2.3.4:5678"
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+func TestRateLimitMiddlewareBuilder_Reload(t *testing.T) {
+	var loads int
+	builder := NewRateLimitMiddlewareBuilder(nil, func() []RateLimitRule {
+		loads++
+		return []RateLimitRule{{Key: RateLimitByIp, Limit: loads, Interval: time.Second}}
+	}, logger.NewZapLogger(zap.NewNop())).ReloadInterval(time.Millisecond * 10)
+
+	assert.Equal(t, 1, builder.currentRules()[0].Limit)
+	assert.Equal(t, 1, builder.currentRules()[0].Limit)
+
+	time.Sleep(time.Millisecond * 20)
+	assert.Equal(t, 2, builder.currentRules()[0].Limit)
+}
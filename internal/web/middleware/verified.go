@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"yellowbook/internal/service"
+	"yellowbook/internal/web"
+)
+
+// VerifiedMiddlewareBuilder 放在登录校验后面，拦下邮箱还没有验证的账号
+type VerifiedMiddlewareBuilder struct {
+	svc service.IUserService
+}
+
+func NewVerifiedMiddlewareBuilder(svc service.IUserService) *VerifiedMiddlewareBuilder {
+	return &VerifiedMiddlewareBuilder{svc: svc}
+}
+
+func (v *VerifiedMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// QueryProfile 走用户缓存，不会每个请求都查库
+		user, err := v.svc.QueryProfile(ctx, ctx.GetUint64("UserId"))
+		if err != nil {
+			log.Printf("查询用户验证状态失败：%v\n", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, web.Result{
+				Code: 5,
+				Msg:  "系统错误",
+			})
+			return
+		}
+
+		if !user.Verified() {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, web.Result{
+				Code: 4,
+				Msg:  "请先验证邮箱",
+			})
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yellowbook/internal/domain"
+	svcmocks "yellowbook/internal/service/mocks"
+)
+
+func TestVerifiedMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     domain.User
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "邮箱已验证",
+			user:     domain.User{Id: 1, Email: "a@qq.com", EmailVerified: true},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "邮箱没验证，但是有手机号",
+			user:     domain.User{Id: 1, Email: "a@qq.com", Phone: "13800000000"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "第三方登录的账号",
+			user:     domain.User{Id: 1},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "邮箱没验证",
+			user:     domain.User{Id: 1, Email: "a@qq.com"},
+			wantCode: http.StatusForbidden,
+			wantBody: `{"code":4,"msg":"请先验证邮箱","data":null}`,
+		},
+		{
+			name:     "查询出错",
+			err:      errors.New("模拟错误"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":5,"msg":"系统错误","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := svcmocks.NewMockIUserService(ctrl)
+			svc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(tc.user, tc.err)
+
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			}, NewVerifiedMiddlewareBuilder(svc).Build())
+			server.POST("/articles/save", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/articles/save", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/shenxiang11/yellowbook-proto/proto"
+	"github.com/shenxiang11/zippo/slice"
+	"net/http"
+	"strconv"
+	"strings"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/service"
+)
+
+// ProfileHandler 公开主页和隐私设置，和 UserHandler 共用 /users 分组
+type ProfileHandler struct {
+	svc service.IProfileService
+}
+
+func NewProfileHandler(svc service.IProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		svc: svc,
+	}
+}
+
+func (h *ProfileHandler) RegisterRoutes(ug *gin.RouterGroup) {
+	// /users/@{handle} 也走这个路由，gin 不支持同一段里既有参数又有前缀
+	ug.GET("/:id", h.Public)
+	ug.POST("/privacy", h.UpdatePrivacy)
+	ug.POST("/handle", h.SetHandle)
+}
+
+type PublicArticleVo struct {
+	Id        uint64   `json:"id"`
+	Title     string   `json:"title"`
+	ImageList []string `json:"imageList"`
+}
+
+// PublicProfileVo 不要加手机号、邮箱这类字段，这个接口不需要登录
+type PublicProfileVo struct {
+	UserId         uint64            `json:"userId"`
+	Handle         string            `json:"handle,omitempty"`
+	Nickname       string            `json:"nickname"`
+	Avatar         string            `json:"avatar"`
+	Avatars        map[int]string    `json:"avatars,omitempty"`
+	Introduction   string            `json:"introduction"`
+	Birthday       string            `json:"birthday"`
+	Gender         proto.Gender      `json:"gender"`
+	Private        bool              `json:"private"`
+	ArticleCount   int64             `json:"articleCount"`
+	RecentArticles []PublicArticleVo `json:"recentArticles"`
+}
+
+// Public 任何人都能看的主页，不需要登录，可以用 id 或者 @用户名 访问
+func (h *ProfileHandler) Public(ctx *gin.Context) {
+	var p domain.PublicProfile
+	var err error
+	if param := ctx.Param("id"); strings.HasPrefix(param, "@") {
+		handle := strings.ToLower(strings.TrimPrefix(param, "@"))
+		p, err = h.svc.PublicByHandle(ctx, handle)
+		// 旧用户名跳到新的，用 302 是因为旧用户名过期以后可能被别人占用
+		if err == nil && p.Handle != handle {
+			ctx.Redirect(http.StatusFound, "/users/@"+p.Handle)
+			return
+		}
+	} else {
+		var id uint64
+		id, err = strconv.ParseUint(param, 10, 64)
+		if err != nil || id == 0 {
+			ctx.JSON(http.StatusBadRequest, Result{
+				Code: 4,
+				Msg:  "输入错误",
+			})
+			return
+		}
+		p, err = h.svc.Public(ctx, id)
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: PublicProfileVo{
+			UserId:       p.UserId,
+			Handle:       p.Handle,
+			Nickname:     p.Nickname,
+			Avatar:       p.Avatar,
+			Avatars:      p.Avatars,
+			Introduction: p.Introduction,
+			Birthday:     p.Birthday,
+			Gender:       p.Gender,
+			Private:      p.Private,
+			ArticleCount: p.ArticleCount,
+			RecentArticles: slice.Map[domain.Article, PublicArticleVo](p.RecentArticles, func(el domain.Article, index int) PublicArticleVo {
+				return PublicArticleVo{
+					Id:        el.Id,
+					Title:     el.Title,
+					ImageList: el.ImageList,
+				}
+			}),
+		},
+	})
+}
+
+type PrivacyReq struct {
+	HideBirthday bool `json:"hideBirthday"`
+	HideGender   bool `json:"hideGender"`
+	// Private 公开主页只显示昵称和头像
+	Private bool `json:"private"`
+}
+
+func (h *ProfileHandler) UpdatePrivacy(ctx *gin.Context) {
+	var req PrivacyReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	err := h.svc.UpdatePrivacy(ctx, ctx.GetUint64("UserId"), domain.Privacy{
+		HideBirthday: req.HideBirthday,
+		HideGender:   req.HideGender,
+		Private:      req.Private,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "更新成功",
+	})
+}
+
+type HandleReq struct {
+	Handle string `json:"handle"`
+}
+
+func (h *ProfileHandler) SetHandle(ctx *gin.Context) {
+	var req HandleReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	err := h.svc.SetHandle(ctx, ctx.GetUint64("UserId"), req.Handle)
+	var cooldownErr *service.HandleCooldownError
+	switch {
+	case errors.Is(err, service.ErrHandleInvalid),
+		errors.Is(err, service.ErrHandleReserved),
+		errors.Is(err, service.ErrHandleTaken),
+		errors.As(err, &cooldownErr):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  err.Error(),
+		})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "更新成功",
+	})
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yellowbook/internal/domain"
+	"yellowbook/internal/repository"
+	repomocks "yellowbook/internal/repository/mocks"
+	"yellowbook/internal/service"
+)
+
+func TestProfileHandler_Public(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository)
+		path     string
+		wantCode int
+		wantBody string
+		// wantLocation 跳转的时候只看地址，不比较 body
+		wantLocation string
+	}{
+		{
+			name: "不返回手机号和邮箱",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				// 缓存里的是完整的 User
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{
+					Id:       1,
+					Email:    "863@qq.com",
+					Phone:    "13800000000",
+					Password: "hash",
+					Profile: &domain.Profile{
+						UserId:   1,
+						Nickname: "和黑",
+						Birthday: "1993-12-11",
+						Privacy:  domain.Privacy{HideBirthday: true},
+					},
+				}, nil)
+				articleRepo := repomocks.NewMockIArticleRepository(ctrl)
+				articleRepo.EXPECT().ListPublishedByAuthor(gomock.Any(), uint64(1), gomock.Any()).
+					Return([]domain.Article{{Id: 3, Title: "标题", Content: "正文"}}, int64(1), nil)
+				return userRepo, articleRepo
+			},
+			path:     "/users/1",
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"","data":{"userId":1,"nickname":"和黑","avatar":"","introduction":"","birthday":"","gender":0,"private":false,"articleCount":1,"recentArticles":[{"id":3,"title":"标题","imageList":null}]}}`,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(2)).Return(domain.User{}, repository.ErrUserNotFound)
+				return userRepo, repomocks.NewMockIArticleRepository(ctrl)
+			},
+			path:     "/users/2",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"code":4,"msg":"用户不存在","data":null}`,
+		},
+		{
+			name: "用 @用户名 访问",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindIdByHandle(gomock.Any(), "hehei").Return(uint64(1), nil)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{
+					Id:      1,
+					Handle:  "hehei",
+					Profile: &domain.Profile{Nickname: "和黑", Privacy: domain.Privacy{Private: true}},
+				}, nil)
+				return userRepo, repomocks.NewMockIArticleRepository(ctrl)
+			},
+			path:     "/users/@HeHei",
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"","data":{"userId":1,"handle":"hehei","nickname":"和黑","avatar":"","introduction":"","birthday":"","gender":0,"private":true,"articleCount":0,"recentArticles":[]}}`,
+		},
+		{
+			name: "旧用户名跳转到新的",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindIdByHandle(gomock.Any(), "old").Return(uint64(1), nil)
+				userRepo.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Handle: "hehei"}, nil)
+				return userRepo, repomocks.NewMockIArticleRepository(ctrl)
+			},
+			path:         "/users/@old",
+			wantCode:     http.StatusFound,
+			wantLocation: "/users/@hehei",
+		},
+		{
+			name: "用户名不存在",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindIdByHandle(gomock.Any(), "nobody").Return(uint64(0), repository.ErrUserNotFound)
+				return userRepo, repomocks.NewMockIArticleRepository(ctrl)
+			},
+			path:     "/users/@nobody",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"code":4,"msg":"用户不存在","data":null}`,
+		},
+		{
+			name: "id 不合法",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IArticleRepository) {
+				return repomocks.NewMockUserRepository(ctrl), repomocks.NewMockIArticleRepository(ctrl)
+			},
+			path:     "/users/abc",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"输入错误","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler := NewProfileHandler(service.NewProfileService(tc.mock(ctrl)))
+
+			// 和 UserHandler 注册在同一个分组，/users/profile 这些固定路径不能被 /users/:id 抢走
+			server := gin.Default()
+			NewUserHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil).RegisterRoutes(server.Group("/users"))
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantLocation, recorder.Header().Get("Location"))
+			if tc.wantLocation == "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+			assert.NotContains(t, recorder.Body.String(), "863@qq.com")
+			assert.NotContains(t, recorder.Body.String(), "13800000000")
+		})
+	}
+}
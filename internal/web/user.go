@@ -4,37 +4,73 @@ import (
 	"errors"
 	regexp "github.com/dlclark/regexp2"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/shenxiang11/yellowbook-proto/proto"
 	"github.com/spf13/viper"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 	"yellowbook/internal/domain"
 	"yellowbook/internal/pkg/jwt_generator"
 	"yellowbook/internal/service"
-	"yellowbook/internal/service/github"
+	"yellowbook/internal/service/oauth"
 	"yellowbook/pkg/ginx"
 )
 
 type UserHandler struct {
 	svc         service.IUserService
 	codeSvs     service.CodeService
-	githubSvc   github.IService
+	emailCode   service.EmailCodeService
+	oauth       *oauth.Registry
+	stateSvc    service.IOAuthStateService
 	phoneExp    *regexp.Regexp
 	emailExp    *regexp.Regexp
 	passwordExp *regexp.Regexp
+	sessionSvc  service.ISessionService
 	jwt         jwt_generator.IJWTGenerator
+	limiter     service.ILoginLimitService
+	twoFactor   service.ITwoFactorService
+	deletion    service.IAccountDeletionService
 }
 
-const biz = "login"
+// 不同业务的验证码互不通用
+const (
+	bizLogin         = "login"
+	bizResetPassword = "reset_password"
+	bizBindPhone     = "bind_phone"
+	bizUnlockLogin   = "unlock_login"
+	bizVerifyEmail   = "verify_email"
+	bizBindEmail     = "bind_email"
+	bizDeleteAccount = "delete_account"
+)
+
+const accessTokenExpire = time.Minute * 10
+
+// oauthStateCookie 把 state 和发起授权的浏览器绑定
+const (
+	oauthStateCookie = "oauth_state"
+	oauthCookiePath  = "/users/oauth"
+)
 
-func NewUserHandler(svc service.IUserService, codeSvc service.CodeService, githubSvc github.IService, jwt jwt_generator.IJWTGenerator) *UserHandler {
+func NewUserHandler(
+	svc service.IUserService,
+	codeSvc service.CodeService,
+	emailCodeSvc service.EmailCodeService,
+	oauthRegistry *oauth.Registry,
+	stateSvc service.IOAuthStateService,
+	sessionSvc service.ISessionService,
+	jwt jwt_generator.IJWTGenerator,
+	limiter service.ILoginLimitService,
+	twoFactor service.ITwoFactorService,
+	deletion service.IAccountDeletionService,
+) *UserHandler {
 	const (
 		emailRegexPattern    = "^\\w+([-+.]\\w+)*@\\w+([-.]\\w+)*\\.\\w+([-.]\\w+)*$"
 		passwordRegexPattern = `^(?=.*[A-Za-z])(?=.*\d)(?=.*[$@$!%*#?&])[A-Za-z\d$@$!%*#?&]{8,}$`
-		phoneRegexPattern    = `^1\d{10}$`
+		phoneRegexPattern    = domain.PhoneRegexPattern
 	)
 
 	emailExp := regexp.MustCompile(emailRegexPattern, regexp.None)
@@ -44,11 +80,17 @@ func NewUserHandler(svc service.IUserService, codeSvc service.CodeService, githu
 	return &UserHandler{
 		svc:         svc,
 		codeSvs:     codeSvc,
-		githubSvc:   githubSvc,
+		emailCode:   emailCodeSvc,
+		oauth:       oauthRegistry,
+		stateSvc:    stateSvc,
 		emailExp:    emailExp,
 		passwordExp: passwordExp,
 		phoneExp:    phoneExp,
+		sessionSvc:  sessionSvc,
 		jwt:         jwt,
+		limiter:     limiter,
+		twoFactor:   twoFactor,
+		deletion:    deletion,
 	}
 }
 
@@ -56,11 +98,36 @@ func (u *UserHandler) RegisterRoutes(ug *gin.RouterGroup) {
 	ug.GET("/profile", u.Profile)
 	ug.POST("/signup", u.SignUp)
 	ug.POST("/login", u.Login)
+	ug.POST("/login/unlock/code/send", u.SendUnlockLoginCode)
+	ug.POST("/login/unlock", u.UnlockLogin)
+	ug.POST("/login/2fa", u.LoginTwoFactor)
+	ug.POST("/2fa/enroll", u.EnrollTwoFactor)
+	ug.POST("/2fa/confirm", u.ConfirmTwoFactor)
+	ug.POST("/2fa/disable", u.DisableTwoFactor)
 	ug.POST("/edit", u.Edit)
 	ug.POST("/login_sms/code/send", u.SendLoginSMSCode)
 	ug.POST("/login_sms", u.LoginSMS)
-	ug.GET("/github/oauth", u.Oauth)
-	ug.GET("/github/authorize", u.Authorize)
+	ug.POST("/password", u.ChangePassword)
+	ug.POST("/password/reset/code/send", u.SendResetPasswordCode)
+	ug.POST("/password/reset", u.ResetPassword)
+	ug.POST("/bind/phone/code/send", u.SendBindPhoneCode)
+	ug.POST("/bind/phone", u.BindPhone)
+	ug.POST("/bind/email/code/send", u.SendBindEmailCode)
+	ug.POST("/bind/email", u.BindEmail)
+	ug.POST("/email/verify/code/send", u.SendVerifyEmailCode)
+	ug.POST("/email/verify", u.VerifyEmail)
+	ug.POST("/unbind/:kind", u.Unbind)
+	ug.GET("/oauth/:provider", u.OAuth)
+	ug.GET("/oauth/:provider/callback", u.OAuthCallback)
+	ug.POST("/oauth/:provider/bind/start", u.StartBindOAuth)
+	ug.POST("/oauth/:provider/bind", u.BindOAuth)
+	ug.POST("/refresh_token", u.RefreshToken)
+	ug.POST("/logout", u.Logout)
+	ug.GET("/sessions", u.Sessions)
+	ug.POST("/sessions/:id/logout", u.LogoutSession)
+	ug.POST("/sessions/logout_others", u.LogoutOtherSessions)
+	ug.POST("/delete/code/send", u.SendDeleteAccountCode)
+	ug.POST("/delete", u.Delete)
 
 	ug.GET("/version", ginx.NewExtendContext(func(ctx ginx.Context) {
 		val := viper.Get("version")
@@ -68,23 +135,23 @@ func (u *UserHandler) RegisterRoutes(ug *gin.RouterGroup) {
 	}))
 }
 
-func (u *UserHandler) Oauth(ctx *gin.Context) {
-	ctx.Redirect(http.StatusFound, u.githubSvc.AuthURL(ctx))
-}
-
-func (u *UserHandler) Authorize(ctx *gin.Context) {
-	code := ctx.Query("code")
+// OAuth 跳转到第三方的授权页面，provider 见 oauth.Registry
+func (u *UserHandler) OAuth(ctx *gin.Context) {
+	provider, ok := u.provider(ctx)
+	if !ok {
+		return
+	}
 
-	githubId, err := u.githubSvc.VerifyCode(ctx, code)
+	state, err := u.stateSvc.Generate(ctx, 0)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, Result{
 			Code: 5,
-			Msg:  "系统错误，获取 github 用户信息失败",
+			Msg:  "系统错误",
 		})
 		return
 	}
 
-	user, err := u.svc.FindOrCreateByGithubId(ctx, githubId)
+	authURL, err := provider.AuthURL(ctx, state)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, Result{
 			Code: 5,
@@ -93,7 +160,53 @@ func (u *UserHandler) Authorize(ctx *gin.Context) {
 		return
 	}
 
-	err = u.setJWTToken(ctx, user)
+	ctx.SetCookie(oauthStateCookie, state, 600, oauthCookiePath, "", false, true)
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+func (u *UserHandler) OAuthCallback(ctx *gin.Context) {
+	provider, ok := u.provider(ctx)
+	if !ok {
+		return
+	}
+
+	state := ctx.Query("state")
+	cookie, _ := ctx.Cookie(oauthStateCookie)
+	if state == "" || state != cookie {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "授权请求已失效，请重新登录",
+		})
+		return
+	}
+
+	// 绑定发起的授权也回调到这里，不能拿 code 去登录，交给登录状态下的 BindOAuth
+	uid, err := u.stateSvc.Peek(ctx, state)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "授权请求已失效，请重新登录",
+		})
+		return
+	}
+	if uid != 0 {
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "请提交绑定",
+			Data: gin.H{
+				"code":  ctx.Query("code"),
+				"state": state,
+			},
+		})
+		return
+	}
+	ctx.SetCookie(oauthStateCookie, "", -1, oauthCookiePath, "", false, true)
+
+	identity, ok := u.exchangeCode(ctx, provider, state, ctx.Query("code"), 0)
+	if !ok {
+		return
+	}
+
+	user, err := u.svc.FindOrCreateByIdentity(ctx, identity)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, Result{
 			Code: 5,
@@ -102,9 +215,7 @@ func (u *UserHandler) Authorize(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "github 登录成功",
-	})
+	u.completeLogin(ctx, user, provider.Name(), provider.Name()+" 登录成功")
 }
 
 func (u *UserHandler) SignUp(ctx *gin.Context) {
@@ -156,17 +267,13 @@ func (u *UserHandler) SignUp(ctx *gin.Context) {
 		return
 	}
 
+	u.sendVerifyEmailCode(ctx, req.Email)
+
 	ctx.JSON(http.StatusOK, Result{
 		Msg: "注册成功",
 	})
 }
 
-type UserClaims struct {
-	jwt.RegisteredClaims
-	UserId    uint64
-	UserAgent string
-}
-
 func (u *UserHandler) Login(ctx *gin.Context) {
 	var req proto.LoginRequest
 	if err := ctx.Bind(&req); err != nil {
@@ -177,23 +284,41 @@ func (u *UserHandler) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, err := u.svc.Login(ctx, req.Email, req.Password)
-	if errors.Is(err, service.ErrInvalidUserOrPassword) {
+	// 手机号注册的用户设置过密码以后，也可以用手机号加密码登录
+	// 既不是邮箱也不是手机号的直接拒绝，不让随便填的字符串去占登录限制的计数
+	var method string
+	if ok, _ := u.phoneExp.MatchString(req.Email); ok {
+		method = domain.LoginByPhone
+	} else if ok, _ = u.emailExp.MatchString(req.Email); ok {
+		method = domain.LoginByEmail
+	} else {
 		ctx.JSON(http.StatusBadRequest, Result{
 			Code: 4,
-			Msg:  "用户名或密码不正确",
+			Msg:  "请输入邮箱或手机号",
 		})
 		return
 	}
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
+
+	if !u.checkLoginLimit(ctx, req.Email) {
 		return
 	}
 
-	if err = u.setJWTToken(ctx, user); err != nil {
+	var user domain.User
+	var err error
+	if method == domain.LoginByPhone {
+		user, err = u.svc.LoginByPhone(ctx, req.Email, req.Password)
+	} else {
+		user, err = u.svc.Login(ctx, req.Email, req.Password)
+	}
+	if errors.Is(err, service.ErrInvalidUserOrPassword) {
+		u.loginFailed(ctx, req.Email)
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "用户名或密码不正确",
+		})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, Result{
 			Code: 5,
 			Msg:  "系统错误",
@@ -201,9 +326,8 @@ func (u *UserHandler) Login(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "登录成功",
-	})
+	u.loginSucceeded(ctx, req.Email)
+	u.completeLogin(ctx, user, method, "登录成功")
 }
 
 func (u *UserHandler) Edit(ctx *gin.Context) {
@@ -233,14 +357,12 @@ func (u *UserHandler) Edit(ctx *gin.Context) {
 		return
 	}
 
-	userId := ctx.GetUint64("UserId")
-
+	// 头像只能通过 /users/avatar 上传，这里忽略 req.Avatar，EditProfile 也不会动头像
 	err := u.svc.EditProfile(ctx, domain.Profile{
-		UserId:       userId,
+		UserId:       ctx.GetUint64("UserId"),
 		Nickname:     req.Nickname,
 		Birthday:     req.Birthday,
 		Introduction: req.Introduction,
-		Avatar:       req.Avatar,
 		Gender:       req.Gender,
 	})
 	if err != nil {
@@ -281,11 +403,38 @@ func (u *UserHandler) Profile(ctx *gin.Context) {
 		res.Gender = user.Profile.Gender
 	}
 
+	vo := ProfileVo{
+		ProfileResponse: res,
+		EmailVerified:   user.EmailVerified,
+		Handle:          user.Handle,
+		Verified:        user.Verified(),
+	}
+	if user.Profile != nil {
+		vo.Avatars = user.Profile.Avatars
+		vo.Privacy = PrivacyReq{
+			HideBirthday: user.Profile.Privacy.HideBirthday,
+			HideGender:   user.Profile.Privacy.HideGender,
+			Private:      user.Profile.Privacy.Private,
+		}
+	}
+
 	ctx.JSON(http.StatusOK, Result{
-		Data: res,
+		Data: vo,
 	})
 }
 
+// ProfileVo proto 里没有验证状态和隐私设置，在外面补上
+type ProfileVo struct {
+	*proto.ProfileResponse
+	EmailVerified bool   `json:"emailVerified"`
+	Handle        string `json:"handle,omitempty"`
+	// Verified 为 false 时发文章、上传资源等操作会被拦下
+	Verified bool       `json:"verified"`
+	Privacy  PrivacyReq `json:"privacy"`
+	// Avatars 上传头像生成的各个尺寸，key 是边长
+	Avatars map[int]string `json:"avatars,omitempty"`
+}
+
 func (u *UserHandler) SendLoginSMSCode(ctx *gin.Context) {
 	var req proto.SendLoginSMSCodeRequest
 	if err := ctx.Bind(&req); err != nil {
@@ -305,7 +454,7 @@ func (u *UserHandler) SendLoginSMSCode(ctx *gin.Context) {
 		return
 	}
 
-	err := u.codeSvs.Send(ctx, biz, req.Phone)
+	err := u.codeSvs.Send(ctx, bizLogin, req.Phone)
 	switch {
 	case err == nil:
 		ctx.JSON(http.StatusOK, Result{
@@ -343,8 +492,13 @@ func (u *UserHandler) LoginSMS(ctx *gin.Context) {
 		return
 	}
 
-	err := u.codeSvs.Verify(ctx, biz, req.Phone, req.Code)
+	if !u.checkLoginLimit(ctx, req.Phone) {
+		return
+	}
+
+	err := u.codeSvs.Verify(ctx, bizLogin, req.Phone, req.Code)
 	if errors.Is(err, service.ErrCodeVerifyFailed) {
+		u.loginFailed(ctx, req.Phone)
 		ctx.JSON(http.StatusBadRequest, Result{
 			Code: 4,
 			Msg:  "验证码错误",
@@ -367,7 +521,217 @@ func (u *UserHandler) LoginSMS(ctx *gin.Context) {
 		return
 	}
 
-	if err = u.setJWTToken(ctx, user); err != nil {
+	u.loginSucceeded(ctx, req.Phone)
+	u.completeLogin(ctx, user, domain.LoginBySMS, "验证成功")
+}
+
+// checkLoginLimit 被限制时已经写好响应，Retry-After 告诉客户端多久以后再试
+func (u *UserHandler) checkLoginLimit(ctx *gin.Context, account string) bool {
+	err := u.limiter.Check(ctx, domain.LockoutByAccount, account, ctx.ClientIP())
+	var limitedErr *service.LoginLimitedError
+	if errors.As(err, &limitedErr) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(limitedErr.RetryAfter.Seconds()))))
+		msg := "尝试太频繁，请稍后再试"
+		if limitedErr.Locked {
+			msg = "登录失败次数过多，账号已临时锁定，可以通过短信验证解锁"
+		}
+		ctx.JSON(http.StatusTooManyRequests, Result{
+			Code: 4,
+			Msg:  msg,
+		})
+		return false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return false
+	}
+	return true
+}
+
+// loginFailed 记录失败不影响这次的响应
+func (u *UserHandler) loginFailed(ctx *gin.Context, account string) {
+	if err := u.limiter.Fail(ctx, domain.LockoutByAccount, account, ctx.ClientIP()); err != nil {
+		log.Printf("记录登录失败出错：%v\n", err)
+	}
+}
+
+func (u *UserHandler) loginSucceeded(ctx *gin.Context, account string) {
+	if err := u.limiter.Succeed(ctx, domain.LockoutByAccount, account); err != nil {
+		log.Printf("清除登录失败记录出错：%v\n", err)
+	}
+}
+
+type SendUnlockLoginCodeReq struct {
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+}
+
+func (u *UserHandler) SendUnlockLoginCode(ctx *gin.Context) {
+	var req SendUnlockLoginCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	codeSvc, target, ok := u.codeChannel(ctx, req.Phone, req.Email)
+	if !ok {
+		return
+	}
+
+	err := codeSvc.Send(ctx, bizUnlockLogin, target)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "发送成功",
+		})
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+type UnlockLoginReq struct {
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// UnlockLogin 验证手机号或者邮箱以后，解锁同一账号的手机号和邮箱，以及当前 IP
+func (u *UserHandler) UnlockLogin(ctx *gin.Context) {
+	var req UnlockLoginReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	codeSvc, target, ok := u.codeChannel(ctx, req.Phone, req.Email)
+	if !ok {
+		return
+	}
+
+	if !u.verifyCode(ctx, codeSvc, bizUnlockLogin, target, req.Code) {
+		return
+	}
+
+	var (
+		user domain.User
+		err  error
+	)
+	if req.Email != "" {
+		user, err = u.svc.FindByEmail(ctx, target)
+	} else {
+		user, err = u.svc.FindByPhone(ctx, target)
+	}
+	if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	accounts := []string{target}
+	for _, account := range []string{user.Phone, user.Email} {
+		if account != "" && account != target {
+			accounts = append(accounts, account)
+		}
+	}
+
+	for _, account := range accounts {
+		if err = u.limiter.Unlock(ctx, domain.LockoutByAccount, account); err != nil {
+			ctx.JSON(http.StatusInternalServerError, Result{
+				Code: 5,
+				Msg:  "系统错误",
+			})
+			return
+		}
+	}
+	// 同一个 IP 失败太多次也会被锁，只解锁发起请求的这个 IP
+	if err = u.limiter.Unlock(ctx, domain.LockoutByIp, ctx.ClientIP()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已解锁",
+	})
+}
+
+type LoginTwoFactorReq struct {
+	Challenge string `json:"challenge"`
+	// Code 验证器上的 6 位数字，或者一个恢复码
+	Code string `json:"code"`
+}
+
+// LoginTwoFactor 登录的第二步，用 challenge 和验证码换 token
+func (u *UserHandler) LoginTwoFactor(ctx *gin.Context) {
+	var req LoginTwoFactorReq
+	if err := ctx.Bind(&req); err != nil || req.Challenge == "" || req.Code == "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	c, err := u.twoFactor.VerifyChallenge(ctx, req.Challenge, req.Code)
+	if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "验证码错误",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidChallenge) {
+		ctx.JSON(http.StatusUnauthorized, Result{
+			Code: 4,
+			Msg:  "两步验证已失效，请重新登录",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	// 输入验证码的这段时间里账号可能被封禁
+	err = u.svc.CheckBan(ctx, c.UserId)
+	var bannedErr *service.UserBannedError
+	if errors.As(err, &bannedErr) {
+		ctx.JSON(http.StatusForbidden, BannedResult(bannedErr))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	if err = u.setLoginToken(ctx, domain.User{Id: c.UserId}, c.LoginMethod); err != nil {
 		ctx.JSON(http.StatusInternalServerError, Result{
 			Code: 5,
 			Msg:  "系统错误",
@@ -376,17 +740,1230 @@ func (u *UserHandler) LoginSMS(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, Result{
-		Msg: "验证成功",
+		Msg: "登录成功",
 	})
 }
 
-func (u *UserHandler) setJWTToken(ctx *gin.Context, user domain.User) error {
-	tokenStr, err := u.jwt.Generate(strconv.FormatUint(user.Id, 10), time.Minute*10)
+type TwoFactorEnrollmentVo struct {
+	Secret string `json:"secret"`
+	// URI otpauth:// 链接，前端生成二维码给验证器扫
+	URI string `json:"uri"`
+}
 
+// EnrollTwoFactor 重复调用会换一个新的密钥，确认以后才生效
+func (u *UserHandler) EnrollTwoFactor(ctx *gin.Context) {
+	secret, uri, err := u.twoFactor.Enroll(ctx, ctx.GetUint64("UserId"))
+	if errors.Is(err, service.ErrTwoFactorEnabled) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "已经开启两步验证",
+		})
+		return
+	}
 	if err != nil {
-		return err
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
 	}
 
-	ctx.Header("X-Jwt-Token", tokenStr)
-	return nil
+	ctx.JSON(http.StatusOK, Result{
+		Data: TwoFactorEnrollmentVo{
+			Secret: secret,
+			URI:    uri,
+		},
+	})
+}
+
+type TwoFactorCodeReq struct {
+	Code string `json:"code"`
+}
+
+// ConfirmTwoFactor 恢复码只在这里返回一次
+func (u *UserHandler) ConfirmTwoFactor(ctx *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	codes, err := u.twoFactor.Confirm(ctx, ctx.GetUint64("UserId"), req.Code)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg:  "两步验证已开启，请妥善保存恢复码",
+			Data: gin.H{"recoveryCodes": codes},
+		})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "验证码错误",
+		})
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "请先获取两步验证密钥",
+		})
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "已经开启两步验证",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// DisableTwoFactor 需要验证码或者恢复码，防止拿到登录态的人直接关掉
+func (u *UserHandler) DisableTwoFactor(ctx *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	err := u.twoFactor.Disable(ctx, ctx.GetUint64("UserId"), req.Code)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "两步验证已关闭",
+		})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "验证码错误",
+		})
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "没有开启两步验证",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+type ChangePasswordReq struct {
+	OldPassword     string `json:"oldPassword"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirmPassword"`
+}
+
+// ChangePassword 修改成功后其它设备需要重新登录，当前设备保持登录
+func (u *UserHandler) ChangePassword(ctx *gin.Context) {
+	var req ChangePasswordReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	if req.Password != req.ConfirmPassword {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "两次输入的密码不一致",
+		})
+		return
+	}
+
+	ok, _ := u.passwordExp.MatchString(req.Password)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "密码必须大于8位，包含数字、特殊字符",
+		})
+		return
+	}
+
+	userId := ctx.GetUint64("UserId")
+	err := u.svc.ChangePassword(ctx, userId, req.OldPassword, req.Password)
+	if errors.Is(err, service.ErrInvalidUserOrPassword) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "原密码不正确",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	if err = u.sessionSvc.RevokeOthers(ctx, userId, ctx.GetString("SessionId")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "密码修改成功",
+	})
+}
+
+// SendResetPasswordCodeReq Phone 和 Email 二选一，验证码发到对应的地方
+type SendResetPasswordCodeReq struct {
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+}
+
+func (u *UserHandler) SendResetPasswordCode(ctx *gin.Context) {
+	var req SendResetPasswordCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	codeSvc, target, ok := u.codeChannel(ctx, req.Phone, req.Email)
+	if !ok {
+		return
+	}
+
+	err := codeSvc.Send(ctx, bizResetPassword, target)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "发送成功",
+		})
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+type ResetPasswordReq struct {
+	Phone           string `json:"phone"`
+	Email           string `json:"email"`
+	Code            string `json:"code"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirmPassword"`
+}
+
+// ResetPassword 重置成功后所有设备都要重新登录
+func (u *UserHandler) ResetPassword(ctx *gin.Context) {
+	var req ResetPasswordReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	codeSvc, target, ok := u.codeChannel(ctx, req.Phone, req.Email)
+	if !ok {
+		return
+	}
+
+	if req.Password != req.ConfirmPassword {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "两次输入的密码不一致",
+		})
+		return
+	}
+
+	ok, _ = u.passwordExp.MatchString(req.Password)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "密码必须大于8位，包含数字、特殊字符",
+		})
+		return
+	}
+
+	// 先校验密码格式再校验验证码，避免格式错误把验证码消耗掉
+	if !u.verifyCode(ctx, codeSvc, bizResetPassword, target, req.Code) {
+		return
+	}
+
+	var user domain.User
+	var err error
+	notFoundMsg := "手机号未注册"
+	if req.Email != "" {
+		notFoundMsg = "邮箱未注册"
+		user, err = u.svc.ResetPasswordByEmail(ctx, req.Email, req.Password)
+	} else {
+		user, err = u.svc.ResetPassword(ctx, req.Phone, req.Password)
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  notFoundMsg,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	if err = u.sessionSvc.RevokeAll(ctx, user.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "密码已重置，请重新登录",
+	})
+}
+
+// codeChannel 填了邮箱走邮件，否则走短信，格式不对时已经写好响应
+func (u *UserHandler) codeChannel(ctx *gin.Context, phone string, email string) (service.CodeService, string, bool) {
+	if email != "" {
+		ok, _ := u.emailExp.MatchString(email)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, Result{
+				Code: 4,
+				Msg:  "邮箱格式不正确",
+			})
+			return nil, "", false
+		}
+		return u.emailCode, email, true
+	}
+
+	ok, _ := u.phoneExp.MatchString(phone)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "手机格式不正确",
+		})
+		return nil, "", false
+	}
+	return u.codeSvs, phone, true
+}
+
+func (u *UserHandler) SendBindPhoneCode(ctx *gin.Context) {
+	var req proto.SendLoginSMSCodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	ok, _ := u.phoneExp.MatchString(req.Phone)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "手机格式不正确",
+		})
+		return
+	}
+
+	err := u.codeSvs.Send(ctx, bizBindPhone, req.Phone)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "发送成功",
+		})
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+func (u *UserHandler) BindPhone(ctx *gin.Context) {
+	var req proto.LoginSMSRequest
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	ok, _ := u.phoneExp.MatchString(req.Phone)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "手机格式不正确",
+		})
+		return
+	}
+
+	err := u.codeSvs.Verify(ctx, bizBindPhone, req.Phone, req.Code)
+	if errors.Is(err, service.ErrCodeVerifyFailed) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "验证码错误",
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	err = u.svc.BindPhone(ctx, ctx.GetUint64("UserId"), req.Phone)
+	u.bindResult(ctx, err, "手机号已被其他账号绑定")
+}
+
+type SendBindEmailCodeReq struct {
+	Email string `json:"email"`
+}
+
+// SendBindEmailCode 先证明邮箱是自己的才能绑定，避免占用别人的邮箱
+func (u *UserHandler) SendBindEmailCode(ctx *gin.Context) {
+	var req SendBindEmailCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	ok, _ := u.emailExp.MatchString(req.Email)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "邮箱格式不正确",
+		})
+		return
+	}
+
+	err := u.emailCode.Send(ctx, bizBindEmail, req.Email)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "发送成功",
+		})
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+type BindEmailReq struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// BindEmail 验证码通过以后才绑定，绑定的邮箱直接是已验证的
+func (u *UserHandler) BindEmail(ctx *gin.Context) {
+	var req BindEmailReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	ok, _ := u.emailExp.MatchString(req.Email)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "邮箱格式不正确",
+		})
+		return
+	}
+
+	if !u.verifyCode(ctx, u.emailCode, bizBindEmail, req.Email, req.Code) {
+		return
+	}
+
+	err := u.svc.BindEmail(ctx, ctx.GetUint64("UserId"), req.Email)
+	u.bindResult(ctx, err, "邮箱已被其他账号绑定")
+}
+
+// sendVerifyEmailCode 发送失败不影响注册和绑定，用户可以重新发送
+func (u *UserHandler) sendVerifyEmailCode(ctx *gin.Context, email string) {
+	if err := u.emailCode.Send(ctx, bizVerifyEmail, email); err != nil {
+		log.Printf("发送邮箱验证码失败：%v\n", err)
+	}
+}
+
+type SendVerifyEmailCodeReq struct {
+	Email string `json:"email"`
+}
+
+// SendVerifyEmailCode 邮箱没有注册或者已经验证过也返回发送成功，避免被用来探测邮箱是否注册
+func (u *UserHandler) SendVerifyEmailCode(ctx *gin.Context) {
+	var req SendVerifyEmailCodeReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	ok, _ := u.emailExp.MatchString(req.Email)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "邮箱格式不正确",
+		})
+		return
+	}
+
+	user, err := u.svc.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	if err == nil && !user.EmailVerified {
+		err = u.emailCode.Send(ctx, bizVerifyEmail, req.Email)
+	} else {
+		err = nil
+	}
+
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "发送成功",
+		})
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+type VerifyEmailReq struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// VerifyEmail 不需要登录，注册以后直接输入邮件里的验证码
+func (u *UserHandler) VerifyEmail(ctx *gin.Context) {
+	var req VerifyEmailReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	if !u.verifyCode(ctx, u.emailCode, bizVerifyEmail, req.Email, req.Code) {
+		return
+	}
+
+	err := u.svc.VerifyEmail(ctx, req.Email)
+	if errors.Is(err, service.ErrUserNotFound) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "邮箱未注册",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "邮箱验证成功",
+	})
+}
+
+// verifyCode 校验失败时已经写好响应
+func (u *UserHandler) verifyCode(ctx *gin.Context, codeSvc service.CodeService, biz string, target string, code string) bool {
+	err := codeSvc.Verify(ctx, biz, target, code)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrCodeVerifyFailed):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "验证码错误",
+		})
+	case errors.Is(err, service.ErrCodeVerifyTooManyTimes):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "验证次数太多，请重新获取验证码",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+	return false
+}
+
+// StartBindOAuth 登录状态下发起绑定，state 里记下当前用户，返回第三方的授权地址
+func (u *UserHandler) StartBindOAuth(ctx *gin.Context) {
+	provider, ok := u.provider(ctx)
+	if !ok {
+		return
+	}
+
+	state, err := u.stateSvc.Generate(ctx, ctx.GetUint64("UserId"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	authURL, err := provider.AuthURL(ctx, state)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.SetCookie(oauthStateCookie, state, 600, oauthCookiePath, "", false, true)
+	ctx.JSON(http.StatusOK, Result{
+		Data: gin.H{"url": authURL},
+	})
+}
+
+type BindOAuthReq struct {
+	// Code 和 State 是第三方授权后回调带回来的
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// BindOAuth state 必须是当前用户通过 StartBindOAuth 在这个浏览器发起的
+func (u *UserHandler) BindOAuth(ctx *gin.Context) {
+	provider, ok := u.provider(ctx)
+	if !ok {
+		return
+	}
+
+	var req BindOAuthReq
+	if err := ctx.Bind(&req); err != nil || req.Code == "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	cookie, _ := ctx.Cookie(oauthStateCookie)
+	if req.State == "" || req.State != cookie {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "授权请求已失效，请重新绑定",
+		})
+		return
+	}
+	ctx.SetCookie(oauthStateCookie, "", -1, oauthCookiePath, "", false, true)
+
+	uid := ctx.GetUint64("UserId")
+	identity, ok := u.exchangeCode(ctx, provider, req.State, req.Code, uid)
+	if !ok {
+		return
+	}
+
+	err := u.svc.BindIdentity(ctx, uid, identity)
+	u.bindResult(ctx, err, provider.Name()+" 账号已被其他账号绑定")
+}
+
+// provider 路由里的 provider 不支持时已经写好响应
+func (u *UserHandler) provider(ctx *gin.Context) (oauth.Provider, bool) {
+	provider, err := u.oauth.Get(ctx.Param("provider"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, Result{
+			Code: 4,
+			Msg:  "不支持的登录方式",
+		})
+		return nil, false
+	}
+	return provider, true
+}
+
+// exchangeCode 校验 state 并用 code 换取第三方的用户信息，失败时已经写好响应
+// uid 必须和发起授权时记下的一致，登录是 0
+func (u *UserHandler) exchangeCode(ctx *gin.Context, provider oauth.Provider, state string, code string, uid uint64) (domain.ExternalIdentity, bool) {
+	stateUid, err := u.stateSvc.Verify(ctx, state)
+	if err == nil && stateUid != uid {
+		err = service.ErrInvalidOAuthState
+	}
+	if errors.Is(err, service.ErrInvalidOAuthState) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "授权请求已失效，请重新登录",
+		})
+		return domain.ExternalIdentity{}, false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return domain.ExternalIdentity{}, false
+	}
+
+	identity, err := provider.Exchange(ctx, code)
+	var oauthErr *oauth.Error
+	if errors.As(err, &oauthErr) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  provider.Name() + " 授权失败：" + oauthErr.Description,
+		})
+		return domain.ExternalIdentity{}, false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误，获取 " + provider.Name() + " 用户信息失败",
+		})
+		return domain.ExternalIdentity{}, false
+	}
+	return identity, true
+}
+
+func (u *UserHandler) bindResult(ctx *gin.Context, err error, duplicateMsg string) {
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "绑定成功",
+		})
+	case errors.Is(err, service.ErrUserDuplicate):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  duplicateMsg,
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// Unbind kind 可选 phone、email 或者 oauth.Registry 里的 provider
+func (u *UserHandler) Unbind(ctx *gin.Context) {
+	kind := ctx.Param("kind")
+	if kind != domain.IdentityPhone && kind != domain.IdentityEmail {
+		if _, err := u.oauth.Get(kind); err != nil {
+			ctx.JSON(http.StatusBadRequest, Result{
+				Code: 4,
+				Msg:  "不支持的登录方式",
+			})
+			return
+		}
+	}
+
+	err := u.svc.Unbind(ctx, ctx.GetUint64("UserId"), kind)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "解绑成功",
+		})
+	case errors.Is(err, service.ErrUnknownIdentity):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "不支持的登录方式",
+		})
+	case errors.Is(err, service.ErrLastLoginMethod):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "至少需要保留一种登录方式",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// RefreshToken refresh token 放在 Authorization 里，换一对新的 token
+func (u *UserHandler) RefreshToken(ctx *gin.Context) {
+	refreshToken := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+
+	sess, refreshToken, err := u.sessionSvc.Refresh(ctx, refreshToken, ctx.Request.UserAgent())
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		ctx.JSON(http.StatusUnauthorized, Result{
+			Code: 4,
+			Msg:  "请重新登录",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	if err = u.setAccessToken(ctx, sess.UserId, sess.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.Header("X-Refresh-Token", refreshToken)
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "刷新成功",
+	})
+}
+
+func (u *UserHandler) Logout(ctx *gin.Context) {
+	err := u.sessionSvc.Revoke(ctx, ctx.GetUint64("UserId"), ctx.GetString("SessionId"))
+	if err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "退出登录成功",
+	})
+}
+
+// SendDeleteAccountCode 没有密码的账号申请注销前发验证码，有手机号发短信，否则发邮件
+func (u *UserHandler) SendDeleteAccountCode(ctx *gin.Context) {
+	user, ok := u.deletionUser(ctx)
+	if !ok {
+		return
+	}
+	if user.Password != "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "请输入密码确认注销",
+		})
+		return
+	}
+	enabled, err := u.twoFactor.Enabled(ctx, user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	if enabled {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "请输入两步验证码确认注销",
+		})
+		return
+	}
+
+	codeSvc, target, ok := u.deleteAccountChannel(ctx, user)
+	if !ok {
+		return
+	}
+
+	err = codeSvc.Send(ctx, bizDeleteAccount, target)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "发送成功",
+		})
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+type DeleteAccountReq struct {
+	// Password 设置过密码的账号必填
+	Password string `json:"password"`
+	// Code 没有密码的账号必填，开启了两步验证的填两步验证码，否则填短信或者邮件验证码
+	Code string `json:"code"`
+}
+
+type DeleteAccountVo struct {
+	DeleteAfter int64 `json:"deleteAfter"`
+}
+
+// Delete 申请注销账号，冷静期内重新登录就会取消
+func (u *UserHandler) Delete(ctx *gin.Context) {
+	var req DeleteAccountReq
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "输入错误",
+		})
+		return
+	}
+
+	user, ok := u.deletionUser(ctx)
+	if !ok {
+		return
+	}
+	// 只拿到 token 不能注销没有密码的账号
+	if user.Password == "" && !u.verifyDeletionCode(ctx, user, req.Code) {
+		return
+	}
+
+	deleteAfter, err := u.deletion.Request(ctx, user.Id, req.Password)
+	if errors.Is(err, service.ErrInvalidUserOrPassword) {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "密码不正确",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已申请注销，冷静期内重新登录可以取消",
+		Data: DeleteAccountVo{
+			DeleteAfter: deleteAfter.UnixMilli(),
+		},
+	})
+}
+
+// deletionUser 查询失败时已经写好响应
+func (u *UserHandler) deletionUser(ctx *gin.Context) (domain.User, bool) {
+	user, err := u.svc.QueryProfile(ctx, ctx.GetUint64("UserId"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return domain.User{}, false
+	}
+	return user, true
+}
+
+// verifyDeletionCode 开启了两步验证的用两步验证码，否则用发到手机或者邮箱的验证码，失败时已经写好响应
+func (u *UserHandler) verifyDeletionCode(ctx *gin.Context, user domain.User, code string) bool {
+	if code == "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "请输入验证码",
+		})
+		return false
+	}
+
+	err := u.twoFactor.Verify(ctx, user.Id, code)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "两步验证码错误",
+		})
+		return false
+	case !errors.Is(err, service.ErrTwoFactorNotEnabled):
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return false
+	}
+
+	codeSvc, target, ok := u.deleteAccountChannel(ctx, user)
+	if !ok {
+		return false
+	}
+	return u.verifyCode(ctx, codeSvc, bizDeleteAccount, target, code)
+}
+
+// deleteAccountChannel 优先发短信，手机号和邮箱都没有的账号没办法验证，已经写好响应
+func (u *UserHandler) deleteAccountChannel(ctx *gin.Context, user domain.User) (service.CodeService, string, bool) {
+	switch {
+	case user.Phone != "":
+		return u.codeSvs, user.Phone, true
+	case user.Email != "":
+		return u.emailCode, user.Email, true
+	default:
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: 4,
+			Msg:  "请先绑定手机号或者邮箱再注销",
+		})
+		return nil, "", false
+	}
+}
+
+type SessionVo struct {
+	Id          string `json:"id"`
+	Device      string `json:"device"`
+	UserAgent   string `json:"userAgent"`
+	Ip          string `json:"ip"`
+	LoginMethod string `json:"loginMethod"`
+	CreateTime  int64  `json:"createTime"`
+	LastSeen    int64  `json:"lastSeen"`
+	// Current 是否是发起请求的这个设备
+	Current bool `json:"current"`
+}
+
+func (u *UserHandler) Sessions(ctx *gin.Context) {
+	sessions, err := u.sessionSvc.List(ctx, ctx.GetUint64("UserId"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ssid := ctx.GetString("SessionId")
+	vos := make([]SessionVo, 0, len(sessions))
+	for _, s := range sessions {
+		vos = append(vos, SessionVo{
+			Id:          s.Id,
+			Device:      s.Device,
+			UserAgent:   s.UserAgent,
+			Ip:          s.Ip,
+			LoginMethod: s.LoginMethod,
+			CreateTime:  s.CreateTime.UnixMilli(),
+			LastSeen:    s.LastSeen.UnixMilli(),
+			Current:     s.Id == ssid,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: vos,
+	})
+}
+
+// LogoutSession 让指定设备下线
+func (u *UserHandler) LogoutSession(ctx *gin.Context) {
+	err := u.sessionSvc.Revoke(ctx, ctx.GetUint64("UserId"), ctx.Param("id"))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "会话不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已下线",
+	})
+}
+
+// LogoutOtherSessions 除了当前设备，其它设备全部下线
+func (u *UserHandler) LogoutOtherSessions(ctx *gin.Context) {
+	err := u.sessionSvc.RevokeOthers(ctx, ctx.GetUint64("UserId"), ctx.GetString("SessionId"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: "已下线其它设备",
+	})
+}
+
+type TwoFactorChallengeVo struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	Challenge         string `json:"challenge"`
+	ExpireTime        int64  `json:"expireTime"`
+}
+
+// completeLogin 第一步验证已经通过，开启了两步验证的账号先返回 challenge，其它的直接发 token
+func (u *UserHandler) completeLogin(ctx *gin.Context, user domain.User, method string, msg string) {
+	if user.BannedAt(time.Now()) {
+		ctx.JSON(http.StatusForbidden, BannedResult(&service.UserBannedError{
+			Reason: user.BanReason,
+			Until:  user.BanUntil,
+		}))
+		return
+	}
+
+	enabled, err := u.twoFactor.Enabled(ctx, user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	if enabled {
+		c, err := u.twoFactor.Challenge(ctx, user.Id, method)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, Result{
+				Code: 5,
+				Msg:  "系统错误",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, Result{
+			Msg: "请输入两步验证码",
+			Data: TwoFactorChallengeVo{
+				TwoFactorRequired: true,
+				Challenge:         c.Id,
+				ExpireTime:        c.ExpireTime.UnixMilli(),
+			},
+		})
+		return
+	}
+
+	if err = u.setLoginToken(ctx, user, method); err != nil {
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Msg: msg,
+	})
+}
+
+// BannedVo Until 为 0 表示永久封禁
+type BannedVo struct {
+	Reason string `json:"reason"`
+	Until  int64  `json:"until"`
+}
+
+// BannedResult 登录和登录校验中间件共用，把封禁原因和解封时间告诉客户端
+func BannedResult(err *service.UserBannedError) Result {
+	vo := BannedVo{Reason: err.Reason}
+	if !err.Until.IsZero() {
+		vo.Until = err.Until.UnixMilli()
+	}
+	return Result{
+		Code: 4,
+		Msg:  err.Error(),
+		Data: vo,
+	}
+}
+
+// setLoginToken 创建会话，返回 access token 和 refresh token，冷静期内的注销申请会被取消
+func (u *UserHandler) setLoginToken(ctx *gin.Context, user domain.User, method string) error {
+	if _, err := u.deletion.Cancel(ctx, user.Id); err != nil {
+		return err
+	}
+
+	ua := ctx.Request.UserAgent()
+	sess, refreshToken, err := u.sessionSvc.Create(ctx, domain.Session{
+		UserId:      user.Id,
+		Device:      deviceName(ua),
+		UserAgent:   ua,
+		Ip:          ctx.ClientIP(),
+		LoginMethod: method,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = u.setAccessToken(ctx, user.Id, sess.Id); err != nil {
+		return err
+	}
+
+	ctx.Header("X-Refresh-Token", refreshToken)
+	return nil
+}
+
+func (u *UserHandler) setAccessToken(ctx *gin.Context, uid uint64, ssid string) error {
+	tokenStr, err := u.jwt.Generate(strconv.FormatUint(uid, 10), ssid, accessTokenExpire)
+	if err != nil {
+		return err
+	}
+
+	ctx.Header("X-Jwt-Token", tokenStr)
+	return nil
+}
+
+// deviceName 从 User-Agent 粗略判断设备，只用于展示
+func deviceName(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		return "iPhone"
+	case strings.Contains(ua, "iPad"):
+		return "iPad"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Macintosh"):
+		return "Mac"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	default:
+		return "未知设备"
+	}
 }
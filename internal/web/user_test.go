@@ -10,13 +10,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"yellowbook/internal/domain"
 	"yellowbook/internal/pkg/jwt_generator"
 	jwtmocks "yellowbook/internal/pkg/jwt_generator/mocks"
 	"yellowbook/internal/service"
-	"yellowbook/internal/service/github"
-	githubmocks "yellowbook/internal/service/github/mocks"
 	svcmocks "yellowbook/internal/service/mocks"
+	"yellowbook/internal/service/oauth"
+	oauthmocks "yellowbook/internal/service/oauth/mocks"
 )
 
 func TestEmailPattern(t *testing.T) {
@@ -42,7 +43,7 @@ func TestEmailPattern(t *testing.T) {
 		},
 	}
 
-	h := NewUserHandler(nil, nil, nil, nil)
+	h := NewUserHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -81,7 +82,7 @@ func TestPasswordPattern(t *testing.T) {
 		},
 	}
 
-	h := NewUserHandler(nil, nil, nil, nil)
+	h := NewUserHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -120,7 +121,7 @@ func TestPhonePattern(t *testing.T) {
 		},
 	}
 
-	h := NewUserHandler(nil, nil, nil, nil)
+	h := NewUserHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -131,26 +132,49 @@ func TestPhonePattern(t *testing.T) {
 	}
 }
 
+// newSessionSvc 登录成功都会创建会话
+func newSessionSvc(ctrl *gomock.Controller) service.ISessionService {
+	sessionSvc := svcmocks.NewMockISessionService(ctrl)
+	sessionSvc.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Return(domain.Session{Id: "ssid"}, "ssid.secret", nil).AnyTimes()
+	return sessionSvc
+}
+
+func newLimiter(ctrl *gomock.Controller) service.ILoginLimitService {
+	limiter := svcmocks.NewMockILoginLimitService(ctrl)
+	limiter.EXPECT().Check(gomock.Any(), domain.LockoutByAccount, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	limiter.EXPECT().Fail(gomock.Any(), domain.LockoutByAccount, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	limiter.EXPECT().Succeed(gomock.Any(), domain.LockoutByAccount, gomock.Any()).Return(nil).AnyTimes()
+	return limiter
+}
+
+// newEmailCode 注册、绑定邮箱以后会发验证邮件
+func newEmailCode(ctrl *gomock.Controller) service.EmailCodeService {
+	emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+	emailCode.EXPECT().Send(gomock.Any(), bizVerifyEmail, gomock.Any()).Return(nil).AnyTimes()
+	return emailCode
+}
+
 func TestUserHandler_SignUp(t *testing.T) {
 	const signUpUrl = "/users/signup"
 
 	testCases := []struct {
 		name       string
-		mock       func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator)
+		mock       func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator)
 		reqBuilder func(t *testing.T) *http.Request
 		wantCode   int
 		wantBody   string
 	}{
 		{
 			name: "注册成功",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(nil)
 
 				codeSvc := svcmocks.NewMockCodeService(ctrl)
-				githubSvc := githubmocks.NewMockIService(ctrl)
+				registry := oauth.NewRegistry()
 
-				return userSvc, codeSvc, githubSvc, nil
+				return userSvc, codeSvc, registry, nil
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
 				body := bytes.NewBuffer([]byte(`{"email": "any@qq.com", "password": "hello@world#123"}`))
@@ -166,7 +190,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 		},
 		{
 			name: "非 JSON 输入",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				return nil, nil, nil, nil
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
@@ -183,7 +207,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 		},
 		{
 			name: "邮箱错误",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				return nil, nil, nil, nil
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
@@ -200,7 +224,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 		},
 		{
 			name: "密码错误",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				return nil, nil, nil, nil
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
@@ -217,7 +241,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 		},
 		{
 			name: "邮箱冲突",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(service.ErrUserDuplicate)
 				return userSvc, nil, nil, nil
@@ -236,7 +260,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 		},
 		{
 			name: "系统异常",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(errors.New("其他任意系统异常"))
 				return userSvc, nil, nil, nil
@@ -260,8 +284,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			userSvc, codeSvc, githubSvc, jwt := tc.mock(ctrl)
-			handler := NewUserHandler(userSvc, codeSvc, githubSvc, jwt)
+			userSvc, codeSvc, registry, jwt := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, codeSvc, newEmailCode(ctrl), registry, nil, newSessionSvc(ctrl), jwt, newLimiter(ctrl), newTwoFactor(ctrl), newDeletion(ctrl))
 
 			server := gin.Default()
 			handler.RegisterRoutes(server.Group("/users"))
@@ -277,12 +301,25 @@ func TestUserHandler_SignUp(t *testing.T) {
 	}
 }
 
+// newTwoFactor 没有开启两步验证的账号
+func newTwoFactor(ctrl *gomock.Controller) service.ITwoFactorService {
+	twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+	twoFactor.EXPECT().Enabled(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	return twoFactor
+}
+
+func newDeletion(ctrl *gomock.Controller) service.IAccountDeletionService {
+	deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+	deletion.EXPECT().Cancel(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	return deletion
+}
+
 func TestUserHandler_Login(t *testing.T) {
 	const loginUrl = "/users/login"
 
 	testCases := []struct {
 		name       string
-		mock       func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator)
+		mock       func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator)
 		reqBuilder func(t *testing.T) *http.Request
 		setJWTErr  bool
 		wantCode   int
@@ -290,7 +327,7 @@ func TestUserHandler_Login(t *testing.T) {
 	}{
 		{
 			name: "登录成功",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().Login(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 					domain.User{},
@@ -300,11 +337,11 @@ func TestUserHandler_Login(t *testing.T) {
 				codeSvc := svcmocks.NewMockCodeService(ctrl)
 
 				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
-				jwt.EXPECT().Generate(gomock.Any(), gomock.Any()).Return("Test Token", nil)
+				jwt.EXPECT().Generate(gomock.Any(), "ssid", gomock.Any()).Return("Test Token", nil)
 
-				githubSvc := githubmocks.NewMockIService(ctrl)
+				registry := oauth.NewRegistry()
 
-				return userSvc, codeSvc, githubSvc, jwt
+				return userSvc, codeSvc, registry, jwt
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
 				body := bytes.NewBuffer([]byte(`{"email": "any@qq.com", "phone": "hello@world#123"}`))
@@ -318,9 +355,83 @@ func TestUserHandler_Login(t *testing.T) {
 			wantCode: 200,
 			wantBody: `{"code":0,"msg":"登录成功","data":null}`,
 		},
+		{
+			name: "账号被封禁",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), "any@qq.com", "hello@world#123").Return(
+					domain.User{Id: 1, Banned: true, BanReason: "发布广告"},
+					nil,
+				)
+				return userSvc, nil, nil, nil
+			},
+			reqBuilder: func(t *testing.T) *http.Request {
+				body := bytes.NewBuffer([]byte(`{"email": "any@qq.com", "password": "hello@world#123"}`))
+				req, err := http.NewRequest(http.MethodPost, loginUrl, body)
+				req.Header.Set("Content-Type", "application/json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return req
+			},
+			wantCode: http.StatusForbidden,
+			wantBody: `{"code":4,"msg":"账号已被封禁，原因：发布广告","data":{"reason":"发布广告","until":0}}`,
+		},
+		{
+			name: "临时封禁已经到期",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), "any@qq.com", "hello@world#123").Return(
+					domain.User{Id: 1, Banned: true, BanUntil: time.Now().Add(-time.Minute)},
+					nil,
+				)
+
+				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
+				jwt.EXPECT().Generate("1", "ssid", gomock.Any()).Return("Test Token", nil)
+
+				return userSvc, nil, nil, jwt
+			},
+			reqBuilder: func(t *testing.T) *http.Request {
+				body := bytes.NewBuffer([]byte(`{"email": "any@qq.com", "password": "hello@world#123"}`))
+				req, err := http.NewRequest(http.MethodPost, loginUrl, body)
+				req.Header.Set("Content-Type", "application/json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return req
+			},
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"登录成功","data":null}`,
+		},
+		{
+			name: "手机号加密码登录成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().LoginByPhone(gomock.Any(), "13800000000", "hello@world#123").Return(
+					domain.User{Id: 1},
+					nil,
+				)
+
+				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
+				jwt.EXPECT().Generate("1", "ssid", gomock.Any()).Return("Test Token", nil)
+
+				return userSvc, nil, nil, jwt
+			},
+			reqBuilder: func(t *testing.T) *http.Request {
+				body := bytes.NewBuffer([]byte(`{"email": "13800000000", "password": "hello@world#123"}`))
+				req, err := http.NewRequest(http.MethodPost, loginUrl, body)
+				req.Header.Set("Content-Type", "application/json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return req
+			},
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"登录成功","data":null}`,
+		},
 		{
 			name: "设置 JWT 报错",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().Login(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 					domain.User{},
@@ -330,11 +441,11 @@ func TestUserHandler_Login(t *testing.T) {
 				codeSvc := svcmocks.NewMockCodeService(ctrl)
 
 				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
-				jwt.EXPECT().Generate(gomock.Any(), gomock.Any()).Return("", errors.New("模拟错误"))
+				jwt.EXPECT().Generate(gomock.Any(), "ssid", gomock.Any()).Return("", errors.New("模拟错误"))
 
-				githubSvc := githubmocks.NewMockIService(ctrl)
+				registry := oauth.NewRegistry()
 
-				return userSvc, codeSvc, githubSvc, jwt
+				return userSvc, codeSvc, registry, jwt
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
 				body := bytes.NewBuffer([]byte(`{"email": "any@qq.com", "phone": "hello@world#123"}`))
@@ -351,7 +462,7 @@ func TestUserHandler_Login(t *testing.T) {
 		},
 		{
 			name: "非 JSON 输入",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				return nil, nil, nil, nil
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
@@ -366,9 +477,26 @@ func TestUserHandler_Login(t *testing.T) {
 			wantCode: 400,
 			wantBody: `{"code":4,"msg":"输入错误","data":null}`,
 		},
+		{
+			name: "既不是邮箱也不是手机号",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
+				return svcmocks.NewMockIUserService(ctrl), nil, nil, nil
+			},
+			reqBuilder: func(t *testing.T) *http.Request {
+				body := bytes.NewBuffer([]byte(`{"email": "admin:admin", "password": "hello#world123"}`))
+				req, err := http.NewRequest(http.MethodPost, loginUrl, body)
+				req.Header.Set("Content-Type", "application/json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return req
+			},
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"请输入邮箱或手机号","data":null}`,
+		},
 		{
 			name: "用户名或密码不正确",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().Login(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 					domain.User{},
@@ -392,7 +520,7 @@ func TestUserHandler_Login(t *testing.T) {
 		},
 		{
 			name: "系统错误",
-			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, github.IService, jwt_generator.IJWTGenerator) {
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, *oauth.Registry, jwt_generator.IJWTGenerator) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
 				userSvc.EXPECT().Login(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 					domain.User{},
@@ -421,8 +549,8 @@ func TestUserHandler_Login(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			userSvc, codeSvc, githubSvc, jwt := tc.mock(ctrl)
-			handler := NewUserHandler(userSvc, codeSvc, githubSvc, jwt)
+			userSvc, codeSvc, registry, jwt := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, codeSvc, nil, registry, nil, newSessionSvc(ctrl), jwt, newLimiter(ctrl), newTwoFactor(ctrl), newDeletion(ctrl))
 
 			server := gin.Default()
 			handler.RegisterRoutes(server.Group("/users"))
@@ -453,11 +581,15 @@ func TestUserHandler_Edit(t *testing.T) {
 			name: "编辑成功",
 			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService) {
 				userSvc := svcmocks.NewMockIUserService(ctrl)
-				userSvc.EXPECT().EditProfile(gomock.Any(), gomock.Any()).Return(nil)
+				userSvc.EXPECT().EditProfile(gomock.Any(), domain.Profile{
+					Nickname:     "any@qq.com",
+					Birthday:     "1993-11-11",
+					Introduction: "我很懒惰不想介绍",
+				}).Return(nil)
 				return userSvc, nil
 			},
 			reqBuilder: func(t *testing.T) *http.Request {
-				body := bytes.NewBuffer([]byte(`{"nickname": "any@qq.com", "birthday": "1993-11-11", "introduction": "我很懒惰不想介绍"}`))
+				body := bytes.NewBuffer([]byte(`{"nickname": "any@qq.com", "birthday": "1993-11-11", "introduction": "我很懒惰不想介绍", "avatar": "https://evil.com/b.png"}`))
 				req, err := http.NewRequest(http.MethodPost, url, body)
 				req.Header.Set("Content-Type", "application/json")
 				if err != nil {
@@ -551,7 +683,7 @@ func TestUserHandler_Edit(t *testing.T) {
 			defer ctrl.Finish()
 
 			userSvc, codeSvc := tc.mock(ctrl)
-			handler := NewUserHandler(userSvc, codeSvc, nil, nil)
+			handler := NewUserHandler(userSvc, codeSvc, nil, nil, nil, nil, nil, nil, nil, nil)
 
 			server := gin.Default()
 			handler.RegisterRoutes(server.Group("/users"))
@@ -606,7 +738,7 @@ func TestUserHandler_Profile(t *testing.T) {
 			},
 			userValid: true,
 			wantCode:  200,
-			wantBody:  `{"code":0,"msg":"","data":{"user_id":1,"email":"863@qq.com","phone":"186","nickname":"和黑","birthday":"1993-12-11","introduction":"我不想自我介绍"}}`,
+			wantBody:  `{"code":0,"msg":"","data":{"user_id":1,"email":"863@qq.com","phone":"186","nickname":"和黑","birthday":"1993-12-11","introduction":"我不想自我介绍","emailVerified":false,"verified":true,"privacy":{"hideBirthday":false,"hideGender":false,"private":false}}}`,
 		},
 		{
 			name: "获取失败",
@@ -635,7 +767,7 @@ func TestUserHandler_Profile(t *testing.T) {
 			defer ctrl.Finish()
 
 			userSvc, codeSvc := tc.mock(ctrl)
-			handler := NewUserHandler(userSvc, codeSvc, nil, nil)
+			handler := NewUserHandler(userSvc, codeSvc, nil, nil, nil, nil, nil, nil, nil, nil)
 
 			server := gin.Default()
 			handler.RegisterRoutes(server.Group("/users"))
@@ -769,7 +901,7 @@ func TestUserHandler_SendLoginSMSCode(t *testing.T) {
 			defer ctrl.Finish()
 
 			userSvc, codeSvc := tc.mock(ctrl)
-			handler := NewUserHandler(userSvc, codeSvc, nil, nil)
+			handler := NewUserHandler(userSvc, codeSvc, nil, nil, nil, nil, nil, nil, nil, nil)
 
 			server := gin.Default()
 			handler.RegisterRoutes(server.Group("/users"))
@@ -806,7 +938,7 @@ func TestUserHandler_LoginSMS(t *testing.T) {
 				codeSvc.EXPECT().Verify(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
-				jwt.EXPECT().Generate(gomock.Any(), gomock.Any()).Return("any string", nil)
+				jwt.EXPECT().Generate(gomock.Any(), "ssid", gomock.Any()).Return("any string", nil)
 
 				return userSvc, codeSvc, jwt
 			},
@@ -933,7 +1065,7 @@ func TestUserHandler_LoginSMS(t *testing.T) {
 				codeSvc.EXPECT().Verify(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
-				jwt.EXPECT().Generate(gomock.Any(), gomock.Any()).Return("", errors.New("模拟错误"))
+				jwt.EXPECT().Generate(gomock.Any(), "ssid", gomock.Any()).Return("", errors.New("模拟错误"))
 
 				return userSvc, codeSvc, jwt
 			},
@@ -958,7 +1090,7 @@ func TestUserHandler_LoginSMS(t *testing.T) {
 			defer ctrl.Finish()
 
 			userSvc, codeSvc, jwt := tc.mock(ctrl)
-			handler := NewUserHandler(userSvc, codeSvc, nil, jwt)
+			handler := NewUserHandler(userSvc, codeSvc, nil, nil, nil, newSessionSvc(ctrl), jwt, newLimiter(ctrl), newTwoFactor(ctrl), newDeletion(ctrl))
 
 			server := gin.Default()
 			handler.RegisterRoutes(server.Group("/users"))
@@ -973,3 +1105,1513 @@ func TestUserHandler_LoginSMS(t *testing.T) {
 		})
 	}
 }
+
+func TestUserHandler_RefreshToken(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mock        func(ctrl *gomock.Controller) (service.ISessionService, jwt_generator.IJWTGenerator)
+		wantCode    int
+		wantBody    string
+		wantRefresh string
+	}{
+		{
+			name: "刷新成功",
+			mock: func(ctrl *gomock.Controller) (service.ISessionService, jwt_generator.IJWTGenerator) {
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().Refresh(gomock.Any(), "ssid.old", "test-agent").
+					Return(domain.Session{Id: "ssid", UserId: 1}, "ssid.new", nil)
+				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
+				jwt.EXPECT().Generate("1", "ssid", gomock.Any()).Return("access", nil)
+				return sessionSvc, jwt
+			},
+			wantCode:    200,
+			wantBody:    `{"code":0,"msg":"刷新成功","data":null}`,
+			wantRefresh: "ssid.new",
+		},
+		{
+			name: "refresh token 不合法",
+			mock: func(ctrl *gomock.Controller) (service.ISessionService, jwt_generator.IJWTGenerator) {
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().Refresh(gomock.Any(), "ssid.old", "test-agent").
+					Return(domain.Session{}, "", service.ErrInvalidRefreshToken)
+				return sessionSvc, nil
+			},
+			wantCode: 401,
+			wantBody: `{"code":4,"msg":"请重新登录","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessionSvc, jwt := tc.mock(ctrl)
+			handler := NewUserHandler(nil, nil, nil, nil, nil, sessionSvc, jwt, nil, nil, nil)
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/refresh_token", nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer ssid.old")
+			req.Header.Set("User-Agent", "test-agent")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+			assert.Equal(t, tc.wantRefresh, recorder.Header().Get("X-Refresh-Token"))
+		})
+	}
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionSvc := svcmocks.NewMockISessionService(ctrl)
+	sessionSvc.EXPECT().Revoke(gomock.Any(), uint64(1), "ssid").Return(nil)
+	handler := NewUserHandler(nil, nil, nil, nil, nil, sessionSvc, nil, nil, nil, nil)
+
+	server := gin.Default()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("UserId", uint64(1))
+		ctx.Set("SessionId", "ssid")
+	})
+	handler.RegisterRoutes(server.Group("/users"))
+
+	req, err := http.NewRequest(http.MethodPost, "/users/logout", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, `{"code":0,"msg":"退出登录成功","data":null}`, recorder.Body.String())
+}
+
+func TestUserHandler_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionSvc := svcmocks.NewMockISessionService(ctrl)
+	sessionSvc.EXPECT().List(gomock.Any(), uint64(1)).Return([]domain.Session{
+		{
+			Id:          "ssid",
+			Device:      "iPhone",
+			UserAgent:   "ua1",
+			Ip:          "127.0.0.1",
+			LoginMethod: domain.LoginBySMS,
+			CreateTime:  time.UnixMilli(1000),
+			LastSeen:    time.UnixMilli(2000),
+		},
+		{
+			Id:          "other",
+			Device:      "Mac",
+			UserAgent:   "ua2",
+			Ip:          "10.0.0.1",
+			LoginMethod: domain.LoginByEmail,
+			CreateTime:  time.UnixMilli(500),
+			LastSeen:    time.UnixMilli(600),
+		},
+	}, nil)
+	handler := NewUserHandler(nil, nil, nil, nil, nil, sessionSvc, nil, nil, nil, nil)
+
+	server := gin.Default()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("UserId", uint64(1))
+		ctx.Set("SessionId", "ssid")
+	})
+	handler.RegisterRoutes(server.Group("/users"))
+
+	req, err := http.NewRequest(http.MethodGet, "/users/sessions", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, `{"code":0,"msg":"","data":[`+
+		`{"id":"ssid","device":"iPhone","userAgent":"ua1","ip":"127.0.0.1","loginMethod":"sms","createTime":1000,"lastSeen":2000,"current":true},`+
+		`{"id":"other","device":"Mac","userAgent":"ua2","ip":"10.0.0.1","loginMethod":"email","createTime":500,"lastSeen":600,"current":false}]}`,
+		recorder.Body.String())
+}
+
+func TestUserHandler_LogoutSession(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		mock     func(ctrl *gomock.Controller) service.ISessionService
+		wantBody string
+	}{
+		{
+			name: "下线指定设备",
+			path: "/users/sessions/other/logout",
+			mock: func(ctrl *gomock.Controller) service.ISessionService {
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().Revoke(gomock.Any(), uint64(1), "other").Return(nil)
+				return sessionSvc
+			},
+			wantBody: `{"code":0,"msg":"已下线","data":null}`,
+		},
+		{
+			name: "会话不存在或者不是自己的",
+			path: "/users/sessions/other/logout",
+			mock: func(ctrl *gomock.Controller) service.ISessionService {
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().Revoke(gomock.Any(), uint64(1), "other").Return(service.ErrSessionNotFound)
+				return sessionSvc
+			},
+			wantBody: `{"code":4,"msg":"会话不存在","data":null}`,
+		},
+		{
+			name: "下线其它设备",
+			path: "/users/sessions/logout_others",
+			mock: func(ctrl *gomock.Controller) service.ISessionService {
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().RevokeOthers(gomock.Any(), uint64(1), "ssid").Return(nil)
+				return sessionSvc
+			},
+			wantBody: `{"code":0,"msg":"已下线其它设备","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler := NewUserHandler(nil, nil, nil, nil, nil, tc.mock(ctrl), nil, nil, nil, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+				ctx.Set("SessionId", "ssid")
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, 200, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_ResetPassword(t *testing.T) {
+	const url = "/users/password/reset"
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.ISessionService)
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "重置成功，所有设备下线",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.ISessionService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "reset_password", "13800000000", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().ResetPassword(gomock.Any(), "13800000000", "hello@world#123").
+					Return(domain.User{Id: 1}, nil)
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().RevokeAll(gomock.Any(), uint64(1)).Return(nil)
+				return userSvc, codeSvc, sessionSvc
+			},
+			body:     `{"phone":"13800000000","code":"1234","password":"hello@world#123","confirmPassword":"hello@world#123"}`,
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"密码已重置，请重新登录","data":null}`,
+		},
+		{
+			name: "两次密码不一致",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.ISessionService) {
+				return nil, nil, nil
+			},
+			body:     `{"phone":"13800000000","code":"1234","password":"hello@world#123","confirmPassword":"hello@world#124"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"两次输入的密码不一致","data":null}`,
+		},
+		{
+			name: "密码太简单，不消耗验证码",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.ISessionService) {
+				return nil, nil, nil
+			},
+			body:     `{"phone":"13800000000","code":"1234","password":"123456","confirmPassword":"123456"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"密码必须大于8位，包含数字、特殊字符","data":null}`,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.ISessionService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "reset_password", "13800000000", "1234").
+					Return(service.ErrCodeVerifyFailed)
+				return nil, codeSvc, nil
+			},
+			body:     `{"phone":"13800000000","code":"1234","password":"hello@world#123","confirmPassword":"hello@world#123"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"验证码错误","data":null}`,
+		},
+		{
+			name: "手机号未注册",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.ISessionService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "reset_password", "13800000000", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().ResetPassword(gomock.Any(), "13800000000", "hello@world#123").
+					Return(domain.User{}, service.ErrUserNotFound)
+				return userSvc, codeSvc, nil
+			},
+			body:     `{"phone":"13800000000","code":"1234","password":"hello@world#123","confirmPassword":"hello@world#123"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"手机号未注册","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, codeSvc, sessionSvc := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, codeSvc, nil, nil, nil, sessionSvc, nil, nil, nil, nil)
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.ISessionService)
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "修改成功，其它设备下线",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ISessionService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().ChangePassword(gomock.Any(), uint64(1), "old@world#123", "hello@world#123").Return(nil)
+				sessionSvc := svcmocks.NewMockISessionService(ctrl)
+				sessionSvc.EXPECT().RevokeOthers(gomock.Any(), uint64(1), "ssid").Return(nil)
+				return userSvc, sessionSvc
+			},
+			body:     `{"oldPassword":"old@world#123","password":"hello@world#123","confirmPassword":"hello@world#123"}`,
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"密码修改成功","data":null}`,
+		},
+		{
+			name: "原密码不正确",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ISessionService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().ChangePassword(gomock.Any(), uint64(1), "wrong", "hello@world#123").
+					Return(service.ErrInvalidUserOrPassword)
+				return userSvc, nil
+			},
+			body:     `{"oldPassword":"wrong","password":"hello@world#123","confirmPassword":"hello@world#123"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"原密码不正确","data":null}`,
+		},
+		{
+			name: "新密码太简单",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ISessionService) {
+				return nil, nil
+			},
+			body:     `{"oldPassword":"old@world#123","password":"123456","confirmPassword":"123456"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"密码必须大于8位，包含数字、特殊字符","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, sessionSvc := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, nil, nil, nil, sessionSvc, nil, nil, nil, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+				ctx.Set("SessionId", "ssid")
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/password", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_BindPhone(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.CodeService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "绑定成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "bind_phone", "13800000000", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().BindPhone(gomock.Any(), uint64(1), "13800000000").Return(nil)
+				return userSvc, codeSvc
+			},
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"绑定成功","data":null}`,
+		},
+		{
+			name: "手机号已被其他账号绑定",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "bind_phone", "13800000000", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().BindPhone(gomock.Any(), uint64(1), "13800000000").Return(service.ErrUserDuplicate)
+				return userSvc, codeSvc
+			},
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"手机号已被其他账号绑定","data":null}`,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "bind_phone", "13800000000", "1234").
+					Return(service.ErrCodeVerifyFailed)
+				return nil, codeSvc
+			},
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"验证码错误","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, codeSvc := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, codeSvc, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			body := bytes.NewBufferString(`{"phone":"13800000000","code":"1234"}`)
+			req, err := http.NewRequest(http.MethodPost, "/users/bind/phone", body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_BindEmail(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "绑定成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "bind_email", "a@qq.com", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().BindEmail(gomock.Any(), uint64(1), "a@qq.com").Return(nil)
+				return userSvc, emailCode
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"绑定成功","data":null}`,
+		},
+		{
+			name: "验证码错误不绑定",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "bind_email", "a@qq.com", "1234").Return(service.ErrCodeVerifyFailed)
+				return nil, emailCode
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"验证码错误","data":null}`,
+		},
+		{
+			name: "邮箱已被其他账号绑定",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "bind_email", "a@qq.com", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().BindEmail(gomock.Any(), uint64(1), "a@qq.com").Return(service.ErrUserDuplicate)
+				return userSvc, emailCode
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"邮箱已被其他账号绑定","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, emailCode := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, emailCode, nil, nil, nil, nil, nil, nil, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/bind/email", bytes.NewBufferString(`{"email":"a@qq.com","code":"1234"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_Unbind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kind     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "解绑成功",
+			kind:     "github",
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"解绑成功","data":null}`,
+		},
+		{
+			name:     "最后一种登录方式",
+			kind:     "phone",
+			err:      service.ErrLastLoginMethod,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"至少需要保留一种登录方式","data":null}`,
+		},
+		{
+			name:     "不支持的登录方式",
+			kind:     "password",
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"不支持的登录方式","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := svcmocks.NewMockIUserService(ctrl)
+			if tc.kind != "password" {
+				userSvc.EXPECT().Unbind(gomock.Any(), uint64(1), tc.kind).Return(tc.err)
+			}
+			registry := oauth.NewRegistry(newProvider(ctrl, "github"))
+			handler := NewUserHandler(userSvc, nil, nil, registry, nil, nil, nil, nil, nil, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/unbind/"+tc.kind, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_OAuthCallback(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider)
+		url      string
+		cookie   string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "登录成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Peek(gomock.Any(), "s1").Return(uint64(0), nil)
+				stateSvc.EXPECT().Verify(gomock.Any(), "s1").Return(uint64(0), nil)
+				identity := domain.ExternalIdentity{Provider: "github", Subject: "1", Login: "octocat"}
+				provider := newProvider(ctrl, "github")
+				provider.EXPECT().Exchange(gomock.Any(), "c").Return(identity, nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindOrCreateByIdentity(gomock.Any(), identity).Return(domain.User{Id: 1}, nil)
+				return userSvc, stateSvc, provider
+			},
+			url:      "/users/oauth/github/callback?code=c&state=s1",
+			cookie:   "s1",
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"github 登录成功","data":null}`,
+		},
+		{
+			name: "不支持的 provider",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				return nil, nil, newProvider(ctrl, "github")
+			},
+			url:      "/users/oauth/gitlab/callback?code=c&state=s1",
+			cookie:   "s1",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"code":4,"msg":"不支持的登录方式","data":null}`,
+		},
+		{
+			name: "state 和 cookie 对不上",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				return nil, svcmocks.NewMockIOAuthStateService(ctrl), newProvider(ctrl, "github")
+			},
+			url:      "/users/oauth/github/callback?code=c&state=s1",
+			cookie:   "s2",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"授权请求已失效，请重新登录","data":null}`,
+		},
+		{
+			name: "state 已经用过",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Peek(gomock.Any(), "s1").Return(uint64(0), nil)
+				stateSvc.EXPECT().Verify(gomock.Any(), "s1").Return(uint64(0), service.ErrInvalidOAuthState)
+				return nil, stateSvc, newProvider(ctrl, "github")
+			},
+			url:      "/users/oauth/github/callback?code=c&state=s1",
+			cookie:   "s1",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"授权请求已失效，请重新登录","data":null}`,
+		},
+		{
+			name: "绑定发起的授权不登录",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Peek(gomock.Any(), "s1").Return(uint64(1), nil)
+				return nil, stateSvc, newProvider(ctrl, "github")
+			},
+			url:      "/users/oauth/github/callback?code=c&state=s1",
+			cookie:   "s1",
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"请提交绑定","data":{"code":"c","state":"s1"}}`,
+		},
+		{
+			name: "授权服务器返回错误",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Peek(gomock.Any(), "s1").Return(uint64(0), nil)
+				stateSvc.EXPECT().Verify(gomock.Any(), "s1").Return(uint64(0), nil)
+				provider := newProvider(ctrl, "sso")
+				provider.EXPECT().Exchange(gomock.Any(), "c").Return(domain.ExternalIdentity{}, &oauth.Error{
+					Code:        "invalid_grant",
+					Description: "code 已过期",
+				})
+				return nil, stateSvc, provider
+			},
+			url:      "/users/oauth/sso/callback?code=c&state=s1",
+			cookie:   "s1",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"sso 授权失败：code 已过期","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, stateSvc, provider := tc.mock(ctrl)
+			jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
+			jwt.EXPECT().Generate("1", "ssid", gomock.Any()).Return("Test Token", nil).AnyTimes()
+			handler := NewUserHandler(userSvc, nil, nil, oauth.NewRegistry(provider), stateSvc, newSessionSvc(ctrl), jwt, nil, newTwoFactor(ctrl), newDeletion(ctrl))
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+			req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: tc.cookie})
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func newProvider(ctrl *gomock.Controller, name string) *oauthmocks.MockProvider {
+	provider := oauthmocks.NewMockProvider(ctrl)
+	provider.EXPECT().Name().Return(name).AnyTimes()
+	return provider
+}
+
+func TestUserHandler_BindOAuth(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider)
+		cookie   string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "绑定成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Verify(gomock.Any(), "s1").Return(uint64(1), nil)
+				identity := domain.ExternalIdentity{Provider: "github", Subject: "1"}
+				provider := newProvider(ctrl, "github")
+				provider.EXPECT().Exchange(gomock.Any(), "c").Return(identity, nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().BindIdentity(gomock.Any(), uint64(1), identity).Return(nil)
+				return userSvc, stateSvc, provider
+			},
+			cookie:   "s1",
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"绑定成功","data":null}`,
+		},
+		{
+			name: "不是这个浏览器发起的",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				return nil, svcmocks.NewMockIOAuthStateService(ctrl), newProvider(ctrl, "github")
+			},
+			cookie:   "s2",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"授权请求已失效，请重新绑定","data":null}`,
+		},
+		{
+			name: "别的用户发起的",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Verify(gomock.Any(), "s1").Return(uint64(2), nil)
+				return nil, stateSvc, newProvider(ctrl, "github")
+			},
+			cookie:   "s1",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"授权请求已失效，请重新登录","data":null}`,
+		},
+		{
+			name: "登录发起的 state 不能用来绑定",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.IOAuthStateService, oauth.Provider) {
+				stateSvc := svcmocks.NewMockIOAuthStateService(ctrl)
+				stateSvc.EXPECT().Verify(gomock.Any(), "s1").Return(uint64(0), nil)
+				return nil, stateSvc, newProvider(ctrl, "github")
+			},
+			cookie:   "s1",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"授权请求已失效，请重新登录","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, stateSvc, provider := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, nil, oauth.NewRegistry(provider), stateSvc, nil, nil, nil, nil, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/oauth/github/bind", bytes.NewBufferString(`{"code":"c","state":"s1"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: tc.cookie})
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_LoginLimit(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mock           func(ctrl *gomock.Controller) (service.IUserService, service.ILoginLimitService)
+		wantCode       int
+		wantRetryAfter string
+		wantBody       string
+	}{
+		{
+			name: "密码错误，记录失败",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ILoginLimitService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), "a@qq.com", "wrong").Return(domain.User{}, service.ErrInvalidUserOrPassword)
+				limiter := svcmocks.NewMockILoginLimitService(ctrl)
+				limiter.EXPECT().Check(gomock.Any(), domain.LockoutByAccount, "a@qq.com", "1.2.3.4").Return(nil)
+				limiter.EXPECT().Fail(gomock.Any(), domain.LockoutByAccount, "a@qq.com", "1.2.3.4").Return(nil)
+				return userSvc, limiter
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"用户名或密码不正确","data":null}`,
+		},
+		{
+			name: "需要等待",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ILoginLimitService) {
+				limiter := svcmocks.NewMockILoginLimitService(ctrl)
+				limiter.EXPECT().Check(gomock.Any(), domain.LockoutByAccount, "a@qq.com", "1.2.3.4").
+					Return(&service.LoginLimitedError{RetryAfter: time.Millisecond * 1500})
+				return svcmocks.NewMockIUserService(ctrl), limiter
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "2",
+			wantBody:       `{"code":4,"msg":"尝试太频繁，请稍后再试","data":null}`,
+		},
+		{
+			name: "已锁定",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ILoginLimitService) {
+				limiter := svcmocks.NewMockILoginLimitService(ctrl)
+				limiter.EXPECT().Check(gomock.Any(), domain.LockoutByAccount, "a@qq.com", "1.2.3.4").
+					Return(&service.LoginLimitedError{Locked: true, RetryAfter: time.Minute * 30})
+				return svcmocks.NewMockIUserService(ctrl), limiter
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "1800",
+			wantBody:       `{"code":4,"msg":"登录失败次数过多，账号已临时锁定，可以通过短信验证解锁","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, limiter := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, nil, nil, nil, nil, nil, limiter, nil, nil)
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			body := bytes.NewBuffer([]byte(`{"email": "a@qq.com", "password": "wrong"}`))
+			req, err := http.NewRequest(http.MethodPost, "/users/login", body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "1.2.3.4:5678"
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantRetryAfter, recorder.Header().Get("Retry-After"))
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_UnlockLogin(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.EmailCodeService, service.ILoginLimitService)
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "解锁手机号、邮箱和 IP",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.EmailCodeService, service.ILoginLimitService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "unlock_login", "13800000000", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindByPhone(gomock.Any(), "13800000000").
+					Return(domain.User{Id: 1, Phone: "13800000000", Email: "a@qq.com"}, nil)
+				limiter := svcmocks.NewMockILoginLimitService(ctrl)
+				limiter.EXPECT().Unlock(gomock.Any(), domain.LockoutByAccount, "13800000000").Return(nil)
+				limiter.EXPECT().Unlock(gomock.Any(), domain.LockoutByAccount, "a@qq.com").Return(nil)
+				limiter.EXPECT().Unlock(gomock.Any(), domain.LockoutByIp, "10.0.0.1").Return(nil)
+				return userSvc, codeSvc, nil, limiter
+			},
+			body:     `{"phone": "13800000000", "code": "1234"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"已解锁","data":null}`,
+		},
+		{
+			name: "邮箱验证码解锁",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.EmailCodeService, service.ILoginLimitService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "unlock_login", "a@qq.com", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").
+					Return(domain.User{Id: 1, Phone: "13800000000", Email: "a@qq.com"}, nil)
+				limiter := svcmocks.NewMockILoginLimitService(ctrl)
+				limiter.EXPECT().Unlock(gomock.Any(), domain.LockoutByAccount, "a@qq.com").Return(nil)
+				limiter.EXPECT().Unlock(gomock.Any(), domain.LockoutByAccount, "13800000000").Return(nil)
+				limiter.EXPECT().Unlock(gomock.Any(), domain.LockoutByIp, "10.0.0.1").Return(nil)
+				return userSvc, nil, emailCode, limiter
+			},
+			body:     `{"email": "a@qq.com", "code": "1234"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"已解锁","data":null}`,
+		},
+		{
+			name: "手机格式不正确",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.EmailCodeService, service.ILoginLimitService) {
+				return nil, nil, nil, nil
+			},
+			body:     `{"phone": "a@qq.com", "code": "1234"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"手机格式不正确","data":null}`,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.CodeService, service.EmailCodeService, service.ILoginLimitService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "unlock_login", "13800000000", "1234").Return(service.ErrCodeVerifyFailed)
+				return svcmocks.NewMockIUserService(ctrl), codeSvc, nil, svcmocks.NewMockILoginLimitService(ctrl)
+			},
+			body:     `{"phone": "13800000000", "code": "1234"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"验证码错误","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, codeSvc, emailCode, limiter := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, codeSvc, emailCode, nil, nil, nil, nil, limiter, nil, nil)
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			body := bytes.NewBuffer([]byte(tc.body))
+			req, err := http.NewRequest(http.MethodPost, "/users/login/unlock", body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "10.0.0.1:12345"
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_LoginWithTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userSvc := svcmocks.NewMockIUserService(ctrl)
+	userSvc.EXPECT().Login(gomock.Any(), "a@qq.com", "hello@world#123").Return(domain.User{Id: 1}, nil)
+	twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+	twoFactor.EXPECT().Enabled(gomock.Any(), uint64(1)).Return(true, nil)
+	twoFactor.EXPECT().Challenge(gomock.Any(), uint64(1), domain.LoginByEmail).Return(domain.TwoFactorChallenge{
+		Id:         "challenge",
+		UserId:     1,
+		ExpireTime: time.UnixMilli(1694575373000),
+	}, nil)
+	// 没有通过第二步之前不能创建会话，也不能取消注销申请
+	sessionSvc := svcmocks.NewMockISessionService(ctrl)
+	deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+
+	handler := NewUserHandler(userSvc, nil, nil, nil, nil, sessionSvc, nil, newLimiter(ctrl), twoFactor, deletion)
+	server := gin.Default()
+	handler.RegisterRoutes(server.Group("/users"))
+
+	body := bytes.NewBuffer([]byte(`{"email": "a@qq.com", "password": "hello@world#123"}`))
+	req, err := http.NewRequest(http.MethodPost, "/users/login", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"code":0,"msg":"请输入两步验证码","data":{"twoFactorRequired":true,"challenge":"challenge","expireTime":1694575373000}}`, recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get("X-Jwt-Token"))
+}
+
+func TestUserHandler_LoginCancelsDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userSvc := svcmocks.NewMockIUserService(ctrl)
+	userSvc.EXPECT().Login(gomock.Any(), "a@qq.com", "hello@world#123").Return(domain.User{Id: 1}, nil)
+	deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+	deletion.EXPECT().Cancel(gomock.Any(), uint64(1)).Return(true, nil)
+	jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
+	jwt.EXPECT().Generate("1", "ssid", gomock.Any()).Return("Test Token", nil)
+
+	handler := NewUserHandler(userSvc, nil, nil, nil, nil, newSessionSvc(ctrl), jwt, newLimiter(ctrl), newTwoFactor(ctrl), deletion)
+	server := gin.Default()
+	handler.RegisterRoutes(server.Group("/users"))
+
+	body := bytes.NewBuffer([]byte(`{"email": "a@qq.com", "password": "hello@world#123"}`))
+	req, err := http.NewRequest(http.MethodPost, "/users/login", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "Test Token", recorder.Header().Get("X-Jwt-Token"))
+}
+
+func TestUserHandler_LoginTwoFactor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) (service.IUserService, service.ITwoFactorService, jwt_generator.IJWTGenerator)
+		body      string
+		wantCode  int
+		wantBody  string
+		wantToken string
+	}{
+		{
+			name: "验证成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ITwoFactorService, jwt_generator.IJWTGenerator) {
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().VerifyChallenge(gomock.Any(), "challenge", "123456").Return(domain.TwoFactorChallenge{
+					Id:          "challenge",
+					UserId:      1,
+					LoginMethod: domain.LoginBySMS,
+				}, nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().CheckBan(gomock.Any(), uint64(1)).Return(nil)
+				jwt := jwtmocks.NewMockIJWTGenerator(ctrl)
+				jwt.EXPECT().Generate("1", "ssid", gomock.Any()).Return("Test Token", nil)
+				return userSvc, twoFactor, jwt
+			},
+			body:      `{"challenge": "challenge", "code": "123456"}`,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":0,"msg":"登录成功","data":null}`,
+			wantToken: "Test Token",
+		},
+		{
+			name: "缺少验证码",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ITwoFactorService, jwt_generator.IJWTGenerator) {
+				return nil, nil, nil
+			},
+			body:     `{"challenge": "challenge"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"输入错误","data":null}`,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ITwoFactorService, jwt_generator.IJWTGenerator) {
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().VerifyChallenge(gomock.Any(), "challenge", "000000").
+					Return(domain.TwoFactorChallenge{}, service.ErrInvalidTwoFactorCode)
+				return nil, twoFactor, nil
+			},
+			body:     `{"challenge": "challenge", "code": "000000"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"验证码错误","data":null}`,
+		},
+		{
+			name: "challenge 失效",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ITwoFactorService, jwt_generator.IJWTGenerator) {
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().VerifyChallenge(gomock.Any(), "challenge", "123456").
+					Return(domain.TwoFactorChallenge{}, service.ErrInvalidChallenge)
+				return nil, twoFactor, nil
+			},
+			body:     `{"challenge": "challenge", "code": "123456"}`,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":4,"msg":"两步验证已失效，请重新登录","data":null}`,
+		},
+		{
+			name: "账号被封禁",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.ITwoFactorService, jwt_generator.IJWTGenerator) {
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().VerifyChallenge(gomock.Any(), "challenge", "123456").Return(domain.TwoFactorChallenge{
+					Id:          "challenge",
+					UserId:      1,
+					LoginMethod: domain.LoginBySMS,
+				}, nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().CheckBan(gomock.Any(), uint64(1)).Return(&service.UserBannedError{Reason: "发布广告"})
+				return userSvc, twoFactor, nil
+			},
+			body:     `{"challenge": "challenge", "code": "123456"}`,
+			wantCode: http.StatusForbidden,
+			wantBody: `{"code":4,"msg":"账号已被封禁，原因：发布广告","data":{"reason":"发布广告","until":0}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, twoFactor, jwt := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, nil, nil, nil, newSessionSvc(ctrl), jwt, nil, twoFactor, newDeletion(ctrl))
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/login/2fa", bytes.NewBuffer([]byte(tc.body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+			assert.Equal(t, tc.wantToken, recorder.Header().Get("X-Jwt-Token"))
+		})
+	}
+}
+
+func TestUserHandler_ResetPasswordByEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 填了邮箱就不走短信
+	codeSvc := svcmocks.NewMockCodeService(ctrl)
+	emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+	emailCode.EXPECT().Verify(gomock.Any(), "reset_password", "a@qq.com", "1234").Return(nil)
+	userSvc := svcmocks.NewMockIUserService(ctrl)
+	userSvc.EXPECT().ResetPasswordByEmail(gomock.Any(), "a@qq.com", "hello@world#123").
+		Return(domain.User{Id: 1}, nil)
+	sessionSvc := svcmocks.NewMockISessionService(ctrl)
+	sessionSvc.EXPECT().RevokeAll(gomock.Any(), uint64(1)).Return(nil)
+
+	handler := NewUserHandler(userSvc, codeSvc, emailCode, nil, nil, sessionSvc, nil, nil, nil, nil)
+	server := gin.Default()
+	handler.RegisterRoutes(server.Group("/users"))
+
+	body := `{"phone":"13800000000","email":"a@qq.com","code":"1234","password":"hello@world#123","confirmPassword":"hello@world#123"}`
+	req, err := http.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"code":0,"msg":"密码已重置，请重新登录","data":null}`, recorder.Body.String())
+}
+
+func TestUserHandler_SendVerifyEmailCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService)
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "发送成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(domain.User{Id: 1, Email: "a@qq.com"}, nil)
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Send(gomock.Any(), "verify_email", "a@qq.com").Return(nil)
+				return userSvc, emailCode
+			},
+			body:     `{"email":"a@qq.com"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功","data":null}`,
+		},
+		{
+			name: "没有注册，不发送但是一样返回成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(domain.User{}, service.ErrUserNotFound)
+				return userSvc, svcmocks.NewMockEmailCodeService(ctrl)
+			},
+			body:     `{"email":"a@qq.com"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功","data":null}`,
+		},
+		{
+			name: "已经验证过，不发送",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").
+					Return(domain.User{Id: 1, Email: "a@qq.com", EmailVerified: true}, nil)
+				return userSvc, svcmocks.NewMockEmailCodeService(ctrl)
+			},
+			body:     `{"email":"a@qq.com"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功","data":null}`,
+		},
+		{
+			name: "发送太频繁",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(domain.User{Id: 1, Email: "a@qq.com"}, nil)
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Send(gomock.Any(), "verify_email", "a@qq.com").Return(service.ErrCodeSendTooMany)
+				return userSvc, emailCode
+			},
+			body:     `{"email":"a@qq.com"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"发送太频繁，请稍后再试","data":null}`,
+		},
+		{
+			name: "邮箱格式不正确",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				return nil, nil
+			},
+			body:     `{"email":"a"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"邮箱格式不正确","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, emailCode := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, emailCode, nil, nil, nil, nil, nil, nil, nil)
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/email/verify/code/send", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_VerifyEmail(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "验证成功",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "verify_email", "a@qq.com", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().VerifyEmail(gomock.Any(), "a@qq.com").Return(nil)
+				return userSvc, emailCode
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"邮箱验证成功","data":null}`,
+		},
+		{
+			name: "验证码错误",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "verify_email", "a@qq.com", "1234").Return(service.ErrCodeVerifyFailed)
+				return nil, emailCode
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"验证码错误","data":null}`,
+		},
+		{
+			name: "邮箱已经换掉了",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService) {
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Verify(gomock.Any(), "verify_email", "a@qq.com", "1234").Return(nil)
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().VerifyEmail(gomock.Any(), "a@qq.com").Return(service.ErrUserNotFound)
+				return userSvc, emailCode
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"邮箱未注册","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, emailCode := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, emailCode, nil, nil, nil, nil, nil, nil, nil)
+
+			server := gin.Default()
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/email/verify", bytes.NewBufferString(`{"email":"a@qq.com","code":"1234"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_Delete(t *testing.T) {
+	type deps struct {
+		userSvc   service.IUserService
+		codeSvc   service.CodeService
+		twoFactor service.ITwoFactorService
+		deletion  service.IAccountDeletionService
+	}
+
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) deps
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "申请成功",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Password: "hash"}, nil)
+				deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+				deletion.EXPECT().Request(gomock.Any(), uint64(1), "hello@world#123").
+					Return(time.UnixMilli(1694575373863), nil)
+				return deps{userSvc: userSvc, deletion: deletion}
+			},
+			body:     `{"password":"hello@world#123"}`,
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"已申请注销，冷静期内重新登录可以取消","data":{"deleteAfter":1694575373863}}`,
+		},
+		{
+			name: "密码错误",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Password: "hash"}, nil)
+				deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+				deletion.EXPECT().Request(gomock.Any(), uint64(1), "wrong").
+					Return(time.Time{}, service.ErrInvalidUserOrPassword)
+				return deps{userSvc: userSvc, deletion: deletion}
+			},
+			body:     `{"password":"wrong"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"密码不正确","data":null}`,
+		},
+		{
+			name: "没有密码，短信验证码正确",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Phone: "13800000000"}, nil)
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().Verify(gomock.Any(), uint64(1), "1234").Return(service.ErrTwoFactorNotEnabled)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), "delete_account", "13800000000", "1234").Return(nil)
+				deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+				deletion.EXPECT().Request(gomock.Any(), uint64(1), "").
+					Return(time.UnixMilli(1694575373863), nil)
+				return deps{userSvc: userSvc, codeSvc: codeSvc, twoFactor: twoFactor, deletion: deletion}
+			},
+			body:     `{"code":"1234"}`,
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"已申请注销，冷静期内重新登录可以取消","data":{"deleteAfter":1694575373863}}`,
+		},
+		{
+			name: "没有密码，缺少验证码",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Phone: "13800000000"}, nil)
+				return deps{userSvc: userSvc}
+			},
+			body:     `{}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"请输入验证码","data":null}`,
+		},
+		{
+			name: "没有密码，两步验证码错误",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1}, nil)
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().Verify(gomock.Any(), uint64(1), "123456").Return(service.ErrInvalidTwoFactorCode)
+				return deps{userSvc: userSvc, twoFactor: twoFactor}
+			},
+			body:     `{"code":"123456"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"两步验证码错误","data":null}`,
+		},
+		{
+			name: "没有密码，也没有手机号和邮箱",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1}, nil)
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().Verify(gomock.Any(), uint64(1), "1234").Return(service.ErrTwoFactorNotEnabled)
+				return deps{userSvc: userSvc, twoFactor: twoFactor}
+			},
+			body:     `{"code":"1234"}`,
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"请先绑定手机号或者邮箱再注销","data":null}`,
+		},
+		{
+			name: "系统错误",
+			mock: func(ctrl *gomock.Controller) deps {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Password: "hash"}, nil)
+				deletion := svcmocks.NewMockIAccountDeletionService(ctrl)
+				deletion.EXPECT().Request(gomock.Any(), uint64(1), "").
+					Return(time.Time{}, errors.New("db error"))
+				return deps{userSvc: userSvc, deletion: deletion}
+			},
+			body:     `{}`,
+			wantCode: 500,
+			wantBody: `{"code":5,"msg":"系统错误","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := tc.mock(ctrl)
+			handler := NewUserHandler(d.userSvc, d.codeSvc, nil, nil, nil, nil, nil, nil, d.twoFactor, d.deletion)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/delete", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_SendDeleteAccountCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService, service.ITwoFactorService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "没有手机号时发邮件",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService, service.ITwoFactorService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Email: "a@qq.com"}, nil)
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().Enabled(gomock.Any(), uint64(1)).Return(false, nil)
+				emailCode := svcmocks.NewMockEmailCodeService(ctrl)
+				emailCode.EXPECT().Send(gomock.Any(), "delete_account", "a@qq.com").Return(nil)
+				return userSvc, emailCode, twoFactor
+			},
+			wantCode: 200,
+			wantBody: `{"code":0,"msg":"发送成功","data":null}`,
+		},
+		{
+			name: "设置过密码",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService, service.ITwoFactorService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Password: "hash"}, nil)
+				return userSvc, nil, nil
+			},
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"请输入密码确认注销","data":null}`,
+		},
+		{
+			name: "开启了两步验证",
+			mock: func(ctrl *gomock.Controller) (service.IUserService, service.EmailCodeService, service.ITwoFactorService) {
+				userSvc := svcmocks.NewMockIUserService(ctrl)
+				userSvc.EXPECT().QueryProfile(gomock.Any(), uint64(1)).Return(domain.User{Id: 1, Email: "a@qq.com"}, nil)
+				twoFactor := svcmocks.NewMockITwoFactorService(ctrl)
+				twoFactor.EXPECT().Enabled(gomock.Any(), uint64(1)).Return(true, nil)
+				return userSvc, nil, twoFactor
+			},
+			wantCode: 400,
+			wantBody: `{"code":4,"msg":"请输入两步验证码确认注销","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, emailCode, twoFactor := tc.mock(ctrl)
+			handler := NewUserHandler(userSvc, nil, emailCode, nil, nil, nil, nil, nil, twoFactor, nil)
+
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("UserId", uint64(1))
+			})
+			handler.RegisterRoutes(server.Group("/users"))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/delete/code/send", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
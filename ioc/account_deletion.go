@@ -0,0 +1,60 @@
+package ioc
+
+import (
+	"context"
+	"time"
+	"yellowbook/config"
+	"yellowbook/internal/service"
+	"yellowbook/pkg/logger"
+)
+
+func InitAccountDeletionPolicy() service.AccountDeletionPolicy {
+	c := config.Conf.Account
+	policy := service.AccountDeletionPolicy{CoolingOff: c.DeletionCoolingOff}
+	if c.ArticlePolicy == "reassign" {
+		if c.ReassignTo == 0 {
+			panic("ArticlePolicy 为 reassign 时需要配置 ReassignTo")
+		}
+		policy.ReassignTo = c.ReassignTo
+	}
+	return policy
+}
+
+// AccountDeleter 定时匿名化冷静期已过的账号
+type AccountDeleter struct {
+	svc      service.IAccountDeletionService
+	l        logger.Logger
+	interval time.Duration
+	batch    int
+}
+
+func NewAccountDeleter(svc service.IAccountDeletionService, l logger.Logger) *AccountDeleter {
+	return &AccountDeleter{
+		svc:      svc,
+		l:        l,
+		interval: time.Minute,
+		batch:    20,
+	}
+}
+
+func (d *AccountDeleter) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := d.svc.ProcessDue(ctx, d.batch)
+		if err != nil {
+			d.l.Error("账号注销任务执行失败", logger.Field{Key: "error", Value: err.Error()})
+		}
+
+		if err == nil && n == d.batch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -4,15 +4,24 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"yellowbook/config"
+	"yellowbook/internal/pkg/gormutil"
 	"yellowbook/internal/repository/dao"
 )
 
+// 进程内所有的 gorm.DB 共用一份统计，爬虫根据它判断数据库的压力
+var queryStats = gormutil.NewQueryStats()
+
 func InitDB() *gorm.DB {
 	db, err := gorm.Open(mysql.Open(config.Conf.DB.DSN))
 	if err != nil {
 		panic(err)
 	}
 
+	err = db.Use(queryStats)
+	if err != nil {
+		panic(err)
+	}
+
 	err = dao.InitTable(db)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,16 @@
+package ioc
+
+import (
+	"yellowbook/config"
+	"yellowbook/internal/service/email"
+	"yellowbook/internal/service/email/memory"
+	"yellowbook/internal/service/email/smtp"
+)
+
+func InitEmailService() email.Service {
+	c := config.Conf.Email
+	if c.Driver == "smtp" {
+		return smtp.NewService(c.Addr, c.Username, c.Password, c.From)
+	}
+	return memory.NewService()
+}
@@ -1,13 +1,32 @@
 package ioc
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"time"
+	"yellowbook/config"
 	"yellowbook/internal/manage"
+	"yellowbook/internal/pkg/jwt_generator"
+	"yellowbook/internal/service"
 )
 
-func InitManageServer(userHandler *manage.UserHandler, articleHandler *manage.ArticleHandler) *gin.Engine {
+func InitManageServer(
+	userHandler *manage.UserHandler,
+	articleHandler *manage.ArticleHandler,
+	spiderHandler *manage.SpiderHandler,
+	adminHandler *manage.AdminHandler,
+	auditHandler *manage.AuditHandler,
+	jwt jwt_generator.IJWTGenerator,
+	adminSvc service.IAdminService,
+	adminSessions service.IAdminSessionService,
+	auditSvc service.IAuditService,
+) *gin.Engine {
+	bootstrapAdmin(adminSvc)
+
 	server := gin.Default()
 
 	server.Use(cors.New(cors.Config{
@@ -18,13 +37,50 @@ func InitManageServer(userHandler *manage.UserHandler, articleHandler *manage.Ar
 		MaxAge:           2 * time.Minute,
 	}))
 
-	//server.Use(
-	//	middleware.NewLoginMiddlewareBuilder().
-	//		Build(),
-	//)
+	server.Use(
+		manage.NewLoginMiddlewareBuilder(jwt, adminSvc, adminSessions).
+			IgnorePaths("/admins/login").
+			Build(),
+		// 只是浏览的列表不记，用户列表能看到手机号和邮箱，要记
+		manage.NewAuditMiddlewareBuilder(auditSvc).
+			IgnorePaths("/articles/list").
+			IgnorePaths("/admins/list").
+			IgnorePaths("/admins/roles/list").
+			IgnorePaths("/audit/list").
+			Build(),
+	)
 
 	userHandler.RegisterRoutes(server.Group("/users"))
 	articleHandler.RegisterRoutes(server.Group("/articles"))
+	spiderHandler.RegisterRoutes(server.Group("/spider"))
+	adminHandler.RegisterRoutes(server.Group("/admins"))
+	auditHandler.RegisterRoutes(server.Group("/audit"))
 
 	return server
 }
+
+// bootstrapAdmin 没有配置密码时随机生成一个，只在真正创建了管理员的时候打印这一次
+func bootstrapAdmin(adminSvc service.IAdminService) {
+	c := config.Conf.Admin
+	if c.BootstrapUsername == "" {
+		return
+	}
+
+	password := readSecret(c.BootstrapPassword, c.BootstrapPasswordPath)
+	generated := password == ""
+	if generated {
+		b := make([]byte, 18)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		password = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	created, err := adminSvc.Bootstrap(context.Background(), c.BootstrapUsername, password)
+	if err != nil {
+		panic(err)
+	}
+	if created && generated {
+		fmt.Printf("已创建管理员 %s，初始密码 %s，只显示这一次，请妥善保存\n", c.BootstrapUsername, password)
+	}
+}
@@ -0,0 +1,25 @@
+package ioc
+
+import (
+	"yellowbook/config"
+	"yellowbook/pkg/mq"
+	"yellowbook/pkg/mq/kafka"
+	"yellowbook/pkg/mq/memory"
+)
+
+// 同一个进程里的生产者和消费者共用一个内存 broker
+var memoryBroker = memory.NewBroker()
+
+func InitMQProducer() mq.Producer {
+	if config.Conf.MQ.Driver == "memory" {
+		return memoryBroker.Producer()
+	}
+	return kafka.NewProducer(config.Conf.MQ.Brokers)
+}
+
+func newMQConsumer(topic string, group string) mq.Consumer {
+	if config.Conf.MQ.Driver == "memory" {
+		return memoryBroker.Consumer(topic, group)
+	}
+	return kafka.NewConsumer(config.Conf.MQ.Brokers, topic, group)
+}
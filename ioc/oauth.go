@@ -0,0 +1,46 @@
+package ioc
+
+import (
+	"yellowbook/config"
+	"yellowbook/internal/repository"
+	"yellowbook/internal/service"
+	"yellowbook/internal/service/oauth"
+)
+
+func InitOAuthRegistry() *oauth.Registry {
+	var providers []oauth.Provider
+
+	gh := config.Conf.OAuth.Github
+	if gh.ClientId != "" {
+		secret := readSecret(gh.ClientSecret, gh.ClientSecretPath)
+		if secret == "" {
+			panic("GitHub 登录缺少 ClientSecret")
+		}
+		providers = append(providers, oauth.NewGithubProvider(gh.ClientId, secret, gh.RedirectURL))
+	}
+
+	for _, c := range config.Conf.OAuth.OIDC {
+		if c.Name == oauth.ProviderGithub {
+			panic("OIDC 的 Name 不能是 " + oauth.ProviderGithub)
+		}
+		providers = append(providers, oauth.NewOIDCProvider(oauth.OIDCConfig{
+			Name:         c.Name,
+			Issuer:       c.Issuer,
+			ClientId:     c.ClientId,
+			ClientSecret: readSecret(c.ClientSecret, c.ClientSecretPath),
+			RedirectURL:  c.RedirectURL,
+			Scopes:       c.Scopes,
+		}))
+	}
+
+	return oauth.NewRegistry(providers...)
+}
+
+func InitOAuthStateService(repo repository.IOAuthStateRepository) service.IOAuthStateService {
+	c := config.Conf.OAuth
+	key := readSecret(c.StateKey, c.StateKeyPath)
+	if key == "" {
+		panic("OAuth StateKey 不能为空")
+	}
+	return service.NewOAuthStateService(repo, []byte(key))
+}
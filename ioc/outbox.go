@@ -0,0 +1,97 @@
+package ioc
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/repository/dao"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/mq"
+)
+
+// OutboxRelay 把 outbox 表里的事件按写入顺序投递到消息队列
+// 可以多实例同时运行，互斥由 dao.Relay 里的行锁保证
+type OutboxRelay struct {
+	dao      dao.IOutboxDAO
+	producer mq.Producer
+	l        logger.Logger
+
+	interval      time.Duration
+	batch         int
+	retention     time.Duration
+	purgeInterval time.Duration
+	nowFunc       func() time.Time
+}
+
+func NewOutboxRelay(d dao.IOutboxDAO, producer mq.Producer, l logger.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		dao:           d,
+		producer:      producer,
+		l:             l,
+		interval:      time.Second,
+		batch:         100,
+		retention:     time.Hour * 24 * 7,
+		purgeInterval: time.Hour,
+		nowFunc:       time.Now,
+	}
+}
+
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil {
+			r.l.Error("outbox 投递失败", logger.Field{Key: "error", Value: err.Error()})
+		}
+
+		if now := r.nowFunc(); now.Sub(lastPurge) >= r.purgeInterval {
+			r.purge(ctx)
+			lastPurge = now
+		}
+
+		// 一批处理满了，说明还有积压，不等待直接处理下一批；
+		// 出错时 n 可能也是满的，比如已经投递但是标记失败，要等下一轮再试
+		if err == nil && n == r.batch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce 投递一批事件，返回投递的数量
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	return r.dao.Relay(ctx, r.batch, func(rows []dao.OutboxMessage) error {
+		msgs := make([]mq.Message, 0, len(rows))
+		for _, row := range rows {
+			msgs = append(msgs, mq.Message{
+				Topic: row.Topic,
+				Key:   []byte(row.Key),
+				Value: row.Payload,
+			})
+		}
+
+		return r.producer.Produce(ctx, msgs...)
+	})
+}
+
+// purge 已发送的事件只保留一段时间，方便排查问题
+func (r *OutboxRelay) purge(ctx context.Context) {
+	before := r.nowFunc().Add(-r.retention).UnixMilli()
+	for {
+		n, err := r.dao.PurgeSent(ctx, before, 1000)
+		if err != nil {
+			r.l.Error("outbox 清理失败", logger.Field{Key: "error", Value: err.Error()})
+			return
+		}
+		if n < 1000 {
+			return
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+	"yellowbook/internal/repository/dao"
+	daomocks "yellowbook/internal/repository/dao/mocks"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/mq"
+	"yellowbook/pkg/mq/memory"
+)
+
+type failingProducer struct{}
+
+func (failingProducer) Produce(ctx context.Context, msgs ...mq.Message) error {
+	return errors.New("broker down")
+}
+
+func (failingProducer) Close() error {
+	return nil
+}
+
+func TestOutboxRelay_RelayOnce(t *testing.T) {
+	rows := []dao.OutboxMessage{
+		{Id: 1, Topic: "yellowbook_user_events", Key: "1", Payload: []byte("one")},
+		{Id: 2, Topic: "yellowbook_user_events", Key: "1", Payload: []byte("two")},
+	}
+
+	testCases := []struct {
+		name     string
+		producer func(b *memory.Broker) mq.Producer
+		wantN    int
+		wantErr  bool
+		wantMsgs []string
+	}{
+		{
+			name: "按顺序投递",
+			producer: func(b *memory.Broker) mq.Producer {
+				return b.Producer()
+			},
+			wantN:    2,
+			wantMsgs: []string{"one", "two"},
+		},
+		{
+			name: "投递失败，不标记为已发送",
+			producer: func(b *memory.Broker) mq.Producer {
+				return failingProducer{}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := daomocks.NewMockIOutboxDAO(ctrl)
+			d.EXPECT().Relay(gomock.Any(), 100, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, limit int, fn func(msgs []dao.OutboxMessage) error) (int, error) {
+					// 和真实实现一样，fn 出错时整个事务回滚
+					if err := fn(rows); err != nil {
+						return 0, err
+					}
+					return len(rows), nil
+				})
+
+			broker := memory.NewBroker()
+			relay := NewOutboxRelay(d, tc.producer(broker), logger.NewZapLogger(zap.NewNop()))
+
+			n, err := relay.RelayOnce(context.Background())
+			assert.Equal(t, tc.wantN, n)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			c := broker.Consumer("yellowbook_user_events", "test")
+			for _, want := range tc.wantMsgs {
+				m, err := c.Fetch(context.Background())
+				require.NoError(t, err)
+				assert.Equal(t, want, string(m.Value))
+				assert.Equal(t, "1", string(m.Key))
+			}
+		})
+	}
+}
+
+func TestOutboxRelay_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := daomocks.NewMockIOutboxDAO(ctrl)
+	d.EXPECT().PurgeSent(gomock.Any(), gomock.Any(), 1000).Return(int64(0), nil).AnyTimes()
+	// 已经投递但是标记失败，n 是满的也不能马上重试，否则会一直重复投递
+	d.EXPECT().Relay(gomock.Any(), 100, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, limit int, fn func(msgs []dao.OutboxMessage) error) (int, error) {
+			cancel()
+			return limit, errors.New("update failed")
+		}).Times(1)
+
+	relay := NewOutboxRelay(d, memory.NewBroker().Producer(), logger.NewZapLogger(zap.NewNop()))
+	relay.interval = time.Hour
+
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run 没有在 ctx 取消后退出")
+	}
+}
@@ -0,0 +1,12 @@
+package ioc
+
+import (
+	"yellowbook/config"
+	"yellowbook/internal/service"
+)
+
+func InitPasswordPolicy() service.PasswordPolicy {
+	return service.PasswordPolicy{
+		BcryptCost: config.Conf.Password.BcryptCost,
+	}
+}
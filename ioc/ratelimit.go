@@ -0,0 +1,66 @@
+package ioc
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"yellowbook/config"
+	"yellowbook/internal/web/middleware"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/ratelimit"
+)
+
+// RateLimitHandlers 同一套规则拆成两个中间件，BeforeLogin 放在登录校验前面，
+// 这样没通过校验的请求也会被计数，被限流的请求也不用再验签、查会话
+type RateLimitHandlers struct {
+	// BeforeLogin 处理按 ip 和 route 的规则
+	BeforeLogin gin.HandlerFunc
+	// AfterLogin 处理按 user 的规则
+	AfterLogin gin.HandlerFunc
+}
+
+func InitRateLimitMiddleware(client redis.Cmdable, l logger.Logger) RateLimitHandlers {
+	limiter := ratelimit.NewFallbackLimiter(
+		ratelimit.NewRedisSlideWindowLimiter(client),
+		ratelimit.NewLocalLimiter(),
+		func(err error) {
+			l.Warn("Redis 限流失败，改用本地限流", logger.Field{Key: "error", Value: err})
+		},
+	)
+
+	rules := rateLimitRules(l)
+	return RateLimitHandlers{
+		BeforeLogin: middleware.NewRateLimitMiddlewareBuilder(limiter, rules, l).
+			Keys(middleware.RateLimitByIp, middleware.RateLimitByRoute).
+			Build(),
+		AfterLogin: middleware.NewRateLimitMiddlewareBuilder(limiter, rules, l).
+			Keys(middleware.RateLimitByUser).
+			Build(),
+	}
+}
+
+// rateLimitRules 远程配置每 5 秒刷新一次，中间件也按这个频率重新读规则
+func rateLimitRules(l logger.Logger) func() []middleware.RateLimitRule {
+	return func() []middleware.RateLimitRule {
+		cfgs := config.Conf.RateLimit.Rules
+		if viper.IsSet("ratelimit.rules") {
+			var remote []config.RateLimitRuleConfig
+			if err := viper.UnmarshalKey("ratelimit.rules", &remote); err != nil {
+				l.Error("限流规则配置错误，使用默认规则", logger.Field{Key: "error", Value: err})
+			} else {
+				cfgs = remote
+			}
+		}
+
+		rules := make([]middleware.RateLimitRule, 0, len(cfgs))
+		for _, c := range cfgs {
+			rules = append(rules, middleware.RateLimitRule{
+				Prefix:   c.Prefix,
+				Key:      c.Key,
+				Limit:    c.Limit,
+				Interval: c.Interval,
+			})
+		}
+		return rules
+	}
+}
@@ -0,0 +1,52 @@
+package ioc
+
+import (
+	"context"
+	"time"
+	"yellowbook/internal/pkg/imagefetcher"
+	"yellowbook/internal/service"
+	"yellowbook/pkg/logger"
+)
+
+func InitImageFetcher() service.ImageFetcher {
+	return imagefetcher.NewFetcher(time.Second*15, 10<<20)
+}
+
+type ImageRehoster struct {
+	svc      service.IImageRehostService
+	l        logger.Logger
+	interval time.Duration
+	batch    int
+}
+
+func NewImageRehoster(svc service.IImageRehostService, l logger.Logger) *ImageRehoster {
+	return &ImageRehoster{
+		svc:      svc,
+		l:        l,
+		interval: time.Second * 5,
+		batch:    20,
+	}
+}
+
+func (r *ImageRehoster) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.svc.ProcessDue(ctx, r.batch)
+		if err != nil {
+			r.l.Error("图片转存任务执行失败", logger.Field{Key: "error", Value: err.Error()})
+		}
+
+		// 一批处理满了，说明还有积压，不等待直接处理下一批
+		if n == r.batch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package ioc
+
+import (
+	"os"
+	"strings"
+)
+
+// readSecret path 不为空时从文件读，比如挂载进来的 k8s secret，读不到直接启动失败
+func readSecret(value string, path string) string {
+	if path == "" {
+		return value
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	// kubectl create secret --from-file 经常带着结尾的换行
+	return strings.TrimSpace(string(data))
+}
@@ -1,66 +1,63 @@
 package ioc
 
 import (
-	"context"
-	"encoding/json"
-	"github.com/segmentio/kafka-go"
-	"log"
-	"yellowbook/internal/domain"
+	"yellowbook/config"
 	"yellowbook/internal/service"
+	"yellowbook/internal/spider"
+	"yellowbook/pkg/logger"
+	"yellowbook/pkg/mq"
 )
 
-type Spider struct {
-	srv service.IArticleService
-}
+const (
+	spiderArticleTopic  = "weimi_luyao"
+	spiderUserTopic     = "yellowbook_import_users"
+	spiderResourceTopic = "yellowbook_import_resources"
+
+	// 爬取的文章统一挂在这个账号下
+	spiderAuthorId = 1
+)
 
-func NewSpider(srv service.IArticleService) *Spider {
-	return &Spider{srv: srv}
+func InitSpiderConsumerFactory() spider.ConsumerFactory {
+	return newMQConsumer
 }
 
-func (s *Spider) Run() Spider {
-	type Message struct {
-		Title     string   `json:"title"`
-		Content   string   `json:"content"`
-		ImageList []string `json:"imageList"`
-	}
+func InitSpiderThrottle() *spider.Throttle {
+	return spider.NewThrottle(config.Conf.Spider.RateLimit, config.Conf.Spider.Burst, queryStats)
+}
 
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{
-			"localhost:9092",
-			"localhost:9093",
-			"localhost:9094",
-			"localhost:9095",
-		},
-		Topic:       "weimi_luyao",
-		StartOffset: kafka.SeekStart,
+func InitSpider(
+	newConsumer spider.ConsumerFactory,
+	throttle *spider.Throttle,
+	producer mq.Producer,
+	articleSvc service.IArticleService,
+	rehostSvc service.IImageRehostService,
+	userSvc service.IUserService,
+	resourceSvc service.IResourceService,
+	l logger.Logger,
+) *spider.Router {
+	r := spider.NewRouter(newConsumer, producer, throttle, l)
+
+	r.Register(spider.Route{
+		Topic:    spiderArticleTopic,
+		Handler:  spider.NewArticleHandler(articleSvc, rehostSvc, spiderAuthorId, l),
+		Policy:   spider.DeadLetterOnError,
+		MaxRetry: 3,
+	})
+	r.Register(spider.Route{
+		Topic:       spiderUserTopic,
+		Handler:     spider.NewUserImportHandler(userSvc),
+		Concurrency: 4,
+		Policy:      spider.RetryOnError,
+		MaxRetry:    3,
+	})
+	// 资源导入要下载图片，比较慢，并发多一些
+	r.Register(spider.Route{
+		Topic:       spiderResourceTopic,
+		Handler:     spider.NewResourceImportHandler(resourceSvc),
+		Concurrency: 8,
+		Policy:      spider.DeadLetterOnError,
+		MaxRetry:    3,
 	})
 
-	for {
-		m, err := r.ReadMessage(context.Background())
-		if err != nil {
-			continue
-		}
-
-		var message Message
-		err = json.Unmarshal(m.Value, &message)
-		if err != nil {
-			log.Println("unmarshal failed")
-			continue
-		}
-
-		_, err = s.srv.Save(context.Background(), domain.Article{
-			Title:     message.Title,
-			Content:   message.Content,
-			ImageList: message.ImageList,
-			Author: domain.Author{
-				Id: 1,
-			},
-		})
-		if err != nil {
-			log.Println("unmarshal failed")
-			continue
-		}
-
-		//fmt.Println("", string(m.Key), string(m.Value))
-	}
+	return r
 }
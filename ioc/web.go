@@ -5,6 +5,9 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"time"
+	"yellowbook/internal/pkg/jwt_generator"
+	"yellowbook/internal/service"
+	"yellowbook/internal/service/oauth"
 	"yellowbook/internal/web"
 	"yellowbook/internal/web/middleware"
 	"yellowbook/pkg/logger"
@@ -14,6 +17,14 @@ func InitWebServer(
 	userHandler *web.UserHandler,
 	resourceHandler *web.ResourceHandler,
 	articleHandler *web.ArticleHandler,
+	jwksHandler *web.JWKSHandler,
+	profileHandler *web.ProfileHandler,
+	avatarHandler *web.AvatarHandler,
+	jwt jwt_generator.IJWTGenerator,
+	sessionSvc service.ISessionService,
+	oauthRegistry *oauth.Registry,
+	userSvc service.IUserService,
+	rateLimit RateLimitHandlers,
 	l logger.Logger,
 ) *gin.Engine {
 	server := gin.Default()
@@ -22,7 +33,7 @@ func InitWebServer(
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowHeaders:     []string{},
 		AllowCredentials: true,
-		ExposeHeaders:    []string{"X-Jwt-Token"},
+		ExposeHeaders:    []string{"X-Jwt-Token", "X-Refresh-Token"},
 		MaxAge:           2 * time.Minute,
 	}))
 
@@ -33,21 +44,48 @@ func InitWebServer(
 			Build(),
 	)
 
+	// 放在登录校验前面，401 的请求也要计数
+	server.Use(rateLimit.BeforeLogin)
+
+	loginMiddleware := middleware.NewLoginMiddlewareBuilder(jwt, sessionSvc, userSvc)
+	// 第三方登录的跳转和回调不需要登录，绑定需要
+	for _, name := range oauthRegistry.Names() {
+		loginMiddleware.IgnorePaths("/users/oauth/" + name).
+			IgnorePaths("/users/oauth/" + name + "/callback")
+	}
+
 	server.Use(
-		middleware.NewLoginMiddlewareBuilder().
+		loginMiddleware.
 			IgnorePaths("/users/signup").
 			IgnorePaths("/users/login").
+			IgnorePaths("/users/login/unlock/code/send").
+			IgnorePaths("/users/login/unlock").
+			IgnorePaths("/users/login/2fa").
 			IgnorePaths("/users/login_sms/code/send").
 			IgnorePaths("/users/login_sms").
-			IgnorePaths("/users/github/oauth").
-			IgnorePaths("/users/github/authorize").
+			IgnorePaths("/users/password/reset/code/send").
+			IgnorePaths("/users/password/reset").
+			IgnorePaths("/users/email/verify/code/send").
+			IgnorePaths("/users/email/verify").
 			IgnorePaths("/users/version").
+			IgnorePaths("/users/refresh_token").
+			IgnorePaths("/.well-known/jwks.json").
+			IgnoreRoutes("/users/:id").
 			Build(),
 	)
 
+	// 放在登录校验后面，才能按用户限流
+	server.Use(rateLimit.AfterLogin)
+
+	// 邮箱没有验证的账号可以登录、修改资料，但是不能发内容
+	verified := middleware.NewVerifiedMiddlewareBuilder(userSvc).Build()
+
 	userHandler.RegisterRoutes(server.Group("/users"))
-	resourceHandler.RegisterRoutes(server.Group("/resources"))
-	articleHandler.RegisterRoutes(server.Group("/articles"))
+	profileHandler.RegisterRoutes(server.Group("/users"))
+	avatarHandler.RegisterRoutes(server.Group("/users", verified))
+	resourceHandler.RegisterRoutes(server.Group("/resources", verified))
+	articleHandler.RegisterRoutes(server.Group("/articles", verified))
+	jwksHandler.RegisterRoutes(server)
 
 	return server
 }
@@ -17,7 +17,8 @@ func main() {
 	initViper()
 
 	webEngine := InitWebServer()
-	manageEngine := InitManageServer()
+	sp := InitSpider()
+	manageEngine := InitManageServer(sp)
 
 	webServer := &http.Server{
 		Addr:    config.Conf.Web.Port,
@@ -48,10 +49,22 @@ func main() {
 	}()
 
 	go func() {
-		InitSpider().Run()
+		sp.Run(context.Background())
 	}()
 
-	quit := make(chan os.Signal)
+	go func() {
+		InitImageRehoster().Run(context.Background())
+	}()
+
+	go func() {
+		InitOutboxRelay().Run(context.Background())
+	}()
+
+	go func() {
+		InitAccountDeleter().Run(context.Background())
+	}()
+
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	<-quit
 
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"yellowbook/pkg/mq"
+)
+
+type Producer struct {
+	w *kafka.Writer
+}
+
+func NewProducer(brokers []string) mq.Producer {
+	return &Producer{
+		w: &kafka.Writer{
+			Addr: kafka.TCP(brokers...),
+			// 相同 key 的消息进同一个分区，保证顺序
+			Balancer:               &kafka.Hash{},
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (p *Producer) Produce(ctx context.Context, msgs ...mq.Message) error {
+	kms := make([]kafka.Message, 0, len(msgs))
+	for _, m := range msgs {
+		kms = append(kms, kafka.Message{
+			Topic: m.Topic,
+			Key:   m.Key,
+			Value: m.Value,
+		})
+	}
+
+	return p.w.WriteMessages(ctx, kms...)
+}
+
+func (p *Producer) Close() error {
+	return p.w.Close()
+}
+
+type Consumer struct {
+	r *kafka.Reader
+}
+
+func NewConsumer(brokers []string, topic string, group string) mq.Consumer {
+	return &Consumer{
+		r: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     brokers,
+			Topic:       topic,
+			GroupID:     group,
+			StartOffset: kafka.FirstOffset,
+		}),
+	}
+}
+
+func (c *Consumer) Fetch(ctx context.Context) (mq.Message, error) {
+	m, err := c.r.FetchMessage(ctx)
+	if err != nil {
+		return mq.Message{}, err
+	}
+
+	return mq.Message{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Value:     m.Value,
+		Time:      m.Time,
+	}, nil
+}
+
+func (c *Consumer) Commit(ctx context.Context, msgs ...mq.Message) error {
+	kms := make([]kafka.Message, 0, len(msgs))
+	for _, m := range msgs {
+		kms = append(kms, kafka.Message{
+			Topic:     m.Topic,
+			Partition: m.Partition,
+			Offset:    m.Offset,
+		})
+	}
+
+	return c.r.CommitMessages(ctx, kms...)
+}
+
+// Lag 取最近一次拉取时 high watermark 和读取位置的差
+func (c *Consumer) Lag() int64 {
+	return c.r.Stats().Lag
+}
+
+func (c *Consumer) Close() error {
+	return c.r.Close()
+}
@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"yellowbook/pkg/mq"
+)
+
+// 需要真实的 Kafka，例如：KAFKA_BROKERS=localhost:9092 go test ./pkg/mq/kafka/
+func brokers(t *testing.T) []string {
+	val := os.Getenv("KAFKA_BROKERS")
+	if val == "" {
+		t.Skip("KAFKA_BROKERS 未设置，跳过 Kafka 集成测试")
+	}
+	return strings.Split(val, ",")
+}
+
+func TestProducerAndConsumer(t *testing.T) {
+	bs := brokers(t)
+	topic := fmt.Sprintf("yellowbook_test_%d", time.Now().UnixNano())
+
+	p := NewProducer(bs)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := p.Produce(ctx,
+		mq.Message{Topic: topic, Value: []byte("one")},
+		mq.Message{Topic: topic, Value: []byte("two")},
+	)
+	require.NoError(t, err)
+
+	c := NewConsumer(bs, topic, "yellowbook_test")
+	defer c.Close()
+
+	for _, want := range []string{"one", "two"} {
+		m, err := c.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(m.Value))
+		require.NoError(t, c.Commit(ctx, m))
+	}
+}
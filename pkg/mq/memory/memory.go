@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"yellowbook/pkg/mq"
+)
+
+// defaultMaxMessages 每个 topic 最多保留的消息数，没人消费的 topic 也不会无限增长
+const defaultMaxMessages = 10000
+
+// Broker 进程内的消息队列，给单测和没有 Kafka 的本地开发用
+// 每个 topic 是一个只追加的日志，同一个 group 的消费者共享读取进度
+// 所有 group 都提交过的消息会被丢掉，超过 maxMessages 时最旧的消息也会被丢掉
+type Broker struct {
+	mu          sync.Mutex
+	topics      map[string]*topic
+	maxMessages int
+}
+
+type topic struct {
+	// base 是 messages[0] 的 offset
+	base     int64
+	messages []mq.Message
+	groups   map[string]*group
+	// 有新消息时关闭并替换，用来唤醒等待的消费者
+	notify chan struct{}
+}
+
+type group struct {
+	next      int64
+	committed int64
+}
+
+func NewBroker() *Broker {
+	return &Broker{topics: map[string]*topic{}, maxMessages: defaultMaxMessages}
+}
+
+func (b *Broker) Producer() mq.Producer {
+	return &Producer{b: b}
+}
+
+func (b *Broker) Consumer(topic string, group string) mq.Consumer {
+	return &Consumer{
+		b:     b,
+		topic: topic,
+		group: group,
+		done:  make(chan struct{}),
+	}
+}
+
+// topicLocked 调用方需要持有锁
+func (b *Broker) topicLocked(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{
+			groups: map[string]*group{},
+			notify: make(chan struct{}),
+		}
+		b.topics[name] = t
+	}
+	return t
+}
+
+func (b *Broker) groupLocked(t *topic, name string) *group {
+	g, ok := t.groups[name]
+	if !ok {
+		g = &group{next: t.base, committed: t.base}
+		t.groups[name] = g
+	}
+	// 没来得及消费的消息已经被丢掉了，从还在的第一条开始
+	if g.next < t.base {
+		g.next = t.base
+	}
+	if g.committed < t.base {
+		g.committed = t.base
+	}
+	return g
+}
+
+// trimLocked 丢掉所有 group 都提交过的消息，剩下的再按 maxMessages 截断
+func (b *Broker) trimLocked(t *topic) {
+	drop := 0
+	if len(t.groups) > 0 {
+		low := t.base + int64(len(t.messages))
+		for _, g := range t.groups {
+			if g.committed < low {
+				low = g.committed
+			}
+		}
+		if low > t.base {
+			drop = int(low - t.base)
+		}
+	}
+	if over := len(t.messages) - drop - b.maxMessages; over > 0 {
+		drop += over
+	}
+	if drop <= 0 {
+		return
+	}
+
+	// 清空引用，底层数组扩容时旧的消息才能被回收
+	for i := 0; i < drop; i++ {
+		t.messages[i] = mq.Message{}
+	}
+	t.messages = t.messages[drop:]
+	t.base += int64(drop)
+}
+
+type Producer struct {
+	b *Broker
+}
+
+func (p *Producer) Produce(ctx context.Context, msgs ...mq.Message) error {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+
+	woken := map[*topic]struct{}{}
+	for _, m := range msgs {
+		t := p.b.topicLocked(m.Topic)
+		m.Offset = t.base + int64(len(t.messages))
+		m.Time = time.Now()
+		t.messages = append(t.messages, m)
+		woken[t] = struct{}{}
+	}
+
+	for t := range woken {
+		p.b.trimLocked(t)
+		close(t.notify)
+		t.notify = make(chan struct{})
+	}
+
+	return nil
+}
+
+func (p *Producer) Close() error {
+	return nil
+}
+
+type Consumer struct {
+	b     *Broker
+	topic string
+	group string
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (c *Consumer) Fetch(ctx context.Context) (mq.Message, error) {
+	for {
+		select {
+		case <-c.done:
+			return mq.Message{}, mq.ErrClosed
+		default:
+		}
+
+		c.b.mu.Lock()
+		t := c.b.topicLocked(c.topic)
+		g := c.b.groupLocked(t, c.group)
+		if g.next < t.base+int64(len(t.messages)) {
+			m := t.messages[g.next-t.base]
+			g.next++
+			c.b.mu.Unlock()
+			return m, nil
+		}
+		notify := t.notify
+		c.b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return mq.Message{}, ctx.Err()
+		case <-c.done:
+			return mq.Message{}, mq.ErrClosed
+		case <-notify:
+		}
+	}
+}
+
+func (c *Consumer) Commit(ctx context.Context, msgs ...mq.Message) error {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	t := c.b.topicLocked(c.topic)
+	g := c.b.groupLocked(t, c.group)
+	for _, m := range msgs {
+		if m.Offset+1 > g.committed {
+			g.committed = m.Offset + 1
+		}
+	}
+	c.b.trimLocked(t)
+
+	return nil
+}
+
+// Lag 还没提交的消息都算积压
+func (c *Consumer) Lag() int64 {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	t := c.b.topicLocked(c.topic)
+	g := c.b.groupLocked(t, c.group)
+	return t.base + int64(len(t.messages)) - g.committed
+}
+
+// Close 未提交的消息会在下一个消费者重新投递
+func (c *Consumer) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.b.mu.Lock()
+		defer c.b.mu.Unlock()
+		t := c.b.topicLocked(c.topic)
+		g := c.b.groupLocked(t, c.group)
+		g.next = g.committed
+	})
+	return nil
+}
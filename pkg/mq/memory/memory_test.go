@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"yellowbook/pkg/mq"
+)
+
+func TestBroker_ProduceAndFetch(t *testing.T) {
+	b := NewBroker()
+	p := b.Producer()
+
+	err := p.Produce(context.Background(),
+		mq.Message{Topic: "weimi", Value: []byte("one")},
+		mq.Message{Topic: "weimi", Value: []byte("two")},
+		mq.Message{Topic: "other", Value: []byte("three")},
+	)
+	require.NoError(t, err)
+
+	c := b.Consumer("weimi", "g1")
+	m, err := c.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(m.Value))
+	assert.Equal(t, int64(0), m.Offset)
+
+	m, err = c.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(m.Value))
+
+	// 不同 group 各自从头消费
+	m, err = b.Consumer("weimi", "g2").Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(m.Value))
+}
+
+func TestBroker_FetchBlocksUntilProduce(t *testing.T) {
+	b := NewBroker()
+	c := b.Consumer("weimi", "g1")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = b.Producer().Produce(context.Background(), mq.Message{Topic: "weimi", Value: []byte("late")})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, err := c.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "late", string(m.Value))
+}
+
+func TestBroker_FetchHonorsContext(t *testing.T) {
+	b := NewBroker()
+	c := b.Consumer("weimi", "g1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Fetch(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestBroker_RedeliverUncommitted(t *testing.T) {
+	b := NewBroker()
+	err := b.Producer().Produce(context.Background(),
+		mq.Message{Topic: "weimi", Value: []byte("one")},
+		mq.Message{Topic: "weimi", Value: []byte("two")},
+	)
+	require.NoError(t, err)
+
+	c := b.Consumer("weimi", "g1")
+	assert.Equal(t, int64(2), c.Lag())
+	m, err := c.Fetch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, c.Commit(context.Background(), m))
+	assert.Equal(t, int64(1), c.Lag())
+
+	_, err = c.Fetch(context.Background())
+	require.NoError(t, err)
+	// 第二条没有提交就关闭了
+	require.NoError(t, c.Close())
+
+	_, err = c.Fetch(context.Background())
+	assert.ErrorIs(t, err, mq.ErrClosed)
+
+	m, err = b.Consumer("weimi", "g1").Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(m.Value))
+}
+
+func TestBroker_TrimCommitted(t *testing.T) {
+	b := NewBroker()
+	c1 := b.Consumer("weimi", "g1")
+	c2 := b.Consumer("weimi", "g2")
+	assert.Equal(t, int64(0), c1.Lag())
+	assert.Equal(t, int64(0), c2.Lag())
+
+	err := b.Producer().Produce(context.Background(),
+		mq.Message{Topic: "weimi", Value: []byte("one")},
+		mq.Message{Topic: "weimi", Value: []byte("two")},
+	)
+	require.NoError(t, err)
+
+	m, err := c1.Fetch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, c1.Commit(context.Background(), m))
+	// g2 还没提交，不能丢
+	assert.Len(t, b.topics["weimi"].messages, 2)
+
+	m, err = c2.Fetch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, c2.Commit(context.Background(), m))
+	assert.Len(t, b.topics["weimi"].messages, 1)
+
+	m, err = c1.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(m.Value))
+	assert.Equal(t, int64(1), m.Offset)
+	assert.Equal(t, int64(1), c2.Lag())
+}
+
+func TestBroker_MaxMessages(t *testing.T) {
+	b := NewBroker()
+	b.maxMessages = 2
+
+	for _, v := range []string{"one", "two", "three"} {
+		err := b.Producer().Produce(context.Background(), mq.Message{Topic: "weimi", Value: []byte(v)})
+		require.NoError(t, err)
+	}
+	assert.Len(t, b.topics["weimi"].messages, 2)
+
+	// 后来的 group 从还在的第一条开始
+	c := b.Consumer("weimi", "g1")
+	m, err := c.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(m.Value))
+	assert.Equal(t, int64(1), m.Offset)
+}
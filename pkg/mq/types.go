@@ -0,0 +1,32 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrClosed = errors.New("mq: closed")
+
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Time      time.Time
+}
+
+type Producer interface {
+	Produce(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Consumer 读到的消息需要调用 Commit 确认，否则重启后会重新消费
+type Consumer interface {
+	Fetch(ctx context.Context) (Message, error)
+	Commit(ctx context.Context, msgs ...Message) error
+	// Lag 还没有消费的消息数，只是估算值
+	Lag() int64
+	Close() error
+}
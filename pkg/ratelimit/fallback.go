@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// FallbackLimiter primary 出错时改用 fallback，比如 Redis 连不上时用进程内的限流
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	onError  func(err error)
+}
+
+func NewFallbackLimiter(primary Limiter, fallback Limiter, onError func(err error)) Limiter {
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+		onError:  onError,
+	}
+}
+
+func (f *FallbackLimiter) Limit(ctx context.Context, key string, limit int, interval time.Duration) (bool, error) {
+	limited, err := f.primary.Limit(ctx, key, limit, interval)
+	if err == nil {
+		return limited, nil
+	}
+
+	if f.onError != nil {
+		f.onError(err)
+	}
+	return f.fallback.Limit(ctx, key, limit, interval)
+}
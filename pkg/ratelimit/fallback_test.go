@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+	ratelimitmocks "yellowbook/pkg/ratelimit/mocks"
+)
+
+func TestFallbackLimiter_Limit(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mock        func(ctrl *gomock.Controller) (Limiter, Limiter)
+		wantLimited bool
+		wantOnError bool
+	}{
+		{
+			name: "Redis 正常",
+			mock: func(ctrl *gomock.Controller) (Limiter, Limiter) {
+				primary := ratelimitmocks.NewMockLimiter(ctrl)
+				primary.EXPECT().Limit(gomock.Any(), "key", 10, time.Second).Return(true, nil)
+				return primary, ratelimitmocks.NewMockLimiter(ctrl)
+			},
+			wantLimited: true,
+		},
+		{
+			name: "Redis 出错，改用本地",
+			mock: func(ctrl *gomock.Controller) (Limiter, Limiter) {
+				primary := ratelimitmocks.NewMockLimiter(ctrl)
+				primary.EXPECT().Limit(gomock.Any(), "key", 10, time.Second).Return(false, errors.New("连接被拒绝"))
+				fallback := ratelimitmocks.NewMockLimiter(ctrl)
+				fallback.EXPECT().Limit(gomock.Any(), "key", 10, time.Second).Return(true, nil)
+				return primary, fallback
+			},
+			wantLimited: true,
+			wantOnError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var gotOnError bool
+			primary, fallback := tc.mock(ctrl)
+			l := NewFallbackLimiter(primary, fallback, func(err error) {
+				gotOnError = true
+			})
+
+			limited, err := l.Limit(context.Background(), "key", 10, time.Second)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantLimited, limited)
+			assert.Equal(t, tc.wantOnError, gotOnError)
+		})
+	}
+}
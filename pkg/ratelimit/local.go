@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+// LocalLimiter 进程内的令牌桶，只用在 Redis 不可用的时候，多个实例各算各的
+type LocalLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*localBucket
+	lastSweep time.Time
+	nowFunc   func() time.Time
+}
+
+type localBucket struct {
+	limiter  *rate.Limiter
+	interval time.Duration
+	lastSeen time.Time
+}
+
+func NewLocalLimiter() *LocalLimiter {
+	return &LocalLimiter{
+		buckets: make(map[string]*localBucket),
+		nowFunc: time.Now,
+	}
+}
+
+func (l *LocalLimiter) Limit(ctx context.Context, key string, limit int, interval time.Duration) (bool, error) {
+	now := l.nowFunc()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	every := rate.Every(interval / time.Duration(limit))
+	b, ok := l.buckets[key]
+	if !ok || b.limiter.Burst() != limit || b.limiter.Limit() != every {
+		// 规则热更新以后重新建桶
+		b = &localBucket{limiter: rate.NewLimiter(every, limit), interval: interval}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	return !b.limiter.AllowN(now, 1), nil
+}
+
+// sweep 每分钟清理一次闲置超过一个周期的桶，此时桶已经是满的，删掉不影响结果
+func (l *LocalLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > b.interval {
+			delete(l.buckets, key)
+		}
+	}
+}
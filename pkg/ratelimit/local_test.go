@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLocalLimiter_Limit(t *testing.T) {
+	now := time.UnixMilli(1694575373000)
+	l := NewLocalLimiter()
+	l.nowFunc = func() time.Time {
+		return now
+	}
+
+	limit := func(key string) bool {
+		limited, err := l.Limit(context.Background(), key, 2, time.Second)
+		require.NoError(t, err)
+		return limited
+	}
+
+	assert.False(t, limit("a"))
+	assert.False(t, limit("a"))
+	assert.True(t, limit("a"))
+	// 不同的 key 互不影响
+	assert.False(t, limit("b"))
+
+	// 半秒补回一个令牌
+	now = now.Add(time.Millisecond * 500)
+	assert.False(t, limit("a"))
+	assert.True(t, limit("a"))
+
+	// 闲置超过一个周期的桶会被清理
+	now = now.Add(time.Minute * 2)
+	assert.False(t, limit("c"))
+	assert.Len(t, l.buckets, 1)
+}
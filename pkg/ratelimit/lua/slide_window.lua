@@ -0,0 +1,17 @@
+-- 滑动窗口，zset 里每个成员是一次放行的请求，score 是毫秒时间
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local threshold = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call("zremrangebyscore", key, "-inf", now - window)
+local cnt = redis.call("zcard", key)
+if cnt >= threshold then
+    -- 被限流的请求不计数，否则持续请求永远等不到窗口滑过去
+    return 1
+end
+
+redis.call("zadd", key, now, member)
+redis.call("pexpire", key, window)
+return 0
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"context"
+	_ "embed"
+	"github.com/redis/go-redis/v9"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+//go:embed lua/slide_window.lua
+var luaSlideWindow string
+
+// RedisSlideWindowLimiter 多个实例共享同一个窗口
+type RedisSlideWindowLimiter struct {
+	client  redis.Cmdable
+	nowFunc func() time.Time
+}
+
+func NewRedisSlideWindowLimiter(client redis.Cmdable) Limiter {
+	return &RedisSlideWindowLimiter{
+		client:  client,
+		nowFunc: time.Now,
+	}
+}
+
+func (r *RedisSlideWindowLimiter) Limit(ctx context.Context, key string, limit int, interval time.Duration) (bool, error) {
+	now := r.nowFunc()
+	// 同一毫秒内的请求也要分开记
+	member := strconv.FormatInt(now.UnixNano(), 36) + strconv.FormatInt(rand.Int63(), 36)
+	return r.client.Eval(ctx, luaSlideWindow, []string{key},
+		interval.Milliseconds(), limit, now.UnixMilli(), member).Bool()
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+	"yellowbook/testing/redismocks"
+)
+
+func TestRedisSlideWindowLimiter_Limit(t *testing.T) {
+	now := time.UnixMilli(1694575373000)
+
+	testCases := []struct {
+		name        string
+		result      any
+		err         error
+		wantLimited bool
+		wantErr     error
+	}{
+		{
+			name:   "放行",
+			result: int64(0),
+		},
+		{
+			name:        "限流",
+			result:      int64(1),
+			wantLimited: true,
+		},
+		{
+			name:    "Redis 出错",
+			err:     errors.New("连接被拒绝"),
+			wantErr: errors.New("连接被拒绝"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := redismocks.NewMockCmdable(ctrl)
+			cmd := redis.NewCmd(context.Background())
+			cmd.SetVal(tc.result)
+			cmd.SetErr(tc.err)
+			client.EXPECT().Eval(gomock.Any(), luaSlideWindow, []string{"key"},
+				int64(60000), 10, now.UnixMilli(), gomock.Any()).Return(cmd)
+
+			l := NewRedisSlideWindowLimiter(client).(*RedisSlideWindowLimiter)
+			l.nowFunc = func() time.Time {
+				return now
+			}
+
+			limited, err := l.Limit(context.Background(), "key", 10, time.Minute)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantLimited, limited)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type Limiter interface {
+	// Limit interval 内最多放行 limit 个请求，返回 true 表示这次要被限流
+	Limit(ctx context.Context, key string, limit int, interval time.Duration) (bool, error)
+}
@@ -0,0 +1,82 @@
+package totp
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，取后 6 位
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tc := range testCases {
+		code, err := Code(secret, Step(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1694575373, 0)
+	current := Step(now)
+
+	codeAt := func(step int64) string {
+		code, err := Code(secret, step)
+		require.NoError(t, err)
+		return code
+	}
+
+	testCases := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOk   bool
+	}{
+		{name: "当前", code: codeAt(current), wantStep: current, wantOk: true},
+		{name: "慢一步", code: codeAt(current - 1), wantStep: current - 1, wantOk: true},
+		{name: "快一步", code: codeAt(current + 1), wantStep: current + 1, wantOk: true},
+		{name: "超出偏差", code: codeAt(current - 2)},
+		{name: "长度不对", code: "12345"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			step, ok := Validate(secret, tc.code, now)
+			assert.Equal(t, tc.wantOk, ok)
+			assert.Equal(t, tc.wantStep, step)
+		})
+	}
+
+	_, ok := Validate("不是 base32", "123456", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("yellowbook", "a@b.com", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/yellowbook:a@b.com", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "yellowbook", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+}
@@ -12,6 +12,7 @@ import (
 	"yellowbook/internal/repository/cache/ristretto"
 	"yellowbook/internal/repository/dao"
 	"yellowbook/internal/service"
+	"yellowbook/internal/spider"
 	"yellowbook/internal/web"
 	"yellowbook/ioc"
 )
@@ -21,52 +22,105 @@ func InitWebServer() *gin.Engine {
 		web.NewResourceHandler,
 		web.NewUserHandler,
 		web.NewArticleHandler,
+		web.NewJWKSHandler,
+		web.NewProfileHandler,
+		web.NewAvatarHandler,
 
 		service.NewUserService,
+		service.NewProfileService,
+		service.NewAvatarService,
+		ioc.InitPasswordPolicy,
 		service.NewResourceService,
 		service.NewArticleService,
 		service.NewCodeService,
+		service.NewEmailCodeService,
+		service.NewSessionService,
 
 		repository.NewCachedUserRepository,
 		repository.NewResourceRepository,
 		repository.NewArticleRepository,
 		repository.NewCodeRepository,
+		repository.NewSessionRepository,
 
 		dao.NewResourceDAO,
 		dao.NewUserDAO,
 		dao.NewArticleDAO,
 
 		cache.NewUserCache,
+		cache.NewSessionCache,
 		ristretto.NewCodeCache,
 
 		ioc.InitOss,
+		ioc.InitImageFetcher,
 		ioc.InitRistretto,
 		ioc.InitWebServer,
 		ioc.InitSMSService,
+		ioc.InitEmailService,
 		ioc.InitDB,
 		ioc.InitRedis,
 		ioc.InitCloopen,
 		ioc.InitJWT,
-		ioc.InitGithub,
+		ioc.InitJWTKeys,
+		ioc.InitOAuthRegistry,
+		ioc.InitRateLimitMiddleware,
+		ioc.InitOAuthStateService,
+		repository.NewOAuthStateRepository,
+		cache.NewOAuthStateCache,
+		service.NewLoginLimitService,
+		repository.NewLoginLimitRepository,
+		cache.NewLoginLimitCache,
+		service.NewTwoFactorService,
+		repository.NewTwoFactorRepository,
+		dao.NewTwoFactorDAO,
+		cache.NewTwoFactorChallengeCache,
+		service.NewAccountDeletionService,
+		ioc.InitAccountDeletionPolicy,
 		ioc.InitLogger,
 	)
 	return new(gin.Engine)
 }
 
-func InitManageServer() *gin.Engine {
+// InitManageServer 爬虫在同一个进程里运行，管理后台直接控制它
+func InitManageServer(sp *spider.Router) *gin.Engine {
 	wire.Build(
 		manage.NewArticleHandler,
 		manage.NewUserHandler,
+		manage.NewSpiderHandler,
+		manage.NewAdminHandler,
+		wire.Bind(new(manage.SpiderController), new(*spider.Router)),
 
 		service.NewArticleService,
 		service.NewUserService,
+		ioc.InitPasswordPolicy,
+		service.NewSessionService,
+		service.NewLoginLimitService,
+		service.NewTwoFactorService,
 		repository.NewCachedUserRepository,
 		repository.NewArticleRepository,
+		repository.NewSessionRepository,
+		repository.NewLoginLimitRepository,
+		repository.NewTwoFactorRepository,
 
 		dao.NewArticleDAO,
 		dao.NewUserDAO,
+		dao.NewTwoFactorDAO,
 		cache.NewUserCache,
+		cache.NewSessionCache,
+		cache.NewLoginLimitCache,
+		cache.NewTwoFactorChallengeCache,
+		service.NewAdminService,
+		repository.NewAdminRepository,
+		dao.NewAdminDAO,
+		service.NewAdminSessionService,
+		repository.NewAdminSessionRepository,
+		cache.NewAdminSessionCache,
+		manage.NewAuditHandler,
+		service.NewAuditService,
+		repository.NewAuditRepository,
+		dao.NewAuditLogDAO,
 
+		ioc.InitJWT,
+		ioc.InitJWTKeys,
 		ioc.InitLogger,
 		ioc.InitManageServer,
 		ioc.InitDB,
@@ -75,14 +129,80 @@ func InitManageServer() *gin.Engine {
 	return new(gin.Engine)
 }
 
-func InitSpider() *ioc.Spider {
+func InitSpider() *spider.Router {
 	wire.Build(
+		ioc.InitSpiderConsumerFactory,
+		ioc.InitSpiderThrottle,
+		ioc.InitMQProducer,
 		ioc.InitLogger,
 		ioc.InitDB,
+		ioc.InitRedis,
+		ioc.InitOss,
+		ioc.InitImageFetcher,
 		dao.NewArticleDAO,
+		dao.NewResourceDAO,
+		dao.NewImageRehostDAO,
+		dao.NewUserDAO,
+		cache.NewUserCache,
 		repository.NewArticleRepository,
+		repository.NewResourceRepository,
+		repository.NewImageRehostRepository,
+		repository.NewCachedUserRepository,
 		service.NewArticleService,
-		ioc.NewSpider,
+		service.NewImageRehostService,
+		service.NewUserService,
+		ioc.InitPasswordPolicy,
+		service.NewResourceService,
+		ioc.InitSpider,
+	)
+	return &spider.Router{}
+}
+
+func InitImageRehoster() *ioc.ImageRehoster {
+	wire.Build(
+		ioc.InitLogger,
+		ioc.InitDB,
+		ioc.InitOss,
+		ioc.InitImageFetcher,
+		dao.NewArticleDAO,
+		dao.NewResourceDAO,
+		dao.NewImageRehostDAO,
+		repository.NewArticleRepository,
+		repository.NewResourceRepository,
+		repository.NewImageRehostRepository,
+		service.NewImageRehostService,
+		ioc.NewImageRehoster,
+	)
+	return &ioc.ImageRehoster{}
+}
+
+func InitOutboxRelay() *ioc.OutboxRelay {
+	wire.Build(
+		ioc.InitLogger,
+		ioc.InitDB,
+		ioc.InitMQProducer,
+		dao.NewOutboxDAO,
+		ioc.NewOutboxRelay,
+	)
+	return &ioc.OutboxRelay{}
+}
+
+func InitAccountDeleter() *ioc.AccountDeleter {
+	wire.Build(
+		ioc.InitLogger,
+		ioc.InitDB,
+		ioc.InitRedis,
+		ioc.InitPasswordPolicy,
+		ioc.InitAccountDeletionPolicy,
+		dao.NewUserDAO,
+		cache.NewUserCache,
+		cache.NewSessionCache,
+		repository.NewCachedUserRepository,
+		repository.NewSessionRepository,
+		service.NewUserService,
+		service.NewSessionService,
+		service.NewAccountDeletionService,
+		ioc.NewAccountDeleter,
 	)
-	return &ioc.Spider{}
+	return &ioc.AccountDeleter{}
 }
@@ -14,6 +14,7 @@ import (
 	"yellowbook/internal/repository/cache/ristretto"
 	"yellowbook/internal/repository/dao"
 	"yellowbook/internal/service"
+	"yellowbook/internal/spider"
 	"yellowbook/internal/web"
 	"yellowbook/ioc"
 )
@@ -30,53 +31,165 @@ func InitWebServer() *gin.Engine {
 	cmdable := ioc.InitRedis()
 	userCache := cache.NewUserCache(cmdable)
 	userRepository := repository.NewCachedUserRepository(userDao, userCache)
-	iUserService := service.NewUserService(userRepository)
+	passwordPolicy := ioc.InitPasswordPolicy()
+	iUserService := service.NewUserService(userRepository, passwordPolicy)
 	ristrettoCache := ioc.InitRistretto()
 	codeCache := ristretto.NewCodeCache(ristrettoCache)
 	codeRepository := repository.NewCodeRepository(codeCache)
 	client := ioc.InitCloopen()
 	smsService := ioc.InitSMSService(client)
 	codeService := service.NewCodeService(codeRepository, smsService)
-	iService := ioc.InitGithub()
-	ijwtGenerator := ioc.InitJWT()
-	userHandler := web.NewUserHandler(iUserService, codeService, iService, ijwtGenerator)
-	ossIService := ioc.InitOss()
+	emailService := ioc.InitEmailService()
+	emailCodeService := service.NewEmailCodeService(codeRepository, emailService)
+	registry := ioc.InitOAuthRegistry()
+	oAuthStateCache := cache.NewOAuthStateCache(cmdable)
+	ioAuthStateRepository := repository.NewOAuthStateRepository(oAuthStateCache)
+	ioAuthStateService := ioc.InitOAuthStateService(ioAuthStateRepository)
+	sessionCache := cache.NewSessionCache(cmdable)
+	iSessionRepository := repository.NewSessionRepository(sessionCache)
+	logger := ioc.InitLogger()
+	iSessionService := service.NewSessionService(iSessionRepository, logger)
+	keySet := ioc.InitJWTKeys()
+	ijwtGenerator := ioc.InitJWT(keySet)
+	loginLimitCache := cache.NewLoginLimitCache(cmdable)
+	iLoginLimitRepository := repository.NewLoginLimitRepository(loginLimitCache)
+	iLoginLimitService := service.NewLoginLimitService(iLoginLimitRepository, logger)
+	twoFactorDAO := dao.NewTwoFactorDAO(db)
+	twoFactorChallengeCache := cache.NewTwoFactorChallengeCache(cmdable)
+	iTwoFactorRepository := repository.NewTwoFactorRepository(twoFactorDAO, twoFactorChallengeCache)
+	iTwoFactorService := service.NewTwoFactorService(iTwoFactorRepository, userRepository)
+	accountDeletionPolicy := ioc.InitAccountDeletionPolicy()
+	iAccountDeletionService := service.NewAccountDeletionService(userRepository, iUserService, iSessionService, logger, accountDeletionPolicy)
+	userHandler := web.NewUserHandler(iUserService, codeService, emailCodeService, registry, ioAuthStateService, iSessionService, ijwtGenerator, iLoginLimitService, iTwoFactorService, iAccountDeletionService)
+	iService := ioc.InitOss()
 	iResourceDao := dao.NewResourceDAO(db)
 	iResourceRepository := repository.NewResourceRepository(iResourceDao)
-	logger := ioc.InitLogger()
-	iResourceService := service.NewResourceService(ossIService, iResourceRepository, logger)
+	imageFetcher := ioc.InitImageFetcher()
+	iResourceService := service.NewResourceService(iService, iResourceRepository, imageFetcher, logger)
 	resourceHandler := web.NewResourceHandler(iResourceService)
 	iArticleDAO := dao.NewArticleDAO(db)
 	iArticleRepository := repository.NewArticleRepository(iArticleDAO)
 	iArticleService := service.NewArticleService(iArticleRepository, logger)
 	articleHandler := web.NewArticleHandler(iArticleService)
-	engine := ioc.InitWebServer(userHandler, resourceHandler, articleHandler, logger)
+	jwksHandler := web.NewJWKSHandler(keySet)
+	iProfileService := service.NewProfileService(userRepository, iArticleRepository)
+	profileHandler := web.NewProfileHandler(iProfileService)
+	iAvatarService := service.NewAvatarService(iService, userRepository)
+	avatarHandler := web.NewAvatarHandler(iAvatarService)
+	rateLimitHandlers := ioc.InitRateLimitMiddleware(cmdable, logger)
+	engine := ioc.InitWebServer(userHandler, resourceHandler, articleHandler, jwksHandler, profileHandler, avatarHandler, ijwtGenerator, iSessionService, registry, iUserService, rateLimitHandlers, logger)
 	return engine
 }
 
-func InitManageServer() *gin.Engine {
+// InitManageServer 爬虫在同一个进程里运行，管理后台直接控制它
+func InitManageServer(sp *spider.Router) *gin.Engine {
 	db := ioc.InitDB()
 	userDao := dao.NewUserDAO(db)
 	cmdable := ioc.InitRedis()
 	userCache := cache.NewUserCache(cmdable)
 	userRepository := repository.NewCachedUserRepository(userDao, userCache)
-	iUserService := service.NewUserService(userRepository)
-	userHandler := manage.NewUserHandler(iUserService)
+	passwordPolicy := ioc.InitPasswordPolicy()
+	iUserService := service.NewUserService(userRepository, passwordPolicy)
+	sessionCache := cache.NewSessionCache(cmdable)
+	iSessionRepository := repository.NewSessionRepository(sessionCache)
+	logger := ioc.InitLogger()
+	iSessionService := service.NewSessionService(iSessionRepository, logger)
+	loginLimitCache := cache.NewLoginLimitCache(cmdable)
+	iLoginLimitRepository := repository.NewLoginLimitRepository(loginLimitCache)
+	iLoginLimitService := service.NewLoginLimitService(iLoginLimitRepository, logger)
+	twoFactorDAO := dao.NewTwoFactorDAO(db)
+	twoFactorChallengeCache := cache.NewTwoFactorChallengeCache(cmdable)
+	iTwoFactorRepository := repository.NewTwoFactorRepository(twoFactorDAO, twoFactorChallengeCache)
+	iTwoFactorService := service.NewTwoFactorService(iTwoFactorRepository, userRepository)
+	userHandler := manage.NewUserHandler(iUserService, iSessionService, iLoginLimitService, iTwoFactorService)
 	iArticleDAO := dao.NewArticleDAO(db)
 	iArticleRepository := repository.NewArticleRepository(iArticleDAO)
-	logger := ioc.InitLogger()
 	iArticleService := service.NewArticleService(iArticleRepository, logger)
 	articleHandler := manage.NewArticleHandler(iArticleService)
-	engine := ioc.InitManageServer(userHandler, articleHandler)
+	spiderHandler := manage.NewSpiderHandler(sp)
+	adminDAO := dao.NewAdminDAO(db)
+	iAdminRepository := repository.NewAdminRepository(adminDAO)
+	iAdminService := service.NewAdminService(iAdminRepository, passwordPolicy)
+	keySet := ioc.InitJWTKeys()
+	ijwtGenerator := ioc.InitJWT(keySet)
+	adminSessionCache := cache.NewAdminSessionCache(cmdable)
+	iAdminSessionRepository := repository.NewAdminSessionRepository(adminSessionCache)
+	iAdminSessionService := service.NewAdminSessionService(iAdminSessionRepository)
+	adminHandler := manage.NewAdminHandler(iAdminService, ijwtGenerator, iLoginLimitService, iAdminSessionService)
+	iAuditLogDAO := dao.NewAuditLogDAO(db)
+	iAuditRepository := repository.NewAuditRepository(iAuditLogDAO)
+	iAuditService := service.NewAuditService(iAuditRepository)
+	auditHandler := manage.NewAuditHandler(iAuditService)
+	engine := ioc.InitManageServer(userHandler, articleHandler, spiderHandler, adminHandler, auditHandler, ijwtGenerator, iAdminService, iAdminSessionService, iAuditService)
 	return engine
 }
 
-func InitSpider() *ioc.Spider {
+func InitSpider() *spider.Router {
+	consumerFactory := ioc.InitSpiderConsumerFactory()
+	throttle := ioc.InitSpiderThrottle()
+	producer := ioc.InitMQProducer()
 	db := ioc.InitDB()
 	iArticleDAO := dao.NewArticleDAO(db)
 	iArticleRepository := repository.NewArticleRepository(iArticleDAO)
 	logger := ioc.InitLogger()
 	iArticleService := service.NewArticleService(iArticleRepository, logger)
-	spider := ioc.NewSpider(iArticleService)
-	return spider
+	iImageRehostDAO := dao.NewImageRehostDAO(db)
+	iImageRehostRepository := repository.NewImageRehostRepository(iImageRehostDAO)
+	iResourceDao := dao.NewResourceDAO(db)
+	iResourceRepository := repository.NewResourceRepository(iResourceDao)
+	iService := ioc.InitOss()
+	imageFetcher := ioc.InitImageFetcher()
+	iImageRehostService := service.NewImageRehostService(iImageRehostRepository, iArticleRepository, iResourceRepository, iService, imageFetcher, logger)
+	userDao := dao.NewUserDAO(db)
+	cmdable := ioc.InitRedis()
+	userCache := cache.NewUserCache(cmdable)
+	userRepository := repository.NewCachedUserRepository(userDao, userCache)
+	passwordPolicy := ioc.InitPasswordPolicy()
+	iUserService := service.NewUserService(userRepository, passwordPolicy)
+	iResourceService := service.NewResourceService(iService, iResourceRepository, imageFetcher, logger)
+	router := ioc.InitSpider(consumerFactory, throttle, producer, iArticleService, iImageRehostService, iUserService, iResourceService, logger)
+	return router
+}
+
+func InitImageRehoster() *ioc.ImageRehoster {
+	db := ioc.InitDB()
+	iImageRehostDAO := dao.NewImageRehostDAO(db)
+	iImageRehostRepository := repository.NewImageRehostRepository(iImageRehostDAO)
+	iArticleDAO := dao.NewArticleDAO(db)
+	iArticleRepository := repository.NewArticleRepository(iArticleDAO)
+	iResourceDao := dao.NewResourceDAO(db)
+	iResourceRepository := repository.NewResourceRepository(iResourceDao)
+	iService := ioc.InitOss()
+	imageFetcher := ioc.InitImageFetcher()
+	logger := ioc.InitLogger()
+	iImageRehostService := service.NewImageRehostService(iImageRehostRepository, iArticleRepository, iResourceRepository, iService, imageFetcher, logger)
+	imageRehoster := ioc.NewImageRehoster(iImageRehostService, logger)
+	return imageRehoster
+}
+
+func InitOutboxRelay() *ioc.OutboxRelay {
+	db := ioc.InitDB()
+	iOutboxDAO := dao.NewOutboxDAO(db)
+	producer := ioc.InitMQProducer()
+	logger := ioc.InitLogger()
+	outboxRelay := ioc.NewOutboxRelay(iOutboxDAO, producer, logger)
+	return outboxRelay
+}
+
+func InitAccountDeleter() *ioc.AccountDeleter {
+	db := ioc.InitDB()
+	userDao := dao.NewUserDAO(db)
+	cmdable := ioc.InitRedis()
+	userCache := cache.NewUserCache(cmdable)
+	userRepository := repository.NewCachedUserRepository(userDao, userCache)
+	passwordPolicy := ioc.InitPasswordPolicy()
+	iUserService := service.NewUserService(userRepository, passwordPolicy)
+	sessionCache := cache.NewSessionCache(cmdable)
+	iSessionRepository := repository.NewSessionRepository(sessionCache)
+	logger := ioc.InitLogger()
+	iSessionService := service.NewSessionService(iSessionRepository, logger)
+	accountDeletionPolicy := ioc.InitAccountDeletionPolicy()
+	iAccountDeletionService := service.NewAccountDeletionService(userRepository, iUserService, iSessionService, logger, accountDeletionPolicy)
+	accountDeleter := ioc.NewAccountDeleter(iAccountDeletionService, logger)
+	return accountDeleter
 }